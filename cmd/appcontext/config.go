@@ -6,14 +6,26 @@ import (
 	"time"
 )
 
+type TLSConfig struct {
+	CertFile       string        `field:"cert_file" json:"cert_file,omitempty" yaml:"cert_file" toml:"cert_file"`
+	KeyFile        string        `field:"key_file" json:"key_file,omitempty" yaml:"key_file" toml:"key_file"`
+	ClientCAFile   string        `field:"client_ca_file" json:"client_ca_file,omitempty" yaml:"client_ca_file" toml:"client_ca_file"`
+	ClientAuth     string        `field:"client_auth" json:"client_auth,omitempty" yaml:"client_auth" toml:"client_auth"` // none, verify_if_given, require
+	ReloadInterval time.Duration `field:"reload_interval" json:"reload_interval,omitempty" yaml:"reload_interval" toml:"reload_interval"`
+}
+
 type serverConfig struct {
 	HTTPServer struct {
 		Listen      string        `default:":8080" field:"listen" json:"listen" yaml:"listen" toml:"listen" cli:"http_listen" env:"SERVER_HTTP_LISTEN"`
 		ReadTimeout time.Duration `default:"120s" field:"read_timeout" json:"read_timeout" yaml:"read_timeout" toml:"read_timeout" env:"SERVER_HTTP_READ_TIMEOUT"`
+		TLS         TLSConfig     `field:"tls" json:"tls" yaml:"tls" toml:"tls"`
 	} `json:"http_server" yaml:"http_server" toml:"http_server"`
 	RedisServer struct {
 		Listen      string        `default:":6380" field:"listen" json:"listen" yaml:"listen" toml:"listen" cli:"redis_listen" env:"SERVER_REDIS_LISTEN"`
 		ReadTimeout time.Duration `default:"120s" field:"read_timeout" json:"read_timeout" yaml:"read_timeout" toml:"read_timeout" env:"SERVER_REDIS_READ_TIMEOUT"`
+		TLS         TLSConfig     `field:"tls" json:"tls" yaml:"tls" toml:"tls"`
+		// NotifyKeyspaceEvents flags like `KEA`, empty value disables notifications
+		NotifyKeyspaceEvents string `field:"notify_keyspace_events" json:"notify_keyspace_events" yaml:"notify_keyspace_events" toml:"notify_keyspace_events" env:"SERVER_REDIS_NOTIFY_KEYSPACE_EVENTS"`
 	} `json:"redis_server" yaml:"redis_server" toml:"redis_server"`
 	Profile struct {
 		Listen string `field:"listen" json:"listen" yaml:"listen" toml:"listen" default:":6060" env:"SERVER_PROFILE_LISTEN"`
@@ -25,6 +37,25 @@ type cacheConfig struct {
 	Connect string        `field:"connect" json:"connect" yaml:"connect" toml:"connect" env:"CACHE_CONNECT" default:"memory"`
 	Size    int           `field:"size" json:"size" yaml:"size" toml:"size" env:"CACHE_SIZE" default:"1000"`
 	TTL     time.Duration `field:"ttl" json:"ttl" yaml:"ttl" toml:"ttl" env:"CACHE_TTL" default:"60s"`
+	Version string        `field:"version" json:"version" yaml:"version" toml:"version" env:"CACHE_VERSION" default:"1"` // Change invalidates all cached values
+
+	StaleTTL     time.Duration `field:"stale_ttl" json:"stale_ttl" yaml:"stale_ttl" toml:"stale_ttl" env:"CACHE_STALE_TTL"`                     // Expired values are served while refreshing and on store errors
+	RefreshAhead time.Duration `field:"refresh_ahead" json:"refresh_ahead" yaml:"refresh_ahead" toml:"refresh_ahead" env:"CACHE_REFRESH_AHEAD"` // Refresh the values in the background before the expiration
+}
+
+// BindCacheConfig overrides the global cache for the keys of the bind
+type BindCacheConfig struct {
+	Connect  string        `field:"connect" json:"connect,omitempty" yaml:"connect" toml:"connect"` // Empty value shares the global cache backend
+	Size     int           `field:"size" json:"size,omitempty" yaml:"size" toml:"size"`
+	TTL      time.Duration `field:"ttl" json:"ttl,omitempty" yaml:"ttl" toml:"ttl"`
+	Disabled bool          `field:"disabled" json:"disabled,omitempty" yaml:"disabled" toml:"disabled"` // Bypass the cache for the keys of the bind
+
+	NegativeTTL time.Duration `field:"negative_ttl" json:"negative_ttl,omitempty" yaml:"negative_ttl" toml:"negative_ttl"` // Cache the missing keys of the bind
+}
+
+// IsEmpty returns true if the bind uses the global cache, the negative TTL doesn't change the cache
+func (c *BindCacheConfig) IsEmpty() bool {
+	return c.Connect == "" && c.Size <= 0 && c.TTL <= 0 && !c.Disabled
 }
 
 type DatatypeMapper struct {
@@ -33,23 +64,64 @@ type DatatypeMapper struct {
 }
 
 type dataSourceKeyBind struct {
-	DBNum            int              `field:"dbnum" json:"dbnum" yaml:"dbnum" toml:"dbnum"`
-	TableName        string           `field:"table_name" json:"table_name,omitempty" yaml:"table_name" toml:"table_name"`
-	Key              string           `field:"key" json:"key" yaml:"key" toml:"key"` // Pattern prefix1_{{id}}_suffix, prefix2_{{id}}_{{codename}}
-	Readonly         bool             `field:"readonly" json:"readonly" yaml:"readonly" toml:"readonly"`
-	WhereExt         string           `field:"where_ext" json:"where_ext,omitempty" yaml:"where_ext" toml:"where_ext"`
-	GetQuery         string           `field:"get_query" json:"get_query,omitempty" yaml:"get_query" toml:"get_query"`
-	ListQuery        string           `field:"list_query" json:"list_query,omitempty" yaml:"list_query" toml:"list_query"`
-	UpsertQuery      string           `field:"upsert_query" json:"upsert_query,omitempty" yaml:"upsert_query" toml:"upsert_query"`
-	DelQuery         string           `field:"del_query" json:"del_query,omitempty" yaml:"del_query" toml:"del_query"`
-	ReorganizeNested bool             `field:"reorganize_nested" json:"reorganize_nested,omitempty" yaml:"reorganize_nested" toml:"reorganize_nested"` // Reorganize nested data to flat structure
-	DatatypeMapping  []DatatypeMapper `field:"datatype_mapping" json:"datatype_mapping,omitempty" yaml:"datatype_mapping" toml:"datatype_mapping"`
+	DBNum             int              `field:"dbnum" json:"dbnum" yaml:"dbnum" toml:"dbnum"`
+	Type              string           `field:"type" json:"type,omitempty" yaml:"type" toml:"type"` // Type of the keys: hash (default), list or set
+	TableName         string           `field:"table_name" json:"table_name,omitempty" yaml:"table_name" toml:"table_name"`
+	Key               string           `field:"key" json:"key" yaml:"key" toml:"key"` // Pattern prefix1_{{id}}_suffix, prefix2_{{id}}_{{codename}}
+	Readonly          bool             `field:"readonly" json:"readonly" yaml:"readonly" toml:"readonly"`
+	WhereExt          string           `field:"where_ext" json:"where_ext,omitempty" yaml:"where_ext" toml:"where_ext"`
+	GetQuery          string           `field:"get_query" json:"get_query,omitempty" yaml:"get_query" toml:"get_query"`
+	ListQuery         string           `field:"list_query" json:"list_query,omitempty" yaml:"list_query" toml:"list_query"`
+	UpsertQuery       string           `field:"upsert_query" json:"upsert_query,omitempty" yaml:"upsert_query" toml:"upsert_query"`
+	InsertQuery       string           `field:"insert_query" json:"insert_query,omitempty" yaml:"insert_query" toml:"insert_query"`
+	UpdateQuery       string           `field:"update_query" json:"update_query,omitempty" yaml:"update_query" toml:"update_query"`
+	UpdateRecordQuery string           `field:"update_record_query" json:"update_record_query,omitempty" yaml:"update_record_query" toml:"update_record_query"` // Custom query of SET XX
+	DelQuery          string           `field:"del_query" json:"del_query,omitempty" yaml:"del_query" toml:"del_query"`
+	MemberColumn      string           `field:"member_column" json:"member_column,omitempty" yaml:"member_column" toml:"member_column"`                     // Column of the list or set members, expression of the zset members
+	ScoreColumn       string           `field:"score_column" json:"score_column,omitempty" yaml:"score_column" toml:"score_column"`                         // Column or expression of the zset member scores
+	OrderBy           string           `field:"order_by" json:"order_by,omitempty" yaml:"order_by" toml:"order_by"`                                         // Order of the list members of the table bind
+	AddQuery          string           `field:"add_query" json:"add_query,omitempty" yaml:"add_query" toml:"add_query"`                                     // Custom query of RPUSH, SADD and ZADD
+	RemQuery          string           `field:"rem_query" json:"rem_query,omitempty" yaml:"rem_query" toml:"rem_query"`                                     // Custom query of SREM and ZREM
+	CounterColumn     string           `field:"counter_column" json:"counter_column,omitempty" yaml:"counter_column" toml:"counter_column"`                 // Numeric column of INCR/DECR commands
+	IncrQuery         string           `field:"incr_query" json:"incr_query,omitempty" yaml:"incr_query" toml:"incr_query"`                                 // Custom counter increment query
+	TTLColumn         string           `field:"ttl_column" json:"ttl_column,omitempty" yaml:"ttl_column" toml:"ttl_column"`                                 // Expiration time of the record
+	TTLSweepInterval  time.Duration    `field:"ttl_sweep_interval" json:"ttl_sweep_interval,omitempty" yaml:"ttl_sweep_interval" toml:"ttl_sweep_interval"` // Interval of the expired records deletion
+	TTLSweepQuery     string           `field:"ttl_sweep_query" json:"ttl_sweep_query,omitempty" yaml:"ttl_sweep_query" toml:"ttl_sweep_query"`             // Custom deletion of the expired records
+	ReorganizeNested  bool             `field:"reorganize_nested" json:"reorganize_nested,omitempty" yaml:"reorganize_nested" toml:"reorganize_nested"`     // Reorganize nested data to flat structure
+	DatatypeMapping   []DatatypeMapper `field:"datatype_mapping" json:"datatype_mapping,omitempty" yaml:"datatype_mapping" toml:"datatype_mapping"`
+	Cache             BindCacheConfig  `field:"cache" json:"cache,omitempty" yaml:"cache" toml:"cache"`
 }
 
 type dataSource struct {
-	Connect       string              `field:"connect" json:"connect" yaml:"connect" toml:"connect"`
-	NotifyChannel string              `field:"notify_channel" json:"notify_channel,omitempty" yaml:"notify_channel" toml:"notify_channel"`
-	Binds         []dataSourceKeyBind `field:"binds" json:"binds" yaml:"binds" toml:"binds"`
+	ID               string              `field:"id" json:"id,omitempty" yaml:"id" toml:"id"` // Namespace of the cached keys, the hash of the connect by default
+	Connect          string              `field:"connect" json:"connect" yaml:"connect" toml:"connect"`
+	NotifyChannel    string              `field:"notify_channel" json:"notify_channel,omitempty" yaml:"notify_channel" toml:"notify_channel"`
+	CacheMaxInFlight int                 `field:"cache_max_inflight" json:"cache_max_inflight,omitempty" yaml:"cache_max_inflight" toml:"cache_max_inflight"` // Limit of the distinct cache misses loaded at once
+	Binds            []dataSourceKeyBind `field:"binds" json:"binds" yaml:"binds" toml:"binds"`
+}
+
+// HasBindCache returns true if any bind of the source defines its own cache
+func (s *dataSource) HasBindCache() bool {
+	for _, bind := range s.Binds {
+		if !bind.Cache.IsEmpty() && !bind.Cache.Disabled {
+			return true
+		}
+	}
+	return false
+}
+
+type ACLUser struct {
+	Name       string   `field:"name" json:"name" yaml:"name" toml:"name"`
+	Password   string   `field:"password" json:"password,omitempty" yaml:"password" toml:"password"` // Plain text or `sha256:<hex>`
+	Token      string   `field:"token" json:"token,omitempty" yaml:"token" toml:"token"`             // HTTP bearer token
+	NoPass     bool     `field:"nopass" json:"nopass,omitempty" yaml:"nopass" toml:"nopass"`         // Accept any password
+	DBNums     []int    `field:"dbnums" json:"dbnums,omitempty" yaml:"dbnums" toml:"dbnums"`
+	Keys       []string `field:"keys" json:"keys,omitempty" yaml:"keys" toml:"keys"`                         // Glob patterns of the accessible keys
+	Categories []string `field:"categories" json:"categories,omitempty" yaml:"categories" toml:"categories"` // read, write, admin
+}
+
+type aclConfig struct {
+	Users []ACLUser `field:"users" json:"users,omitempty" yaml:"users" toml:"users"`
 }
 
 // ConfigType contains all application options
@@ -66,6 +138,7 @@ type ConfigType struct {
 
 	Server  serverConfig `field:"server" json:"server" yaml:"server" toml:"server"`
 	Cache   cacheConfig  `field:"cache" json:"cache" yaml:"cache" toml:"cache"`
+	ACL     aclConfig    `field:"acl" json:"acl" yaml:"acl" toml:"acl"`
 	Sources []dataSource `field:"sources" json:"sources" yaml:"sources" toml:"sources"`
 }
 
@@ -9,7 +9,14 @@ import (
 var regExpEnvVarsExpression = regexp.MustCompile(`\$\{\{(\s*env.[a-zA-Z0-9_]+\s*)\}\}`)
 
 func (c *ConfigType) Prepare() {
+	c.Server.HTTPServer.TLS.prepare()
+	c.Server.RedisServer.TLS.prepare()
 	c.Cache.Connect = prepareItem(c.Cache.Connect)
+	for i := range c.ACL.Users {
+		user := &c.ACL.Users[i]
+		user.Password = prepareItem(user.Password)
+		user.Token = prepareItem(user.Token)
+	}
 	for i := range c.Sources {
 		src := &c.Sources[i]
 		src.Connect = prepareItem(c.Sources[i].Connect)
@@ -22,7 +29,15 @@ func (c *ConfigType) Prepare() {
 			bind.GetQuery = prepareItem(bind.GetQuery)
 			bind.ListQuery = prepareItem(bind.ListQuery)
 			bind.UpsertQuery = prepareItem(bind.UpsertQuery)
+			bind.InsertQuery = prepareItem(bind.InsertQuery)
+			bind.UpdateQuery = prepareItem(bind.UpdateQuery)
+			bind.UpdateRecordQuery = prepareItem(bind.UpdateRecordQuery)
 			bind.DelQuery = prepareItem(bind.DelQuery)
+			bind.IncrQuery = prepareItem(bind.IncrQuery)
+			bind.AddQuery = prepareItem(bind.AddQuery)
+			bind.RemQuery = prepareItem(bind.RemQuery)
+			bind.TTLSweepQuery = prepareItem(bind.TTLSweepQuery)
+			bind.Cache.Connect = prepareItem(bind.Cache.Connect)
 			for k := range bind.DatatypeMapping {
 				dm := &bind.DatatypeMapping[k]
 				dm.Name = prepareItem(dm.Name)
@@ -32,6 +47,12 @@ func (c *ConfigType) Prepare() {
 	}
 }
 
+func (c *TLSConfig) prepare() {
+	c.CertFile = prepareItem(c.CertFile)
+	c.KeyFile = prepareItem(c.KeyFile)
+	c.ClientCAFile = prepareItem(c.ClientCAFile)
+}
+
 func prepareItem(s string) string {
 	return regExpEnvVarsExpression.ReplaceAllStringFunc(s, func(s string) string {
 		envName := strings.TrimPrefix(s, "${{")
@@ -10,6 +10,13 @@ import (
 func TestPrepareConfig(t *testing.T) {
 	os.Setenv("CACHE_CONNECT", "cache_connect")
 
+	os.Setenv("REDIS_TLS_CERT_FILE", "redis_tls_cert_file")
+	os.Setenv("REDIS_TLS_KEY_FILE", "redis_tls_key_file")
+	os.Setenv("REDIS_TLS_CLIENT_CA_FILE", "redis_tls_client_ca_file")
+
+	os.Setenv("ACL_USER1_PASSWORD", "acl_user1_password")
+	os.Setenv("ACL_USER1_TOKEN", "acl_user1_token")
+
 	os.Setenv("SOURCE1_CONNECT", "source1_connect")
 	os.Setenv("SOURCE1_NOTIFY_CHANNEL", "source1_notify_channel")
 	os.Setenv("SOURCE1_BIND1_TABLE_NAME", "source1_bind1_table_name")
@@ -18,7 +25,13 @@ func TestPrepareConfig(t *testing.T) {
 	os.Setenv("SOURCE1_BIND1_GET_QUERY", "source1_bind1_get_query")
 	os.Setenv("SOURCE1_BIND1_LIST_QUERY", "source1_bind1_list_query")
 	os.Setenv("SOURCE1_BIND1_UPSERT_QUERY", "source1_bind1_upsert_query")
+	os.Setenv("SOURCE1_BIND1_INSERT_QUERY", "source1_bind1_insert_query")
+	os.Setenv("SOURCE1_BIND1_UPDATE_QUERY", "source1_bind1_update_query")
 	os.Setenv("SOURCE1_BIND1_DEL_QUERY", "source1_bind1_del_query")
+	os.Setenv("SOURCE1_BIND1_CACHE_CONNECT", "source1_bind1_cache_connect")
+	os.Setenv("SOURCE1_BIND1_INCR_QUERY", "source1_bind1_incr_query")
+	os.Setenv("SOURCE1_BIND1_ADD_QUERY", "source1_bind1_add_query")
+	os.Setenv("SOURCE1_BIND1_REM_QUERY", "source1_bind1_rem_query")
 
 	os.Setenv("SOURCE1_BIND1_DATATYPE_MAPPING1_NAME", "source1_bind1_datatype_mapping1_name")
 	os.Setenv("SOURCE1_BIND1_DATATYPE_MAPPING1_TYPE", "source1_bind1_datatype_mapping1_type")
@@ -27,6 +40,15 @@ func TestPrepareConfig(t *testing.T) {
 		Cache: cacheConfig{
 			Connect: "${{env.CACHE_CONNECT}}",
 		},
+		ACL: aclConfig{
+			Users: []ACLUser{
+				{
+					Name:     "user1",
+					Password: "${{env.ACL_USER1_PASSWORD}}",
+					Token:    "${{env.ACL_USER1_TOKEN}}",
+				},
+			},
+		},
 		Sources: []dataSource{
 			{
 				Connect:       "${{env.SOURCE1_CONNECT}}",
@@ -39,7 +61,13 @@ func TestPrepareConfig(t *testing.T) {
 						GetQuery:    "${{env.SOURCE1_BIND1_GET_QUERY}}",
 						ListQuery:   "${{env.SOURCE1_BIND1_LIST_QUERY}}",
 						UpsertQuery: "${{env.SOURCE1_BIND1_UPSERT_QUERY}}",
+						InsertQuery: "${{env.SOURCE1_BIND1_INSERT_QUERY}}",
+						UpdateQuery: "${{env.SOURCE1_BIND1_UPDATE_QUERY}}",
 						DelQuery:    "${{env.SOURCE1_BIND1_DEL_QUERY}}",
+						IncrQuery:   "${{env.SOURCE1_BIND1_INCR_QUERY}}",
+						AddQuery:    "${{env.SOURCE1_BIND1_ADD_QUERY}}",
+						RemQuery:    "${{env.SOURCE1_BIND1_REM_QUERY}}",
+						Cache:       BindCacheConfig{Connect: "${{env.SOURCE1_BIND1_CACHE_CONNECT}}"},
 						DatatypeMapping: []DatatypeMapper{
 							{
 								Name: "${{env.SOURCE1_BIND1_DATATYPE_MAPPING1_NAME}}",
@@ -51,9 +79,19 @@ func TestPrepareConfig(t *testing.T) {
 			},
 		},
 	}
+	conf.Server.RedisServer.TLS = TLSConfig{
+		CertFile:     "${{env.REDIS_TLS_CERT_FILE}}",
+		KeyFile:      "${{env.REDIS_TLS_KEY_FILE}}",
+		ClientCAFile: "${{env.REDIS_TLS_CLIENT_CA_FILE}}",
+	}
 	conf.Prepare()
 
+	assert.Equal(t, "redis_tls_cert_file", conf.Server.RedisServer.TLS.CertFile)
+	assert.Equal(t, "redis_tls_key_file", conf.Server.RedisServer.TLS.KeyFile)
+	assert.Equal(t, "redis_tls_client_ca_file", conf.Server.RedisServer.TLS.ClientCAFile)
 	assert.Equal(t, "cache_connect", conf.Cache.Connect)
+	assert.Equal(t, "acl_user1_password", conf.ACL.Users[0].Password)
+	assert.Equal(t, "acl_user1_token", conf.ACL.Users[0].Token)
 	assert.Equal(t, "source1_connect", conf.Sources[0].Connect)
 	assert.Equal(t, "source1_notify_channel", conf.Sources[0].NotifyChannel)
 	assert.Equal(t, "source1_bind1_table_name", conf.Sources[0].Binds[0].TableName)
@@ -62,7 +100,14 @@ func TestPrepareConfig(t *testing.T) {
 	assert.Equal(t, "source1_bind1_get_query", conf.Sources[0].Binds[0].GetQuery)
 	assert.Equal(t, "source1_bind1_list_query", conf.Sources[0].Binds[0].ListQuery)
 	assert.Equal(t, "source1_bind1_upsert_query", conf.Sources[0].Binds[0].UpsertQuery)
+	assert.Equal(t, "source1_bind1_insert_query", conf.Sources[0].Binds[0].InsertQuery)
+	assert.Equal(t, "source1_bind1_update_query", conf.Sources[0].Binds[0].UpdateQuery)
 	assert.Equal(t, "source1_bind1_del_query", conf.Sources[0].Binds[0].DelQuery)
+	assert.Equal(t, "source1_bind1_incr_query", conf.Sources[0].Binds[0].IncrQuery)
+	assert.Equal(t, "source1_bind1_add_query", conf.Sources[0].Binds[0].AddQuery)
+	assert.Equal(t, "source1_bind1_rem_query", conf.Sources[0].Binds[0].RemQuery)
+	assert.Equal(t, "source1_bind1_cache_connect", conf.Sources[0].Binds[0].Cache.Connect)
+	assert.True(t, conf.Sources[0].HasBindCache())
 	assert.Equal(t, "source1_bind1_datatype_mapping1_name", conf.Sources[0].Binds[0].DatatypeMapping[0].Name)
 	assert.Equal(t, "source1_bind1_datatype_mapping1_type", conf.Sources[0].Binds[0].DatatypeMapping[0].Type)
 }
@@ -2,16 +2,19 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
 
+	"github.com/demdxx/gocast/v2"
 	"github.com/demdxx/goconfig"
 	"go.uber.org/zap"
 
 	"github.com/demdxx/redify/cmd/appcontext"
 	"github.com/demdxx/redify/cmd/server"
+	"github.com/demdxx/redify/internal/acl"
 	"github.com/demdxx/redify/internal/cache"
 	cachecon "github.com/demdxx/redify/internal/cache/connect"
 	"github.com/demdxx/redify/internal/context/ctxlogger"
@@ -20,6 +23,7 @@ import (
 	"github.com/demdxx/redify/internal/storage/multistore"
 	"github.com/demdxx/redify/internal/storage/profiler"
 	"github.com/demdxx/redify/internal/storage/proxy"
+	"github.com/demdxx/redify/internal/tlsutil"
 	"github.com/demdxx/redify/internal/zlogger"
 )
 
@@ -62,6 +66,7 @@ func main() {
 	var (
 		globalCache cache.Cacher
 		stores      []storage.Driver
+		channels    []storage.ChannelBridge
 	)
 
 	// Connect global cache
@@ -69,36 +74,78 @@ func main() {
 		var err error
 		globalCache, err = cachecon.Connect(config.Cache.Connect, config.Cache.Size, config.Cache.TTL)
 		fatalError(err, "create simple cache")
+		// Sources use the prefixed caches which retain the backend until it's closed
+		defer func() { _ = globalCache.Close() }()
 	}
 
 	// Connect sources and bind redify keys
 	for _, sconf := range config.Sources {
 		st, err := connect.Connect(ctx, sconf.Connect)
 		fatalError(err, sconf.Connect)
-		if cacheSupport, ok := st.(storage.CacheSupporter); ok && !cacheSupport.SupportCache() {
-			stores = append(stores, st)
-		} else {
-			stores = append(stores, proxy.New(ctx, globalCache, st, sconf.NotifyChannel))
+		var (
+			drv         = st
+			sourceID    = gocast.Or(sconf.ID, cache.SourceID(sconf.Connect))
+			namespace   = cache.Namespace(config.Cache.Version, sourceID)
+			sourceCache cache.Cacher
+		)
+		cacheSupport, ok := st.(storage.CacheSupporter)
+		if (!ok || cacheSupport.SupportCache()) && (globalCache != nil || sconf.HasBindCache()) {
+			if globalCache != nil {
+				sourceCache = globalCache.WithPrefix(namespace)
+			}
+			drv = proxy.New(ctx, sourceCache, st, proxy.Options{
+				NotifyChannel: sconf.NotifyChannel,
+				MaxInFlight:   sconf.CacheMaxInFlight,
+				StaleTTL:      config.Cache.StaleTTL,
+				RefreshAhead:  config.Cache.RefreshAhead,
+			})
+		}
+		stores = append(stores, drv)
+		if bridge, ok := st.(storage.ChannelBridge); ok {
+			channels = append(channels, bridge)
+		}
+		if notifier, ok := st.(storage.NotifyChanneler); ok && sconf.NotifyChannel != "" {
+			channels = append(channels, notifier.NotifyChannel(sconf.NotifyChannel))
 		}
 		for _, bind := range sconf.Binds {
-			err = st.Bind(ctx, &storage.BindConfig{
-				Pattern:          bind.Key,
-				DBNum:            bind.DBNum,
-				TableName:        bind.TableName,
-				Readonly:         bind.Readonly,
-				WhereExt:         bind.WhereExt,
-				GetQuery:         bind.GetQuery,
-				ListQuery:        bind.ListQuery,
-				UpsertQuery:      bind.UpsertQuery,
-				DelQuery:         bind.DelQuery,
-				ReorganizeNested: bind.ReorganizeNested,
-				DatatypeMapping:  datatypeMappingCast(bind.DatatypeMapping),
+			bindCache, err := newBindCache(globalCache, bind.Cache, namespace)
+			fatalError(err, sconf.Connect+" @ bind cache error")
+			err = drv.Bind(ctx, &storage.BindConfig{
+				Pattern:           bind.Key,
+				DBNum:             bind.DBNum,
+				Type:              bind.Type,
+				TableName:         bind.TableName,
+				Readonly:          bind.Readonly,
+				WhereExt:          bind.WhereExt,
+				GetQuery:          bind.GetQuery,
+				ListQuery:         bind.ListQuery,
+				UpsertQuery:       bind.UpsertQuery,
+				InsertQuery:       bind.InsertQuery,
+				UpdateQuery:       bind.UpdateQuery,
+				UpdateRecordQuery: bind.UpdateRecordQuery,
+				DelQuery:          bind.DelQuery,
+				MemberColumn:      bind.MemberColumn,
+				ScoreColumn:       bind.ScoreColumn,
+				OrderBy:           bind.OrderBy,
+				AddQuery:          bind.AddQuery,
+				RemQuery:          bind.RemQuery,
+				CounterColumn:     bind.CounterColumn,
+				IncrQuery:         bind.IncrQuery,
+				TTLColumn:         bind.TTLColumn,
+				TTLSweepInterval:  bind.TTLSweepInterval,
+				NegativeTTL:       bind.Cache.NegativeTTL,
+				TTLSweepQuery:     bind.TTLSweepQuery,
+				ReorganizeNested:  bind.ReorganizeNested,
+				DatatypeMapping:   datatypeMappingCast(bind.DatatypeMapping),
+				Cache:             bindCache,
+				NoCache:           bind.Cache.Disabled,
 			})
 			fatalError(err, sconf.Connect+" @ bind error")
 		}
 	}
 
 	store := multistore.New(stores...)
+	users := aclUsers(config.ACL.Users)
 	defer func() {
 		fatalError(store.Close(), "close DB connection")
 	}()
@@ -111,12 +158,24 @@ func main() {
 			true)
 	}
 
+	// Changes made by the HTTP server are published by the Redis server
+	keyspace := server.NewKeyspace()
+
 	if config.Server.RedisServer.Listen != "" {
 		zap.L().Info("Run Redis server", zap.String("listen", config.Server.RedisServer.Listen))
+		tlsConfig, err := listenerTLS(config.Server.RedisServer.TLS)
+		fatalError(err, "Redis server TLS")
 		go func() {
 			srv := server.RedisServer{
 				RequestTimeout: config.Server.RedisServer.ReadTimeout,
 				Driver:         store,
+				Version:        appVersion,
+				ACL:            users,
+				TLS:            tlsConfig,
+				Channels:       channels,
+				Keyspace:       keyspace,
+
+				NotifyKeyspaceEvents: config.Server.RedisServer.NotifyKeyspaceEvents,
 			}
 			err := srv.ListenAndServe(ctx, config.Server.RedisServer.Listen)
 			fatalError(err, "Listen Redis server")
@@ -125,10 +184,15 @@ func main() {
 
 	if config.Server.HTTPServer.Listen != "" {
 		zap.L().Info("Run HTTP server", zap.String("listen", config.Server.HTTPServer.Listen))
+		tlsConfig, err := listenerTLS(config.Server.HTTPServer.TLS)
+		fatalError(err, "HTTP server TLS")
 		go func() {
 			srv := server.HTTPServer{
 				RequestTimeout: config.Server.HTTPServer.ReadTimeout,
 				Driver:         store,
+				ACL:            users,
+				TLS:            tlsConfig,
+				Keyspace:       keyspace,
 			}
 			err := srv.ListenAndServe(ctx, config.Server.HTTPServer.Listen)
 			fatalError(err, "Listen HTTP server")
@@ -138,6 +202,41 @@ func main() {
 	<-ctx.Done()
 }
 
+// newBindCache returns the cache of the bind or nil if the bind uses the global cache.
+// The global cache backend is shared if the bind doesn't define its own backend.
+func newBindCache(globalCache cache.Cacher, conf appcontext.BindCacheConfig, namespace string) (cache.Cacher, error) {
+	if conf.IsEmpty() || conf.Disabled {
+		return nil, nil
+	}
+	var (
+		connect = conf.Connect
+		size    = conf.Size
+		ttl     = conf.TTL
+	)
+	if connect == "" {
+		connect = config.Cache.Connect
+	}
+	if size <= 0 {
+		size = config.Cache.Size
+	}
+	if globalCache != nil && connect == config.Cache.Connect && size == config.Cache.Size {
+		return cache.WithPrefixTTL(globalCache, namespace, ttl), nil
+	}
+	if connect == "" {
+		connect = "memory"
+	}
+	if ttl <= 0 {
+		ttl = config.Cache.TTL
+	}
+	bindCache, err := cachecon.Connect(connect, size, ttl)
+	if err != nil {
+		return nil, err
+	}
+	// The prefixed cache retains the backend after the close
+	defer func() { _ = bindCache.Close() }()
+	return bindCache.WithPrefix(namespace), nil
+}
+
 func datatypeMappingCast(mappers []appcontext.DatatypeMapper) []storage.DatatypeMapper {
 	result := make([]storage.DatatypeMapper, len(mappers))
 	for i, m := range mappers {
@@ -149,6 +248,37 @@ func datatypeMappingCast(mappers []appcontext.DatatypeMapper) []storage.Datatype
 	return result
 }
 
+func aclUsers(users []appcontext.ACLUser) *acl.ACL {
+	result := make([]*acl.User, len(users))
+	for i, u := range users {
+		result[i] = &acl.User{
+			Name:       u.Name,
+			Password:   u.Password,
+			Token:      u.Token,
+			NoPassword: u.NoPass,
+			DBNums:     u.DBNums,
+			Keys:       u.Keys,
+			Categories: u.Categories,
+		}
+	}
+	return acl.New(result...)
+}
+
+// listenerTLS returns nil if the certificate of the listener is not defined
+func listenerTLS(conf appcontext.TLSConfig) (*tls.Config, error) {
+	opt := tlsutil.Options{
+		CertFile:      conf.CertFile,
+		KeyFile:       conf.KeyFile,
+		ClientCAFile:  conf.ClientCAFile,
+		ClientAuth:    conf.ClientAuth,
+		CheckInterval: conf.ReloadInterval,
+	}
+	if !opt.Enabled() {
+		return nil, nil
+	}
+	return tlsutil.NewConfig(opt)
+}
+
 func fatalError(err error, msgs ...any) {
 	if err != nil {
 		log.Fatalln(append(msgs, err)...)
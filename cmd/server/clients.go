@@ -0,0 +1,213 @@
+package server
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tidwall/redcon"
+)
+
+// clientInfo is the state of the connection reported by CLIENT LIST.
+// It's the copy of the user context updated by every command of the connection.
+type clientInfo struct {
+	id       int64
+	addr     string
+	laddr    string
+	created  time.Time
+	lastTime time.Time
+	cmd      string
+	name     string
+	libName  string
+	libVer   string
+	dbnum    int
+	protocol int
+	user     string
+	multi    int
+	tracking bool
+}
+
+// clientTable of the connected clients and the server statistics
+type clientTable struct {
+	mx      sync.RWMutex
+	clients map[int64]*clientInfo
+
+	connections atomic.Int64
+	commands    atomic.Int64
+}
+
+func newClientTable() *clientTable {
+	return &clientTable{clients: map[int64]*clientInfo{}}
+}
+
+// add the accepted connection
+func (t *clientTable) add(conn redcon.Conn, rCtx *userContext) {
+	now := time.Now()
+	info := &clientInfo{
+		id:       rCtx.ID,
+		addr:     conn.RemoteAddr(),
+		created:  now,
+		lastTime: now,
+		multi:    -1,
+	}
+	if nc := conn.NetConn(); nc != nil {
+		info.laddr = nc.LocalAddr().String()
+	}
+	t.connections.Add(1)
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	t.clients[rCtx.ID] = info
+}
+
+// remove the closed connection
+func (t *clientTable) remove(rCtx *userContext) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	delete(t.clients, rCtx.ID)
+}
+
+// touch the client by the command and copy the state of the connection
+func (t *clientTable) touch(rCtx *userContext, cmd redcon.Command) {
+	t.commands.Add(1)
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	info := t.clients[rCtx.ID]
+	if info == nil {
+		return
+	}
+	info.lastTime = time.Now()
+	info.cmd = commandName(cmd)
+	info.name = rCtx.Name
+	info.libName = rCtx.LibName
+	info.libVer = rCtx.LibVer
+	info.dbnum = rCtx.DBNum
+	info.protocol = rCtx.Protocol
+	info.tracking = rCtx.tracking != nil
+	info.user, info.multi = "", -1
+	if rCtx.User != nil {
+		info.user = rCtx.User.Name
+	}
+	if rCtx.multi != nil {
+		info.multi = len(rCtx.multi.queue)
+	}
+}
+
+// list of the clients ordered by ID, empty IDs select all clients
+func (t *clientTable) list(ids ...int64) []clientInfo {
+	t.mx.RLock()
+	defer t.mx.RUnlock()
+	res := make([]clientInfo, 0, len(t.clients))
+	for id, info := range t.clients {
+		if len(ids) == 0 || slices.Contains(ids, id) {
+			res = append(res, *info)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].id < res[j].id })
+	return res
+}
+
+// count of the connected clients
+func (t *clientTable) count() int {
+	t.mx.RLock()
+	defer t.mx.RUnlock()
+	return len(t.clients)
+}
+
+// String of the client in the CLIENT LIST format
+func (c *clientInfo) String() string {
+	now := time.Now()
+	flags := "N"
+	switch {
+	case c.multi >= 0:
+		flags = "x"
+	case c.tracking:
+		flags = "t"
+	}
+	fields := []string{
+		"id=" + strconv.FormatInt(c.id, 10),
+		"addr=" + c.addr,
+		"laddr=" + c.laddr,
+		"name=" + c.name,
+		"age=" + strconv.FormatInt(int64(now.Sub(c.created)/time.Second), 10),
+		"idle=" + strconv.FormatInt(int64(now.Sub(c.lastTime)/time.Second), 10),
+		"flags=" + flags,
+		"db=" + strconv.Itoa(c.dbnum),
+		"multi=" + strconv.Itoa(c.multi),
+		"cmd=" + c.cmd,
+		"user=" + c.user,
+		"lib-name=" + c.libName,
+		"lib-ver=" + c.libVer,
+		"resp=" + strconv.Itoa(c.protocol),
+	}
+	return strings.Join(fields, " ")
+}
+
+// commandName with the subcommand of the container commands like `client|list`
+func commandName(cmd redcon.Command) string {
+	name := strings.ToLower(string(cmd.Args[0]))
+	switch name {
+	case "client", "config", "acl", "command", "xgroup":
+		if len(cmd.Args) > 1 {
+			return name + "|" + strings.ToLower(string(cmd.Args[1]))
+		}
+	}
+	return name
+}
+
+// cmdClientList returns the connected clients
+//
+//	CLIENT LIST [TYPE <NORMAL | MASTER | REPLICA | PUBSUB>] [ID client-id [client-id ...]]
+func (srv *RedisServer) cmdClientList(conn redcon.Conn, cmd redcon.Command) {
+	var (
+		ids     []int64
+		reqType string
+	)
+	for i := 2; i < len(cmd.Args); i++ {
+		switch opt := strings.ToLower(string(cmd.Args[i])); {
+		case opt == "type" && i+1 < len(cmd.Args):
+			reqType = strings.ToLower(string(cmd.Args[i+1]))
+			switch reqType {
+			case "normal", "master", "replica", "pubsub":
+			default:
+				conn.WriteError("ERR Unknown client type '" + string(cmd.Args[i+1]) + "'")
+				return
+			}
+			i++
+		case opt == "id" && i+1 < len(cmd.Args):
+			for i++; i < len(cmd.Args); i++ {
+				id, err := strconv.ParseInt(string(cmd.Args[i]), 10, 64)
+				if err != nil || id <= 0 {
+					conn.WriteError("ERR Invalid client ID")
+					return
+				}
+				ids = append(ids, id)
+			}
+		default:
+			conn.WriteError("ERR syntax error")
+			return
+		}
+	}
+	var buf strings.Builder
+	// Pub/sub connections are served by the pub/sub and aren't in the table,
+	// the replication is not supported so only normal clients are reported
+	if reqType == "" || reqType == "normal" {
+		for _, client := range srv.clients.list(ids...) {
+			buf.WriteString(client.String() + "\n")
+		}
+	}
+	conn.WriteBulkString(buf.String())
+}
+
+// validClientName returns true if the name doesn't contain spaces, newlines and special characters
+func validClientName(name string) bool {
+	for _, c := range name {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
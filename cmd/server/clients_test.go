@@ -0,0 +1,65 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/redcon"
+)
+
+func TestClientInfo(t *testing.T) {
+	table := newClientTable()
+	for id := int64(3); id > 0; id-- {
+		table.clients[id] = &clientInfo{id: id, multi: -1}
+	}
+	rCtx := &userContext{ID: 2, Name: "worker", LibName: "go-redis", DBNum: 1, Protocol: protoRESP3}
+	table.touch(rCtx, redcon.Command{Args: [][]byte{[]byte("CLIENT"), []byte("List")}})
+
+	assert.Equal(t, 3, table.count())
+	assert.Equal(t, int64(1), table.commands.Load())
+
+	clients := table.list()
+	if assert.Len(t, clients, 3) {
+		assert.Equal(t, []int64{1, 2, 3}, []int64{clients[0].id, clients[1].id, clients[2].id})
+	}
+	clients = table.list(2)
+	if !assert.Len(t, clients, 1) {
+		return
+	}
+	line := clients[0].String()
+	for _, field := range []string{"id=2", "name=worker", "flags=N", "db=1", "multi=-1",
+		"cmd=client|list", "lib-name=go-redis", "lib-ver=", "resp=3"} {
+		assert.Contains(t, strings.Fields(line), field)
+	}
+
+	table.remove(rCtx)
+	assert.Equal(t, 2, table.count())
+}
+
+func TestCommandName(t *testing.T) {
+	assert.Equal(t, "get", commandName(redcon.Command{Args: [][]byte{[]byte("GET"), []byte("key")}}))
+	assert.Equal(t, "config|get", commandName(redcon.Command{Args: [][]byte{[]byte("CONFIG"), []byte("GET")}}))
+	assert.Equal(t, "client", commandName(redcon.Command{Args: [][]byte{[]byte("client")}}))
+}
+
+func TestValidClientName(t *testing.T) {
+	assert.True(t, validClientName("worker-1"))
+	assert.True(t, validClientName(""))
+	assert.False(t, validClientName("worker 1"))
+	assert.False(t, validClientName("worker\n"))
+}
+
+func TestCommandTableKeys(t *testing.T) {
+	for name, spec := range commandTable {
+		// WATCH keys are checked by the queued commands
+		if spec.firstKey == 0 || name == "watch" {
+			continue
+		}
+		args := [][]byte{[]byte(name)}
+		for i := 1; i < max(spec.arity, -spec.arity, 4); i++ {
+			args = append(args, []byte("arg"))
+		}
+		assert.NotEmpty(t, commandKeys(redcon.Command{Args: args}), name)
+	}
+}
@@ -0,0 +1,331 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/redcon"
+
+	"github.com/demdxx/redify/internal/storage"
+)
+
+// errWrongType is the reply for the commands against the keys of other types
+const errWrongType = "WRONGTYPE Operation against a key holding the wrong kind of value"
+
+// cmdLRange returns the members of the list from start to stop inclusive
+//
+//	LRANGE key start stop
+func (srv *RedisServer) cmdLRange(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) != 4 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	start, err1 := strconv.ParseInt(string(cmd.Args[2]), 10, 64)
+	stop, err2 := strconv.ParseInt(string(cmd.Args[3]), 10, 64)
+	if err1 != nil || err2 != nil {
+		conn.WriteError("ERR value is not an integer or out of range")
+		return
+	}
+	cr := srv.collectionReader(conn)
+	if cr == nil {
+		return
+	}
+	members, err := cr.Range(ctx, dbnum, string(cmd.Args[1]), start, stop)
+	if writeCollectionError(conn, err) {
+		return
+	}
+	conn.WriteArray(len(members))
+	for _, member := range members {
+		conn.WriteBulkString(member)
+	}
+}
+
+// cmdLIndex returns the member of the list by the index
+//
+//	LINDEX key index
+func (srv *RedisServer) cmdLIndex(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) != 3 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	index, err := strconv.ParseInt(string(cmd.Args[2]), 10, 64)
+	if err != nil {
+		conn.WriteError("ERR value is not an integer or out of range")
+		return
+	}
+	cr := srv.collectionReader(conn)
+	if cr == nil {
+		return
+	}
+	members, err := cr.Range(ctx, dbnum, string(cmd.Args[1]), index, index)
+	if writeCollectionError(conn, err) {
+		return
+	}
+	if len(members) == 0 {
+		conn.WriteNull()
+	} else {
+		conn.WriteBulkString(members[0])
+	}
+}
+
+// cmdLen returns the number of the members of the list, set or sorted set
+//
+//	LLEN key
+//	SCARD key
+//	ZCARD key
+func (srv *RedisServer) cmdLen(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) != 2 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	cr := srv.collectionReader(conn)
+	if cr == nil {
+		return
+	}
+	count, err := cr.Len(ctx, dbnum, string(cmd.Args[1]))
+	if writeCollectionError(conn, err) {
+		return
+	}
+	conn.WriteInt64(count)
+}
+
+// cmdSMembers returns the members of the set
+//
+//	SMEMBERS key
+func (srv *RedisServer) cmdSMembers(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) != 2 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	cr := srv.collectionReader(conn)
+	if cr == nil {
+		return
+	}
+	members, err := cr.Members(ctx, dbnum, string(cmd.Args[1]))
+	if writeCollectionError(conn, err) {
+		return
+	}
+	writeSet(conn, len(members))
+	for _, member := range members {
+		conn.WriteBulkString(member)
+	}
+}
+
+// cmdSScan iterates over the members of the set.
+// Members are sorted, so the cursor is the offset in the list of the matched members.
+//
+//	SSCAN key cursor [MATCH pattern] [COUNT count]
+func (srv *RedisServer) cmdSScan(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) < 3 || len(cmd.Args)%2 != 1 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	cursor, err := strconv.ParseUint(string(cmd.Args[2]), 10, 64)
+	if err != nil {
+		conn.WriteError("ERR invalid cursor")
+		return
+	}
+	var opts storage.ScanOptions
+	for i := 3; i < len(cmd.Args); i += 2 {
+		switch strings.ToLower(string(cmd.Args[i])) {
+		case "match":
+			opts.Pattern = string(cmd.Args[i+1])
+		case "count":
+			if opts.Count, err = strconv.Atoi(string(cmd.Args[i+1])); err != nil || opts.Count < 1 {
+				conn.WriteError("ERR value is not an integer or out of range")
+				return
+			}
+		default:
+			conn.WriteError("ERR syntax error")
+			return
+		}
+	}
+	cr := srv.collectionReader(conn)
+	if cr == nil {
+		return
+	}
+	members, err := cr.Members(ctx, dbnum, string(cmd.Args[1]))
+	if writeCollectionError(conn, err) {
+		return
+	}
+	matched := members[:0]
+	for _, member := range members {
+		if opts.MatchKey(member) {
+			matched = append(matched, member)
+		}
+	}
+	sort.Strings(matched)
+
+	var (
+		next  uint64
+		count = uint64(opts.PageSize())
+	)
+	if cursor >= uint64(len(matched)) {
+		matched = matched[:0]
+	} else if matched = matched[cursor:]; uint64(len(matched)) > count {
+		matched = matched[:count]
+		next = cursor + count
+	}
+	conn.WriteArray(2)
+	conn.WriteBulkString(strconv.FormatUint(next, 10))
+	conn.WriteArray(len(matched))
+	for _, member := range matched {
+		conn.WriteBulkString(member)
+	}
+}
+
+// cmdSIsMember checks if the member belongs to the set
+//
+//	SISMEMBER key member
+func (srv *RedisServer) cmdSIsMember(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) != 3 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	cr := srv.collectionReader(conn)
+	if cr == nil {
+		return
+	}
+	ok, err := cr.IsMember(ctx, dbnum, string(cmd.Args[1]), string(cmd.Args[2]))
+	if writeCollectionError(conn, err) {
+		return
+	}
+	if ok {
+		conn.WriteInt(1)
+	} else {
+		conn.WriteInt(0)
+	}
+}
+
+// cmdAddMembers appends the members to the list or set.
+// RPUSH replies with the length of the list, SADD with the number of added members.
+//
+//	RPUSH key element [element ...]
+//	SADD key member [member ...]
+func (srv *RedisServer) cmdAddMembers(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) < 3 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	cw, _ := srv.Driver.(storage.CollectionWriter)
+	if cw == nil {
+		conn.WriteError("ERR " + storage.ErrMethodIsNotSupported.Error())
+		return
+	}
+	key := string(cmd.Args[1])
+	count, err := cw.AddMembers(ctx, dbnum, key, argStrings(cmd.Args[2:], 1))
+	if writeCollectionWriteError(conn, err) {
+		return
+	}
+	isPush := strings.EqualFold(string(cmd.Args[0]), "rpush")
+	switch {
+	case isPush:
+		srv.keyEvent(ctx, dbnum, key, storage.KeyEventRPush)
+	case count > 0:
+		srv.keyEvent(ctx, dbnum, key, storage.KeyEventSAdd)
+	}
+	if isPush {
+		cr, _ := srv.Driver.(storage.CollectionReader)
+		if cr == nil {
+			conn.WriteError("ERR " + storage.ErrMethodIsNotSupported.Error())
+			return
+		}
+		if count, err = cr.Len(ctx, dbnum, key); writeCollectionWriteError(conn, err) {
+			return
+		}
+	}
+	conn.WriteInt64(count)
+}
+
+// cmdSRem removes the members of the set or sorted set
+//
+//	SREM key member [member ...]
+//	ZREM key member [member ...]
+func (srv *RedisServer) cmdSRem(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) < 3 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	cw, _ := srv.Driver.(storage.CollectionWriter)
+	if cw == nil {
+		conn.WriteError("ERR " + storage.ErrMethodIsNotSupported.Error())
+		return
+	}
+	key := string(cmd.Args[1])
+	count, err := cw.RemMembers(ctx, dbnum, key, argStrings(cmd.Args[2:], 1))
+	if errors.Is(err, storage.ErrNoKey) {
+		conn.WriteInt(0)
+		return
+	}
+	if writeCollectionWriteError(conn, err) {
+		return
+	}
+	if count > 0 {
+		event := storage.KeyEventSRem
+		if strings.EqualFold(string(cmd.Args[0]), "zrem") {
+			event = storage.KeyEventZRem
+		}
+		srv.keyEvent(ctx, dbnum, key, event)
+	}
+	conn.WriteInt64(count)
+}
+
+// cmdType returns the type of the key or `none` if the key doesn't exist
+//
+//	TYPE key
+func (srv *RedisServer) cmdType(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) != 2 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	tp, err := storage.KeyType(ctx, srv.Driver, dbnum, string(cmd.Args[1]))
+	switch {
+	case errors.Is(err, storage.ErrNoKey) || errors.Is(err, storage.ErrNotFound):
+		conn.WriteString("none")
+	case err != nil:
+		conn.WriteError("ERR " + err.Error())
+	default:
+		conn.WriteString(tp)
+	}
+}
+
+func (srv *RedisServer) collectionReader(conn redcon.Conn) storage.CollectionReader {
+	cr, _ := srv.Driver.(storage.CollectionReader)
+	if cr == nil {
+		conn.WriteError("ERR " + storage.ErrMethodIsNotSupported.Error())
+	}
+	return cr
+}
+
+// writeCollectionError writes the error of the collection reading and returns true if it's written.
+// Missing keys are empty collections.
+func writeCollectionError(conn redcon.Conn, err error) bool {
+	if err == nil || errors.Is(err, storage.ErrNoKey) || errors.Is(err, storage.ErrNotFound) {
+		return false
+	}
+	if errors.Is(err, storage.ErrWrongType) {
+		conn.WriteError(errWrongType)
+	} else {
+		conn.WriteError("ERR " + err.Error())
+	}
+	return true
+}
+
+// writeCollectionWriteError writes the error of the collection modification
+// and returns true if it's written
+func writeCollectionWriteError(conn redcon.Conn, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, storage.ErrWrongType):
+		conn.WriteError(errWrongType)
+	case errors.Is(err, storage.ErrNoKey):
+		conn.WriteError("ERR no such key")
+	default:
+		conn.WriteError("ERR " + err.Error())
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/redcon"
+)
+
+// setDriver returns the members of the sets
+type setDriver struct {
+	mapDriver
+	sets map[string][]string
+}
+
+func (d setDriver) Range(ctx context.Context, dbnum int, key string, start, stop int64) ([]string, error) {
+	return d.sets[key], nil
+}
+
+func (d setDriver) Len(ctx context.Context, dbnum int, key string) (int64, error) {
+	return int64(len(d.sets[key])), nil
+}
+
+func (d setDriver) Members(ctx context.Context, dbnum int, key string) ([]string, error) {
+	return append([]string{}, d.sets[key]...), nil
+}
+
+func (d setDriver) IsMember(ctx context.Context, dbnum int, key, member string) (bool, error) {
+	return false, nil
+}
+
+func TestSScan(t *testing.T) {
+	var (
+		ctx = context.Background()
+		srv = &RedisServer{Driver: setDriver{sets: map[string][]string{
+			"tags_1": {"go", "redis", "sql", "gopher", "cache"},
+		}}}
+		sscan = func(args ...string) []string {
+			cmd := redcon.Command{Args: [][]byte{[]byte("SSCAN")}}
+			for _, arg := range args {
+				cmd.Args = append(cmd.Args, []byte(arg))
+			}
+			conn := &pipelineConn{}
+			srv.cmdSScan(ctx, conn, 0, cmd)
+			return conn.replies
+		}
+	)
+	assert.Equal(t, []string{"*2", "2", "*2", "cache", "go"}, sscan("tags_1", "0", "COUNT", "2"))
+	assert.Equal(t, []string{"*2", "4", "*2", "gopher", "redis"}, sscan("tags_1", "2", "COUNT", "2"))
+	assert.Equal(t, []string{"*2", "0", "*1", "sql"}, sscan("tags_1", "4", "COUNT", "2"))
+	assert.Equal(t, []string{"*2", "0", "*2", "go", "gopher"}, sscan("tags_1", "0", "MATCH", "go*"))
+	assert.Equal(t, []string{"*2", "0", "*0"}, sscan("tags_2", "0"))
+	assert.Equal(t, []string{"-ERR syntax error"}, sscan("tags_1", "0", "TYPE", "set"))
+}
@@ -0,0 +1,234 @@
+package server
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/tidwall/redcon"
+
+	"github.com/demdxx/redify/internal/acl"
+)
+
+// commandSpec of the COMMAND reply. Arity includes the command name,
+// negative arity is the minimal number of the arguments. Key positions
+// are the indexes of the arguments, negative last key counts from the end.
+type commandSpec struct {
+	arity    int
+	firstKey int
+	lastKey  int
+	step     int
+	group    string
+	summary  string
+}
+
+// commandTable of the supported commands
+var commandTable = map[string]commandSpec{
+	"publish":          {3, 0, 0, 0, "pubsub", "Posts a message to a channel."},
+	"subscribe":        {-2, 0, 0, 0, "pubsub", "Listens for messages published to channels."},
+	"psubscribe":       {-2, 0, 0, 0, "pubsub", "Listens for messages published to channels that match one or more patterns."},
+	"detach":           {1, 0, 0, 0, "connection", "Detaches the connection from the server loop."},
+	"hello":            {-1, 0, 0, 0, "connection", "Handshakes with the server."},
+	"auth":             {-2, 0, 0, 0, "connection", "Authenticates the connection."},
+	"acl":              {-2, 0, 0, 0, "server", "Reports the ACL users."},
+	"client":           {-2, 0, 0, 0, "connection", "Manages the client connections."},
+	"multi":            {1, 0, 0, 0, "transactions", "Starts a transaction."},
+	"exec":             {1, 0, 0, 0, "transactions", "Executes all commands in a transaction."},
+	"discard":          {1, 0, 0, 0, "transactions", "Discards a transaction."},
+	"watch":            {-2, 1, -1, 1, "transactions", "Monitors changes to keys to determine the execution of a transaction."},
+	"unwatch":          {1, 0, 0, 0, "transactions", "Forgets about watched keys of a transaction."},
+	"ping":             {-1, 0, 0, 0, "connection", "Returns the server's liveliness response."},
+	"echo":             {2, 0, 0, 0, "connection", "Returns the given string."},
+	"quit":             {-1, 0, 0, 0, "connection", "Closes the connection."},
+	"select":           {2, 0, 0, 0, "connection", "Changes the selected database."},
+	"config":           {-2, 0, 0, 0, "server", "Gets and sets the runtime parameters."},
+	"info":             {-1, 0, 0, 0, "server", "Returns information and statistics about the server."},
+	"dbsize":           {1, 0, 0, 0, "server", "Returns the number of keys in the database."},
+	"command":          {-1, 0, 0, 0, "server", "Returns detailed information about all commands."},
+	"get":              {2, 1, 1, 1, "string", "Returns the string value of a key."},
+	"set":              {-3, 1, 1, 1, "string", "Sets the string value of a key."},
+	"mget":             {-2, 1, -1, 1, "string", "Atomically returns the string values of one or more keys."},
+	"mset":             {-3, 1, -1, 2, "string", "Atomically creates or modifies the string values of one or more keys."},
+	"incr":             {2, 1, 1, 1, "string", "Increments the integer value of a key by one."},
+	"decr":             {2, 1, 1, 1, "string", "Decrements the integer value of a key by one."},
+	"incrby":           {3, 1, 1, 1, "string", "Increments the integer value of a key by a number."},
+	"decrby":           {3, 1, 1, 1, "string", "Decrements a number from the integer value of a key."},
+	"incrbyfloat":      {3, 1, 1, 1, "string", "Increments the floating point value of a key by a number."},
+	"hget":             {3, 1, 1, 1, "hash", "Returns the value of a field in a hash."},
+	"hmget":            {-3, 1, 1, 1, "hash", "Returns the values of all fields in a hash."},
+	"hgetall":          {2, 1, 1, 1, "hash", "Returns all fields and values in a hash."},
+	"hkeys":            {2, 1, 1, 1, "hash", "Returns all fields in a hash."},
+	"hvals":            {2, 1, 1, 1, "hash", "Returns all values in a hash."},
+	"hlen":             {2, 1, 1, 1, "hash", "Returns the number of fields in a hash."},
+	"hexists":          {3, 1, 1, 1, "hash", "Determines whether a field exists in a hash."},
+	"hstrlen":          {3, 1, 1, 1, "hash", "Returns the length of the value of a field."},
+	"hset":             {-4, 1, 1, 1, "hash", "Creates or modifies the value of a field in a hash."},
+	"hmset":            {-4, 1, 1, 1, "hash", "Sets the values of multiple fields."},
+	"hdel":             {-3, 1, 1, 1, "hash", "Deletes one or more fields and their values from a hash."},
+	"hincrby":          {4, 1, 1, 1, "hash", "Increments the integer value of a field in a hash by a number."},
+	"hincrbyfloat":     {4, 1, 1, 1, "hash", "Increments the floating point value of a field by a number."},
+	"hscan":            {-3, 1, 1, 1, "hash", "Iterates over fields and values of a hash."},
+	"lrange":           {4, 1, 1, 1, "list", "Returns a range of elements from a list."},
+	"lindex":           {3, 1, 1, 1, "list", "Returns an element from a list by its index."},
+	"llen":             {2, 1, 1, 1, "list", "Returns the length of a list."},
+	"rpush":            {-3, 1, 1, 1, "list", "Appends one or more elements to a list."},
+	"smembers":         {2, 1, 1, 1, "set", "Returns all members of a set."},
+	"sismember":        {3, 1, 1, 1, "set", "Determines whether a member belongs to a set."},
+	"sscan":            {-3, 1, 1, 1, "set", "Iterates over members of a set."},
+	"scard":            {2, 1, 1, 1, "set", "Returns the number of members in a set."},
+	"sadd":             {-3, 1, 1, 1, "set", "Adds one or more members to a set."},
+	"srem":             {-3, 1, 1, 1, "set", "Removes one or more members from a set."},
+	"zrange":           {-4, 1, 1, 1, "sorted-set", "Returns members in a sorted set within a range of indexes."},
+	"zrevrange":        {-4, 1, 1, 1, "sorted-set", "Returns members in a sorted set within a range of indexes in reverse order."},
+	"zrangebyscore":    {-4, 1, 1, 1, "sorted-set", "Returns members in a sorted set within a range of scores."},
+	"zrevrangebyscore": {-4, 1, 1, 1, "sorted-set", "Returns members in a sorted set within a range of scores in reverse order."},
+	"zrangebylex":      {-4, 1, 1, 1, "sorted-set", "Returns members in a sorted set within a lexicographical range."},
+	"zrevrangebylex":   {-4, 1, 1, 1, "sorted-set", "Returns members in a sorted set within a lexicographical range in reverse order."},
+	"zscore":           {3, 1, 1, 1, "sorted-set", "Returns the score of a member in a sorted set."},
+	"zrank":            {-3, 1, 1, 1, "sorted-set", "Returns the index of a member in a sorted set ordered by ascending scores."},
+	"zrevrank":         {-3, 1, 1, 1, "sorted-set", "Returns the index of a member in a sorted set ordered by descending scores."},
+	"zcard":            {2, 1, 1, 1, "sorted-set", "Returns the number of members in a sorted set."},
+	"zadd":             {-4, 1, 1, 1, "sorted-set", "Adds one or more members to a sorted set, or updates their scores."},
+	"zincrby":          {4, 1, 1, 1, "sorted-set", "Increments the score of a member in a sorted set."},
+	"zrem":             {-3, 1, 1, 1, "sorted-set", "Removes one or more members from a sorted set."},
+	"xadd":             {-5, 1, 1, 1, "stream", "Appends a new message to a stream."},
+	"xread":            {-4, 0, 0, 0, "stream", "Returns messages from multiple streams with IDs greater than the ones requested."},
+	"xreadgroup":       {-7, 0, 0, 0, "stream", "Returns new or historical messages from a stream for a consumer in a group. Groups are kept in the memory of the instance."},
+	"xack":             {-4, 1, 1, 1, "stream", "Returns the number of messages that were successfully acknowledged by the consumer group member of a stream. The broker message is acknowledged after the last group."},
+	"xgroup":           {-2, 2, 2, 1, "stream", "Creates or destroys a consumer group in the memory of the instance."},
+	"json.get":         {-2, 1, 1, 1, "json", "Gets the value at one or more paths in JSON serialized form."},
+	"json.mget":        {-3, 1, -2, 1, "json", "Returns the values at a path from one or more keys."},
+	"json.type":        {-2, 1, 1, 1, "json", "Returns the type of the JSON value at path."},
+	"json.arrlen":      {-2, 1, 1, 1, "json", "Returns the length of the array at path."},
+	"json.set":         {-4, 1, 1, 1, "json", "Sets or updates the JSON value at a path."},
+	"json.del":         {-2, 1, 1, 1, "json", "Deletes a value."},
+	"json.forget":      {-2, 1, 1, 1, "json", "Deletes a value."},
+	"del":              {2, 1, 1, 1, "generic", "Deletes the key."},
+	"type":             {2, 1, 1, 1, "generic", "Determines the type of value stored at a key."},
+	"expire":           {3, 1, 1, 1, "generic", "Sets the expiration time of a key in seconds."},
+	"pexpire":          {3, 1, 1, 1, "generic", "Sets the expiration time of a key in milliseconds."},
+	"expireat":         {3, 1, 1, 1, "generic", "Sets the expiration time of a key to a Unix timestamp."},
+	"pexpireat":        {3, 1, 1, 1, "generic", "Sets the expiration time of a key to a Unix milliseconds timestamp."},
+	"persist":          {2, 1, 1, 1, "generic", "Removes the expiration time of a key."},
+	"ttl":              {2, 1, 1, 1, "generic", "Returns the expiration time in seconds of a key."},
+	"pttl":             {2, 1, 1, 1, "generic", "Returns the expiration time in milliseconds of a key."},
+	"keys":             {2, 0, 0, 0, "generic", "Returns all key names that match a pattern."},
+	"scan":             {-2, 0, 0, 0, "generic", "Iterates over the key names in the database."},
+}
+
+// cmdCommand returns the information about the supported commands
+//
+//	COMMAND
+//	COMMAND COUNT
+//	COMMAND LIST
+//	COMMAND INFO [command-name ...]
+//	COMMAND DOCS [command-name ...]
+func (srv *RedisServer) cmdCommand(conn redcon.Conn, cmd redcon.Command) {
+	if len(cmd.Args) == 1 {
+		names := commandNames()
+		conn.WriteArray(len(names))
+		for _, name := range names {
+			writeCommandInfo(conn, name, commandTable[name])
+		}
+		return
+	}
+	names := argStrings(cmd.Args[2:], 1)
+	for i, name := range names {
+		names[i] = strings.ToLower(name)
+	}
+	switch strings.ToLower(string(cmd.Args[1])) {
+	case "count":
+		conn.WriteInt(len(commandTable))
+	case "list":
+		names = commandNames()
+		conn.WriteArray(len(names))
+		for _, name := range names {
+			conn.WriteBulkString(name)
+		}
+	case "info":
+		if len(names) == 0 {
+			names = commandNames()
+		}
+		conn.WriteArray(len(names))
+		for _, name := range names {
+			if spec, ok := commandTable[name]; ok {
+				writeCommandInfo(conn, name, spec)
+			} else {
+				conn.WriteNull()
+			}
+		}
+	case "docs":
+		if len(names) == 0 {
+			names = commandNames()
+		}
+		docs := make([]string, 0, len(names))
+		for _, name := range names {
+			if _, ok := commandTable[name]; ok {
+				docs = append(docs, name)
+			}
+		}
+		writeMap(conn, len(docs))
+		for _, name := range docs {
+			spec := commandTable[name]
+			conn.WriteBulkString(name)
+			writeMap(conn, 2)
+			conn.WriteBulkString("summary")
+			conn.WriteBulkString(spec.summary)
+			conn.WriteBulkString("group")
+			conn.WriteBulkString(spec.group)
+		}
+	default:
+		conn.WriteError("ERR unknown subcommand '" + string(cmd.Args[1]) + "'. Try COMMAND HELP.")
+	}
+}
+
+// writeCommandInfo in the format of Redis 7 with empty tips, key specifications and subcommands
+func writeCommandInfo(conn redcon.Conn, name string, spec commandSpec) {
+	var (
+		flags      []string
+		categories []string
+	)
+	switch category := commandCategory(name); category {
+	case acl.CategoryRead:
+		flags, categories = append(flags, "readonly"), append(categories, "@"+category)
+	case acl.CategoryWrite:
+		flags, categories = append(flags, "write"), append(categories, "@"+category)
+	case acl.CategoryAdmin:
+		flags, categories = append(flags, "admin", "noscript"), append(categories, "@"+category)
+	}
+	switch {
+	case spec.group == "pubsub":
+		flags = append(flags, "pubsub")
+	case spec.group == "connection":
+		flags = append(flags, "fast")
+	case name == "xread" || name == "xreadgroup":
+		flags = append(flags, "movablekeys")
+	}
+	conn.WriteArray(10)
+	conn.WriteBulkString(name)
+	conn.WriteInt(spec.arity)
+	writeStatusArray(conn, flags)
+	conn.WriteInt(spec.firstKey)
+	conn.WriteInt(spec.lastKey)
+	conn.WriteInt(spec.step)
+	writeStatusArray(conn, categories)
+	conn.WriteArray(0)
+	conn.WriteArray(0)
+	conn.WriteArray(0)
+}
+
+func writeStatusArray(conn redcon.Conn, values []string) {
+	writeSet(conn, len(values))
+	for _, value := range values {
+		conn.WriteString(value)
+	}
+}
+
+// commandNames of the command table in the alphabetical order
+func commandNames() []string {
+	names := make([]string, 0, len(commandTable))
+	for name := range commandTable {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/tidwall/redcon"
+
+	"github.com/demdxx/redify/internal/acl"
+)
+
+// commandCategory returns the ACL category of the command.
+// Empty category means the connection command which is allowed for every user.
+func commandCategory(name string) string {
+	switch name {
+	case "get", "mget", "hget", "hmget", "hgetall", "hkeys", "hvals", "hlen",
+		"hexists", "hstrlen", "keys", "scan", "hscan", "ttl", "pttl", "type",
+		"lrange", "lindex", "llen", "smembers", "sismember", "sscan", "scard", "zrange", "zrevrange",
+		"zrangebyscore", "zrevrangebyscore", "zrangebylex", "zrevrangebylex", "zscore", "zrank", "zrevrank",
+		"zcard", "json.get", "json.mget", "json.type", "json.arrlen", "xread",
+		"subscribe", "psubscribe", "dbsize":
+		return acl.CategoryRead
+	case "set", "mset", "del", "hset", "hmset", "hdel", "hincrby", "hincrbyfloat",
+		"incr", "decr", "incrby", "decrby", "incrbyfloat", "rpush", "sadd", "srem",
+		"zadd", "zincrby", "zrem", "json.set", "json.del", "json.forget", "xadd", "xreadgroup", "xack", "xgroup",
+		"expire", "pexpire", "expireat", "pexpireat", "persist", "publish":
+		return acl.CategoryWrite
+	case "config", "detach":
+		return acl.CategoryAdmin
+	}
+	return ""
+}
+
+// adminSubcommand returns true if the subcommand of the connection command
+// exposes other connections, so it requires the admin category
+func adminSubcommand(name string, cmd redcon.Command) bool {
+	if len(cmd.Args) < 2 {
+		return false
+	}
+	return name == "client" && strings.EqualFold(string(cmd.Args[1]), "list")
+}
+
+// transactionalCommand returns true if the command can be queued by MULTI
+func transactionalCommand(name string) bool {
+	switch name {
+	case "get", "mget", "hget", "hmget", "hgetall", "hkeys", "hvals", "hlen", "hexists", "hstrlen",
+		"set", "mset", "del", "hset", "hmset", "hdel", "hincrby", "hincrbyfloat",
+		"incr", "decr", "incrby", "decrby", "incrbyfloat", "type",
+		"lrange", "lindex", "llen", "smembers", "sismember", "scard", "rpush", "sadd", "srem",
+		"zrange", "zrevrange", "zrangebyscore", "zrevrangebyscore", "zrangebylex", "zrevrangebylex",
+		"zscore", "zrank", "zrevrank", "zcard", "zadd", "zincrby", "zrem",
+		"json.get", "json.mget", "json.type", "json.arrlen", "json.set", "json.del", "json.forget",
+		"xadd", "xack", "expire", "pexpire", "expireat", "pexpireat", "persist", "ttl", "pttl", "ping", "echo", "dbsize":
+		return true
+	}
+	return false
+}
+
+// commandKeys returns the keys which are accessed by the command
+func commandKeys(cmd redcon.Command) []string {
+	if len(cmd.Args) < 2 {
+		return nil
+	}
+	switch strings.ToLower(string(cmd.Args[0])) {
+	case "mget", "del":
+		return argStrings(cmd.Args[1:], 1)
+	case "mset":
+		return argStrings(cmd.Args[1:], 2)
+	case "json.mget":
+		return argStrings(cmd.Args[1:len(cmd.Args)-1], 1)
+	case "xread", "xreadgroup":
+		return streamKeys(cmd.Args)
+	case "xgroup":
+		if len(cmd.Args) > 2 {
+			return []string{string(cmd.Args[2])}
+		}
+		return nil
+	case "get", "set", "hget", "hmget", "hgetall", "hkeys", "hvals", "hlen", "hexists",
+		"hstrlen", "hscan", "hset", "hmset", "hdel", "hincrby", "hincrbyfloat",
+		"incr", "decr", "incrby", "decrby", "incrbyfloat", "type",
+		"lrange", "lindex", "llen", "smembers", "sismember", "sscan", "scard", "rpush", "sadd", "srem",
+		"zrange", "zrevrange", "zrangebyscore", "zrevrangebyscore", "zrangebylex", "zrevrangebylex",
+		"zscore", "zrank", "zrevrank", "zcard", "zadd", "zincrby", "zrem",
+		"json.get", "json.type", "json.arrlen", "json.set", "json.del", "json.forget", "xadd", "xack",
+		"expire", "pexpire", "expireat", "pexpireat", "persist", "ttl", "pttl":
+		return []string{string(cmd.Args[1])}
+	}
+	return nil
+}
+
+// argStrings returns every step-th argument as string
+func argStrings(args [][]byte, step int) []string {
+	res := make([]string, 0, (len(args)+step-1)/step)
+	for i := 0; i < len(args); i += step {
+		res = append(res, string(args[i]))
+	}
+	return res
+}
+
+// streamKeys returns the first half of the arguments after STREAMS
+func streamKeys(args [][]byte) []string {
+	for i, arg := range args {
+		if strings.EqualFold(string(arg), "streams") {
+			rest := args[i+1:]
+			return argStrings(rest[:len(rest)/2], 1)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/tidwall/redcon"
+
+	"github.com/demdxx/redify/internal/keypattern"
+	"github.com/demdxx/redify/internal/storage"
+)
+
+const configNotifyKeyspaceEvents = "notify-keyspace-events"
+
+// cmdConfig reads and changes the runtime parameters of the server.
+// Unknown parameters are returned empty for compatibility with the Redis tools.
+//
+//	CONFIG GET parameter [parameter ...]
+//	CONFIG SET parameter value [parameter value ...]
+//	CONFIG RESETSTAT
+func (srv *RedisServer) cmdConfig(conn redcon.Conn, cmd redcon.Command) {
+	if len(cmd.Args) < 2 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	switch strings.ToLower(string(cmd.Args[1])) {
+	case "get":
+		if len(cmd.Args) < 3 {
+			srv.wrongNumberArgsError(conn, cmd)
+			return
+		}
+		params := make([]string, 0, (len(cmd.Args)-2)*2)
+		for _, arg := range cmd.Args[2:] {
+			pattern := strings.ToLower(string(arg))
+			switch {
+			case keypattern.GlobMatch(pattern, configNotifyKeyspaceEvents):
+				params = append(params, configNotifyKeyspaceEvents,
+					keyspaceEvents(srv.keyspaceEvents.Load()).String())
+			case !strings.ContainsAny(pattern, "*?["):
+				params = append(params, pattern, "")
+			}
+		}
+		writeMap(conn, len(params)/2)
+		for _, param := range params {
+			conn.WriteBulkString(param)
+		}
+	case "set":
+		if len(cmd.Args) < 4 || len(cmd.Args)%2 != 0 {
+			srv.wrongNumberArgsError(conn, cmd)
+			return
+		}
+		for i := 2; i < len(cmd.Args); i += 2 {
+			name, value := strings.ToLower(string(cmd.Args[i])), string(cmd.Args[i+1])
+			if name != configNotifyKeyspaceEvents {
+				conn.WriteError("ERR Unknown option or number of arguments for CONFIG SET - '" + name + "'")
+				return
+			}
+			if _, err := parseKeyspaceEvents(value); err != nil {
+				conn.WriteError("ERR Invalid argument '" + value + "' for CONFIG SET '" + name + "' - " + err.Error())
+				return
+			}
+		}
+		for i := 2; i < len(cmd.Args); i += 2 {
+			flags, _ := parseKeyspaceEvents(string(cmd.Args[i+1]))
+			srv.keyspaceEvents.Store(uint32(flags))
+			storage.EnableExpiredEvents(srv.Driver, flags.expiredEvents())
+		}
+		conn.WriteString("OK")
+	case "resetstat":
+		srv.clients.connections.Store(0)
+		srv.clients.commands.Store(0)
+		conn.WriteString("OK")
+	default:
+		conn.WriteError("ERR unknown subcommand '" + string(cmd.Args[1]) + "'. Try CONFIG HELP.")
+	}
+}
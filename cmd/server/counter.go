@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/demdxx/gocast/v2"
+	"github.com/tidwall/redcon"
+
+	"github.com/demdxx/redify/internal/storage"
+)
+
+// cmdIncr increments the counter column of the key atomically
+//
+//	INCR key
+//	DECR key
+//	INCRBY key increment
+//	DECRBY key decrement
+//	INCRBYFLOAT key increment
+func (srv *RedisServer) cmdIncr(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	var (
+		name    = strings.ToLower(string(cmd.Args[0]))
+		isFloat = name == "incrbyfloat"
+		delta   any
+		err     error
+	)
+	switch name {
+	case "incr", "decr":
+		if len(cmd.Args) != 2 {
+			srv.wrongNumberArgsError(conn, cmd)
+			return
+		}
+		delta = int64(1)
+	default:
+		if len(cmd.Args) != 3 {
+			srv.wrongNumberArgsError(conn, cmd)
+			return
+		}
+		if isFloat {
+			delta, err = strconv.ParseFloat(string(cmd.Args[2]), 64)
+		} else {
+			delta, err = strconv.ParseInt(string(cmd.Args[2]), 10, 64)
+		}
+	}
+	if err != nil {
+		if isFloat {
+			conn.WriteError("ERR value is not a valid float")
+		} else {
+			conn.WriteError("ERR value is not an integer or out of range")
+		}
+		return
+	}
+	if name == "decr" || name == "decrby" {
+		if delta.(int64) == math.MinInt64 {
+			conn.WriteError("ERR decrement would overflow")
+			return
+		}
+		delta = -delta.(int64)
+	}
+	key := string(cmd.Args[1])
+	_, value, err := storage.IncrBy(ctx, srv.Driver, dbnum, key, delta)
+	switch {
+	case err == nil && isFloat:
+		srv.keyEvent(ctx, dbnum, key, storage.KeyEventIncrByFloat)
+	case err == nil:
+		srv.keyEvent(ctx, dbnum, key, storage.KeyEventIncrBy)
+	}
+	switch {
+	case errors.Is(err, storage.ErrNoKey) || errors.Is(err, storage.ErrNotFound):
+		conn.WriteError("ERR no such key")
+	case errors.Is(err, storage.ErrMethodIsNotSupported):
+		conn.WriteError("ERR counters are not supported by the key storage, counter_column or incr_query is required")
+	case err != nil:
+		conn.WriteError("ERR " + err.Error())
+	case isFloat:
+		conn.WriteBulkString(strconv.FormatFloat(gocast.Number[float64](value), 'f', -1, 64))
+	default:
+		conn.WriteInt64(gocast.Number[int64](value))
+	}
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tidwall/redcon"
+
+	"github.com/demdxx/redify/internal/storage"
+)
+
+// cmdExpire sets the expiration time of the key
+//
+//	EXPIRE key seconds
+//	PEXPIRE key milliseconds
+//	EXPIREAT key unix-time-seconds
+//	PEXPIREAT key unix-time-milliseconds
+func (srv *RedisServer) cmdExpire(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) != 3 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	num, err := strconv.ParseInt(string(cmd.Args[2]), 10, 64)
+	if err != nil {
+		conn.WriteError("ERR value is not an integer or out of range")
+		return
+	}
+	var at time.Time
+	switch strings.ToLower(string(cmd.Args[0])) {
+	case "expire":
+		at = time.Now().Add(time.Duration(num) * time.Second)
+	case "pexpire":
+		at = time.Now().Add(time.Duration(num) * time.Millisecond)
+	case "expireat":
+		at = time.Unix(num, 0)
+	case "pexpireat":
+		at = time.UnixMilli(num)
+	}
+	srv.writeExpire(ctx, conn, dbnum, string(cmd.Args[1]), at)
+}
+
+// cmdPersist removes the expiration of the key
+//
+//	PERSIST key
+func (srv *RedisServer) cmdPersist(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) != 2 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	srv.writeExpire(ctx, conn, dbnum, string(cmd.Args[1]), time.Time{})
+}
+
+func (srv *RedisServer) writeExpire(ctx context.Context, conn redcon.Conn, dbnum int, key string, at time.Time) {
+	ok, err := storage.Expire(ctx, srv.Driver, dbnum, key, at)
+	switch {
+	case errors.Is(err, storage.ErrNoKey) || errors.Is(err, storage.ErrNotFound):
+		conn.WriteInt(0)
+	case errors.Is(err, storage.ErrMethodIsNotSupported):
+		conn.WriteError("ERR expiration is not supported by the key storage, ttl_column is required")
+	case err != nil:
+		conn.WriteError("ERR " + err.Error())
+	case ok:
+		srv.keyEvent(ctx, dbnum, key, expireEvent(at))
+		conn.WriteInt(1)
+	default:
+		conn.WriteInt(0)
+	}
+}
+
+// expireEvent of the changed expiration, the time in the past deletes the key
+func expireEvent(at time.Time) string {
+	switch {
+	case at.IsZero():
+		return storage.KeyEventPersist
+	case !at.After(time.Now()):
+		return storage.KeyEventDel
+	default:
+		return storage.KeyEventExpire
+	}
+}
+
+// cmdTTL returns the remaining time to live of the key
+//
+//	TTL key
+//	PTTL key
+func (srv *RedisServer) cmdTTL(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) != 2 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	ttl, err := storage.TTL(ctx, srv.Driver, dbnum, string(cmd.Args[1]))
+	switch {
+	case errors.Is(err, storage.ErrNoKey) || errors.Is(err, storage.ErrNotFound):
+		conn.WriteInt(-2)
+	case err != nil:
+		conn.WriteError("ERR " + err.Error())
+	case ttl == storage.NoExpiration:
+		conn.WriteInt(-1)
+	case strings.EqualFold(string(cmd.Args[0]), "pttl"):
+		conn.WriteInt64(max(ttl.Milliseconds(), 0))
+	default:
+		// Round to the nearest second as Redis does
+		conn.WriteInt64(max(int64((ttl+time.Second/2)/time.Second), 0))
+	}
+}
@@ -3,6 +3,8 @@ package server
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"encoding/base64"
 	"encoding/csv"
 	"encoding/json"
 	"errors"
@@ -14,6 +16,7 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/logger"
 
 	"github.com/demdxx/gocast/v2"
+	"github.com/demdxx/redify/internal/acl"
 	"github.com/demdxx/redify/internal/storage"
 	"github.com/demdxx/xtypes"
 )
@@ -21,8 +24,15 @@ import (
 type HTTPServer struct {
 	Driver         storage.Driver
 	RequestTimeout time.Duration
+	ACL            *acl.ACL
+	TLS            *tls.Config
+	// Keyspace of the changes shared with the Redis server
+	Keyspace *Keyspace
 }
 
+// userLocalKey of the authenticated user in the request locals
+const userLocalKey = "acl_user"
+
 // ListenAndServe redify HTTPServer
 func (srv *HTTPServer) ListenAndServe(ctx context.Context, addr string) error {
 	srvApp := fiber.New(fiber.Config{
@@ -30,24 +40,81 @@ func (srv *HTTPServer) ListenAndServe(ctx context.Context, addr string) error {
 		ReadTimeout:  srv.RequestTimeout,
 	})
 	srvApp.Use(logger.New(logger.ConfigDefault))
+	if srv.ACL.Enabled() {
+		srvApp.Use(srv.authenticate)
+	}
 
 	srvApp.Get("/:dbnum/:key", srv.get)
 	srvApp.Put("/:dbnum/:key", srv.set)
 	srvApp.Post("/:dbnum/:key", srv.set)
 	srvApp.Get("/:dbnum/list/:pattern", srv.list)
 	srvApp.Get("/:dbnum/keys/:pattern", srv.keys)
+	srvApp.Post("/:dbnum/bulk/get", srv.bulkGet)
 	srvApp.Delete("/:dbnum/:key", srv.del)
 
+	if srv.TLS != nil {
+		ln, err := tls.Listen("tcp", addr, srv.TLS)
+		if err != nil {
+			return err
+		}
+		return srvApp.Listener(ln)
+	}
 	return srvApp.Listen(addr)
 }
 
+// authenticate the request by the bearer token or basic auth
+func (srv *HTTPServer) authenticate(c *fiber.Ctx) error {
+	var (
+		user *acl.User
+		err  error
+		auth = c.Get(fiber.HeaderAuthorization)
+	)
+	switch {
+	case auth == "":
+		if user = srv.ACL.Default(); user == nil {
+			err = acl.ErrAuthRequired
+		}
+	case strings.HasPrefix(auth, "Bearer "):
+		user, err = srv.ACL.AuthenticateToken(strings.TrimPrefix(auth, "Bearer "))
+	case strings.HasPrefix(auth, "Basic "):
+		var (
+			data, _        = base64.StdEncoding.DecodeString(strings.TrimPrefix(auth, "Basic "))
+			name, pass, ok = strings.Cut(string(data), ":")
+		)
+		if !ok {
+			err = acl.ErrWrongPass
+		} else {
+			user, err = srv.ACL.Authenticate(name, pass)
+		}
+	default:
+		err = acl.ErrWrongPass
+	}
+	if err != nil {
+		c.Set(fiber.HeaderWWWAuthenticate, `Basic realm="redify"`)
+		return sendErrorStatus(c, fiber.StatusUnauthorized, err)
+	}
+	c.Locals(userLocalKey, user)
+	return c.Next()
+}
+
+// checkAccess of the authenticated user to the keys of the database
+func (srv *HTTPServer) checkAccess(c *fiber.Ctx, command, category string, dbnum int, keys ...string) error {
+	if user, _ := c.Locals(userLocalKey).(*acl.User); user != nil {
+		return user.Check(command, category, dbnum, keys...)
+	}
+	return nil
+}
+
 func (srv *HTTPServer) get(c *fiber.Ctx) error {
 	var (
-		ctx        = c.UserContext()
-		key        = c.Params("key")
-		dbnum, _   = c.ParamsInt("dbnum")
-		value, err = srv.Driver.Get(ctx, dbnum, key)
+		ctx      = c.UserContext()
+		key      = c.Params("key")
+		dbnum, _ = c.ParamsInt("dbnum")
 	)
+	if err := srv.checkAccess(c, "get", acl.CategoryRead, dbnum, key); err != nil {
+		return sendErrorStatus(c, fiber.StatusForbidden, err)
+	}
+	value, err := srv.Driver.Get(ctx, dbnum, key)
 	if err != nil && !errors.Is(err, storage.ErrNotFound) && !errors.Is(err, storage.ErrNoKey) {
 		return sendError(c, err)
 	}
@@ -57,30 +124,75 @@ func (srv *HTTPServer) get(c *fiber.Ctx) error {
 	return sendJSON(c, value)
 }
 
+func (srv *HTTPServer) bulkGet(c *fiber.Ctx) error {
+	var (
+		ctx      = c.UserContext()
+		dbnum, _ = c.ParamsInt("dbnum")
+		keys     []string
+	)
+	if err := json.Unmarshal(c.Body(), &keys); err != nil {
+		return sendError(c, err)
+	}
+	if err := srv.checkAccess(c, "mget", acl.CategoryRead, dbnum, keys...); err != nil {
+		return sendErrorStatus(c, fiber.StatusForbidden, err)
+	}
+	var (
+		items  = storage.GetMany(ctx, srv.Driver, dbnum, keys)
+		result = make(map[string]json.RawMessage, len(keys))
+	)
+	for i, item := range items {
+		if item.Err != nil && !errors.Is(item.Err, storage.ErrNotFound) && !errors.Is(item.Err, storage.ErrNoKey) {
+			return sendError(c, item.Err)
+		}
+		switch {
+		case item.Value == nil:
+			result[keys[i]] = json.RawMessage("null")
+		case json.Valid(item.Value):
+			result[keys[i]] = json.RawMessage(item.Value)
+		default:
+			result[keys[i]], _ = json.Marshal(string(item.Value))
+		}
+	}
+	return sendJSONObject(c, result)
+}
+
 func (srv *HTTPServer) keys(c *fiber.Ctx) error {
 	var (
-		ctx       = c.UserContext()
-		pattern   = strings.ReplaceAll(c.Params("pattern"), "%2A", "*")
-		dbnum, _  = c.ParamsInt("dbnum")
-		keys, err = srv.Driver.Keys(ctx, dbnum, pattern)
+		ctx      = c.UserContext()
+		pattern  = strings.ReplaceAll(c.Params("pattern"), "%2A", "*")
+		dbnum, _ = c.ParamsInt("dbnum")
 	)
+	if err := srv.checkAccess(c, "keys", acl.CategoryRead, dbnum); err != nil {
+		return sendErrorStatus(c, fiber.StatusForbidden, err)
+	}
+	keys, err := srv.Driver.Keys(ctx, dbnum, pattern)
 	if err != nil && !errors.Is(err, storage.ErrNotFound) && !errors.Is(err, storage.ErrNoKey) {
 		return sendError(c, err)
 	}
 	if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrNoKey) {
 		return sendNotFound(c)
 	}
+	if user, _ := c.Locals(userLocalKey).(*acl.User); user != nil {
+		keys = user.FilterKeys(keys)
+	}
 	return sendJSONObject(c, keys)
 }
 
 func (srv *HTTPServer) list(c *fiber.Ctx) error {
 	var (
-		ctx       = c.UserContext()
-		pattern   = strings.ReplaceAll(c.Params("pattern"), "%2A", "*")
-		dbnum, _  = c.ParamsInt("dbnum")
-		format    = strings.ToLower(c.Query("format", "json"))
-		data, err = srv.Driver.List(ctx, dbnum, pattern)
+		ctx      = c.UserContext()
+		pattern  = strings.ReplaceAll(c.Params("pattern"), "%2A", "*")
+		dbnum, _ = c.ParamsInt("dbnum")
+		format   = strings.ToLower(c.Query("format", "json"))
 	)
+	// Records of the list have no keys, so it requires access to all keys
+	if user, _ := c.Locals(userLocalKey).(*acl.User); user != nil && !user.AllKeys() {
+		return sendErrorStatus(c, fiber.StatusForbidden, acl.ErrNoPermKey)
+	}
+	if err := srv.checkAccess(c, "list", acl.CategoryRead, dbnum); err != nil {
+		return sendErrorStatus(c, fiber.StatusForbidden, err)
+	}
+	data, err := srv.Driver.List(ctx, dbnum, pattern)
 	if err != nil && !errors.Is(err, storage.ErrNotFound) && !errors.Is(err, storage.ErrNoKey) {
 		return sendError(c, err)
 	}
@@ -95,11 +207,14 @@ func (srv *HTTPServer) set(c *fiber.Ctx) error {
 		ctx      = c.UserContext()
 		key      = c.Params("key")
 		dbnum, _ = c.ParamsInt("dbnum")
-		err      = srv.Driver.Set(ctx, dbnum, key, c.Body())
 	)
-	if err != nil {
+	if err := srv.checkAccess(c, "set", acl.CategoryWrite, dbnum, key); err != nil {
+		return sendErrorStatus(c, fiber.StatusForbidden, err)
+	}
+	if err := srv.Driver.Set(ctx, dbnum, key, c.Body()); err != nil {
 		return sendError(c, err)
 	}
+	srv.Keyspace.Notify(ctx, dbnum, key, storage.KeyEventSet)
 	return sendOK(c)
 }
 
@@ -108,14 +223,18 @@ func (srv *HTTPServer) del(c *fiber.Ctx) error {
 		ctx      = c.UserContext()
 		key      = c.Params("key")
 		dbnum, _ = c.ParamsInt("dbnum")
-		err      = srv.Driver.Del(ctx, dbnum, key)
 	)
+	if err := srv.checkAccess(c, "del", acl.CategoryWrite, dbnum, key); err != nil {
+		return sendErrorStatus(c, fiber.StatusForbidden, err)
+	}
+	err := srv.Driver.Del(ctx, dbnum, key)
 	if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrNoKey) {
 		return sendNotFound(c)
 	}
 	if err != nil {
 		return sendError(c, err)
 	}
+	srv.Keyspace.Notify(ctx, dbnum, key, storage.KeyEventDel)
 	return sendOK(c)
 }
 
@@ -130,7 +249,11 @@ func sendOK(c *fiber.Ctx) error {
 }
 
 func sendError(c *fiber.Ctx, err error) error {
-	_ = c.SendStatus(fiber.StatusInternalServerError)
+	return sendErrorStatus(c, fiber.StatusInternalServerError, err)
+}
+
+func sendErrorStatus(c *fiber.Ctx, status int, err error) error {
+	_ = c.SendStatus(status)
 	c.Response().Header.Add("content-type", "application/json")
 	return c.SendString(`{"status":"error","error":"` +
 		strings.ReplaceAll(err.Error(), `"`, `\"`) + `"}`)
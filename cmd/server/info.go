@@ -0,0 +1,213 @@
+package server
+
+import (
+	"context"
+	"net"
+	"os"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tidwall/redcon"
+
+	"github.com/demdxx/redify/internal/storage"
+)
+
+// redisVersion is the compatibility level reported to the clients
+const redisVersion = "7.2.0"
+
+// infoDefaultSections are reported by INFO without arguments.
+// Keyspace counts the keys of every bind, so it's reported only on request.
+var infoDefaultSections = []string{"server", "clients", "stats", "binds", "cache", "sources"}
+
+// cmdInfo returns the information and statistics about the server
+//
+//	INFO [section [section ...]]
+func (srv *RedisServer) cmdInfo(ctx context.Context, conn redcon.Conn, cmd redcon.Command) {
+	sections := argStrings(cmd.Args[1:], 1)
+	for i, section := range sections {
+		sections[i] = strings.ToLower(section)
+	}
+	switch {
+	case len(sections) == 0 || (len(sections) == 1 && sections[0] == "default"):
+		sections = infoDefaultSections
+	case len(sections) == 1 && (sections[0] == "all" || sections[0] == "everything"):
+		sections = append(append([]string{}, infoDefaultSections...), "keyspace")
+	}
+	var (
+		buf   strings.Builder
+		stats = storage.Stats(srv.Driver)
+	)
+	for _, section := range sections {
+		lines := srv.infoSection(ctx, section, stats)
+		if lines == nil {
+			continue
+		}
+		if buf.Len() > 0 {
+			buf.WriteString("\r\n")
+		}
+		buf.WriteString("# " + strings.ToUpper(section[:1]) + section[1:] + "\r\n")
+		for _, line := range lines {
+			buf.WriteString(line + "\r\n")
+		}
+	}
+	conn.WriteBulkString(buf.String())
+}
+
+// infoSection returns the lines of the section or nil if the section is unknown
+func (srv *RedisServer) infoSection(ctx context.Context, section string, stats []storage.SourceStats) []string {
+	switch section {
+	case "server":
+		uptime := time.Since(srv.startTime)
+		_, port, _ := net.SplitHostPort(srv.addr)
+		return []string{
+			"redis_version:" + redisVersion,
+			"redify_version:" + srv.Version,
+			"redis_mode:standalone",
+			"os:" + runtime.GOOS + " " + runtime.GOARCH,
+			"arch_bits:" + strconv.Itoa(strconv.IntSize),
+			"go_version:" + runtime.Version(),
+			"process_id:" + strconv.Itoa(os.Getpid()),
+			"tcp_port:" + port,
+			"uptime_in_seconds:" + strconv.FormatInt(int64(uptime/time.Second), 10),
+			"uptime_in_days:" + strconv.FormatInt(int64(uptime/(24*time.Hour)), 10),
+		}
+	case "clients":
+		var tracking int
+		for _, client := range srv.clients.list() {
+			if client.tracking {
+				tracking++
+			}
+		}
+		return []string{
+			"connected_clients:" + strconv.Itoa(srv.clients.count()),
+			"tracking_clients:" + strconv.Itoa(tracking),
+		}
+	case "stats":
+		return []string{
+			"total_connections_received:" + strconv.FormatInt(srv.clients.connections.Load(), 10),
+			"total_commands_processed:" + strconv.FormatInt(srv.clients.commands.Load(), 10),
+		}
+	case "keyspace":
+		lines := []string{}
+		for _, dbnum := range bindDBNums(stats) {
+			size, err := storage.DBSize(ctx, srv.Driver, dbnum)
+			if err != nil {
+				lines = append(lines, "db"+strconv.Itoa(dbnum)+":error="+err.Error())
+				continue
+			}
+			lines = append(lines, "db"+strconv.Itoa(dbnum)+":keys="+strconv.FormatInt(size, 10)+",expires=0,avg_ttl=0")
+		}
+		return lines
+	case "binds":
+		binds := map[int]int{}
+		for _, source := range stats {
+			for dbnum, count := range source.Binds {
+				binds[dbnum] += count
+			}
+		}
+		lines := []string{}
+		for _, dbnum := range bindDBNums(stats) {
+			lines = append(lines, "db"+strconv.Itoa(dbnum)+":binds="+strconv.Itoa(binds[dbnum]))
+		}
+		return lines
+	case "cache":
+		var (
+			cache   storage.CacheStats
+			enabled bool
+		)
+		for _, source := range stats {
+			if source.Cache != nil {
+				enabled = true
+				cache.Hits += source.Cache.Hits
+				cache.Misses += source.Cache.Misses
+				cache.Coalesced += source.Cache.Coalesced
+				cache.Throttled += source.Cache.Throttled
+				cache.InFlight += source.Cache.InFlight
+				cache.Stale += source.Cache.Stale
+				cache.Refreshed += source.Cache.Refreshed
+				cache.Negative += source.Cache.Negative
+			}
+		}
+		if !enabled {
+			return []string{"cache_enabled:0"}
+		}
+		return []string{
+			"cache_enabled:1",
+			"cache_hits:" + strconv.FormatUint(cache.Hits, 10),
+			"cache_misses:" + strconv.FormatUint(cache.Misses, 10),
+			"cache_hit_ratio:" + strconv.FormatFloat(cache.HitRatio(), 'f', 4, 64),
+			"cache_coalesced_misses:" + strconv.FormatUint(cache.Coalesced, 10),
+			"cache_throttled_misses:" + strconv.FormatUint(cache.Throttled, 10),
+			"cache_inflight_loads:" + strconv.FormatInt(cache.InFlight, 10),
+			"cache_stale_hits:" + strconv.FormatUint(cache.Stale, 10),
+			"cache_refreshes:" + strconv.FormatUint(cache.Refreshed, 10),
+			"cache_negative_hits:" + strconv.FormatUint(cache.Negative, 10),
+		}
+	case "sources":
+		lines := make([]string, 0, len(stats))
+		for i, source := range stats {
+			var binds int
+			for _, count := range source.Binds {
+				binds += count
+			}
+			fields := []string{"driver=" + source.Driver, "binds=" + strconv.Itoa(binds)}
+			if pool := source.Pool; pool != nil {
+				fields = append(fields,
+					"max_conns="+strconv.Itoa(pool.MaxConns),
+					"open_conns="+strconv.Itoa(pool.OpenConns),
+					"in_use="+strconv.Itoa(pool.InUse),
+					"idle="+strconv.Itoa(pool.Idle),
+					"wait_count="+strconv.FormatInt(pool.WaitCount, 10),
+					"wait_duration_ms="+strconv.FormatInt(pool.WaitDuration.Milliseconds(), 10))
+			}
+			if cache := source.Cache; cache != nil {
+				fields = append(fields,
+					"cache_hits="+strconv.FormatUint(cache.Hits, 10),
+					"cache_misses="+strconv.FormatUint(cache.Misses, 10),
+					"cache_coalesced="+strconv.FormatUint(cache.Coalesced, 10),
+					"cache_throttled="+strconv.FormatUint(cache.Throttled, 10),
+					"cache_inflight="+strconv.FormatInt(cache.InFlight, 10),
+					"cache_stale="+strconv.FormatUint(cache.Stale, 10),
+					"cache_refreshed="+strconv.FormatUint(cache.Refreshed, 10),
+					"cache_negative="+strconv.FormatUint(cache.Negative, 10))
+			}
+			lines = append(lines, "source"+strconv.Itoa(i)+":"+strings.Join(fields, ","))
+		}
+		return lines
+	}
+	return nil
+}
+
+// cmdDBSize returns the number of the keys of the selected database
+//
+//	DBSIZE
+func (srv *RedisServer) cmdDBSize(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) != 1 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	size, err := storage.DBSize(ctx, srv.Driver, dbnum)
+	if err != nil {
+		conn.WriteError("ERR " + err.Error())
+		return
+	}
+	conn.WriteInt64(size)
+}
+
+// bindDBNums returns the sorted database numbers which have binds
+func bindDBNums(stats []storage.SourceStats) []int {
+	var dbnums []int
+	for _, source := range stats {
+		for dbnum := range source.Binds {
+			if !slices.Contains(dbnums, dbnum) {
+				dbnums = append(dbnums, dbnum)
+			}
+		}
+	}
+	sort.Ints(dbnums)
+	return dbnums
+}
@@ -0,0 +1,358 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/tidwall/redcon"
+
+	"github.com/demdxx/redify/internal/storage"
+)
+
+// jsonFormat of the JSON.GET reply, empty format produces the compact JSON
+type jsonFormat struct {
+	indent  string
+	newline string
+	space   string
+}
+
+// cmdJSONGet returns the values of the record matched by the paths
+//
+//	JSON.GET key [INDENT indent] [NEWLINE newline] [SPACE space] [path [path ...]]
+func (srv *RedisServer) cmdJSONGet(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) < 2 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	var (
+		format jsonFormat
+		args   = cmd.Args[2:]
+	)
+options:
+	for ; len(args) > 1; args = args[2:] {
+		switch strings.ToLower(string(args[0])) {
+		case "indent":
+			format.indent = string(args[1])
+		case "newline":
+			format.newline = string(args[1])
+		case "space":
+			format.space = string(args[1])
+		default:
+			break options
+		}
+	}
+	paths, ok := parseJSONPaths(conn, args, ".")
+	if !ok {
+		return
+	}
+	var (
+		key     = string(cmd.Args[1])
+		legacy  = true
+		results = make([][]json.RawMessage, len(paths))
+	)
+	for i, path := range paths {
+		values, err := storage.GetJSONPath(ctx, srv.Driver, dbnum, key, path)
+		if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrNoKey) {
+			conn.WriteNull()
+			return
+		}
+		if err != nil {
+			conn.WriteError("ERR " + err.Error())
+			return
+		}
+		results[i] = values
+		legacy = legacy && path.Legacy
+	}
+	// Legacy paths select the single value, JSONPath selects the array of matches
+	reply := make([]any, len(paths))
+	for i, values := range results {
+		if !legacy {
+			reply[i] = values
+			continue
+		}
+		if len(values) == 0 {
+			conn.WriteError("ERR Path '" + pathArg(args, i) + "' does not exist")
+			return
+		}
+		reply[i] = values[0]
+	}
+	var value any = reply[0]
+	if len(paths) > 1 {
+		obj := make(map[string]any, len(paths))
+		for i := range paths {
+			obj[pathArg(args, i)] = reply[i]
+		}
+		value = obj
+	}
+	data, err := format.marshal(value)
+	if err != nil {
+		conn.WriteError("ERR " + err.Error())
+		return
+	}
+	conn.WriteBulk(data)
+}
+
+// cmdJSONMGet returns the values matched by the path from every key
+//
+//	JSON.MGET key [key ...] path
+func (srv *RedisServer) cmdJSONMGet(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) < 3 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	path, err := storage.ParseJSONPath(string(cmd.Args[len(cmd.Args)-1]))
+	if err != nil {
+		conn.WriteError("ERR " + err.Error())
+		return
+	}
+	keys := make([]string, 0, len(cmd.Args)-2)
+	for _, arg := range cmd.Args[1 : len(cmd.Args)-1] {
+		keys = append(keys, string(arg))
+	}
+	items := storage.GetMany(ctx, srv.Driver, dbnum, keys)
+	conn.WriteArray(len(items))
+	for _, item := range items {
+		if item.Value == nil {
+			conn.WriteNull()
+			continue
+		}
+		values, err := storage.EvalJSONPath(item.Value, path)
+		if err != nil || (path.Legacy && len(values) == 0) {
+			conn.WriteNull()
+			continue
+		}
+		var value any = values
+		if path.Legacy {
+			value = values[0]
+		}
+		data, err := jsonFormat{}.marshal(value)
+		if err != nil {
+			conn.WriteNull()
+		} else {
+			conn.WriteBulk(data)
+		}
+	}
+}
+
+// cmdJSONType returns the types of the values matched by the path
+//
+//	JSON.TYPE key [path]
+//	JSON.ARRLEN key [path]
+func (srv *RedisServer) cmdJSONType(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) != 2 && len(cmd.Args) != 3 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	paths, ok := parseJSONPaths(conn, cmd.Args[2:], ".")
+	if !ok {
+		return
+	}
+	path := paths[0]
+	values, err := storage.GetJSONPath(ctx, srv.Driver, dbnum, string(cmd.Args[1]), path)
+	if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrNoKey) {
+		conn.WriteNull()
+		return
+	}
+	if err != nil {
+		conn.WriteError("ERR " + err.Error())
+		return
+	}
+	decoded := make([]any, 0, len(values))
+	for _, value := range values {
+		val, err := storage.DecodeJSON(value)
+		if err != nil {
+			conn.WriteError("ERR " + err.Error())
+			return
+		}
+		decoded = append(decoded, val)
+	}
+	arrLen := strings.EqualFold(string(cmd.Args[0]), "json.arrlen")
+	if path.Legacy {
+		switch {
+		case len(decoded) == 0:
+			conn.WriteNull()
+		case !arrLen:
+			conn.WriteBulkString(storage.JSONType(decoded[0]))
+		default:
+			arr, ok := decoded[0].([]any)
+			if !ok {
+				conn.WriteError("ERR wrong type of path value - expected array but found " + storage.JSONType(decoded[0]))
+				return
+			}
+			conn.WriteInt(len(arr))
+		}
+		return
+	}
+	conn.WriteArray(len(decoded))
+	for _, val := range decoded {
+		if !arrLen {
+			conn.WriteBulkString(storage.JSONType(val))
+		} else if arr, ok := val.([]any); ok {
+			conn.WriteInt(len(arr))
+		} else {
+			conn.WriteNull()
+		}
+	}
+}
+
+// cmdJSONSet sets the value of the record by the path
+//
+//	JSON.SET key path value [NX | XX]
+func (srv *RedisServer) cmdJSONSet(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) != 4 && len(cmd.Args) != 5 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	mode := storage.SetAlways
+	if len(cmd.Args) == 5 {
+		switch strings.ToLower(string(cmd.Args[4])) {
+		case "nx":
+			mode = storage.SetIfNotExists
+		case "xx":
+			mode = storage.SetIfExists
+		default:
+			conn.WriteError("ERR syntax error")
+			return
+		}
+	}
+	path, err := storage.ParseJSONPath(string(cmd.Args[2]))
+	if err != nil {
+		conn.WriteError("ERR " + err.Error())
+		return
+	}
+	if !json.Valid(cmd.Args[3]) {
+		conn.WriteError("ERR invalid JSON value")
+		return
+	}
+	applied, err := storage.SetJSONPath(ctx, srv.Driver, dbnum, string(cmd.Args[1]), path, cmd.Args[3], mode)
+	if writeCollectionWriteError(conn, err) {
+		return
+	}
+	if applied {
+		srv.keyEvent(ctx, dbnum, string(cmd.Args[1]), storage.KeyEventJSONSet)
+		conn.WriteString("OK")
+	} else {
+		conn.WriteNull()
+	}
+}
+
+// cmdJSONDel deletes the values of the record matched by the path
+//
+//	JSON.DEL key [path]
+//	JSON.FORGET key [path]
+func (srv *RedisServer) cmdJSONDel(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) != 2 && len(cmd.Args) != 3 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	paths, ok := parseJSONPaths(conn, cmd.Args[2:], "$")
+	if !ok {
+		return
+	}
+	count, err := storage.DelJSONPath(ctx, srv.Driver, dbnum, string(cmd.Args[1]), paths[0])
+	if errors.Is(err, storage.ErrNoKey) {
+		conn.WriteInt(0)
+		return
+	}
+	if writeCollectionWriteError(conn, err) {
+		return
+	}
+	if count > 0 {
+		srv.keyEvent(ctx, dbnum, string(cmd.Args[1]), storage.KeyEventJSONDel)
+	}
+	conn.WriteInt(count)
+}
+
+// parseJSONPaths of the command arguments or the default path if there are no arguments
+func parseJSONPaths(conn redcon.Conn, args [][]byte, defaultPath string) ([]*storage.JSONPath, bool) {
+	if len(args) == 0 {
+		path, _ := storage.ParseJSONPath(defaultPath)
+		return []*storage.JSONPath{path}, true
+	}
+	paths := make([]*storage.JSONPath, 0, len(args))
+	for _, arg := range args {
+		path, err := storage.ParseJSONPath(string(arg))
+		if err != nil {
+			conn.WriteError("ERR " + err.Error())
+			return nil, false
+		}
+		paths = append(paths, path)
+	}
+	return paths, true
+}
+
+func pathArg(args [][]byte, i int) string {
+	if i < len(args) {
+		return string(args[i])
+	}
+	return "."
+}
+
+// marshal the value with the format, raw JSON values are decoded to be formatted the same way
+func (f jsonFormat) marshal(value any) ([]byte, error) {
+	return f.append(nil, value, "")
+}
+
+func (f jsonFormat) append(buf []byte, value any, prefix string) ([]byte, error) {
+	switch v := value.(type) {
+	case json.RawMessage:
+		decoded, err := storage.DecodeJSON(v)
+		if err != nil {
+			return nil, err
+		}
+		return f.append(buf, decoded, prefix)
+	case []json.RawMessage:
+		values := make([]any, len(v))
+		for i, val := range v {
+			values[i] = val
+		}
+		return f.append(buf, values, prefix)
+	case []any:
+		if len(v) == 0 {
+			return append(buf, "[]"...), nil
+		}
+		buf = append(buf, '[')
+		for i, val := range v {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			buf = append(buf, f.newline+prefix+f.indent...)
+			var err error
+			if buf, err = f.append(buf, val, prefix+f.indent); err != nil {
+				return nil, err
+			}
+		}
+		return append(append(buf, f.newline+prefix...), ']'), nil
+	case map[string]any:
+		if len(v) == 0 {
+			return append(buf, "{}"...), nil
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buf = append(buf, '{')
+		for i, key := range keys {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			name, _ := json.Marshal(key)
+			buf = append(buf, f.newline+prefix+f.indent...)
+			buf = append(append(append(buf, name...), ':'), f.space...)
+			var err error
+			if buf, err = f.append(buf, v[key], prefix+f.indent); err != nil {
+				return nil, err
+			}
+		}
+		return append(append(buf, f.newline+prefix...), '}'), nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return append(buf, data...), nil
+}
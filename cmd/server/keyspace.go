@@ -0,0 +1,231 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/demdxx/redify/internal/acl"
+	"github.com/demdxx/redify/internal/storage"
+)
+
+// Prefixes of the notification channels
+const (
+	keyspacePrefix = "__keyspace@"
+	keyeventPrefix = "__keyevent@"
+)
+
+// keyspaceEvents flags of notify-keyspace-events, every flag is the bit
+// of the character position in keyspaceEventFlags
+type keyspaceEvents uint32
+
+const keyspaceEventFlags = "KEg$lshzxetdmn"
+
+const (
+	keyspaceChannel keyspaceEvents = 1 << iota // K: __keyspace@<db>__ channels
+	keyeventChannel                            // E: __keyevent@<db>__ channels
+	keyspaceGeneric                            // g: del, expire, persist
+	keyspaceString                             // $: string commands
+	keyspaceList                               // l: list commands
+	keyspaceSet                                // s: set commands
+	keyspaceHash                               // h: hash commands
+	keyspaceZSet                               // z: sorted set commands
+	keyspaceExpired                            // x: expired events
+	keyspaceEvicted                            // e: evicted events, keys are never evicted
+	keyspaceStream                             // t: stream commands
+	keyspaceModule                             // d: module key type events, JSON commands
+	keyspaceMiss                               // m: key miss events
+	keyspaceNew                                // n: new key events
+)
+
+// keyspaceAll classes of the `A` alias
+const keyspaceAll keyspaceEvents = (1<<12 - 1) &^ (keyspaceChannel | keyeventChannel)
+
+// keyspaceUnsupported classes are rejected because their events are never published
+const keyspaceUnsupported = keyspaceMiss | keyspaceNew
+
+var (
+	errKeyspaceEvents            = errors.New("invalid event class character, use 'Ag$lshzxetdKE'")
+	errKeyspaceEventsUnsupported = errors.New("key miss and new key events ('m', 'n') are not supported")
+)
+
+// keyEventClasses of the reported events
+var keyEventClasses = map[string]keyspaceEvents{
+	storage.KeyEventSet:          keyspaceString,
+	storage.KeyEventIncrBy:       keyspaceString,
+	storage.KeyEventIncrByFloat:  keyspaceString,
+	storage.KeyEventDel:          keyspaceGeneric,
+	storage.KeyEventExpire:       keyspaceGeneric,
+	storage.KeyEventPersist:      keyspaceGeneric,
+	storage.KeyEventExpired:      keyspaceExpired,
+	storage.KeyEventHSet:         keyspaceHash,
+	storage.KeyEventHDel:         keyspaceHash,
+	storage.KeyEventHIncrBy:      keyspaceHash,
+	storage.KeyEventHIncrByFloat: keyspaceHash,
+	storage.KeyEventRPush:        keyspaceList,
+	storage.KeyEventSAdd:         keyspaceSet,
+	storage.KeyEventSRem:         keyspaceSet,
+	storage.KeyEventZAdd:         keyspaceZSet,
+	storage.KeyEventZIncr:        keyspaceZSet,
+	storage.KeyEventZRem:         keyspaceZSet,
+	storage.KeyEventXAdd:         keyspaceStream,
+	storage.KeyEventJSONSet:      keyspaceModule,
+	storage.KeyEventJSONDel:      keyspaceModule,
+}
+
+// expiredEvents returns true if the expired events are published
+func (f keyspaceEvents) expiredEvents() bool {
+	return f&keyspaceExpired != 0 && f&(keyspaceChannel|keyeventChannel) != 0
+}
+
+// parseKeyspaceEvents flags like `KEA` or `Kg$x`, the empty string disables notifications
+func parseKeyspaceEvents(s string) (keyspaceEvents, error) {
+	var flags keyspaceEvents
+	for _, c := range s {
+		if c == 'A' {
+			flags |= keyspaceAll
+			continue
+		}
+		i := strings.IndexRune(keyspaceEventFlags, c)
+		if i < 0 {
+			return 0, errKeyspaceEvents
+		}
+		flags |= 1 << i
+	}
+	if flags&keyspaceUnsupported != 0 {
+		return 0, errKeyspaceEventsUnsupported
+	}
+	return flags, nil
+}
+
+func (f keyspaceEvents) String() string {
+	var buf strings.Builder
+	if f&keyspaceAll == keyspaceAll {
+		buf.WriteByte('A')
+		f &^= keyspaceAll
+	}
+	for i := range keyspaceEventFlags {
+		if f&(1<<i) != 0 {
+			buf.WriteByte(keyspaceEventFlags[i])
+		}
+	}
+	return buf.String()
+}
+
+// Keyspace delivers the changes of the keys made by the commands of the Redis
+// and HTTP servers to the listeners, the Redis server publishes them as notifications
+type Keyspace struct {
+	mx        sync.RWMutex
+	listeners []func(ctx context.Context, event storage.KeyEvent)
+}
+
+// NewKeyspace without listeners
+func NewKeyspace() *Keyspace {
+	return &Keyspace{}
+}
+
+// OnKeyEvent registers the callback which is called for every changed key
+func (k *Keyspace) OnKeyEvent(fn func(ctx context.Context, event storage.KeyEvent)) {
+	k.mx.Lock()
+	defer k.mx.Unlock()
+	k.listeners = append(k.listeners, fn)
+}
+
+// Notify the listeners about the key changed by the command after the commit
+func (k *Keyspace) Notify(ctx context.Context, dbnum int, key, event string) {
+	if k == nil {
+		return
+	}
+	storage.AfterCommit(ctx, func() {
+		k.mx.RLock()
+		defer k.mx.RUnlock()
+		for _, fn := range k.listeners {
+			fn(ctx, storage.KeyEvent{DBNum: dbnum, Key: key, Event: event})
+		}
+	})
+}
+
+// keyEvent reports the key changed by the command
+func (srv *RedisServer) keyEvent(ctx context.Context, dbnum int, key, event string) {
+	srv.Keyspace.Notify(ctx, dbnum, key, event)
+}
+
+// keyChanged invalidates the key changed in the source and publishes the notification
+func (srv *RedisServer) keyChanged(ctx context.Context, event storage.KeyEvent) {
+	srv.invalidateKey(ctx, event.Key)
+	srv.notifyKeyEvent(ctx, event)
+}
+
+// commandKeyChanged invalidates the key changed by the command and publishes the notification
+// unless the source reports the change itself, the notification would be duplicated then
+func (srv *RedisServer) commandKeyChanged(ctx context.Context, event storage.KeyEvent) {
+	srv.invalidateKey(ctx, event.Key)
+	if !storage.ReportsKeyEvents(srv.Driver, event.DBNum, event.Key) {
+		srv.notifyKeyEvent(ctx, event)
+	}
+}
+
+// invalidateKey for the tracking and watching connections
+func (srv *RedisServer) invalidateKey(ctx context.Context, key string) {
+	if srv.tracking != nil {
+		srv.tracking.invalidate(ctx, key)
+	}
+	if srv.watches != nil {
+		srv.watches.invalidate(ctx, key)
+	}
+}
+
+// notifyKeyEvent publishes the keyspace and keyevent notifications enabled by notify-keyspace-events
+func (srv *RedisServer) notifyKeyEvent(_ context.Context, event storage.KeyEvent) {
+	flags := keyspaceEvents(srv.keyspaceEvents.Load())
+	if flags&keyEventClasses[event.Event] == 0 {
+		return
+	}
+	db := strconv.Itoa(event.DBNum)
+	if flags&keyspaceChannel != 0 {
+		srv.ps.Publish(keyspacePrefix+db+"__:"+event.Key, event.Event)
+	}
+	if flags&keyeventChannel != 0 {
+		srv.ps.Publish(keyeventPrefix+db+"__:"+event.Event, event.Key)
+	}
+}
+
+// keyspaceChannelAccess checks the access of the user to the notifications of the channel or the pattern.
+// Keyspace channels are allowed by the databases and the key patterns of the user,
+// keyevent messages contain any key, so they require the access to all keys.
+// Patterns which can match the channels of other databases or keys are rejected.
+func keyspaceChannelAccess(user *acl.User, channel string, pattern bool) error {
+	if user == nil || (user.AllKeys() && len(user.DBNums) == 0) {
+		return nil
+	}
+	prefix := channel
+	if i := strings.IndexAny(channel, `*?[\`); pattern && i >= 0 {
+		prefix = channel[:i]
+	}
+	matchPrefix := func(channelPrefix string) bool {
+		return strings.HasPrefix(prefix, channelPrefix) || (pattern && strings.HasPrefix(channelPrefix, prefix))
+	}
+	if !matchPrefix(keyspacePrefix) && !matchPrefix(keyeventPrefix) {
+		return nil
+	}
+	rest, keyspace := strings.CutPrefix(channel, keyspacePrefix)
+	if !keyspace {
+		var ok bool
+		if rest, ok = strings.CutPrefix(channel, keyeventPrefix); !ok {
+			return acl.ErrNoPermChan
+		}
+	}
+	db, name, ok := strings.Cut(rest, "__:")
+	dbnum, err := strconv.Atoi(db)
+	switch {
+	case !ok || err != nil || !user.AllowDB(dbnum):
+		return acl.ErrNoPermChan
+	case keyspace && !user.AllowKey(name):
+		return acl.ErrNoPermChan
+	case !keyspace && !user.AllKeys():
+		return acl.ErrNoPermChan
+	}
+	return nil
+}
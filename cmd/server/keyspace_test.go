@@ -0,0 +1,125 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demdxx/redify/internal/acl"
+	"github.com/demdxx/redify/internal/storage"
+)
+
+func TestParseKeyspaceEvents(t *testing.T) {
+	allEvents := make([]string, 0, len(keyEventClasses))
+	for event := range keyEventClasses {
+		allEvents = append(allEvents, event)
+	}
+	tests := []struct {
+		value  string
+		flags  string
+		events []string
+	}{
+		{value: "", flags: ""},
+		{value: "KEA", flags: "AKE", events: allEvents},
+		{value: "Kg$lshzxetd", flags: "AK", events: allEvents},
+		{value: "Ex", flags: "Ex", events: []string{storage.KeyEventExpired}},
+		{value: "Eh", flags: "Eh", events: []string{
+			storage.KeyEventHSet, storage.KeyEventHDel, storage.KeyEventHIncrBy, storage.KeyEventHIncrByFloat}},
+		{value: "Kg", flags: "Kg", events: []string{storage.KeyEventDel, storage.KeyEventExpire, storage.KeyEventPersist}},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			flags, err := parseKeyspaceEvents(test.value)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, test.flags, flags.String())
+			for event, class := range keyEventClasses {
+				assert.Equal(t, slices.Contains(test.events, event), flags&class != 0, event)
+			}
+		})
+	}
+
+	for value, expired := range map[string]bool{"Ex": true, "KA": true, "x": false, "KEg": false} {
+		flags, _ := parseKeyspaceEvents(value)
+		assert.Equal(t, expired, flags.expiredEvents(), value)
+	}
+
+	_, err := parseKeyspaceEvents("KEq")
+	assert.ErrorIs(t, err, errKeyspaceEvents)
+	_, err = parseKeyspaceEvents("E$m")
+	assert.ErrorIs(t, err, errKeyspaceEventsUnsupported)
+}
+
+func TestExpireEvent(t *testing.T) {
+	assert.Equal(t, storage.KeyEventPersist, expireEvent(time.Time{}))
+	assert.Equal(t, storage.KeyEventDel, expireEvent(time.Now().Add(-time.Second)))
+	assert.Equal(t, storage.KeyEventExpire, expireEvent(time.Now().Add(time.Minute)))
+}
+
+func TestKeyspaceChannelAccess(t *testing.T) {
+	var (
+		reader = &acl.User{Name: "reader", DBNums: []int{0}, Keys: []string{"post_*"}}
+		admin  = &acl.User{Name: "admin"}
+	)
+	tests := []struct {
+		channel string
+		pattern bool
+		allowed bool
+	}{
+		{channel: "__keyspace@0__:post_1", allowed: true},
+		{channel: "__keyspace@0__:user_1"},
+		{channel: "__keyspace@1__:post_1"},
+		{channel: "__keyevent@0__:set"},
+		{channel: "news_notify", allowed: true},
+		{channel: "__keyspace@0__:post_*", pattern: true, allowed: true},
+		{channel: "__keyspace@0__:*", pattern: true},
+		{channel: "__keyspace@*__:post_*", pattern: true},
+		{channel: "__key*", pattern: true},
+		{channel: "*", pattern: true},
+		{channel: "news_*", pattern: true, allowed: true},
+	}
+	for _, test := range tests {
+		err := keyspaceChannelAccess(reader, test.channel, test.pattern)
+		if test.allowed {
+			assert.NoError(t, err, test.channel)
+		} else {
+			assert.ErrorIs(t, err, acl.ErrNoPermChan, test.channel)
+		}
+		assert.NoError(t, keyspaceChannelAccess(admin, test.channel, test.pattern), test.channel)
+	}
+}
+
+func TestKeyspaceHTTPWrites(t *testing.T) {
+	var (
+		events []storage.KeyEvent
+		srv    = &HTTPServer{Driver: mapDriver{}, Keyspace: NewKeyspace()}
+		app    = fiber.New()
+	)
+	srv.Keyspace.OnKeyEvent(func(_ context.Context, event storage.KeyEvent) {
+		events = append(events, event)
+	})
+	app.Put("/:dbnum/:key", srv.set)
+	app.Delete("/:dbnum/:key", srv.del)
+	for _, req := range []*http.Request{
+		httptest.NewRequest(fiber.MethodPut, "/1/post_1", strings.NewReader(`{"title":"post"}`)),
+		httptest.NewRequest(fiber.MethodDelete, "/1/post_1", nil),
+	} {
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	}
+	assert.Equal(t, []storage.KeyEvent{
+		{DBNum: 1, Key: "post_1", Event: storage.KeyEventSet},
+		{DBNum: 1, Key: "post_1", Event: storage.KeyEventDel},
+	}, events)
+}
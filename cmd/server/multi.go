@@ -0,0 +1,252 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/tidwall/redcon"
+
+	"github.com/demdxx/redify/internal/storage"
+)
+
+// multiState of the connection between MULTI and EXEC
+type multiState struct {
+	queue   []redcon.Command
+	aborted bool
+}
+
+// queue the command of the transaction, the arguments are copied
+// because the connection reuses the read buffer
+func (m *multiState) queueCommand(cmd redcon.Command) {
+	args := make([][]byte, len(cmd.Args))
+	for i, arg := range cmd.Args {
+		args[i] = append([]byte(nil), arg...)
+	}
+	m.queue = append(m.queue, redcon.Command{Args: args})
+}
+
+// keys of the queued commands without duplicates
+func (m *multiState) keys() []string {
+	var (
+		keys = make([]string, 0, len(m.queue))
+		uniq = map[string]struct{}{}
+	)
+	for _, cmd := range m.queue {
+		for _, key := range commandKeys(cmd) {
+			if _, ok := uniq[key]; !ok {
+				uniq[key] = struct{}{}
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+// isMultiControl returns true if the command is executed immediately inside MULTI
+func isMultiControl(name string) bool {
+	switch name {
+	case "multi", "exec", "discard", "watch", "unwatch", "quit":
+		return true
+	}
+	return false
+}
+
+// queueCommand of the transaction or abort it if the command is not supported inside MULTI
+func (srv *RedisServer) queueCommand(conn redcon.Conn, rCtx *userContext, name string, cmd redcon.Command) {
+	if !transactionalCommand(name) {
+		rCtx.multi.aborted = true
+		conn.WriteError("ERR Command not allowed inside a transaction")
+		return
+	}
+	rCtx.multi.queueCommand(cmd)
+	conn.WriteString("QUEUED")
+}
+
+func (srv *RedisServer) cmdMulti(conn redcon.Conn, rCtx *userContext) {
+	if rCtx.multi != nil {
+		conn.WriteError("ERR MULTI calls can not be nested")
+		return
+	}
+	rCtx.multi = &multiState{}
+	conn.WriteString("OK")
+}
+
+func (srv *RedisServer) cmdDiscard(conn redcon.Conn, rCtx *userContext) {
+	if rCtx.multi == nil {
+		conn.WriteError("ERR DISCARD without MULTI")
+		return
+	}
+	rCtx.multi = nil
+	srv.watches.unwatch(rCtx)
+	conn.WriteString("OK")
+}
+
+// cmdWatch marks the keys to abort the next transaction if they are modified
+//
+//	WATCH key [key ...]
+func (srv *RedisServer) cmdWatch(conn redcon.Conn, rCtx *userContext, cmd redcon.Command) {
+	if len(cmd.Args) < 2 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	if rCtx.multi != nil {
+		conn.WriteError("ERR WATCH inside MULTI is not allowed")
+		return
+	}
+	srv.watches.watch(rCtx, argStrings(cmd.Args[1:], 1)...)
+	conn.WriteString("OK")
+}
+
+// cmdExec runs the queued commands inside one database transaction.
+// Any command error rolls back the whole transaction, the commands
+// without keys are not transactional and report the errors per command.
+func (srv *RedisServer) cmdExec(ctx context.Context, conn redcon.Conn, rCtx *userContext) {
+	multi := rCtx.multi
+	if multi == nil {
+		conn.WriteError("ERR EXEC without MULTI")
+		return
+	}
+	rCtx.multi = nil
+	modified := srv.watches.unwatch(rCtx)
+	switch {
+	case multi.aborted:
+		conn.WriteError("EXECABORT Transaction discarded because of previous errors.")
+		return
+	case modified:
+		writeNullArray(conn)
+		return
+	}
+
+	var (
+		tx  storage.Tx
+		err error
+	)
+	if keys := multi.keys(); len(keys) > 0 {
+		if ctx, tx, err = storage.BeginTx(ctx, srv.Driver, rCtx.DBNum, keys); err != nil {
+			conn.WriteError(txError(err))
+			return
+		}
+	}
+	// Without the transaction every command runs and its error is the reply element
+	buf := &bufferConn{Conn: conn}
+	for _, cmd := range multi.queue {
+		if srv.execute(ctx, buf, rCtx, cmd); tx != nil && buf.err != "" {
+			break
+		}
+	}
+	if tx != nil {
+		if buf.err != "" {
+			_ = tx.Rollback()
+			conn.WriteError("EXECABORT Transaction rolled back: " + buf.err)
+			return
+		}
+		if err = tx.Commit(); err != nil {
+			conn.WriteError("ERR " + err.Error())
+			return
+		}
+	}
+	conn.WriteArray(len(multi.queue))
+	conn.WriteRaw(buf.buf)
+}
+
+func txError(err error) string {
+	switch {
+	case errors.Is(err, storage.ErrCrossSourceTx):
+		return "ERR Transaction keys belong to different sources"
+	case errors.Is(err, storage.ErrNoKey):
+		return "ERR Transaction keys are not served by a transactional source"
+	case errors.Is(err, storage.ErrMethodIsNotSupported):
+		return "ERR Transactions are not supported by the storage"
+	}
+	return "ERR " + err.Error()
+}
+
+func writeNullArray(conn redcon.Conn) {
+	if isRESP3(conn) {
+		conn.WriteRaw(appendNull(nil))
+	} else {
+		conn.WriteRaw([]byte("*-1\r\n"))
+	}
+}
+
+// watchTable of the keys watched by the connections
+type watchTable struct {
+	mx   sync.Mutex
+	keys map[string]map[*userContext]struct{}
+}
+
+func newWatchTable() *watchTable {
+	return &watchTable{keys: map[string]map[*userContext]struct{}{}}
+}
+
+func (w *watchTable) watch(rCtx *userContext, keys ...string) {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+	for _, key := range keys {
+		conns := w.keys[key]
+		if conns == nil {
+			conns = map[*userContext]struct{}{}
+			w.keys[key] = conns
+		}
+		conns[rCtx] = struct{}{}
+		rCtx.watched = append(rCtx.watched, key)
+	}
+}
+
+// unwatch all keys of the connection and returns true if any of them was modified
+func (w *watchTable) unwatch(rCtx *userContext) bool {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+	for _, key := range rCtx.watched {
+		if conns := w.keys[key]; conns != nil {
+			if delete(conns, rCtx); len(conns) == 0 {
+				delete(w.keys, key)
+			}
+		}
+	}
+	modified := rCtx.watchModified
+	rCtx.watched = nil
+	rCtx.watchModified = false
+	return modified
+}
+
+// invalidate marks the connections which watch the key as modified
+func (w *watchTable) invalidate(_ context.Context, key string) {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+	for rCtx := range w.keys[key] {
+		rCtx.watchModified = true
+	}
+}
+
+// bufferConn collects replies of the queued commands
+type bufferConn struct {
+	redcon.Conn
+	buf []byte
+	err string // The first error reply
+}
+
+func (c *bufferConn) WriteError(msg string) {
+	if c.err == "" {
+		c.err = msg
+	}
+	c.buf = redcon.AppendError(c.buf, msg)
+}
+
+func (c *bufferConn) WriteString(str string)      { c.buf = redcon.AppendString(c.buf, str) }
+func (c *bufferConn) WriteBulk(bulk []byte)       { c.buf = redcon.AppendBulk(c.buf, bulk) }
+func (c *bufferConn) WriteBulkString(bulk string) { c.buf = redcon.AppendBulkString(c.buf, bulk) }
+func (c *bufferConn) WriteInt(num int)            { c.buf = redcon.AppendInt(c.buf, int64(num)) }
+func (c *bufferConn) WriteInt64(num int64)        { c.buf = redcon.AppendInt(c.buf, num) }
+func (c *bufferConn) WriteUint64(num uint64)      { c.buf = redcon.AppendUint(c.buf, num) }
+func (c *bufferConn) WriteArray(count int)        { c.buf = redcon.AppendArray(c.buf, count) }
+func (c *bufferConn) WriteNull()                  { c.buf = redcon.AppendNull(c.buf) }
+func (c *bufferConn) WriteRaw(data []byte)        { c.buf = append(c.buf, data...) }
+func (c *bufferConn) WriteAny(v any)              { c.buf = redcon.AppendAny(c.buf, v) }
+func (c *bufferConn) ReadPipeline() []redcon.Command {
+	return nil
+}
+func (c *bufferConn) PeekPipeline() []redcon.Command {
+	return nil
+}
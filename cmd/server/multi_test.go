@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/redcon"
+)
+
+func TestMultiStateKeys(t *testing.T) {
+	var multi multiState
+	args := [][]byte{[]byte("mset"), []byte("a"), []byte("1"), []byte("b"), []byte("2")}
+	multi.queueCommand(redcon.Command{Args: args})
+	multi.queueCommand(redcon.Command{Args: [][]byte{[]byte("get"), []byte("a")}})
+	multi.queueCommand(redcon.Command{Args: [][]byte{[]byte("ping")}})
+
+	// Queued arguments must not depend on the read buffer
+	args[1][0] = 'x'
+	assert.Equal(t, []string{"a", "b"}, multi.keys())
+}
+
+func TestWatchTable(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		table = newWatchTable()
+		conn1 = &userContext{}
+		conn2 = &userContext{}
+	)
+	table.watch(conn1, "a", "b")
+	table.watch(conn2, "b")
+
+	table.invalidate(ctx, "a")
+	assert.True(t, table.unwatch(conn1))
+	assert.False(t, table.unwatch(conn2))
+	assert.Empty(t, table.keys)
+
+	table.invalidate(ctx, "b")
+	assert.False(t, table.unwatch(conn1), "unwatched keys must not be tracked")
+}
+
+func TestWatchModifiedByConnection(t *testing.T) {
+	var (
+		srv = &RedisServer{
+			Driver:   mapDriver{"a": []byte("1")},
+			Keyspace: NewKeyspace(),
+			tracking: newTracking(),
+			watches:  newWatchTable(),
+			clients:  newClientTable(),
+		}
+		command = func(conn redcon.Conn, args ...string) {
+			cmd := redcon.Command{}
+			for _, arg := range args {
+				cmd.Args = append(cmd.Args, []byte(arg))
+			}
+			srv.command(conn, cmd)
+		}
+		conn1 = &pipelineConn{rCtx: &userContext{}}
+		conn2 = &pipelineConn{rCtx: &userContext{}}
+	)
+	srv.Keyspace.OnKeyEvent(srv.commandKeyChanged)
+	command(conn1, "WATCH", "a")
+	command(conn2, "SET", "a", "2")
+	command(conn1, "MULTI")
+	command(conn1, "GET", "a")
+	command(conn1, "EXEC")
+	assert.Equal(t, []string{"OK", "OK", "QUEUED", "*-1\r\n"}, conn1.replies,
+		"the transaction must be aborted without the cache")
+}
+
+func TestExecWithoutKeys(t *testing.T) {
+	var (
+		srv = &RedisServer{
+			Driver:   mapDriver{},
+			tracking: newTracking(),
+			watches:  newWatchTable(),
+			clients:  newClientTable(),
+		}
+		conn = &pipelineConn{rCtx: &userContext{}}
+	)
+	for _, args := range [][]string{{"MULTI"}, {"PING"}, {"ECHO"}, {"ECHO", "hi"}, {"EXEC"}} {
+		cmd := redcon.Command{}
+		for _, arg := range args {
+			cmd.Args = append(cmd.Args, []byte(arg))
+		}
+		srv.command(conn, cmd)
+	}
+	if !assert.Len(t, conn.replies, 6) {
+		t.Fatal(conn.replies)
+	}
+	assert.Equal(t, "*3", conn.replies[4], "every queued command must have the reply")
+	assert.Equal(t, "+PONG\r\n-ERR wrong number of arguments for 'ECHO' command\r\n$2\r\nhi\r\n", conn.replies[5])
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/demdxx/redify/internal/acl"
+	"github.com/demdxx/redify/internal/context/ctxlogger"
+	"github.com/demdxx/redify/internal/storage"
+)
+
+// listenChannels starts the bridges of the external channels once,
+// received messages are published to the local subscribers
+func (srv *RedisServer) listenChannels() {
+	srv.channelsOnce.Do(func() {
+		for _, bridge := range srv.Channels {
+			go func(bridge storage.ChannelBridge) {
+				err := bridge.ListenChannels(srv.context(), func(channel string, message []byte) {
+					srv.ps.Publish(channel, string(message))
+				})
+				if err != nil {
+					ctxlogger.Get(srv.context()).Error("listen pub/sub bridge", zap.Error(err))
+				}
+			}(bridge)
+		}
+	})
+}
+
+// publishChannel forwards the message to the bridge of the channel.
+// Returns false if the channel is not bridged and must be published locally.
+func (srv *RedisServer) publishChannel(ctx context.Context, channel string, message []byte) (bool, error) {
+	for _, bridge := range srv.Channels {
+		if bridge.HasChannel(channel) {
+			return true, bridge.PublishChannel(ctx, channel, message)
+		}
+	}
+	return false, nil
+}
+
+// bridgedChannel returns true if the channel is served by any bridge
+func (srv *RedisServer) bridgedChannel(channel string) bool {
+	for _, bridge := range srv.Channels {
+		if bridge.HasChannel(channel) {
+			return true
+		}
+	}
+	return false
+}
+
+// channelAccess checks the access of the user to the channel or the pattern of the subscription.
+// Bridged channels are the keys of the binds and are allowed by the key patterns of the user,
+// the patterns can match any bridged channel, so they must be allowed as keys too.
+func (srv *RedisServer) channelAccess(user *acl.User, channel string, pattern bool) error {
+	if err := keyspaceChannelAccess(user, channel, pattern); err != nil {
+		return err
+	}
+	switch {
+	case user == nil || user.AllKeys():
+		return nil
+	case strings.HasPrefix(channel, keyspacePrefix) || strings.HasPrefix(channel, keyeventPrefix):
+		return nil
+	case (pattern || srv.bridgedChannel(channel)) && !user.AllowKey(channel):
+		return acl.ErrNoPermChan
+	}
+	return nil
+}
@@ -3,36 +3,99 @@ package server
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/demdxx/gocast/v2"
 	"github.com/tidwall/redcon"
 	"go.uber.org/zap"
 
+	"github.com/demdxx/redify/internal/acl"
 	"github.com/demdxx/redify/internal/context/ctxlogger"
 	"github.com/demdxx/redify/internal/storage"
 )
 
 type userContext struct {
-	DBNum int
+	ID       int64
+	Name     string
+	DBNum    int
+	Protocol int
+	User     *acl.User
+	LibName  string
+	LibVer   string
+	tracking *trackingClient
+
+	// Transaction state, watched keys are protected by the watch table lock
+	multi         *multiState
+	watched       []string
+	watchModified bool
 }
 
 // RedisServer redify implement basic functionality of the redis proxy
 type RedisServer struct {
 	Driver         storage.Driver
 	RequestTimeout time.Duration
+	Version        string
+	ACL            *acl.ACL
+	TLS            *tls.Config
+
+	// Channels bridge the external brokers and database notifications to pub/sub
+	Channels []storage.ChannelBridge
+	// NotifyKeyspaceEvents is the initial value of notify-keyspace-events
+	NotifyKeyspaceEvents string
+	// Keyspace of the changes shared with the HTTP server, created if nil
+	Keyspace *Keyspace
 
-	ctx context.Context
-	ps  redcon.PubSub
+	ctx          context.Context
+	channelsOnce sync.Once
+	// Flags of the keyspace notifications changed by CONFIG SET
+	keyspaceEvents atomic.Uint32
+	ps             redcon.PubSub
+	lastID         atomic.Int64
+	tracking       *tracking
+	watches        *watchTable
+	clients        *clientTable
+	addr           string
+	startTime      time.Time
 }
 
 // ListenAndServe redify RedisServer
 func (srv *RedisServer) ListenAndServe(ctx context.Context, addr string) error {
 	srv.ctx = ctx
+	srv.tracking = newTracking()
+	srv.watches = newWatchTable()
+	srv.clients = newClientTable()
+	srv.addr = addr
+	srv.startTime = time.Now()
+	flags, err := parseKeyspaceEvents(srv.NotifyKeyspaceEvents)
+	if err != nil {
+		return fmt.Errorf("notify keyspace events: %w", err)
+	}
+	srv.keyspaceEvents.Store(uint32(flags))
+	storage.EnableExpiredEvents(srv.Driver, flags.expiredEvents())
+	if srv.Keyspace == nil {
+		srv.Keyspace = NewKeyspace()
+	}
+	srv.Keyspace.OnKeyEvent(srv.commandKeyChanged)
+	if n, _ := srv.Driver.(storage.KeyEventNotifier); n != nil {
+		n.OnKeyEvent(srv.keyChanged)
+	}
+	if srv.TLS != nil {
+		return redcon.ListenAndServeTLS(addr,
+			srv.command,
+			srv.acceptConnection,
+			srv.closeConnection,
+			srv.TLS)
+	}
 	return redcon.ListenAndServe(addr,
 		srv.command,
 		srv.acceptConnection,
@@ -57,6 +120,36 @@ func (srv *RedisServer) command(conn redcon.Conn, cmd redcon.Command) {
 	}()
 
 	rCtx := getUserContext(conn.Context())
+	srv.clients.touch(rCtx, cmd)
+	if err := srv.checkAccess(rCtx, cmd); err != nil {
+		if rCtx.multi != nil {
+			rCtx.multi.aborted = true
+		}
+		conn.WriteError(err.Error())
+		return
+	}
+	if rCtx.tracking != nil {
+		// Keys are tracked before the reading to not miss concurrent modifications
+		if keys := trackedKeys(cmd); len(keys) > 0 {
+			srv.tracking.track(rCtx.tracking, keys...)
+		}
+	}
+	if name := strings.ToLower(string(cmd.Args[0])); rCtx.multi != nil && !isMultiControl(name) {
+		srv.queueCommand(conn, rCtx, name, cmd)
+		return
+	}
+	if isGetCommand(cmd) {
+		// Pipelined GETs are read from the store by one batch
+		if pipeline := conn.PeekPipeline(); len(pipeline) > 0 && isGetCommand(pipeline[0]) {
+			srv.cmdGetPipeline(ctx, conn, rCtx, cmd)
+			return
+		}
+	}
+	srv.execute(ctx, conn, rCtx, cmd)
+}
+
+// execute the command with the connection context
+func (srv *RedisServer) execute(ctx context.Context, conn redcon.Conn, rCtx *userContext, cmd redcon.Command) {
 	dbnum := rCtx.DBNum
 	switch strings.ToLower(string(cmd.Args[0])) {
 	default:
@@ -68,8 +161,17 @@ func (srv *RedisServer) command(conn redcon.Conn, cmd redcon.Command) {
 			srv.wrongNumberArgsError(conn, cmd)
 			return
 		}
-		count := srv.ps.Publish(string(cmd.Args[1]), string(cmd.Args[2]))
-		conn.WriteInt(count)
+		// Bridged channels are delivered through the broker to the subscribers
+		// of every instance, so the number of receivers is unknown and 1 is returned.
+		bridged, err := srv.publishChannel(ctx, string(cmd.Args[1]), cmd.Args[2])
+		switch {
+		case err != nil:
+			conn.WriteError("ERR " + err.Error())
+		case bridged:
+			conn.WriteInt(1)
+		default:
+			conn.WriteInt(srv.ps.Publish(string(cmd.Args[1]), string(cmd.Args[2])))
+		}
 	case "subscribe", "psubscribe":
 		// Subscribe to a pub/sub channel. The `Psubscribe` and
 		// `Subscribe` operations will detach the connection from the
@@ -79,7 +181,22 @@ func (srv *RedisServer) command(conn redcon.Conn, cmd redcon.Command) {
 			srv.wrongNumberArgsError(conn, cmd)
 			return
 		}
+		if rCtx.tracking != nil {
+			conn.WriteError("ERR subscription is not allowed with CLIENT TRACKING")
+			return
+		}
 		command := strings.ToLower(string(cmd.Args[0]))
+		if srv.ACL.Enabled() {
+			for _, channel := range cmd.Args[1:] {
+				if err := srv.channelAccess(rCtx.User, string(channel), command == "psubscribe"); err != nil {
+					conn.WriteError(err.Error())
+					return
+				}
+			}
+		}
+		srv.listenChannels()
+		// The connection is served by the pub/sub and isn't closed by the server loop
+		srv.clients.remove(rCtx)
 		for i := 1; i < len(cmd.Args); i++ {
 			if command == "psubscribe" {
 				srv.ps.Psubscribe(conn, string(cmd.Args[i]))
@@ -88,6 +205,10 @@ func (srv *RedisServer) command(conn redcon.Conn, cmd redcon.Command) {
 			}
 		}
 	case "detach":
+		if rCtx.tracking != nil {
+			conn.WriteError("ERR connection is already detached by CLIENT TRACKING")
+			return
+		}
 		hconn := conn.Detach()
 		log.Printf("connection has been detached")
 		go func() {
@@ -95,8 +216,39 @@ func (srv *RedisServer) command(conn redcon.Conn, cmd redcon.Command) {
 			hconn.WriteString("OK")
 			hconn.Flush()
 		}()
+	case "hello":
+		srv.cmdHello(conn, rCtx, cmd)
+	case "auth":
+		srv.cmdAuth(conn, rCtx, cmd)
+	case "acl":
+		srv.cmdACL(conn, rCtx, cmd)
+	case "client":
+		srv.cmdClient(conn, rCtx, cmd)
+	case "multi":
+		srv.cmdMulti(conn, rCtx)
+	case "exec":
+		srv.cmdExec(ctx, conn, rCtx)
+	case "discard":
+		srv.cmdDiscard(conn, rCtx)
+	case "watch":
+		srv.cmdWatch(conn, rCtx, cmd)
+	case "unwatch":
+		srv.watches.unwatch(rCtx)
+		conn.WriteString("OK")
 	case "ping":
 		conn.WriteString("PONG")
+	case "echo":
+		if len(cmd.Args) != 2 {
+			srv.wrongNumberArgsError(conn, cmd)
+			return
+		}
+		conn.WriteBulk(cmd.Args[1])
+	case "info":
+		srv.cmdInfo(ctx, conn, cmd)
+	case "dbsize":
+		srv.cmdDBSize(ctx, conn, dbnum, cmd)
+	case "command":
+		srv.cmdCommand(conn, cmd)
 	case "quit":
 		conn.WriteString("OK")
 		conn.Close()
@@ -110,12 +262,82 @@ func (srv *RedisServer) command(conn redcon.Conn, cmd redcon.Command) {
 		srv.cmdMGet(ctx, conn, dbnum, cmd)
 	case "hget":
 		srv.cmdHGet(ctx, conn, dbnum, cmd)
+	case "hmget":
+		srv.cmdHMGet(ctx, conn, dbnum, cmd)
 	case "hgetall":
 		srv.cmdHGetall(ctx, conn, dbnum, cmd)
+	case "hkeys", "hvals", "hlen":
+		srv.cmdHFields(ctx, conn, dbnum, cmd)
+	case "hexists", "hstrlen":
+		srv.cmdHField(ctx, conn, dbnum, cmd)
+	case "hset", "hmset":
+		srv.cmdHSet(ctx, conn, dbnum, cmd)
+	case "hdel":
+		srv.cmdHDel(ctx, conn, dbnum, cmd)
+	case "hincrby", "hincrbyfloat":
+		srv.cmdHIncrBy(ctx, conn, dbnum, cmd)
 	case "del":
 		srv.cmdDel(ctx, conn, dbnum, cmd)
+	case "lrange":
+		srv.cmdLRange(ctx, conn, dbnum, cmd)
+	case "lindex":
+		srv.cmdLIndex(ctx, conn, dbnum, cmd)
+	case "llen", "scard", "zcard":
+		srv.cmdLen(ctx, conn, dbnum, cmd)
+	case "smembers":
+		srv.cmdSMembers(ctx, conn, dbnum, cmd)
+	case "sismember":
+		srv.cmdSIsMember(ctx, conn, dbnum, cmd)
+	case "sscan":
+		srv.cmdSScan(ctx, conn, dbnum, cmd)
+	case "rpush", "sadd":
+		srv.cmdAddMembers(ctx, conn, dbnum, cmd)
+	case "srem", "zrem":
+		srv.cmdSRem(ctx, conn, dbnum, cmd)
+	case "zrange", "zrevrange", "zrangebyscore", "zrevrangebyscore", "zrangebylex", "zrevrangebylex":
+		srv.cmdZRange(ctx, conn, dbnum, cmd)
+	case "zscore":
+		srv.cmdZScore(ctx, conn, dbnum, cmd)
+	case "zrank", "zrevrank":
+		srv.cmdZRank(ctx, conn, dbnum, cmd)
+	case "zadd":
+		srv.cmdZAdd(ctx, conn, dbnum, cmd)
+	case "zincrby":
+		srv.cmdZIncrBy(ctx, conn, dbnum, cmd)
+	case "xadd":
+		srv.cmdXAdd(ctx, conn, dbnum, cmd)
+	case "xread", "xreadgroup":
+		srv.cmdXRead(ctx, conn, dbnum, cmd)
+	case "xack":
+		srv.cmdXAck(ctx, conn, dbnum, cmd)
+	case "xgroup":
+		srv.cmdXGroup(ctx, conn, dbnum, cmd)
+	case "json.get":
+		srv.cmdJSONGet(ctx, conn, dbnum, cmd)
+	case "json.mget":
+		srv.cmdJSONMGet(ctx, conn, dbnum, cmd)
+	case "json.type", "json.arrlen":
+		srv.cmdJSONType(ctx, conn, dbnum, cmd)
+	case "json.set":
+		srv.cmdJSONSet(ctx, conn, dbnum, cmd)
+	case "json.del", "json.forget":
+		srv.cmdJSONDel(ctx, conn, dbnum, cmd)
+	case "type":
+		srv.cmdType(ctx, conn, dbnum, cmd)
+	case "incr", "decr", "incrby", "decrby", "incrbyfloat":
+		srv.cmdIncr(ctx, conn, dbnum, cmd)
+	case "expire", "pexpire", "expireat", "pexpireat":
+		srv.cmdExpire(ctx, conn, dbnum, cmd)
+	case "persist":
+		srv.cmdPersist(ctx, conn, dbnum, cmd)
+	case "ttl", "pttl":
+		srv.cmdTTL(ctx, conn, dbnum, cmd)
 	case "keys":
 		srv.cmdKeys(ctx, conn, dbnum, cmd)
+	case "scan":
+		srv.cmdScan(ctx, conn, dbnum, cmd)
+	case "hscan":
+		srv.cmdHScan(ctx, conn, dbnum, cmd)
 	case "select":
 		if len(cmd.Args) != 2 {
 			srv.wrongNumberArgsError(conn, cmd)
@@ -126,21 +348,359 @@ func (srv *RedisServer) command(conn redcon.Conn, cmd redcon.Command) {
 			conn.WriteError("ERR invalid database number " + strconv.Itoa(dbnum) + ", must be from 0 to 15")
 			return
 		}
+		if rCtx.User != nil && !rCtx.User.AllowDB(dbnum) {
+			conn.WriteError(acl.ErrNoPermDB.Error())
+			return
+		}
 		rCtx.DBNum = dbnum
 		conn.SetContext(rCtx)
 		conn.WriteString("OK")
 	case "config":
-		conn.WriteArray(2)
-		conn.WriteBulk(cmd.Args[2])
-		conn.WriteBulkString("")
+		srv.cmdConfig(conn, cmd)
 	}
 }
 
 func (srv *RedisServer) acceptConnection(conn redcon.Conn) bool {
+	rCtx := &userContext{
+		ID:       srv.lastID.Add(1),
+		Protocol: protoRESP2,
+		User:     srv.ACL.Default(),
+	}
+	conn.SetContext(rCtx)
+	srv.clients.add(conn, rCtx)
 	return true
 }
 
 func (srv *RedisServer) closeConnection(conn redcon.Conn, err error) {
+	rCtx := getUserContext(conn.Context())
+	if srv.watches != nil {
+		srv.watches.unwatch(rCtx)
+	}
+	srv.clients.remove(rCtx)
+}
+
+// cmdHello switches the protocol of the connection and replies with the server info
+//
+//	HELLO [protover [AUTH username password] [SETNAME clientname]]
+func (srv *RedisServer) cmdHello(conn redcon.Conn, rCtx *userContext, cmd redcon.Command) {
+	proto := rCtx.Protocol
+	if len(cmd.Args) > 1 {
+		var err error
+		if proto, err = strconv.Atoi(string(cmd.Args[1])); err != nil {
+			conn.WriteError("ERR Protocol version is not an integer or out of range")
+			return
+		}
+		if proto != protoRESP2 && proto != protoRESP3 {
+			conn.WriteError("NOPROTO unsupported protocol version")
+			return
+		}
+	}
+	var name string
+	for i := 2; i < len(cmd.Args); i++ {
+		switch strings.ToLower(string(cmd.Args[i])) {
+		case "auth":
+			if i+2 >= len(cmd.Args) {
+				conn.WriteError("ERR syntax error")
+				return
+			}
+			if err := srv.authenticate(rCtx, string(cmd.Args[i+1]), string(cmd.Args[i+2])); err != nil {
+				conn.WriteError(err.Error())
+				return
+			}
+			i += 2
+		case "setname":
+			if i+1 >= len(cmd.Args) {
+				conn.WriteError("ERR syntax error")
+				return
+			}
+			i++
+			name = string(cmd.Args[i])
+		default:
+			conn.WriteError("ERR syntax error")
+			return
+		}
+	}
+	if srv.ACL.Enabled() && rCtx.User == nil {
+		conn.WriteError(acl.ErrAuthRequired.Error())
+		return
+	}
+	if name != "" {
+		rCtx.Name = name
+	}
+	if proto == 0 {
+		proto = protoRESP2
+	}
+	rCtx.Protocol = proto
+	writeMap(conn, 7)
+	conn.WriteBulkString("server")
+	conn.WriteBulkString("redify")
+	conn.WriteBulkString("version")
+	conn.WriteBulkString(srv.Version)
+	conn.WriteBulkString("proto")
+	conn.WriteInt(rCtx.Protocol)
+	conn.WriteBulkString("id")
+	conn.WriteInt64(rCtx.ID)
+	conn.WriteBulkString("mode")
+	conn.WriteBulkString("standalone")
+	conn.WriteBulkString("role")
+	conn.WriteBulkString("master")
+	conn.WriteBulkString("modules")
+	conn.WriteArray(0)
+}
+
+// cmdAuth authenticates the connection
+//
+//	AUTH [username] password
+func (srv *RedisServer) cmdAuth(conn redcon.Conn, rCtx *userContext, cmd redcon.Command) {
+	var name, password string
+	switch len(cmd.Args) {
+	case 2:
+		name, password = acl.DefaultUser, string(cmd.Args[1])
+	case 3:
+		name, password = string(cmd.Args[1]), string(cmd.Args[2])
+	default:
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	if !srv.ACL.Enabled() {
+		conn.WriteError("ERR AUTH called without any password configured for the default user")
+		return
+	}
+	if err := srv.authenticate(rCtx, name, password); err != nil {
+		conn.WriteError(err.Error())
+		return
+	}
+	conn.WriteString("OK")
+}
+
+func (srv *RedisServer) authenticate(rCtx *userContext, name, password string) error {
+	user, err := srv.ACL.Authenticate(name, password)
+	if err != nil {
+		return err
+	}
+	rCtx.User = user
+	return nil
+}
+
+// cmdACL implements ACL WHOAMI, ACL LIST and ACL USERS commands
+func (srv *RedisServer) cmdACL(conn redcon.Conn, rCtx *userContext, cmd redcon.Command) {
+	if len(cmd.Args) != 2 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	subcommand := strings.ToLower(string(cmd.Args[1]))
+	if subcommand == "whoami" {
+		if rCtx.User == nil {
+			conn.WriteBulkString(acl.DefaultUser)
+		} else {
+			conn.WriteBulkString(rCtx.User.Name)
+		}
+		return
+	}
+	if subcommand != "list" && subcommand != "users" {
+		conn.WriteError("ERR unknown subcommand '" + string(cmd.Args[1]) + "'")
+		return
+	}
+	if rCtx.User != nil {
+		if err := rCtx.User.Check("acl|"+subcommand, acl.CategoryAdmin, rCtx.DBNum); err != nil {
+			conn.WriteError(err.Error())
+			return
+		}
+	}
+	users := srv.ACL.Users()
+	conn.WriteArray(len(users))
+	for _, user := range users {
+		if subcommand == "list" {
+			conn.WriteBulkString(user.Rules())
+		} else {
+			conn.WriteBulkString(user.Name)
+		}
+	}
+}
+
+// checkAccess of the connection user to run the command
+func (srv *RedisServer) checkAccess(rCtx *userContext, cmd redcon.Command) error {
+	if !srv.ACL.Enabled() {
+		return nil
+	}
+	name := strings.ToLower(string(cmd.Args[0]))
+	if rCtx.User == nil {
+		switch name {
+		case "auth", "hello", "quit":
+			return nil
+		}
+		return acl.ErrAuthRequired
+	}
+	category := commandCategory(name)
+	if category == "" && adminSubcommand(name, cmd) {
+		category = acl.CategoryAdmin
+	}
+	if category == "" {
+		return nil
+	}
+	keys := commandKeys(cmd)
+	if name == "publish" && len(cmd.Args) > 1 && srv.bridgedChannel(string(cmd.Args[1])) {
+		// Bridged channels are the keys of the binds
+		keys = append(keys, string(cmd.Args[1]))
+	}
+	return rCtx.User.Check(name, category, rCtx.DBNum, keys...)
+}
+
+// filterKeys returns only keys which are accessible by the connection user
+func filterKeys(conn redcon.Conn, keys []string) []string {
+	if user := getUserContext(conn.Context()).User; user != nil {
+		return user.FilterKeys(keys)
+	}
+	return keys
+}
+
+func (srv *RedisServer) cmdClient(conn redcon.Conn, rCtx *userContext, cmd redcon.Command) {
+	if len(cmd.Args) < 2 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	switch strings.ToLower(string(cmd.Args[1])) {
+	case "tracking":
+		srv.cmdClientTracking(conn, rCtx, cmd)
+	case "id":
+		conn.WriteInt64(rCtx.ID)
+	case "getname":
+		if rCtx.Name == "" {
+			conn.WriteNull()
+		} else {
+			conn.WriteBulkString(rCtx.Name)
+		}
+	case "setname":
+		if len(cmd.Args) != 3 {
+			srv.wrongNumberArgsError(conn, cmd)
+			return
+		}
+		name := string(cmd.Args[2])
+		if !validClientName(name) {
+			conn.WriteError("ERR Client names cannot contain spaces, newlines or special characters.")
+			return
+		}
+		rCtx.Name = name
+		conn.WriteString("OK")
+	case "setinfo":
+		if len(cmd.Args) != 4 {
+			srv.wrongNumberArgsError(conn, cmd)
+			return
+		}
+		value := string(cmd.Args[3])
+		if !validClientName(value) {
+			conn.WriteError("ERR " + string(cmd.Args[2]) + " cannot contain spaces, newlines or special characters.")
+			return
+		}
+		switch strings.ToLower(string(cmd.Args[2])) {
+		case "lib-name":
+			rCtx.LibName = value
+		case "lib-ver":
+			rCtx.LibVer = value
+		default:
+			conn.WriteError("ERR Unrecognized option '" + string(cmd.Args[2]) + "'")
+			return
+		}
+		conn.WriteString("OK")
+	case "info":
+		clients := srv.clients.list(rCtx.ID)
+		if len(clients) == 0 {
+			conn.WriteNull()
+			return
+		}
+		conn.WriteBulkString(clients[0].String() + "\n")
+	case "list":
+		srv.cmdClientList(conn, cmd)
+	default:
+		conn.WriteError("ERR unknown subcommand '" + string(cmd.Args[1]) + "'")
+	}
+}
+
+// cmdClientTracking enables client-side caching invalidation messages.
+// The connection is detached from the server loop to be able to receive
+// push messages between the commands.
+//
+//	CLIENT TRACKING ON|OFF [BCAST] [PREFIX prefix [PREFIX prefix ...]]
+func (srv *RedisServer) cmdClientTracking(conn redcon.Conn, rCtx *userContext, cmd redcon.Command) {
+	if len(cmd.Args) < 3 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	var (
+		enable   bool
+		bcast    bool
+		prefixes []string
+	)
+	switch strings.ToLower(string(cmd.Args[2])) {
+	case "on":
+		enable = true
+	case "off":
+	default:
+		conn.WriteError("ERR syntax error")
+		return
+	}
+	for i := 3; i < len(cmd.Args); i++ {
+		switch strings.ToLower(string(cmd.Args[i])) {
+		case "bcast":
+			bcast = true
+		case "prefix":
+			if i++; i >= len(cmd.Args) {
+				conn.WriteError("ERR syntax error")
+				return
+			}
+			prefixes = append(prefixes, string(cmd.Args[i]))
+		default:
+			conn.WriteError("ERR syntax error")
+			return
+		}
+	}
+	if len(prefixes) > 0 && !bcast {
+		conn.WriteError("ERR PREFIX option requires BCAST mode to be enabled")
+		return
+	}
+	switch {
+	case !enable:
+		if rCtx.tracking != nil {
+			srv.tracking.disable(rCtx.tracking)
+		}
+		conn.WriteString("OK")
+	case rCtx.Protocol != protoRESP3:
+		conn.WriteError("ERR CLIENT TRACKING requires RESP3 protocol, use HELLO 3")
+	case rCtx.tracking != nil:
+		srv.tracking.enable(rCtx.tracking, bcast, prefixes)
+		conn.WriteString("OK")
+	default:
+		rCtx.tracking = newTrackingClient(conn.Detach())
+		srv.tracking.enable(rCtx.tracking, bcast, prefixes)
+		go srv.serveTracking(rCtx.tracking)
+	}
+}
+
+// serveTracking handles commands of the detached connection with enabled tracking
+func (srv *RedisServer) serveTracking(client *trackingClient) {
+	defer func() {
+		srv.tracking.disable(client)
+		srv.clients.remove(getUserContext(client.conn.Context()))
+		client.close()
+		_ = client.conn.Close()
+	}()
+	go client.run()
+
+	client.mx.Lock()
+	client.conn.WriteString("OK")
+	err := client.conn.Flush()
+	client.mx.Unlock()
+
+	for err == nil {
+		var cmd redcon.Command
+		if cmd, err = client.conn.ReadCommand(); err != nil {
+			break
+		}
+		client.mx.Lock()
+		srv.command(client.conn, cmd)
+		err = client.conn.Flush()
+		client.mx.Unlock()
+	}
 }
 
 func (srv *RedisServer) cmdGet(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
@@ -168,57 +728,211 @@ func (srv *RedisServer) cmdMGet(ctx context.Context, conn redcon.Conn, dbnum int
 		srv.wrongNumberArgsError(conn, cmd)
 		return
 	}
-	conn.WriteArray(len(cmd.Args) - 1)
+	keys := make([]string, 0, len(cmd.Args)-1)
 	for i := 1; i < len(cmd.Args); i++ {
-		var (
-			key        = string(cmd.Args[i])
-			value, err = srv.Driver.Get(ctx, dbnum, key)
-		)
-		if err != nil && !errors.Is(err, storage.ErrNotFound) && !errors.Is(err, storage.ErrNoKey) {
-			ctxlogger.Get(ctx).Error("mget value", zap.Error(err), zap.String("key", key))
+		keys = append(keys, string(cmd.Args[i]))
+	}
+	items := storage.GetMany(ctx, srv.Driver, dbnum, keys)
+	conn.WriteArray(len(items))
+	for i, item := range items {
+		if item.Err != nil && !errors.Is(item.Err, storage.ErrNotFound) && !errors.Is(item.Err, storage.ErrNoKey) {
+			ctxlogger.Get(ctx).Error("mget value", zap.Error(item.Err), zap.String("key", keys[i]))
 		}
-		if value == nil {
+		if item.Value == nil {
 			conn.WriteNull()
 		} else {
-			conn.WriteBulk(value)
+			conn.WriteBulk(item.Value)
 		}
 	}
 }
 
+// cmdGetPipeline executes the sequence of pipelined GET commands as one batch request
+// and runs the rest of the pipeline in the regular way
+func (srv *RedisServer) cmdGetPipeline(ctx context.Context, conn redcon.Conn, rCtx *userContext, cmd redcon.Command) {
+	var (
+		pipeline = conn.ReadPipeline()
+		keys     = []string{string(cmd.Args[1])}
+		denied   = []error{nil}
+		allowed  = []string{string(cmd.Args[1])}
+		rest     []redcon.Command
+	)
+	// The first command is checked and tracked by the caller
+	for i, pcmd := range pipeline {
+		if !isGetCommand(pcmd) {
+			rest = pipeline[i:]
+			break
+		}
+		srv.clients.touch(rCtx, pcmd)
+		key := string(pcmd.Args[1])
+		keys = append(keys, key)
+		if err := srv.checkAccess(rCtx, pcmd); err != nil {
+			denied = append(denied, err)
+			continue
+		}
+		denied = append(denied, nil)
+		allowed = append(allowed, key)
+		if rCtx.tracking != nil {
+			srv.tracking.track(rCtx.tracking, key)
+		}
+	}
+	items := storage.GetMany(ctx, srv.Driver, rCtx.DBNum, allowed)
+	for i, key := range keys {
+		if denied[i] != nil {
+			conn.WriteError(denied[i].Error())
+			continue
+		}
+		item := items[0]
+		items = items[1:]
+		if item.Err != nil && !errors.Is(item.Err, storage.ErrNotFound) && !errors.Is(item.Err, storage.ErrNoKey) {
+			ctxlogger.Get(ctx).Error("get value", zap.Error(item.Err), zap.String("key", key))
+			conn.WriteError("ERR " + item.Err.Error())
+		} else if item.Value == nil {
+			conn.WriteNull()
+		} else {
+			conn.WriteBulk(item.Value)
+		}
+	}
+	for _, pcmd := range rest {
+		srv.command(conn, pcmd)
+	}
+}
+
 func (srv *RedisServer) cmdHGet(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
 	if len(cmd.Args) != 3 {
 		srv.wrongNumberArgsError(conn, cmd)
 		return
 	}
 	var (
-		key        = string(cmd.Args[1])
-		name       = string(cmd.Args[2])
-		value, err = srv.Driver.Get(ctx, dbnum, key)
+		key         = string(cmd.Args[1])
+		name        = string(cmd.Args[2])
+		record, err = srv.getRecord(ctx, dbnum, key, []string{name})
 	)
-	if err != nil && !errors.Is(err, storage.ErrNotFound) && !errors.Is(err, storage.ErrNoKey) {
-		ctxlogger.Get(ctx).Error("mget value", zap.Error(err), zap.String("key", key))
+	if err != nil {
+		ctxlogger.Get(ctx).Error("hget value", zap.Error(err), zap.String("key", key))
 	}
-	if value == nil {
+	if val, ok := record[name]; ok {
+		writeJSONValue(conn, name, val)
+	} else {
 		conn.WriteNull()
-	} else if bytes.HasPrefix(value, []byte("{")) {
-		var record map[string]json.RawMessage
-		if err := json.Unmarshal(value, &record); err == nil {
-			if val := record[name]; bytes.HasPrefix(val, []byte(`"`)) {
-				var s string
-				if err := json.Unmarshal(val, &s); err != nil {
-					conn.WriteError("ERR decode field '" + key + "' " + err.Error())
-				} else {
-					conn.WriteBulkString(s)
-				}
-			} else {
-				conn.WriteBulk(val)
-			}
+	}
+}
+
+func (srv *RedisServer) cmdHMGet(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) < 3 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	var (
+		key    = string(cmd.Args[1])
+		fields = make([]string, 0, len(cmd.Args)-2)
+	)
+	for _, arg := range cmd.Args[2:] {
+		fields = append(fields, string(arg))
+	}
+	record, err := srv.getRecord(ctx, dbnum, key, fields)
+	if err != nil {
+		conn.WriteError("ERR " + err.Error())
+		return
+	}
+	conn.WriteArray(len(fields))
+	for _, name := range fields {
+		if val, ok := record[name]; ok {
+			writeJSONValue(conn, name, val)
 		} else {
 			conn.WriteNull()
 		}
+	}
+}
+
+// cmdHFields implements HKEYS, HVALS and HLEN commands which require the whole record
+func (srv *RedisServer) cmdHFields(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) != 2 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	key := string(cmd.Args[1])
+	record, err := srv.getRecord(ctx, dbnum, key, nil)
+	if err != nil {
+		conn.WriteError("ERR " + err.Error())
+		return
+	}
+	if strings.EqualFold(string(cmd.Args[0]), "hlen") {
+		conn.WriteInt(len(record))
+		return
+	}
+	fields := make([]string, 0, len(record))
+	for name := range record {
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+	values := strings.EqualFold(string(cmd.Args[0]), "hvals")
+	conn.WriteArray(len(fields))
+	for _, name := range fields {
+		if values {
+			writeJSONValue(conn, name, record[name])
+		} else {
+			conn.WriteBulkString(name)
+		}
+	}
+}
+
+// cmdHField implements HEXISTS and HSTRLEN commands
+func (srv *RedisServer) cmdHField(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) != 3 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	var (
+		key         = string(cmd.Args[1])
+		name        = string(cmd.Args[2])
+		record, err = srv.getRecord(ctx, dbnum, key, []string{name})
+	)
+	if err != nil {
+		conn.WriteError("ERR " + err.Error())
+		return
+	}
+	val, ok := record[name]
+	switch {
+	case !ok:
+		conn.WriteInt(0)
+	case strings.EqualFold(string(cmd.Args[0]), "hexists"):
+		conn.WriteInt(1)
+	case bytes.HasPrefix(val, []byte(`"`)):
+		var s string
+		if err := json.Unmarshal(val, &s); err != nil {
+			conn.WriteError("ERR decode field '" + name + "' " + err.Error())
+		} else {
+			conn.WriteInt(len(s))
+		}
+	default:
+		conn.WriteInt(len(val))
+	}
+}
+
+// getRecord of the key as the map of JSON fields, if fields are defined
+// then only these fields are loaded from the storage.
+// Missing keys and values which are not JSON objects are returned as nil record.
+func (srv *RedisServer) getRecord(ctx context.Context, dbnum int, key string, fields []string) (map[string]json.RawMessage, error) {
+	var (
+		value []byte
+		err   error
+	)
+	if len(fields) > 0 {
+		value, err = storage.GetFields(ctx, srv.Driver, dbnum, key, fields)
 	} else {
-		conn.WriteNull()
+		value, err = srv.Driver.Get(ctx, dbnum, key)
+	}
+	if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrNoKey) {
+		return nil, nil
+	}
+	if err != nil || !bytes.HasPrefix(value, []byte("{")) {
+		return nil, err
 	}
+	var record map[string]json.RawMessage
+	if err = json.Unmarshal(value, &record); err != nil {
+		return nil, fmt.Errorf("decode record '%s' %w", key, err)
+	}
+	return record, nil
 }
 
 func (srv *RedisServer) cmdHGetall(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
@@ -238,19 +952,10 @@ func (srv *RedisServer) cmdHGetall(ctx context.Context, conn redcon.Conn, dbnum
 	} else if bytes.HasPrefix(value, []byte("{")) {
 		var record map[string]json.RawMessage
 		if err := json.Unmarshal(value, &record); err == nil {
-			conn.WriteArray(len(record) * 2)
+			writeMap(conn, len(record))
 			for key, val := range record {
 				conn.WriteBulkString(key)
-				if bytes.HasPrefix(val, []byte(`"`)) {
-					var s string
-					if err := json.Unmarshal(val, &s); err != nil {
-						conn.WriteError("ERR decode field '" + key + "' " + err.Error())
-					} else {
-						conn.WriteBulkString(s)
-					}
-				} else {
-					conn.WriteBulk(val)
-				}
+				writeJSONValue(conn, key, val)
 			}
 		} else {
 			conn.WriteArray(1)
@@ -261,21 +966,216 @@ func (srv *RedisServer) cmdHGetall(ctx context.Context, conn redcon.Conn, dbnum
 	}
 }
 
+func (srv *RedisServer) cmdHSet(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) < 4 || len(cmd.Args)%2 != 0 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	fw, _ := srv.Driver.(storage.FieldWriter)
+	if fw == nil {
+		conn.WriteError("ERR " + storage.ErrMethodIsNotSupported.Error())
+		return
+	}
+	var (
+		key    = string(cmd.Args[1])
+		fields = make(map[string]string, (len(cmd.Args)-2)/2)
+	)
+	for i := 2; i < len(cmd.Args); i += 2 {
+		fields[string(cmd.Args[i])] = string(cmd.Args[i+1])
+	}
+	count, err := fw.SetFields(ctx, dbnum, key, fields)
+	if err == nil && count == 0 {
+		err = storage.ErrNotFound
+	}
+	if err != nil {
+		conn.WriteError("ERR " + err.Error())
+		return
+	}
+	srv.keyEvent(ctx, dbnum, key, storage.KeyEventHSet)
+	if strings.EqualFold(string(cmd.Args[0]), "hmset") {
+		conn.WriteString("OK")
+	} else {
+		conn.WriteInt(len(fields))
+	}
+}
+
+func (srv *RedisServer) cmdHDel(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) < 3 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	fw, _ := srv.Driver.(storage.FieldWriter)
+	if fw == nil {
+		conn.WriteError("ERR " + storage.ErrMethodIsNotSupported.Error())
+		return
+	}
+	var (
+		key    = string(cmd.Args[1])
+		fields = make([]string, 0, len(cmd.Args)-2)
+	)
+	for i := 2; i < len(cmd.Args); i++ {
+		fields = append(fields, string(cmd.Args[i]))
+	}
+	count, err := fw.DelFields(ctx, dbnum, key, fields)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) && !errors.Is(err, storage.ErrNoKey) {
+		conn.WriteError("ERR " + err.Error())
+		return
+	}
+	if count == 0 {
+		conn.WriteInt(0)
+	} else {
+		srv.keyEvent(ctx, dbnum, key, storage.KeyEventHDel)
+		conn.WriteInt(len(fields))
+	}
+}
+
+func (srv *RedisServer) cmdHIncrBy(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) != 4 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	fw, _ := srv.Driver.(storage.FieldWriter)
+	if fw == nil {
+		conn.WriteError("ERR " + storage.ErrMethodIsNotSupported.Error())
+		return
+	}
+	var (
+		key     = string(cmd.Args[1])
+		field   = string(cmd.Args[2])
+		isFloat = strings.EqualFold(string(cmd.Args[0]), "hincrbyfloat")
+		delta   any
+		err     error
+	)
+	if isFloat {
+		delta, err = strconv.ParseFloat(string(cmd.Args[3]), 64)
+	} else {
+		delta, err = strconv.ParseInt(string(cmd.Args[3]), 10, 64)
+	}
+	if err != nil {
+		conn.WriteError("ERR value is not a valid number")
+		return
+	}
+	value, err := fw.IncrField(ctx, dbnum, key, field, delta)
+	if err != nil {
+		conn.WriteError("ERR " + err.Error())
+		return
+	}
+	if isFloat {
+		srv.keyEvent(ctx, dbnum, key, storage.KeyEventHIncrByFloat)
+	} else {
+		srv.keyEvent(ctx, dbnum, key, storage.KeyEventHIncrBy)
+	}
+	if isFloat {
+		conn.WriteBulkString(strconv.FormatFloat(gocast.Number[float64](value), 'f', -1, 64))
+	} else {
+		conn.WriteInt64(gocast.Number[int64](value))
+	}
+}
+
+// cmdSet writes the value of the key
+//
+//	SET key value [NX | XX] [GET] [EX seconds | PX milliseconds | EXAT unix-time-seconds | PXAT unix-time-milliseconds | KEEPTTL]
 func (srv *RedisServer) cmdSet(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
-	if len(cmd.Args) != 3 {
+	if len(cmd.Args) < 3 {
 		srv.wrongNumberArgsError(conn, cmd)
 		return
 	}
+	opts, err := parseSetOptions(cmd.Args[3:])
+	if err != nil {
+		conn.WriteError(err.Error())
+		return
+	}
 	var (
 		key   = string(cmd.Args[1])
 		value = cmd.Args[2]
-		err   = srv.Driver.Set(ctx, dbnum, key, value)
 	)
-	if err != nil {
-		conn.WriteError("ERR " + err.Error())
+	if opts.IsDefault() {
+		if err = srv.Driver.Set(ctx, dbnum, key, value); err != nil {
+			conn.WriteError("ERR " + err.Error())
+			return
+		}
+		srv.keyEvent(ctx, dbnum, key, storage.KeyEventSet)
+		conn.WriteString("OK")
 		return
 	}
-	conn.WriteString("OK")
+	res, err := storage.SetWithOptions(ctx, srv.Driver, dbnum, key, value, opts)
+	if err == nil && res.Applied {
+		srv.keyEvent(ctx, dbnum, key, storage.KeyEventSet)
+	}
+	switch {
+	case errors.Is(err, storage.ErrExpireIsNotSupported):
+		conn.WriteError("ERR expiration is not supported by the key storage, ttl_column is required")
+	case errors.Is(err, storage.ErrMethodIsNotSupported):
+		conn.WriteError("ERR SET options are not supported by the key storage")
+	case err != nil:
+		conn.WriteError("ERR " + err.Error())
+	case opts.Get && res.Prev == nil:
+		conn.WriteNull()
+	case opts.Get:
+		conn.WriteBulk(res.Prev)
+	case !res.Applied:
+		conn.WriteNull()
+	default:
+		conn.WriteString("OK")
+	}
+}
+
+var (
+	errSetSyntax     = errors.New("ERR syntax error")
+	errSetExpireTime = errors.New("ERR invalid expire time in 'set' command")
+)
+
+// parseSetOptions of the SET command arguments after the value
+func parseSetOptions(args [][]byte) (opts storage.SetOptions, err error) {
+	var hasExpire bool
+	for i := 0; i < len(args); i++ {
+		switch name := strings.ToLower(string(args[i])); name {
+		case "nx", "xx":
+			if opts.Mode != storage.SetAlways {
+				return opts, errSetSyntax
+			}
+			if opts.Mode = storage.SetIfNotExists; name == "xx" {
+				opts.Mode = storage.SetIfExists
+			}
+		case "get":
+			opts.Get = true
+		case "keepttl":
+			if hasExpire {
+				return opts, errSetSyntax
+			}
+			hasExpire, opts.KeepTTL = true, true
+		case "ex", "px", "exat", "pxat":
+			if hasExpire || i+1 >= len(args) {
+				return opts, errSetSyntax
+			}
+			i++
+			num, err := strconv.ParseInt(string(args[i]), 10, 64)
+			if err != nil {
+				return opts, errors.New("ERR value is not an integer or out of range")
+			}
+			switch name {
+			case "ex":
+				opts.TTL = time.Duration(num) * time.Second
+			case "px":
+				opts.TTL = time.Duration(num) * time.Millisecond
+			case "exat":
+				opts.TTL = time.Until(time.Unix(num, 0))
+			case "pxat":
+				opts.TTL = time.Until(time.UnixMilli(num))
+			}
+			if num <= 0 {
+				return opts, errSetExpireTime
+			}
+			if opts.TTL <= 0 {
+				// The time is in the past, the key expires immediately
+				opts.TTL = time.Nanosecond
+			}
+			hasExpire = true
+		default:
+			return opts, errSetSyntax
+		}
+	}
+	return opts, nil
 }
 
 func (srv *RedisServer) cmdMSet(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
@@ -283,7 +1183,7 @@ func (srv *RedisServer) cmdMSet(ctx context.Context, conn redcon.Conn, dbnum int
 		srv.wrongNumberArgsError(conn, cmd)
 		return
 	}
-	for i := 0; i < len(cmd.Args); i += 2 {
+	for i := 1; i < len(cmd.Args); i += 2 {
 		var (
 			key   = string(cmd.Args[i])
 			value = cmd.Args[i+1]
@@ -293,6 +1193,7 @@ func (srv *RedisServer) cmdMSet(ctx context.Context, conn redcon.Conn, dbnum int
 			conn.WriteError("ERR " + err.Error())
 			return
 		}
+		srv.keyEvent(ctx, dbnum, key, storage.KeyEventSet)
 	}
 	conn.WriteString("OK")
 }
@@ -313,6 +1214,7 @@ func (srv *RedisServer) cmdDel(ctx context.Context, conn redcon.Conn, dbnum int,
 	if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrNoKey) {
 		conn.WriteInt(0)
 	} else {
+		srv.keyEvent(ctx, dbnum, key, storage.KeyEventDel)
 		conn.WriteInt(1)
 	}
 }
@@ -330,12 +1232,144 @@ func (srv *RedisServer) cmdKeys(ctx context.Context, conn redcon.Conn, dbnum int
 		conn.WriteError("ERR " + err.Error())
 		return
 	}
+	keys = filterKeys(conn, keys)
+	conn.WriteArray(len(keys))
+	for _, key := range keys {
+		conn.WriteBulkString(key)
+	}
+}
+
+func (srv *RedisServer) cmdScan(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) < 2 || len(cmd.Args)%2 != 0 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	cursor, err := strconv.ParseUint(string(cmd.Args[1]), 10, 64)
+	if err != nil {
+		conn.WriteError("ERR invalid cursor")
+		return
+	}
+	var opts storage.ScanOptions
+	for i := 2; i < len(cmd.Args); i += 2 {
+		switch strings.ToLower(string(cmd.Args[i])) {
+		case "match":
+			opts.Pattern = string(cmd.Args[i+1])
+		case "count":
+			if opts.Count, err = strconv.Atoi(string(cmd.Args[i+1])); err != nil || opts.Count < 1 {
+				conn.WriteError("ERR value is not an integer or out of range")
+				return
+			}
+		case "type":
+			opts.Type = string(cmd.Args[i+1])
+		default:
+			conn.WriteError("ERR syntax error")
+			return
+		}
+	}
+	keys, next, err := srv.Driver.Scan(ctx, dbnum, cursor, opts)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) && !errors.Is(err, storage.ErrNoKey) {
+		conn.WriteError("ERR " + err.Error())
+		return
+	}
+	keys = filterKeys(conn, keys)
+	conn.WriteArray(2)
+	conn.WriteBulkString(strconv.FormatUint(next, 10))
 	conn.WriteArray(len(keys))
 	for _, key := range keys {
 		conn.WriteBulkString(key)
 	}
 }
 
+func (srv *RedisServer) cmdHScan(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) < 3 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	cursor, err := strconv.ParseUint(string(cmd.Args[2]), 10, 64)
+	if err != nil {
+		conn.WriteError("ERR invalid cursor")
+		return
+	}
+	var (
+		key      = string(cmd.Args[1])
+		opts     storage.ScanOptions
+		noValues bool
+	)
+	for i := 3; i < len(cmd.Args); i++ {
+		switch strings.ToLower(string(cmd.Args[i])) {
+		case "match":
+			if i++; i >= len(cmd.Args) {
+				conn.WriteError("ERR syntax error")
+				return
+			}
+			opts.Pattern = string(cmd.Args[i])
+		case "count":
+			if i++; i >= len(cmd.Args) {
+				conn.WriteError("ERR syntax error")
+				return
+			}
+			if opts.Count, err = strconv.Atoi(string(cmd.Args[i])); err != nil || opts.Count < 1 {
+				conn.WriteError("ERR value is not an integer or out of range")
+				return
+			}
+		case "novalues":
+			noValues = true
+		default:
+			conn.WriteError("ERR syntax error")
+			return
+		}
+	}
+	value, err := srv.Driver.Get(ctx, dbnum, key)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) && !errors.Is(err, storage.ErrNoKey) {
+		conn.WriteError("ERR " + err.Error())
+		return
+	}
+	var record map[string]json.RawMessage
+	if bytes.HasPrefix(value, []byte("{")) {
+		if err := json.Unmarshal(value, &record); err != nil {
+			conn.WriteError("ERR decode record '" + key + "' " + err.Error())
+			return
+		}
+	}
+	fields := make([]string, 0, len(record))
+	for name := range record {
+		if opts.MatchKey(name) {
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+
+	// Fields are sorted so the cursor is just an offset in the list of fields
+	var (
+		next  uint64
+		count = uint64(opts.PageSize())
+	)
+	if cursor >= uint64(len(fields)) {
+		fields = fields[:0]
+	} else if fields = fields[cursor:]; uint64(len(fields)) > count {
+		fields = fields[:count]
+		next = cursor + count
+	}
+
+	conn.WriteArray(2)
+	conn.WriteBulkString(strconv.FormatUint(next, 10))
+	if noValues {
+		conn.WriteArray(len(fields))
+	} else {
+		conn.WriteArray(len(fields) * 2)
+	}
+	for _, name := range fields {
+		conn.WriteBulkString(name)
+		if !noValues {
+			writeJSONValue(conn, name, record[name])
+		}
+	}
+}
+
+func isGetCommand(cmd redcon.Command) bool {
+	return len(cmd.Args) == 2 && strings.EqualFold(string(cmd.Args[0]), "get")
+}
+
 func (srv *RedisServer) wrongNumberArgsError(conn redcon.Conn, cmd redcon.Command) {
 	conn.WriteError("ERR wrong number of arguments for '" + string(cmd.Args[0]) + "' command")
 }
@@ -347,6 +1381,27 @@ func (srv *RedisServer) context() context.Context {
 	return context.Background()
 }
 
+// writeJSONValue writes JSON strings as plain bulk strings and other values as is,
+// RESP3 connections get values with native types
+func writeJSONValue(conn redcon.Conn, name string, val json.RawMessage) {
+	if isRESP3(conn) {
+		if b, err := appendJSONValue(nil, val); err == nil {
+			conn.WriteRaw(b)
+			return
+		}
+	}
+	if bytes.HasPrefix(val, []byte(`"`)) {
+		var s string
+		if err := json.Unmarshal(val, &s); err != nil {
+			conn.WriteError("ERR decode field '" + name + "' " + err.Error())
+		} else {
+			conn.WriteBulkString(s)
+		}
+	} else {
+		conn.WriteBulk(val)
+	}
+}
+
 func getUserContext(ctx any) *userContext {
 	switch v := ctx.(type) {
 	case *userContext:
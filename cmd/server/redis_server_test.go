@@ -0,0 +1,222 @@
+package server
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/redcon"
+
+	"github.com/demdxx/redify/internal/acl"
+	"github.com/demdxx/redify/internal/storage"
+)
+
+// mapDriver stores the values of db0 in the map
+type mapDriver map[string][]byte
+
+func (d mapDriver) Get(ctx context.Context, dbnum int, key string) ([]byte, error) {
+	if val, ok := d[key]; ok {
+		return val, nil
+	}
+	return nil, storage.ErrNotFound
+}
+
+func (d mapDriver) Set(ctx context.Context, dbnum int, key string, value []byte) error {
+	d[key] = value
+	return nil
+}
+
+func (d mapDriver) Del(ctx context.Context, dbnum int, key string) error {
+	delete(d, key)
+	return nil
+}
+
+func (d mapDriver) Keys(ctx context.Context, dbnum int, pattern string) ([]string, error) {
+	return nil, nil
+}
+
+func (d mapDriver) List(ctx context.Context, dbnum int, pattern string) ([]storage.Record, error) {
+	return nil, nil
+}
+
+func (d mapDriver) Scan(ctx context.Context, dbnum int, cursor uint64, opts storage.ScanOptions) ([]string, uint64, error) {
+	return nil, 0, nil
+}
+
+func (d mapDriver) Bind(ctx context.Context, conf *storage.BindConfig) error { return nil }
+func (d mapDriver) Close() error                                             { return nil }
+
+// pipelineConn returns the pipelined commands and records the replies
+type pipelineConn struct {
+	redcon.Conn
+	rCtx     *userContext
+	pipeline []redcon.Command
+	replies  []string
+}
+
+func (c *pipelineConn) Context() any                   { return c.rCtx }
+func (c *pipelineConn) PeekPipeline() []redcon.Command { return c.pipeline }
+func (c *pipelineConn) WriteError(msg string)          { c.replies = append(c.replies, "-"+msg) }
+func (c *pipelineConn) WriteNull()                     { c.replies = append(c.replies, "(nil)") }
+func (c *pipelineConn) WriteBulk(bulk []byte)          { c.replies = append(c.replies, string(bulk)) }
+func (c *pipelineConn) WriteBulkString(bulk string)    { c.replies = append(c.replies, bulk) }
+func (c *pipelineConn) WriteArray(count int)           { c.replies = append(c.replies, "*"+strconv.Itoa(count)) }
+func (c *pipelineConn) WriteString(str string)         { c.replies = append(c.replies, str) }
+func (c *pipelineConn) WriteInt(num int)               { c.replies = append(c.replies, ":"+strconv.Itoa(num)) }
+func (c *pipelineConn) WriteRaw(data []byte)           { c.replies = append(c.replies, string(data)) }
+
+func (c *pipelineConn) ReadPipeline() []redcon.Command {
+	pipeline := c.pipeline
+	c.pipeline = nil
+	return pipeline
+}
+
+func TestParseSetOptions(t *testing.T) {
+	args := func(list ...string) [][]byte {
+		res := make([][]byte, 0, len(list))
+		for _, arg := range list {
+			res = append(res, []byte(arg))
+		}
+		return res
+	}
+
+	opts, err := parseSetOptions(nil)
+	if assert.NoError(t, err) {
+		assert.True(t, opts.IsDefault())
+	}
+
+	opts, err = parseSetOptions(args("nx", "GET", "EX", "10"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, storage.SetIfNotExists, opts.Mode)
+		assert.True(t, opts.Get)
+		assert.Equal(t, 10*time.Second, opts.TTL)
+	}
+
+	opts, err = parseSetOptions(args("XX", "KEEPTTL"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, storage.SetIfExists, opts.Mode)
+		assert.True(t, opts.KeepTTL)
+	}
+
+	opts, err = parseSetOptions(args("PXAT", "1000"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, time.Nanosecond, opts.TTL, "past time expires immediately")
+	}
+
+	_, err = parseSetOptions(args("NX", "XX"))
+	assert.ErrorIs(t, err, errSetSyntax)
+	_, err = parseSetOptions(args("EX", "10", "KEEPTTL"))
+	assert.ErrorIs(t, err, errSetSyntax)
+	_, err = parseSetOptions(args("PX"))
+	assert.ErrorIs(t, err, errSetSyntax)
+	_, err = parseSetOptions(args("EX", "0"))
+	assert.ErrorIs(t, err, errSetExpireTime)
+	_, err = parseSetOptions(args("EX", "ten"))
+	assert.Error(t, err)
+}
+
+func TestGetPipelineAccess(t *testing.T) {
+	var (
+		srv = &RedisServer{
+			Driver:   mapDriver{"post_1": []byte("post1"), "user_1": []byte("user1")},
+			ACL:      acl.New(&acl.User{Name: "reader", Keys: []string{"post_*"}}),
+			tracking: newTracking(),
+			clients:  newClientTable(),
+		}
+		command = func(args ...string) redcon.Command {
+			cmd := redcon.Command{}
+			for _, arg := range args {
+				cmd.Args = append(cmd.Args, []byte(arg))
+			}
+			return cmd
+		}
+		tracked = newTrackingClient(nil)
+		conn    = &pipelineConn{
+			rCtx: &userContext{User: srv.ACL.Users()[0], tracking: tracked},
+			pipeline: []redcon.Command{
+				command("GET", "user_1"),
+				command("GET", "post_2"),
+			},
+		}
+	)
+	srv.tracking.enable(tracked, false, nil)
+	srv.command(conn, command("GET", "post_1"))
+	assert.Equal(t, []string{"post1", "-" + acl.ErrNoPermKey.Error(), "(nil)"}, conn.replies)
+	assert.Equal(t, int64(3), srv.clients.commands.Load())
+
+	srv.tracking.invalidate(context.Background(), "post_2")
+	srv.tracking.invalidate(context.Background(), "user_1")
+	assert.Equal(t, []string{"post_2"}, tracked.pending, "allowed keys of the pipeline must be tracked")
+}
+
+func TestCheckAccessAdminSubcommand(t *testing.T) {
+	var (
+		reader = &acl.User{Name: "reader", Categories: []string{acl.CategoryRead}}
+		admin  = &acl.User{Name: "admin", Categories: []string{acl.CategoryAdmin}}
+		srv    = &RedisServer{ACL: acl.New(reader, admin)}
+	)
+	command := func(args ...string) redcon.Command {
+		cmd := redcon.Command{}
+		for _, arg := range args {
+			cmd.Args = append(cmd.Args, []byte(arg))
+		}
+		return cmd
+	}
+	assert.NoError(t, srv.checkAccess(&userContext{User: reader}, command("CLIENT", "ID")))
+	assert.Error(t, srv.checkAccess(&userContext{User: reader}, command("CLIENT", "LIST")))
+	assert.Error(t, srv.checkAccess(&userContext{User: reader}, command("CONFIG", "SET", "notify-keyspace-events", "KEA")))
+	assert.NoError(t, srv.checkAccess(&userContext{User: admin}, command("CLIENT", "LIST")))
+	assert.NoError(t, srv.checkAccess(&userContext{User: admin}, command("CONFIG", "SET", "notify-keyspace-events", "KEA")))
+}
+
+// newsBridge serves the channels of the news keys
+type newsBridge struct{}
+
+func (newsBridge) HasChannel(channel string) bool { return strings.HasPrefix(channel, "news_") }
+func (newsBridge) PublishChannel(ctx context.Context, channel string, message []byte) error {
+	return nil
+}
+func (newsBridge) ListenChannels(ctx context.Context, fn func(channel string, message []byte)) error {
+	return nil
+}
+
+func TestBridgedChannelAccess(t *testing.T) {
+	var (
+		user = &acl.User{Name: "posts", Keys: []string{"post_*"}}
+		srv  = &RedisServer{ACL: acl.New(user), Channels: []storage.ChannelBridge{newsBridge{}}}
+		rCtx = &userContext{User: user}
+	)
+	publish := func(channel string) redcon.Command {
+		return redcon.Command{Args: [][]byte{[]byte("PUBLISH"), []byte(channel), []byte("msg")}}
+	}
+	assert.ErrorIs(t, srv.checkAccess(rCtx, publish("news_1")), acl.ErrNoPermKey)
+	assert.NoError(t, srv.checkAccess(rCtx, publish("chat")), "local channels are not keys")
+
+	assert.ErrorIs(t, srv.channelAccess(user, "news_1", false), acl.ErrNoPermChan)
+	assert.NoError(t, srv.channelAccess(user, "chat", false))
+	assert.ErrorIs(t, srv.channelAccess(user, "news_*", true), acl.ErrNoPermChan)
+	assert.ErrorIs(t, srv.channelAccess(user, "*", true), acl.ErrNoPermChan)
+	assert.NoError(t, srv.channelAccess(user, "post_*", true))
+	assert.NoError(t, srv.channelAccess(&acl.User{Name: "all"}, "news_*", true))
+}
+
+func TestDecrByOverflow(t *testing.T) {
+	var (
+		srv  = &RedisServer{Driver: mapDriver{}}
+		conn = &pipelineConn{}
+		decr = func(delta string) {
+			srv.cmdIncr(context.Background(), conn, 0, redcon.Command{
+				Args: [][]byte{[]byte("DECRBY"), []byte("counter"), []byte(delta)},
+			})
+		}
+	)
+	decr("-9223372036854775808")
+	decr("-9223372036854775807")
+	assert.Equal(t, []string{
+		"-ERR decrement would overflow",
+		"-ERR counters are not supported by the key storage, counter_column or incr_query is required",
+	}, conn.replies)
+}
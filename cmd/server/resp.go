@@ -0,0 +1,128 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/tidwall/redcon"
+)
+
+// Versions of the redis serialization protocol
+const (
+	protoRESP2 = 2
+	protoRESP3 = 3
+)
+
+// isRESP3 returns true if the connection negotiated RESP3 by HELLO command
+func isRESP3(conn redcon.Conn) bool {
+	return getUserContext(conn.Context()).Protocol == protoRESP3
+}
+
+// writeMap header of the map reply, RESP2 connections get the flat array
+// of key-value pairs
+func writeMap(conn redcon.Conn, count int) {
+	if isRESP3(conn) {
+		conn.WriteRaw(appendMap(nil, count))
+	} else {
+		conn.WriteArray(count * 2)
+	}
+}
+
+// writeSet header of the set reply, RESP2 connections get the array
+func writeSet(conn redcon.Conn, count int) {
+	if isRESP3(conn) {
+		conn.WriteRaw(strconv.AppendInt([]byte{'~'}, int64(count), 10))
+		conn.WriteRaw([]byte{'\r', '\n'})
+	} else {
+		conn.WriteArray(count)
+	}
+}
+
+func appendMap(b []byte, count int) []byte {
+	b = append(b, '%')
+	b = strconv.AppendInt(b, int64(count), 10)
+	return append(b, '\r', '\n')
+}
+
+func appendDouble(b []byte, val float64) []byte {
+	b = append(b, ',')
+	switch {
+	case math.IsInf(val, 1):
+		b = append(b, "inf"...)
+	case math.IsInf(val, -1):
+		b = append(b, "-inf"...)
+	case math.IsNaN(val):
+		b = append(b, "nan"...)
+	default:
+		b = strconv.AppendFloat(b, val, 'f', -1, 64)
+	}
+	return append(b, '\r', '\n')
+}
+
+func appendBool(b []byte, val bool) []byte {
+	if val {
+		return append(b, "#t\r\n"...)
+	}
+	return append(b, "#f\r\n"...)
+}
+
+func appendNull(b []byte) []byte {
+	return append(b, "_\r\n"...)
+}
+
+// appendValue of the decoded JSON value with native RESP3 types.
+// Objects are encoded as maps with sorted keys.
+func appendValue(b []byte, val any) []byte {
+	switch v := val.(type) {
+	case nil:
+		return appendNull(b)
+	case bool:
+		return appendBool(b, v)
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return redcon.AppendInt(b, n)
+		}
+		f, _ := v.Float64()
+		return appendDouble(b, f)
+	case float64:
+		return appendDouble(b, v)
+	case string:
+		return redcon.AppendBulkString(b, v)
+	case []any:
+		b = redcon.AppendArray(b, len(v))
+		for _, item := range v {
+			b = appendValue(b, item)
+		}
+		return b
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		b = appendMap(b, len(keys))
+		for _, key := range keys {
+			b = redcon.AppendBulkString(b, key)
+			b = appendValue(b, v[key])
+		}
+		return b
+	default:
+		return redcon.AppendAny(b, v)
+	}
+}
+
+// appendJSONValue decodes JSON value and appends it with native RESP3 types
+func appendJSONValue(b []byte, val json.RawMessage) ([]byte, error) {
+	var (
+		v   any
+		dec = json.NewDecoder(bytes.NewReader(val))
+	)
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return b, err
+	}
+	return appendValue(b, v), nil
+}
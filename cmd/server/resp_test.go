@@ -0,0 +1,28 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendJSONValue(t *testing.T) {
+	tests := []struct {
+		json   string
+		result string
+	}{
+		{json: `null`, result: "_\r\n"},
+		{json: `true`, result: "#t\r\n"},
+		{json: `10`, result: ":10\r\n"},
+		{json: `1.5`, result: ",1.5\r\n"},
+		{json: `"text"`, result: "$4\r\ntext\r\n"},
+		{json: `[1,"a"]`, result: "*2\r\n:1\r\n$1\r\na\r\n"},
+		{json: `{"b":false,"a":{"c":null}}`, result: "%2\r\n$1\r\na\r\n%1\r\n$1\r\nc\r\n_\r\n$1\r\nb\r\n#f\r\n"},
+	}
+	for _, test := range tests {
+		b, err := appendJSONValue(nil, []byte(test.json))
+		if assert.NoError(t, err, test.json) {
+			assert.Equal(t, test.result, string(b), test.json)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tidwall/redcon"
+
+	"github.com/demdxx/redify/internal/storage"
+)
+
+// cmdXAdd publishes the entry to the stream
+//
+//	XADD key [NOMKSTREAM] <* | id> field value [field value ...]
+func (srv *RedisServer) cmdXAdd(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) < 5 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	i := 2
+	switch strings.ToLower(string(cmd.Args[i])) {
+	case "nomkstream":
+		i++
+	case "maxlen", "minid":
+		conn.WriteError("ERR MAXLEN and MINID options are not supported")
+		return
+	}
+	id := string(cmd.Args[i])
+	if _, err := storage.ParseStreamID(id); err != nil && id != "*" {
+		conn.WriteError("ERR Invalid stream ID specified as stream command argument")
+		return
+	}
+	fields := argStrings(cmd.Args[i+1:], 1)
+	if len(fields) == 0 || len(fields)%2 != 0 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	sw := srv.streamWriter(conn)
+	if sw == nil {
+		return
+	}
+	key := string(cmd.Args[1])
+	newID, err := sw.XAdd(ctx, dbnum, key, id, fields)
+	if errors.Is(err, storage.ErrInvalidStreamID) {
+		conn.WriteError("ERR The ID specified in XADD is equal or smaller than the target stream top item")
+		return
+	}
+	if writeCollectionWriteError(conn, err) {
+		return
+	}
+	srv.keyEvent(ctx, dbnum, key, storage.KeyEventXAdd)
+	conn.WriteBulkString(newID)
+}
+
+// cmdXRead returns the entries of the streams after the IDs
+//
+//	XREAD [COUNT count] [BLOCK milliseconds] STREAMS key [key ...] id [id ...]
+//	XREADGROUP GROUP group consumer [COUNT count] [BLOCK milliseconds] [NOACK] STREAMS key [key ...] id [id ...]
+func (srv *RedisServer) cmdXRead(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) < 4 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	var (
+		req     storage.StreamRead
+		isGroup = strings.EqualFold(string(cmd.Args[0]), "xreadgroup")
+		i       = 1
+	)
+options:
+	for ; i < len(cmd.Args); i++ {
+		opt := strings.ToLower(string(cmd.Args[i]))
+		switch {
+		case opt == "streams":
+			i++
+			break options
+		case opt == "count" && i+1 < len(cmd.Args):
+			count, err := strconv.Atoi(string(cmd.Args[i+1]))
+			if err != nil {
+				conn.WriteError("ERR value is not an integer or out of range")
+				return
+			}
+			req.Count = max(count, 0)
+			i++
+		case opt == "block" && i+1 < len(cmd.Args):
+			timeout, err := strconv.ParseInt(string(cmd.Args[i+1]), 10, 64)
+			if err != nil || timeout < 0 {
+				conn.WriteError("ERR timeout is not an integer or out of range")
+				return
+			}
+			req.Block, req.Timeout = true, time.Duration(timeout)*time.Millisecond
+			i++
+		case opt == "group" && isGroup && i+2 < len(cmd.Args):
+			req.Group, req.Consumer = string(cmd.Args[i+1]), string(cmd.Args[i+2])
+			i += 2
+		case opt == "noack" && isGroup:
+			req.NoAck = true
+		default:
+			conn.WriteError("ERR syntax error")
+			return
+		}
+	}
+	if isGroup && req.Group == "" {
+		conn.WriteError("ERR Missing GROUP option for XREADGROUP")
+		return
+	}
+	args := argStrings(cmd.Args[min(i, len(cmd.Args)):], 1)
+	if len(args) == 0 || len(args)%2 != 0 {
+		conn.WriteError("ERR Unbalanced '" + strings.ToLower(string(cmd.Args[0])) +
+			"' list of streams: for each stream key an ID or '$' must be specified.")
+		return
+	}
+	req.Keys, req.IDs = args[:len(args)/2], args[len(args)/2:]
+	for _, id := range req.IDs {
+		if (isGroup && id == ">") || (!isGroup && id == "$") {
+			continue
+		}
+		if _, err := storage.ParseStreamID(id); err != nil {
+			conn.WriteError("ERR Invalid stream ID specified as stream command argument")
+			return
+		}
+	}
+	sr := srv.streamReader(conn)
+	if sr == nil {
+		return
+	}
+	res, err := sr.XRead(ctx, dbnum, req)
+	if errors.Is(err, storage.ErrNoGroup) {
+		conn.WriteError("NOGROUP No such key or consumer group '" + req.Group + "' in XREADGROUP with GROUP option")
+		return
+	}
+	if writeCollectionWriteError(conn, err) {
+		return
+	}
+	writeStreams(conn, res)
+}
+
+// cmdXAck acknowledges the pending entries of the consumer group
+//
+//	XACK key group id [id ...]
+func (srv *RedisServer) cmdXAck(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) < 4 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	sw := srv.streamWriter(conn)
+	if sw == nil {
+		return
+	}
+	count, err := sw.XAck(ctx, dbnum, string(cmd.Args[1]), string(cmd.Args[2]), argStrings(cmd.Args[3:], 1))
+	if errors.Is(err, storage.ErrInvalidStreamID) {
+		conn.WriteError("ERR Invalid stream ID specified as stream command argument")
+		return
+	}
+	if writeCollectionWriteError(conn, err) {
+		return
+	}
+	conn.WriteInt64(count)
+}
+
+// cmdXGroup manages the consumer groups of the stream
+//
+//	XGROUP CREATE key group <id | $> [MKSTREAM]
+//	XGROUP DESTROY key group
+func (srv *RedisServer) cmdXGroup(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) < 4 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	sw := srv.streamWriter(conn)
+	if sw == nil {
+		return
+	}
+	var (
+		key   = string(cmd.Args[2])
+		group = string(cmd.Args[3])
+	)
+	switch strings.ToLower(string(cmd.Args[1])) {
+	case "create":
+		if len(cmd.Args) < 5 || len(cmd.Args) > 6 ||
+			(len(cmd.Args) == 6 && !strings.EqualFold(string(cmd.Args[5]), "mkstream")) {
+			conn.WriteError("ERR syntax error")
+			return
+		}
+		err := sw.XGroupCreate(ctx, dbnum, key, group, string(cmd.Args[4]))
+		switch {
+		case errors.Is(err, storage.ErrGroupExists):
+			conn.WriteError("BUSYGROUP Consumer Group name already exists")
+		case errors.Is(err, storage.ErrInvalidStreamID):
+			conn.WriteError("ERR Invalid stream ID specified as stream command argument")
+		case !writeCollectionWriteError(conn, err):
+			conn.WriteString("OK")
+		}
+	case "destroy":
+		ok, err := sw.XGroupDestroy(ctx, dbnum, key, group)
+		if writeCollectionWriteError(conn, err) {
+			return
+		}
+		if ok {
+			conn.WriteInt(1)
+		} else {
+			conn.WriteInt(0)
+		}
+	default:
+		conn.WriteError("ERR unknown subcommand '" + string(cmd.Args[1]) + "'. Try XGROUP HELP.")
+	}
+}
+
+func (srv *RedisServer) streamReader(conn redcon.Conn) storage.StreamReader {
+	sr, _ := srv.Driver.(storage.StreamReader)
+	if sr == nil {
+		conn.WriteError("ERR " + storage.ErrMethodIsNotSupported.Error())
+	}
+	return sr
+}
+
+func (srv *RedisServer) streamWriter(conn redcon.Conn) storage.StreamWriter {
+	sw, _ := srv.Driver.(storage.StreamWriter)
+	if sw == nil {
+		conn.WriteError("ERR " + storage.ErrMethodIsNotSupported.Error())
+	}
+	return sw
+}
+
+// writeStreams reply of XREAD, the timeout is the null array.
+// RESP3 connections get the map of the streams.
+func writeStreams(conn redcon.Conn, streams []storage.StreamEntries) {
+	switch {
+	case len(streams) == 0 && isRESP3(conn):
+		conn.WriteRaw(appendNull(nil))
+		return
+	case len(streams) == 0:
+		conn.WriteRaw([]byte("*-1\r\n"))
+		return
+	case isRESP3(conn):
+		conn.WriteRaw(appendMap(nil, len(streams)))
+	default:
+		conn.WriteArray(len(streams))
+	}
+	for _, stream := range streams {
+		if !isRESP3(conn) {
+			conn.WriteArray(2)
+		}
+		conn.WriteBulkString(stream.Key)
+		conn.WriteArray(len(stream.Entries))
+		for _, entry := range stream.Entries {
+			conn.WriteArray(2)
+			conn.WriteBulkString(entry.ID)
+			conn.WriteArray(len(entry.Fields))
+			for _, field := range entry.Fields {
+				conn.WriteBulkString(field)
+			}
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/tidwall/redcon"
+
+	"github.com/demdxx/redify/internal/acl"
+)
+
+// trackingClient is the detached connection which receives invalidation
+// push messages of the client-side caching
+type trackingClient struct {
+	// mx serializes command replies and push messages of the connection
+	mx   sync.Mutex
+	conn redcon.DetachedConn
+
+	// Invalidated keys are queued because the key could be modified
+	// by the command of the same connection
+	pendingMx sync.Mutex
+	pending   []string
+	signal    chan struct{}
+	done      chan struct{}
+
+	// Options are protected by the tracking table lock
+	enabled  bool
+	bcast    bool
+	prefixes []string
+}
+
+func newTrackingClient(conn redcon.DetachedConn) *trackingClient {
+	return &trackingClient{
+		conn:   conn,
+		signal: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+}
+
+// notify the client about the invalidated key asynchronously
+func (c *trackingClient) notify(key string) {
+	c.pendingMx.Lock()
+	c.pending = append(c.pending, key)
+	c.pendingMx.Unlock()
+	select {
+	case c.signal <- struct{}{}:
+	default:
+	}
+}
+
+// run pushes queued invalidation messages until the client is closed
+func (c *trackingClient) run() {
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-c.signal:
+		}
+		c.pendingMx.Lock()
+		keys := c.pending
+		c.pending = nil
+		c.pendingMx.Unlock()
+		if len(keys) > 0 && c.push(keys...) != nil {
+			return
+		}
+	}
+}
+
+func (c *trackingClient) close() {
+	close(c.done)
+}
+
+// matchKey returns true if the BCAST client is interested in the key
+func (c *trackingClient) matchKey(key string) bool {
+	if len(c.prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range c.prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// push invalidation message of the keys to the connection
+func (c *trackingClient) push(keys ...string) error {
+	b := append([]byte(nil), ">2\r\n"...)
+	b = redcon.AppendBulkString(b, "invalidate")
+	b = redcon.AppendArray(b, len(keys))
+	for _, key := range keys {
+		b = redcon.AppendBulkString(b, key)
+	}
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.conn.WriteRaw(b)
+	return c.conn.Flush()
+}
+
+// tracking table of the keys read by the clients in the default mode
+// and the clients in the broadcasting mode
+type tracking struct {
+	mx    sync.Mutex
+	keys  map[string]map[*trackingClient]struct{}
+	bcast map[*trackingClient]struct{}
+}
+
+func newTracking() *tracking {
+	return &tracking{
+		keys:  map[string]map[*trackingClient]struct{}{},
+		bcast: map[*trackingClient]struct{}{},
+	}
+}
+
+// enable tracking of the client with the options
+func (t *tracking) enable(c *trackingClient, bcast bool, prefixes []string) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	t.removeLocked(c)
+	c.enabled = true
+	c.bcast = bcast
+	c.prefixes = prefixes
+	if bcast {
+		t.bcast[c] = struct{}{}
+	}
+}
+
+// disable tracking of the client and forget all keys read by it
+func (t *tracking) disable(c *trackingClient) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	t.removeLocked(c)
+	c.enabled = false
+}
+
+func (t *tracking) removeLocked(c *trackingClient) {
+	delete(t.bcast, c)
+	for key, clients := range t.keys {
+		if delete(clients, c); len(clients) == 0 {
+			delete(t.keys, key)
+		}
+	}
+}
+
+// track keys read by the client in the default mode
+func (t *tracking) track(c *trackingClient, keys ...string) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	if !c.enabled || c.bcast {
+		return
+	}
+	for _, key := range keys {
+		clients := t.keys[key]
+		if clients == nil {
+			clients = map[*trackingClient]struct{}{}
+			t.keys[key] = clients
+		}
+		clients[c] = struct{}{}
+	}
+}
+
+// invalidate the key for all interested clients.
+// Default mode clients stop tracking the key until they read it again.
+func (t *tracking) invalidate(_ context.Context, key string) {
+	t.mx.Lock()
+	clients := make([]*trackingClient, 0, len(t.keys[key])+len(t.bcast))
+	for c := range t.keys[key] {
+		clients = append(clients, c)
+	}
+	delete(t.keys, key)
+	for c := range t.bcast {
+		if c.matchKey(key) {
+			clients = append(clients, c)
+		}
+	}
+	t.mx.Unlock()
+
+	for _, c := range clients {
+		c.notify(key)
+	}
+}
+
+// trackedKeys returns the keys of the read command which must be tracked
+func trackedKeys(cmd redcon.Command) []string {
+	if commandCategory(strings.ToLower(string(cmd.Args[0]))) != acl.CategoryRead {
+		return nil
+	}
+	return commandKeys(cmd)
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/redcon"
+)
+
+func TestTrackingInvalidate(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		table   = newTracking()
+		client  = newTrackingClient(nil)
+		bclient = newTrackingClient(nil)
+	)
+	table.enable(client, false, nil)
+	table.enable(bclient, true, []string{"user_"})
+
+	table.track(client, "post_1", "user_1")
+	table.track(bclient, "post_1")
+
+	table.invalidate(ctx, "post_1")
+	table.invalidate(ctx, "post_1")
+	table.invalidate(ctx, "user_1")
+	table.invalidate(ctx, "user_2")
+	assert.Equal(t, []string{"post_1", "user_1"}, client.pending)
+	assert.Equal(t, []string{"user_1", "user_2"}, bclient.pending)
+
+	table.disable(client)
+	table.track(client, "post_1")
+	table.invalidate(ctx, "post_1")
+	assert.Equal(t, []string{"post_1", "user_1"}, client.pending)
+}
+
+func TestTrackingWriteCommand(t *testing.T) {
+	var (
+		srv = &RedisServer{
+			Driver:   mapDriver{"post_1": []byte("post1")},
+			Keyspace: NewKeyspace(),
+			tracking: newTracking(),
+			clients:  newClientTable(),
+		}
+		tracked = newTrackingClient(nil)
+		conn1   = &pipelineConn{rCtx: &userContext{tracking: tracked}}
+		conn2   = &pipelineConn{rCtx: &userContext{}}
+	)
+	srv.Keyspace.OnKeyEvent(srv.commandKeyChanged)
+	srv.tracking.enable(tracked, false, nil)
+	srv.command(conn1, redcon.Command{Args: [][]byte{[]byte("GET"), []byte("post_1")}})
+	srv.command(conn2, redcon.Command{Args: [][]byte{[]byte("SET"), []byte("post_1"), []byte("post2")}})
+	assert.Equal(t, []string{"post_1"}, tracked.pending, "the write must invalidate the key without the cache")
+}
@@ -0,0 +1,395 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/redcon"
+
+	"github.com/demdxx/redify/internal/storage"
+)
+
+// cmdZRange returns the members of the sorted set range
+//
+//	ZRANGE key start stop [BYSCORE | BYLEX] [REV] [LIMIT offset count] [WITHSCORES]
+//	ZREVRANGE key start stop [WITHSCORES]
+//	ZRANGEBYSCORE key min max [WITHSCORES] [LIMIT offset count]
+//	ZREVRANGEBYSCORE key max min [WITHSCORES] [LIMIT offset count]
+//	ZRANGEBYLEX key min max [LIMIT offset count]
+//	ZREVRANGEBYLEX key max min [LIMIT offset count]
+func (srv *RedisServer) cmdZRange(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) < 4 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	var (
+		name       = strings.ToLower(string(cmd.Args[0]))
+		rng        = storage.ZRange{Count: -1}
+		withScores bool
+		hasLimit   bool
+	)
+	switch name {
+	case "zrevrange":
+		rng.Rev = true
+	case "zrangebyscore", "zrevrangebyscore":
+		rng.By = storage.ZRangeByScore
+		rng.Rev = name == "zrevrangebyscore"
+	case "zrangebylex", "zrevrangebylex":
+		rng.By = storage.ZRangeByLex
+		rng.Rev = name == "zrevrangebylex"
+	}
+	for i := 4; i < len(cmd.Args); i++ {
+		switch opt := strings.ToLower(string(cmd.Args[i])); {
+		case opt == "withscores" && rng.By != storage.ZRangeByLex:
+			withScores = true
+		case opt == "limit" && i+2 < len(cmd.Args) && name != "zrevrange":
+			offset, err1 := strconv.ParseInt(string(cmd.Args[i+1]), 10, 64)
+			count, err2 := strconv.ParseInt(string(cmd.Args[i+2]), 10, 64)
+			if err1 != nil || err2 != nil {
+				conn.WriteError("ERR value is not an integer or out of range")
+				return
+			}
+			rng.Offset, rng.Count, hasLimit = offset, count, true
+			i += 2
+		case name == "zrange" && opt == "byscore":
+			rng.By = storage.ZRangeByScore
+		case name == "zrange" && opt == "bylex":
+			rng.By = storage.ZRangeByLex
+		case name == "zrange" && opt == "rev":
+			rng.Rev = true
+		default:
+			conn.WriteError("ERR syntax error")
+			return
+		}
+	}
+	if hasLimit && rng.By == storage.ZRangeByIndex {
+		conn.WriteError("ERR syntax error, LIMIT is only supported in combination with either BYSCORE or BYLEX")
+		return
+	}
+	if withScores && rng.By == storage.ZRangeByLex {
+		conn.WriteError("ERR syntax error, WITHSCORES not supported in combination with BYLEX")
+		return
+	}
+	minArg, maxArg := string(cmd.Args[2]), string(cmd.Args[3])
+	if rng.Rev && rng.By != storage.ZRangeByIndex {
+		// Reversed ranges by score or by member start from the max bound
+		minArg, maxArg = maxArg, minArg
+	}
+	var (
+		empty bool
+		err   error
+	)
+	switch rng.By {
+	case storage.ZRangeByIndex:
+		start, err1 := strconv.ParseInt(minArg, 10, 64)
+		stop, err2 := strconv.ParseInt(maxArg, 10, 64)
+		if err1 != nil || err2 != nil {
+			conn.WriteError("ERR value is not an integer or out of range")
+			return
+		}
+		rng.Start, rng.Stop = start, stop
+	case storage.ZRangeByScore:
+		if rng.Min, err = parseScoreBound(minArg); err == nil {
+			rng.Max, err = parseScoreBound(maxArg)
+		}
+		if err != nil {
+			conn.WriteError("ERR min or max is not a float")
+			return
+		}
+		empty = isInfScore(minArg, 1) || isInfScore(maxArg, -1)
+	case storage.ZRangeByLex:
+		if rng.Min, err = parseLexBound(minArg); err == nil {
+			rng.Max, err = parseLexBound(maxArg)
+		}
+		if err != nil {
+			conn.WriteError("ERR min or max not valid string range item")
+			return
+		}
+		empty = minArg == "+" || maxArg == "-"
+	}
+	if empty {
+		conn.WriteArray(0)
+		return
+	}
+	zr := srv.sortedSetReader(conn)
+	if zr == nil {
+		return
+	}
+	members, err := zr.ZRange(ctx, dbnum, string(cmd.Args[1]), rng)
+	if writeCollectionError(conn, err) {
+		return
+	}
+	writeScoredMembers(conn, members, withScores)
+}
+
+// cmdZScore returns the score of the member
+//
+//	ZSCORE key member
+func (srv *RedisServer) cmdZScore(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) != 3 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	zr := srv.sortedSetReader(conn)
+	if zr == nil {
+		return
+	}
+	score, err := zr.ZScore(ctx, dbnum, string(cmd.Args[1]), string(cmd.Args[2]))
+	if errors.Is(err, storage.ErrNoKey) || errors.Is(err, storage.ErrNotFound) {
+		conn.WriteNull()
+		return
+	}
+	if writeCollectionError(conn, err) {
+		return
+	}
+	writeScore(conn, score)
+}
+
+// cmdZRank returns the index of the member ordered by the score
+//
+//	ZRANK key member [WITHSCORE]
+//	ZREVRANK key member [WITHSCORE]
+func (srv *RedisServer) cmdZRank(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) != 3 && len(cmd.Args) != 4 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	withScore := len(cmd.Args) == 4
+	if withScore && !strings.EqualFold(string(cmd.Args[3]), "withscore") {
+		conn.WriteError("ERR syntax error")
+		return
+	}
+	zr := srv.sortedSetReader(conn)
+	if zr == nil {
+		return
+	}
+	var (
+		key    = string(cmd.Args[1])
+		member = string(cmd.Args[2])
+		rev    = strings.EqualFold(string(cmd.Args[0]), "zrevrank")
+	)
+	rank, err := zr.ZRank(ctx, dbnum, key, member, rev)
+	var score float64
+	if err == nil && withScore {
+		score, err = zr.ZScore(ctx, dbnum, key, member)
+	}
+	if errors.Is(err, storage.ErrNoKey) || errors.Is(err, storage.ErrNotFound) {
+		conn.WriteNull()
+		return
+	}
+	if writeCollectionError(conn, err) {
+		return
+	}
+	if withScore {
+		conn.WriteArray(2)
+		conn.WriteInt64(rank)
+		writeScore(conn, score)
+	} else {
+		conn.WriteInt64(rank)
+	}
+}
+
+// cmdZAdd adds the members to the sorted set or updates their scores
+//
+//	ZADD key [NX | XX] [CH] [INCR] score member [score member ...]
+func (srv *RedisServer) cmdZAdd(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) < 4 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	var (
+		mode      = storage.SetAlways
+		changed   bool
+		incr      bool
+		i         = 2
+		isSetMode bool
+	)
+options:
+	for ; i < len(cmd.Args); i++ {
+		switch strings.ToLower(string(cmd.Args[i])) {
+		case "nx":
+			mode, isSetMode = storage.SetIfNotExists, true
+		case "xx":
+			mode, isSetMode = storage.SetIfExists, true
+		case "ch":
+			changed = true
+		case "incr":
+			incr = true
+		case "gt", "lt":
+			conn.WriteError("ERR GT and LT options are not supported")
+			return
+		default:
+			break options
+		}
+	}
+	args := cmd.Args[i:]
+	if len(args) == 0 || len(args)%2 != 0 {
+		conn.WriteError("ERR syntax error")
+		return
+	}
+	if incr && len(args) != 2 {
+		conn.WriteError("ERR INCR option supports a single increment-element pair")
+		return
+	}
+	if isSetMode && incr {
+		conn.WriteError("ERR INCR option is not supported with NX or XX")
+		return
+	}
+	members := make([]storage.ScoredMember, 0, len(args)/2)
+	for j := 0; j < len(args); j += 2 {
+		score, err := parseScore(string(args[j]))
+		if err != nil {
+			conn.WriteError("ERR value is not a valid float")
+			return
+		}
+		members = append(members, storage.ScoredMember{Member: string(args[j+1]), Score: score})
+	}
+	zw := srv.sortedSetWriter(conn)
+	if zw == nil {
+		return
+	}
+	key := string(cmd.Args[1])
+	if incr {
+		score, err := zw.ZIncrBy(ctx, dbnum, key, members[0].Member, members[0].Score)
+		if writeCollectionWriteError(conn, err) {
+			return
+		}
+		srv.keyEvent(ctx, dbnum, key, storage.KeyEventZIncr)
+		writeScore(conn, score)
+		return
+	}
+	added, updated, err := zw.ZAdd(ctx, dbnum, key, members, mode)
+	if writeCollectionWriteError(conn, err) {
+		return
+	}
+	if added+updated > 0 {
+		srv.keyEvent(ctx, dbnum, key, storage.KeyEventZAdd)
+	}
+	if changed {
+		added += updated
+	}
+	conn.WriteInt64(added)
+}
+
+// cmdZIncrBy increments the score of the member
+//
+//	ZINCRBY key increment member
+func (srv *RedisServer) cmdZIncrBy(ctx context.Context, conn redcon.Conn, dbnum int, cmd redcon.Command) {
+	if len(cmd.Args) != 4 {
+		srv.wrongNumberArgsError(conn, cmd)
+		return
+	}
+	delta, err := parseScore(string(cmd.Args[2]))
+	if err != nil {
+		conn.WriteError("ERR value is not a valid float")
+		return
+	}
+	zw := srv.sortedSetWriter(conn)
+	if zw == nil {
+		return
+	}
+	key := string(cmd.Args[1])
+	score, err := zw.ZIncrBy(ctx, dbnum, key, string(cmd.Args[3]), delta)
+	if writeCollectionWriteError(conn, err) {
+		return
+	}
+	srv.keyEvent(ctx, dbnum, key, storage.KeyEventZIncr)
+	writeScore(conn, score)
+}
+
+func (srv *RedisServer) sortedSetReader(conn redcon.Conn) storage.SortedSetReader {
+	zr, _ := srv.Driver.(storage.SortedSetReader)
+	if zr == nil {
+		conn.WriteError("ERR " + storage.ErrMethodIsNotSupported.Error())
+	}
+	return zr
+}
+
+func (srv *RedisServer) sortedSetWriter(conn redcon.Conn) storage.SortedSetWriter {
+	zw, _ := srv.Driver.(storage.SortedSetWriter)
+	if zw == nil {
+		conn.WriteError("ERR " + storage.ErrMethodIsNotSupported.Error())
+	}
+	return zw
+}
+
+// parseScore of the member, NaN is not a valid score
+func parseScore(s string) (float64, error) {
+	score, err := strconv.ParseFloat(s, 64)
+	if err == nil && math.IsNaN(score) {
+		return 0, strconv.ErrSyntax
+	}
+	return score, err
+}
+
+// isInfScore returns true if the bound is the infinity of the sign
+func isInfScore(s string, sign int) bool {
+	score, err := parseScore(strings.TrimPrefix(s, "("))
+	return err == nil && math.IsInf(score, sign)
+}
+
+// parseScoreBound of the range by score: `(` prefix excludes the value, -inf and +inf are unbounded
+func parseScoreBound(s string) (storage.ZBound, error) {
+	bound := storage.ZBound{Value: s}
+	if strings.HasPrefix(s, "(") {
+		bound.Value, bound.Exclusive = s[1:], true
+	}
+	score, err := parseScore(bound.Value)
+	if err != nil {
+		return bound, err
+	}
+	if math.IsInf(score, 0) {
+		return storage.ZBound{Unbounded: true}, nil
+	}
+	bound.Value = storage.FormatScore(score)
+	return bound, nil
+}
+
+// parseLexBound of the range by member: `[` includes and `(` excludes the value, - and + are unbounded
+func parseLexBound(s string) (storage.ZBound, error) {
+	switch {
+	case s == "-" || s == "+":
+		return storage.ZBound{Unbounded: true}, nil
+	case strings.HasPrefix(s, "["):
+		return storage.ZBound{Value: s[1:]}, nil
+	case strings.HasPrefix(s, "("):
+		return storage.ZBound{Value: s[1:], Exclusive: true}, nil
+	}
+	return storage.ZBound{}, strconv.ErrSyntax
+}
+
+// writeScore as the bulk string or the double of RESP3
+func writeScore(conn redcon.Conn, score float64) {
+	if isRESP3(conn) {
+		conn.WriteRaw(appendDouble(nil, score))
+	} else {
+		conn.WriteBulkString(storage.FormatScore(score))
+	}
+}
+
+// writeScoredMembers of the range, RESP3 connections get the pairs of the member and the score
+func writeScoredMembers(conn redcon.Conn, members []storage.ScoredMember, withScores bool) {
+	switch {
+	case !withScores:
+		conn.WriteArray(len(members))
+	case isRESP3(conn):
+		conn.WriteArray(len(members))
+		for _, member := range members {
+			conn.WriteArray(2)
+			conn.WriteBulkString(member.Member)
+			writeScore(conn, member.Score)
+		}
+		return
+	default:
+		conn.WriteArray(len(members) * 2)
+	}
+	for _, member := range members {
+		conn.WriteBulkString(member.Member)
+		if withScores {
+			writeScore(conn, member.Score)
+		}
+	}
+}
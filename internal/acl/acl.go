@@ -0,0 +1,242 @@
+// Package acl implements users and permissions of the redis and HTTP servers
+package acl
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/demdxx/redify/internal/keypattern"
+)
+
+// DefaultUser name which is used by the legacy AUTH command
+// and for not authenticated connections if it has no password
+const DefaultUser = "default"
+
+// Command categories
+const (
+	CategoryRead  = "read"
+	CategoryWrite = "write"
+	CategoryAdmin = "admin"
+)
+
+// hashPrefix of the password defined as SHA256 hex string
+const hashPrefix = "sha256:"
+
+var (
+	ErrAuthRequired = errors.New("NOAUTH Authentication required.")
+	ErrWrongPass    = errors.New("WRONGPASS invalid username-password pair or user is disabled.")
+	ErrNoPermDB     = errors.New("NOPERM No permissions to access the database")
+	ErrNoPermKey    = errors.New("NOPERM No permissions to access a key")
+	ErrNoPermChan   = errors.New("NOPERM No permissions to access a channel")
+)
+
+// User with access permissions.
+// Empty lists of the restrictions mean access to everything.
+type User struct {
+	Name string
+
+	// Password is plain text or SHA256 hex string with `sha256:` prefix
+	Password string
+
+	// NoPassword accepts any password like the `nopass` rule of Redis
+	NoPassword bool
+
+	// Token is used for HTTP bearer authentication
+	Token string
+
+	DBNums     []int
+	Keys       []string // Glob patterns of the keys
+	Categories []string // read, write, admin
+}
+
+// NoPass returns true if the user doesn't require the password.
+// The user `default` without password is passwordless implicitly.
+func (u *User) NoPass() bool {
+	return u.NoPassword || (u.Name == DefaultUser && u.Password == "")
+}
+
+// CheckPassword of the user in constant time.
+// Users without password can't be authenticated by the password unless they are passwordless.
+func (u *User) CheckPassword(password string) bool {
+	if u.NoPass() {
+		return true
+	}
+	if u.Password == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(u.passwordHash()), []byte(hashPassword(password))) == 1
+}
+
+// AllowDB checks access to the database number
+func (u *User) AllowDB(dbnum int) bool {
+	return len(u.DBNums) == 0 || slices.Contains(u.DBNums, dbnum)
+}
+
+// AllKeys returns true if the user has no key restrictions
+func (u *User) AllKeys() bool {
+	return len(u.Keys) == 0
+}
+
+// AllowKey checks access to the key by the glob patterns
+func (u *User) AllowKey(key string) bool {
+	if u.AllKeys() {
+		return true
+	}
+	for _, pattern := range u.Keys {
+		if keypattern.GlobMatch(pattern, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowCategory checks access to the commands of the category
+func (u *User) AllowCategory(category string) bool {
+	return len(u.Categories) == 0 || slices.Contains(u.Categories, category)
+}
+
+// Check access to run the command of the category with the keys
+func (u *User) Check(command, category string, dbnum int, keys ...string) error {
+	if category != "" && !u.AllowCategory(category) {
+		return errors.New("NOPERM User " + u.Name + " has no permissions to run the '" + command + "' command")
+	}
+	if !u.AllowDB(dbnum) {
+		return ErrNoPermDB
+	}
+	for _, key := range keys {
+		if !u.AllowKey(key) {
+			return ErrNoPermKey
+		}
+	}
+	return nil
+}
+
+// FilterKeys returns only accessible keys
+func (u *User) FilterKeys(keys []string) []string {
+	if u.AllKeys() {
+		return keys
+	}
+	res := keys[:0]
+	for _, key := range keys {
+		if u.AllowKey(key) {
+			res = append(res, key)
+		}
+	}
+	return res
+}
+
+// Rules of the user in the format of the ACL LIST command
+func (u *User) Rules() string {
+	var buf strings.Builder
+	buf.WriteString("user ")
+	buf.WriteString(u.Name)
+	buf.WriteString(" on")
+	if u.NoPass() {
+		buf.WriteString(" nopass")
+	} else if u.Password != "" {
+		buf.WriteString(" #")
+		buf.WriteString(u.passwordHash())
+	}
+	if u.AllKeys() {
+		buf.WriteString(" ~*")
+	}
+	for _, key := range u.Keys {
+		buf.WriteString(" ~")
+		buf.WriteString(key)
+	}
+	if len(u.DBNums) > 0 {
+		buf.WriteString(" db=")
+		for i, dbnum := range u.DBNums {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(strconv.Itoa(dbnum))
+		}
+	}
+	if len(u.Categories) == 0 {
+		buf.WriteString(" +@all")
+	}
+	for _, category := range u.Categories {
+		buf.WriteString(" +@")
+		buf.WriteString(category)
+	}
+	return buf.String()
+}
+
+func (u *User) passwordHash() string {
+	if strings.HasPrefix(u.Password, hashPrefix) {
+		return strings.ToLower(strings.TrimPrefix(u.Password, hashPrefix))
+	}
+	return hashPassword(u.Password)
+}
+
+func hashPassword(password string) string {
+	sum := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
+
+// ACL list of the users, empty or nil ACL disables authentication
+type ACL struct {
+	users map[string]*User
+}
+
+// New ACL from the list of users
+func New(users ...*User) *ACL {
+	acl := &ACL{users: make(map[string]*User, len(users))}
+	for _, user := range users {
+		acl.users[user.Name] = user
+	}
+	return acl
+}
+
+// Enabled returns true if authentication is required
+func (a *ACL) Enabled() bool {
+	return a != nil && len(a.users) > 0
+}
+
+// Default user of the new connections, nil if authentication is required
+func (a *ACL) Default() *User {
+	if !a.Enabled() {
+		return nil
+	}
+	if user := a.users[DefaultUser]; user != nil && user.NoPass() {
+		return user
+	}
+	return nil
+}
+
+// Authenticate the user by the name and password
+func (a *ACL) Authenticate(name, password string) (*User, error) {
+	if user := a.users[name]; user != nil && user.CheckPassword(password) {
+		return user, nil
+	}
+	return nil, ErrWrongPass
+}
+
+// AuthenticateToken of the user for HTTP bearer authentication
+func (a *ACL) AuthenticateToken(token string) (*User, error) {
+	if token != "" {
+		for _, user := range a.users {
+			if user.Token != "" && subtle.ConstantTimeCompare([]byte(user.Token), []byte(token)) == 1 {
+				return user, nil
+			}
+		}
+	}
+	return nil, ErrWrongPass
+}
+
+// Users sorted by the name
+func (a *ACL) Users() []*User {
+	users := make([]*User, 0, len(a.users))
+	for _, user := range a.users {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Name < users[j].Name })
+	return users
+}
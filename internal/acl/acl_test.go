@@ -0,0 +1,71 @@
+package acl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestACL(t *testing.T) {
+	var nilACL *ACL
+	assert.False(t, nilACL.Enabled())
+	assert.False(t, New().Enabled())
+
+	acl := New(
+		&User{Name: "admin", Password: "secret", Token: "admin-token"},
+		&User{
+			Name:       "reader",
+			Password:   "sha256:" + hashPassword("pass"),
+			DBNums:     []int{1},
+			Keys:       []string{"post_*"},
+			Categories: []string{CategoryRead},
+		},
+	)
+	assert.True(t, acl.Enabled())
+	assert.Nil(t, acl.Default(), "authentication must be required")
+
+	_, err := acl.Authenticate("admin", "wrong")
+	assert.ErrorIs(t, err, ErrWrongPass)
+	admin, err := acl.Authenticate("admin", "secret")
+	if assert.NoError(t, err) {
+		assert.NoError(t, admin.Check("set", CategoryWrite, 5, "user_1"))
+	}
+	user, err := acl.AuthenticateToken("admin-token")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "admin", user.Name)
+	}
+
+	reader, err := acl.Authenticate("reader", "pass")
+	if assert.NoError(t, err) {
+		assert.NoError(t, reader.Check("get", CategoryRead, 1, "post_1"))
+		assert.Error(t, reader.Check("set", CategoryWrite, 1, "post_1"))
+		assert.ErrorIs(t, reader.Check("get", CategoryRead, 0, "post_1"), ErrNoPermDB)
+		assert.ErrorIs(t, reader.Check("get", CategoryRead, 1, "user_1"), ErrNoPermKey)
+		assert.Equal(t, []string{"post_1"}, reader.FilterKeys([]string{"post_1", "user_1"}))
+		assert.Equal(t, "user reader on #"+hashPassword("pass")+" ~post_* db=1 +@read", reader.Rules())
+	}
+}
+
+func TestACLTokenUser(t *testing.T) {
+	acl := New(
+		&User{Name: "service", Token: "service-token"},
+		&User{Name: "guest", NoPassword: true},
+	)
+	_, err := acl.Authenticate("service", "random")
+	assert.ErrorIs(t, err, ErrWrongPass, "user without password must not accept any password")
+	_, err = acl.Authenticate("service", "")
+	assert.ErrorIs(t, err, ErrWrongPass)
+	user, err := acl.AuthenticateToken("service-token")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "user service on ~* +@all", user.Rules())
+	}
+	_, err = acl.Authenticate("guest", "random")
+	assert.NoError(t, err, "passwordless user must accept any password")
+}
+
+func TestACLDefaultUser(t *testing.T) {
+	acl := New(&User{Name: DefaultUser, Categories: []string{CategoryRead}})
+	if user := acl.Default(); assert.NotNil(t, user) {
+		assert.Equal(t, "user default on nopass ~* +@read", user.Rules())
+	}
+}
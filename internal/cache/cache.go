@@ -2,8 +2,11 @@ package cache
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"io"
+	"time"
 )
 
 var (
@@ -19,3 +22,115 @@ type Cacher interface {
 	Set(ctx context.Context, key string, value []byte) error
 	Del(ctx context.Context, key string) error
 }
+
+// TTLSetter extension of the cache which supports the expiration of separate keys
+type TTLSetter interface {
+	// SetWithTTL stores the value until the TTL or the cache TTL whichever is sooner
+	SetWithTTL(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// SetWithTTL stores the value with the TTL if it's positive.
+// The value is not cached if the cache doesn't support separate expiration of the keys.
+func SetWithTTL(ctx context.Context, c Cacher, key string, value []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		return c.Set(ctx, key, value)
+	}
+	if setter, _ := c.(TTLSetter); setter != nil {
+		return setter.SetWithTTL(ctx, key, value, ttl)
+	}
+	if err := c.Del(ctx, key); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// Replacer extension of the cache which updates cached values without the expiration change
+type Replacer interface {
+	// Replace the value of the cached key keeping its expiration.
+	// Returns ErrNotFound if the key is not cached.
+	Replace(ctx context.Context, key string, value []byte) error
+}
+
+// Replace the value of the cached key keeping its expiration.
+// The key is removed from the cache if the cache doesn't support replacement.
+func Replace(ctx context.Context, c Cacher, key string, value []byte) error {
+	if replacer, _ := c.(Replacer); replacer != nil {
+		return replacer.Replace(ctx, key, value)
+	}
+	if err := c.Del(ctx, key); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return ErrNotFound
+}
+
+// TTLDeriver extension of the cache which shares the backend with the prefixed caches of another TTL
+type TTLDeriver interface {
+	// WithPrefixTTL returns the prefixed cache which expires the values after the TTL
+	WithPrefixTTL(prefix string, ttl time.Duration) Cacher
+}
+
+// WithPrefixTTL returns the prefixed cache of the same backend with the TTL if it's positive.
+// The TTL of the cache is kept if the cache doesn't support the TTL change.
+func WithPrefixTTL(c Cacher, prefix string, ttl time.Duration) Cacher {
+	if deriver, _ := c.(TTLDeriver); deriver != nil && ttl > 0 {
+		return deriver.WithPrefixTTL(prefix, ttl)
+	}
+	return c.WithPrefix(prefix)
+}
+
+// Namespace returns the key prefix of the cached values of the source.
+// Changing the version of the keyspace invalidates all values cached before.
+func Namespace(version, source string) string {
+	return "redify:" + version + ":" + source + ":"
+}
+
+// SourceID of the namespace by the connection of the source.
+// The hash doesn't depend on the order of the sources and hides the credentials.
+func SourceID(connect string) string {
+	sum := sha256.Sum256([]byte(connect))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Entry of the cache with the time of its creation
+type Entry struct {
+	Value     []byte
+	CreatedAt time.Time     // Zero if the creation time is unknown
+	TTL       time.Duration // Fresh period of the value, zero if it's unknown
+}
+
+// Stale returns true if the fresh period of the value is over
+func (e Entry) Stale(now time.Time) bool {
+	return e.TTL > 0 && !e.CreatedAt.IsZero() && !now.Before(e.CreatedAt.Add(e.TTL))
+}
+
+// RefreshDue returns true if the value expires in less than the ahead period
+func (e Entry) RefreshDue(now time.Time, ahead time.Duration) bool {
+	return ahead > 0 && e.TTL > 0 && !e.CreatedAt.IsZero() && !now.Before(e.CreatedAt.Add(e.TTL-ahead))
+}
+
+// StaleCacher extension of the cache which keeps the values after the expiration.
+// Get returns ErrNotFound for the stale values.
+type StaleCacher interface {
+	// SetStale stores the value which is kept for the stale TTL after the cache TTL
+	SetStale(ctx context.Context, key string, value []byte, staleTTL time.Duration) error
+	// GetEntry returns the value with its creation time, stale values are returned too
+	GetEntry(ctx context.Context, key string) (Entry, error)
+}
+
+// SetStale stores the value which is kept for the stale TTL after the expiration if it's positive
+// and the cache supports stale values
+func SetStale(ctx context.Context, c Cacher, key string, value []byte, staleTTL time.Duration) error {
+	if sc, _ := c.(StaleCacher); sc != nil && staleTTL > 0 {
+		return sc.SetStale(ctx, key, value, staleTTL)
+	}
+	return c.Set(ctx, key, value)
+}
+
+// GetEntry returns the cached value with its creation time if the cache supports it
+func GetEntry(ctx context.Context, c Cacher, key string) (Entry, error) {
+	if sc, _ := c.(StaleCacher); sc != nil {
+		return sc.GetEntry(ctx, key)
+	}
+	val, err := c.Get(ctx, key)
+	return Entry{Value: val}, err
+}
@@ -10,12 +10,14 @@ import (
 	"github.com/demdxx/redify/internal/cache/simplecache"
 )
 
+// Connect returns the cache backend of the connect string,
+// the TTL of the memory cache is rounded up to seconds
 func Connect(connect string, size int, ttl time.Duration) (cache.Cacher, error) {
 	switch {
 	case strings.HasPrefix(connect, "redis://"):
 		return rediscache.New(connect, ttl)
 	case connect == "memory":
-		return simplecache.New(size, int(ttl.Seconds()))
+		return simplecache.New(size, int((ttl+time.Second-1)/time.Second))
 	default:
 		return nil, fmt.Errorf("invalid cache connect: %s", connect)
 	}
@@ -0,0 +1,30 @@
+package connect
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demdxx/redify/internal/cache"
+)
+
+func TestConnectMemorySubsecondTTL(t *testing.T) {
+	ctx := context.Background()
+	cacheObj, err := Connect("memory", 10, 500*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cacheObj.Close()
+
+	assert.NoError(t, cacheObj.Set(ctx, "key", []byte("val")))
+	time.Sleep(1100 * time.Millisecond)
+	_, err = cacheObj.Get(ctx, "key")
+	assert.ErrorIs(t, err, cache.ErrNotFound)
+}
+
+func TestConnectInvalid(t *testing.T) {
+	_, err := Connect("unknown://", 10, time.Second)
+	assert.Error(t, err)
+}
@@ -2,6 +2,7 @@ package lrucache
 
 import (
 	"context"
+	"time"
 
 	lru "github.com/hashicorp/golang-lru/v2"
 
@@ -12,6 +13,8 @@ import (
 type item struct {
 	value       []byte
 	createdTime uint64
+	ttl         uint64 // in seconds, zero means the cache TTL
+	staleTTL    uint64 // in seconds, the item is kept after the TTL
 }
 
 type lruCache struct {
@@ -43,17 +46,48 @@ func (d *lruCache) WithPrefix(prefix string) cache.Cacher {
 	}
 }
 
+// WithPrefixTTL returns the cache of the same LRU storage with the TTL rounded up to seconds
+func (d *lruCache) WithPrefixTTL(prefix string, ttl time.Duration) cache.Cacher {
+	return &lruCache{
+		ttl:    uint64((ttl + time.Second - 1) / time.Second),
+		prefix: prefix,
+		cache:  d.cache,
+	}
+}
+
 func (d *lruCache) Get(ctx context.Context, key string) ([]byte, error) {
+	ent, err := d.GetEntry(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if ent.Stale(time.Unix(int64(fasttime.UnixTimestamp()), 0)) {
+		return nil, cache.ErrNotFound
+	}
+	return ent.Value, nil
+}
+
+// GetEntry returns the value with its creation time, stale values are returned too.
+// The time precision is a second.
+func (d *lruCache) GetEntry(ctx context.Context, key string) (cache.Entry, error) {
 	key = d.prefix + key
 	val, ok := d.cache.Get(key)
 	if !ok {
-		return nil, cache.ErrNotFound
+		return cache.Entry{}, cache.ErrNotFound
 	}
-	if val.createdTime+d.ttl < fasttime.UnixTimestamp() {
+	ttl := d.ttl
+	if val.ttl > 0 && val.ttl < ttl {
+		ttl = val.ttl
+	}
+	if val.createdTime+ttl+val.staleTTL < fasttime.UnixTimestamp() {
 		_ = d.cache.Remove(key)
-		return nil, cache.ErrNotFound
+		return cache.Entry{}, cache.ErrNotFound
 	}
-	return val.value, nil
+	return cache.Entry{
+		Value:     val.value,
+		CreatedAt: time.Unix(int64(val.createdTime), 0),
+		// The item expires after the last second of the TTL
+		TTL: time.Duration(ttl+1) * time.Second,
+	}, nil
 }
 
 func (d *lruCache) Set(ctx context.Context, key string, value []byte) error {
@@ -61,6 +95,40 @@ func (d *lruCache) Set(ctx context.Context, key string, value []byte) error {
 	return nil
 }
 
+// SetStale stores the value which is kept for the stale TTL after the cache TTL
+func (d *lruCache) SetStale(ctx context.Context, key string, value []byte, staleTTL time.Duration) error {
+	_ = d.cache.Add(d.prefix+key, item{
+		value:       value,
+		createdTime: fasttime.UnixTimestamp(),
+		staleTTL:    uint64((staleTTL + time.Second - 1) / time.Second),
+	})
+	return nil
+}
+
+// SetWithTTL stores the value until the TTL or the cache TTL whichever is sooner.
+// The TTL less than a second is not cached because of the timestamp precision.
+func (d *lruCache) SetWithTTL(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	seconds := uint64(ttl / time.Second)
+	if seconds == 0 {
+		_ = d.cache.Remove(d.prefix + key)
+		return nil
+	}
+	_ = d.cache.Add(d.prefix+key, item{value: value, createdTime: fasttime.UnixTimestamp(), ttl: seconds})
+	return nil
+}
+
+// Replace the value of the cached key keeping its expiration
+func (d *lruCache) Replace(ctx context.Context, key string, value []byte) error {
+	key = d.prefix + key
+	val, ok := d.cache.Peek(key)
+	if !ok {
+		return cache.ErrNotFound
+	}
+	val.value = value
+	_ = d.cache.Add(key, val)
+	return nil
+}
+
 func (d *lruCache) Del(ctx context.Context, key string) error {
 	if !d.cache.Remove(d.prefix + key) {
 		return cache.ErrNotFound
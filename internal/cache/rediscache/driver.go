@@ -65,19 +65,59 @@ func (d *simpleCache) WithPrefix(prefix string) cache.Cacher {
 	return newFromConnect(d.conn.inc(), d.ttl, prefix)
 }
 
+// WithPrefixTTL returns the cache of the same connection with the TTL
+func (d *simpleCache) WithPrefixTTL(prefix string, ttl time.Duration) cache.Cacher {
+	return newFromConnect(d.conn.inc(), ttl, prefix)
+}
+
 func (d *simpleCache) Get(ctx context.Context, key string) ([]byte, error) {
-	val, err := d.conn.GetEx(ctx, d.prefix+key, d.ttl).Result()
+	ent, err := d.GetEntry(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if ent.Stale(time.Now()) {
+		return nil, cache.ErrNotFound
+	}
+	return ent.Value, nil
+}
+
+// GetEntry returns the value with its creation time, stale values are returned too
+func (d *simpleCache) GetEntry(ctx context.Context, key string) (cache.Entry, error) {
+	val, err := d.conn.Get(ctx, d.prefix+key).Bytes()
 	if err != nil {
-		if err == redis.Nil {
-			return nil, cache.ErrNotFound
-		}
-		return nil, _err(err)
+		return cache.Entry{}, _err(err)
 	}
-	return []byte(val), nil
+	return decodeEntry(val), nil
 }
 
 func (d *simpleCache) Set(ctx context.Context, key string, value []byte) error {
-	return _err(d.conn.SetEX(ctx, d.prefix+key, value, d.ttl).Err())
+	return d.set(ctx, key, value, d.ttl, d.ttl)
+}
+
+// SetStale stores the value which is kept for the stale TTL after the cache TTL
+func (d *simpleCache) SetStale(ctx context.Context, key string, value []byte, staleTTL time.Duration) error {
+	if d.ttl <= 0 {
+		return d.Set(ctx, key, value)
+	}
+	return d.set(ctx, key, value, d.ttl, d.ttl+staleTTL)
+}
+
+// SetWithTTL stores the value until the TTL or the cache TTL whichever is sooner
+func (d *simpleCache) SetWithTTL(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if ttl <= 0 || (d.ttl > 0 && ttl > d.ttl) {
+		return d.Set(ctx, key, value)
+	}
+	return d.set(ctx, key, value, ttl, ttl)
+}
+
+// Replace the value of the cached key keeping its expiration and creation time
+func (d *simpleCache) Replace(ctx context.Context, key string, value []byte) error {
+	ent, err := d.GetEntry(ctx, key)
+	if err != nil {
+		return err
+	}
+	ent.Value = value
+	return _err(d.conn.SetArgs(ctx, d.prefix+key, encodeEntry(ent), redis.SetArgs{Mode: "XX", KeepTTL: true}).Err())
 }
 
 func (d *simpleCache) Del(ctx context.Context, key string) error {
@@ -88,6 +128,11 @@ func (d *simpleCache) Close() error {
 	return d.conn.Close()
 }
 
+func (d *simpleCache) set(ctx context.Context, key string, value []byte, ttl, keyTTL time.Duration) error {
+	ent := cache.Entry{Value: value, CreatedAt: time.Now(), TTL: ttl}
+	return _err(d.conn.SetEX(ctx, d.prefix+key, encodeEntry(ent), keyTTL).Err())
+}
+
 func _err(err error) error {
 	if err == redis.Nil {
 		return cache.ErrNotFound
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/alicebob/miniredis/v2"
 	"github.com/demdxx/redify/internal/cache"
@@ -52,3 +53,87 @@ func TestDriver(t *testing.T) {
 		})
 	}
 }
+
+func TestDriverSetWithTTL(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+
+	var (
+		ctx      = context.Background()
+		cacheObj = newFromConnect(&retainConnect{Cmdable: newTestRedis(mr)}, time.Minute, "")
+	)
+	assert.NoError(t, cache.SetWithTTL(ctx, cacheObj, "key1", []byte("val"), 10*time.Second))
+	assert.NoError(t, cache.SetWithTTL(ctx, cacheObj, "key2", []byte("val"), time.Hour))
+	assert.Equal(t, 10*time.Second, mr.TTL("key1"))
+	assert.Equal(t, time.Minute, mr.TTL("key2"), "TTL must be limited by the cache TTL")
+
+	_, err = cacheObj.Get(ctx, "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, 10*time.Second, mr.TTL("key1"), "TTL must not be changed by the reading")
+	mr.FastForward(11 * time.Second)
+	_, err = cacheObj.Get(ctx, "key1")
+	assert.ErrorIs(t, err, cache.ErrNotFound)
+}
+
+func TestDriverReplace(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+
+	var (
+		ctx      = context.Background()
+		client   = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		cacheObj = newFromConnect(&retainConnect{Cmdable: client}, time.Minute, "")
+	)
+	assert.ErrorIs(t, cache.Replace(ctx, cacheObj, "key1", []byte("val")), cache.ErrNotFound)
+	assert.False(t, mr.Exists("key1"), "missing key must not be added")
+
+	assert.NoError(t, cache.SetWithTTL(ctx, cacheObj, "key1", []byte("val"), 10*time.Second))
+	assert.NoError(t, cache.Replace(ctx, cacheObj, "key1", []byte("new")))
+	val, err := cacheObj.Get(ctx, "key1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []byte("new"), val)
+	}
+	assert.Equal(t, 10*time.Second, mr.TTL("key1"), "TTL must be kept by the replacement")
+}
+
+func TestDriverSetStale(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+
+	var (
+		ctx      = context.Background()
+		client   = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		cacheObj = newFromConnect(&retainConnect{Cmdable: client}, time.Minute, "")
+	)
+	assert.NoError(t, cache.SetStale(ctx, cacheObj, "key1", []byte("val"), time.Hour))
+	assert.Equal(t, time.Hour+time.Minute, mr.TTL("key1"), "stale value must be kept after the cache TTL")
+	ent, err := cache.GetEntry(ctx, cacheObj, "key1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []byte("val"), ent.Value)
+		assert.Equal(t, time.Minute, ent.TTL)
+		assert.False(t, ent.Stale(time.Now()))
+		assert.True(t, ent.Stale(time.Now().Add(2*time.Minute)))
+	}
+
+	assert.NoError(t, cache.Replace(ctx, cacheObj, "key1", []byte("new")))
+	replaced, err := cache.GetEntry(ctx, cacheObj, "key1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []byte("new"), replaced.Value)
+		assert.Equal(t, ent.CreatedAt, replaced.CreatedAt, "creation time must be kept by the replacement")
+	}
+
+	// Values stored without the entry header are never stale
+	assert.NoError(t, mr.Set("key2", "raw"))
+	val, err := cacheObj.Get(ctx, "key2")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("raw"), val)
+}
@@ -0,0 +1,36 @@
+package rediscache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+
+	"github.com/demdxx/redify/internal/cache"
+)
+
+// entryHeader marks the values stored with the creation time and TTL
+var entryHeader = []byte("\x00rdf1")
+
+const entryHeaderSize = 5 + 8 + 8
+
+// encodeEntry as the header, creation time and TTL in milliseconds followed by the value
+func encodeEntry(ent cache.Entry) []byte {
+	buf := make([]byte, entryHeaderSize, entryHeaderSize+len(ent.Value))
+	copy(buf, entryHeader)
+	binary.BigEndian.PutUint64(buf[5:], uint64(ent.CreatedAt.UnixMilli()))
+	binary.BigEndian.PutUint64(buf[13:], uint64(ent.TTL.Milliseconds()))
+	return append(buf, ent.Value...)
+}
+
+// decodeEntry of the cache, values without the header are returned as is
+// with unknown creation time
+func decodeEntry(data []byte) cache.Entry {
+	if len(data) < entryHeaderSize || !bytes.HasPrefix(data, entryHeader) {
+		return cache.Entry{Value: data}
+	}
+	return cache.Entry{
+		Value:     data[entryHeaderSize:],
+		CreatedAt: time.UnixMilli(int64(binary.BigEndian.Uint64(data[5:]))),
+		TTL:       time.Duration(binary.BigEndian.Uint64(data[13:])) * time.Millisecond,
+	}
+}
@@ -12,11 +12,18 @@ import (
 
 var errSaveItem = errors.New(`undefined error of item savings`)
 
+// entry of the cache, the item TTL includes the stale TTL
+type entry struct {
+	value   []byte
+	created time.Time
+	ttl     time.Duration
+}
+
 type simpleCache struct {
 	prefix string
 	ttl    int
 	size   int
-	cache  *ttlcache.Cache[string, []byte]
+	cache  *ttlcache.Cache[string, entry]
 }
 
 // NewCache simple driver cache implementation
@@ -25,8 +32,8 @@ func NewCache(size, ttl int, prefix string) (cache.Cacher, error) {
 		ttl = 60
 	}
 	cache := ttlcache.New(
-		ttlcache.WithTTL[string, []byte](time.Duration(ttl)*time.Second),
-		ttlcache.WithCapacity[string, []byte](uint64(size)),
+		ttlcache.WithTTL[string, entry](time.Duration(ttl)*time.Second),
+		ttlcache.WithCapacity[string, entry](uint64(size)),
 	)
 	// Run automatic cleanup
 	go cache.Start()
@@ -51,16 +58,63 @@ func (d *simpleCache) WithPrefix(prefix string) cache.Cacher {
 	return cache
 }
 
+// WithPrefixTTL returns the new cache of the same size with the TTL rounded up to seconds
+func (d *simpleCache) WithPrefixTTL(prefix string, ttl time.Duration) cache.Cacher {
+	cache, err := NewCache(d.size, int((ttl+time.Second-1)/time.Second), prefix)
+	if err != nil {
+		panic(err)
+	}
+	return cache
+}
+
 func (d *simpleCache) Get(ctx context.Context, key string) ([]byte, error) {
-	val := d.cache.Get(d.prefix + key)
-	if val == nil {
+	ent, err := d.GetEntry(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if ent.Stale(time.Now()) {
 		return nil, cache.ErrNotFound
 	}
-	return val.Value(), nil
+	return ent.Value, nil
+}
+
+// GetEntry returns the value with its creation time, stale values are returned too
+func (d *simpleCache) GetEntry(ctx context.Context, key string) (cache.Entry, error) {
+	// Touch on hit would extend the separate TTL of the item
+	val := d.cache.Get(d.prefix+key, ttlcache.WithDisableTouchOnHit[string, entry]())
+	if val == nil {
+		return cache.Entry{}, cache.ErrNotFound
+	}
+	ent := val.Value()
+	return cache.Entry{Value: ent.value, CreatedAt: ent.created, TTL: ent.ttl}, nil
 }
 
 func (d *simpleCache) Set(ctx context.Context, key string, value []byte) error {
-	if it := d.cache.Set(d.prefix+key, value, ttlcache.DefaultTTL); it == nil {
+	return d.set(key, value, d.cacheTTL(), ttlcache.DefaultTTL)
+}
+
+// SetStale stores the value which is kept for the stale TTL after the cache TTL
+func (d *simpleCache) SetStale(ctx context.Context, key string, value []byte, staleTTL time.Duration) error {
+	return d.set(key, value, d.cacheTTL(), d.cacheTTL()+staleTTL)
+}
+
+// SetWithTTL stores the value until the TTL or the cache TTL whichever is sooner
+func (d *simpleCache) SetWithTTL(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if ttl <= 0 || ttl > d.cacheTTL() {
+		return d.Set(ctx, key, value)
+	}
+	return d.set(key, value, ttl, ttl)
+}
+
+// Replace the value of the cached key keeping its expiration
+func (d *simpleCache) Replace(ctx context.Context, key string, value []byte) error {
+	val := d.cache.Get(d.prefix+key, ttlcache.WithDisableTouchOnHit[string, entry]())
+	if val == nil {
+		return cache.ErrNotFound
+	}
+	ent := val.Value()
+	ent.value = value
+	if it := d.cache.Set(d.prefix+key, ent, ttlcache.PreviousOrDefaultTTL); it == nil {
 		return errSaveItem
 	}
 	return nil
@@ -76,3 +130,15 @@ func (d *simpleCache) Close() error {
 	d.cache.DeleteAll()
 	return nil
 }
+
+func (d *simpleCache) set(key string, value []byte, ttl, itemTTL time.Duration) error {
+	ent := entry{value: value, created: time.Now(), ttl: ttl}
+	if it := d.cache.Set(d.prefix+key, ent, itemTTL); it == nil {
+		return errSaveItem
+	}
+	return nil
+}
+
+func (d *simpleCache) cacheTTL() time.Duration {
+	return time.Duration(d.ttl) * time.Second
+}
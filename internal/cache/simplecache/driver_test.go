@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/demdxx/redify/internal/cache"
 	"github.com/stretchr/testify/assert"
@@ -35,3 +36,97 @@ func TestDriver(t *testing.T) {
 		})
 	}
 }
+
+func TestDriverSetWithTTL(t *testing.T) {
+	ctx := context.Background()
+	cacheObj, err := New(10, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cacheObj.Close()
+
+	assert.NoError(t, cache.SetWithTTL(ctx, cacheObj, "key1", []byte("val"), 50*time.Millisecond))
+	assert.NoError(t, cache.SetWithTTL(ctx, cacheObj, "key2", []byte("val"), time.Hour))
+	_, err = cacheObj.Get(ctx, "key1")
+	assert.NoError(t, err, "key must exist before the expiration")
+
+	time.Sleep(100 * time.Millisecond)
+	_, err = cacheObj.Get(ctx, "key1")
+	assert.ErrorIs(t, err, cache.ErrNotFound)
+	_, err = cacheObj.Get(ctx, "key2")
+	assert.NoError(t, err, "key must be limited by the cache TTL")
+}
+
+func TestDriverReplace(t *testing.T) {
+	ctx := context.Background()
+	cacheObj, err := New(10, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cacheObj.Close()
+
+	assert.ErrorIs(t, cache.Replace(ctx, cacheObj, "key1", []byte("val")), cache.ErrNotFound)
+	_, err = cacheObj.Get(ctx, "key1")
+	assert.ErrorIs(t, err, cache.ErrNotFound, "missing key must not be added")
+
+	assert.NoError(t, cache.SetWithTTL(ctx, cacheObj, "key1", []byte("val"), 50*time.Millisecond))
+	assert.NoError(t, cache.Replace(ctx, cacheObj, "key1", []byte("new")))
+	val, err := cacheObj.Get(ctx, "key1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []byte("new"), val)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	_, err = cacheObj.Get(ctx, "key1")
+	assert.ErrorIs(t, err, cache.ErrNotFound, "TTL must be kept by the replacement")
+}
+
+func TestDriverWithPrefixTTL(t *testing.T) {
+	ctx := context.Background()
+	cacheObj, err := New(10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cacheObj.Close()
+	hourCache := cache.WithPrefixTTL(cacheObj, "hour_", time.Hour)
+	defer hourCache.Close()
+
+	// The TTL of the derived cache is not limited by the TTL of the parent
+	assert.NoError(t, cache.SetWithTTL(ctx, hourCache, "key1", []byte("val"), 30*time.Minute))
+	assert.NoError(t, cacheObj.Set(ctx, "key1", []byte("val")))
+	time.Sleep(1100 * time.Millisecond)
+	_, err = cacheObj.Get(ctx, "key1")
+	assert.ErrorIs(t, err, cache.ErrNotFound)
+	data, err := hourCache.Get(ctx, "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("val"), data)
+}
+
+func TestDriverSetStale(t *testing.T) {
+	ctx := context.Background()
+	cacheObj, err := New(10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cacheObj.Close()
+
+	assert.NoError(t, cache.SetStale(ctx, cacheObj, "key1", []byte("val"), time.Minute))
+	assert.NoError(t, cacheObj.Set(ctx, "key2", []byte("val")))
+	ent, err := cache.GetEntry(ctx, cacheObj, "key1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []byte("val"), ent.Value)
+		assert.Equal(t, time.Second, ent.TTL)
+		assert.False(t, ent.Stale(time.Now()))
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+	_, err = cacheObj.Get(ctx, "key1")
+	assert.ErrorIs(t, err, cache.ErrNotFound, "stale value must not be returned by Get")
+	ent, err = cache.GetEntry(ctx, cacheObj, "key1")
+	if assert.NoError(t, err, "stale value must be kept") {
+		assert.Equal(t, []byte("val"), ent.Value)
+		assert.True(t, ent.Stale(time.Now()))
+	}
+	_, err = cache.GetEntry(ctx, cacheObj, "key2")
+	assert.ErrorIs(t, err, cache.ErrNotFound)
+}
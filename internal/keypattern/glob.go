@@ -0,0 +1,296 @@
+package keypattern
+
+import "strings"
+
+// GlobVar restriction of the pattern variable extracted from the glob expression
+type GlobVar struct {
+	Name   string
+	Value  string // Complete value of the variable if Exact
+	Prefix string
+	Suffix string
+	Exact  bool
+}
+
+type globToken struct {
+	literal string
+	meta    bool
+}
+
+// MatchGlob checks if the glob expression can match any key of the pattern
+// and returns restrictions of the pattern variables which could be applied
+// to prefilter values on the data source side.
+//
+// Example:
+//
+//	pattern: post_{{year}}_{{slug}}
+//	glob:    post_2024_hello*
+//	result:  [{Name: "year", Value: "2024", Exact: true}, {Name: "slug", Prefix: "hello"}]
+func (p *Pattern) MatchGlob(glob string) ([]GlobVar, bool) {
+	tokens := parseGlob(glob)
+	if len(tokens) == 0 {
+		return nil, true
+	}
+
+	// Glob without any special symbols is the complete key
+	if len(tokens) == 1 && !tokens[0].meta {
+		ectx := ExecContext{}
+		if !p.Match(tokens[0].literal, ectx) || p.Format(ectx) != tokens[0].literal {
+			return nil, false
+		}
+		vars := make([]GlobVar, 0, len(ectx))
+		for _, name := range p.Keys() {
+			vars = append(vars, GlobVar{Name: name, Value: ectx[name], Exact: true})
+		}
+		return vars, true
+	}
+
+	var (
+		prefix, suffix string
+		vars           = map[int]*GlobVar{}
+		stop           = len(p.matchers)
+	)
+	if !tokens[0].meta {
+		prefix = tokens[0].literal
+	}
+	if last := tokens[len(tokens)-1]; !last.meta {
+		suffix = last.literal
+	}
+
+	// Apply literal prefix of the glob from the beginning of the pattern
+	for i := 0; i < len(p.matchers); i++ {
+		if prefix == "" {
+			stop = i
+			break
+		}
+		switch m := p.matchers[i].(type) {
+		case *constMatcher:
+			if len(prefix) < len(m.val) {
+				if !strings.HasPrefix(m.val, prefix) {
+					return nil, false
+				}
+				prefix, stop = "", i
+			} else if !strings.HasPrefix(prefix, m.val) {
+				return nil, false
+			} else {
+				prefix = prefix[len(m.val):]
+			}
+		case *varMatcher:
+			if next, _ := p.matcher(i + 1).(*constMatcher); next != nil {
+				if idx := strings.Index(prefix, next.val); idx > 0 {
+					vars[i] = &GlobVar{Name: m.name, Value: prefix[:idx], Exact: true}
+					prefix = prefix[idx:]
+					continue
+				}
+			}
+			vars[i] = &GlobVar{Name: m.name, Prefix: prefix[:len(prefix)-p.prefixOverlap(i, prefix)]}
+			prefix, stop = "", i
+		}
+		if stop == i {
+			break
+		}
+	}
+	if prefix != "" {
+		// The prefix is longer than any key of the pattern
+		return nil, false
+	}
+
+	// Apply literal suffix of the glob from the end of the pattern
+	for i := len(p.matchers) - 1; i >= stop && suffix != ""; i-- {
+		switch m := p.matchers[i].(type) {
+		case *constMatcher:
+			if len(suffix) < len(m.val) {
+				if !strings.HasSuffix(m.val, suffix) {
+					return nil, false
+				}
+				suffix = ""
+			} else if !strings.HasSuffix(suffix, m.val) {
+				return nil, false
+			} else {
+				suffix = suffix[:len(suffix)-len(m.val)]
+			}
+		case *varMatcher:
+			// Variable value takes everything till the first occurrence of the next
+			// constant, so the suffix can't define it completely
+			tail := suffix[p.suffixOverlap(i, suffix):]
+			if v := vars[i]; v != nil {
+				v.Suffix = tail
+			} else {
+				vars[i] = &GlobVar{Name: m.name, Suffix: tail}
+			}
+			suffix = ""
+		}
+		if i == stop {
+			break
+		}
+	}
+
+	result := make([]GlobVar, 0, len(vars))
+	for i := range p.matchers {
+		if v := vars[i]; v != nil && (v.Exact || v.Prefix != "" || v.Suffix != "") {
+			result = append(result, *v)
+		}
+	}
+	return result, true
+}
+
+// prefixOverlap returns the length of the longest end of the glob prefix which
+// could belong to the pattern after the variable i if the variable value is short
+func (p *Pattern) prefixOverlap(i int, prefix string) int {
+	for k := len(prefix); k > 0; k-- {
+		switch m := p.matcher(i + 1).(type) {
+		case *constMatcher:
+			if k <= len(m.val) && strings.HasPrefix(m.val, prefix[len(prefix)-k:]) ||
+				k > len(m.val) && strings.HasPrefix(prefix[len(prefix)-k:], m.val) {
+				return k
+			}
+		case *varMatcher:
+			return k
+		}
+	}
+	return 0
+}
+
+// suffixOverlap returns the length of the longest beginning of the glob suffix which
+// could belong to the pattern before the variable i if the variable value is short
+func (p *Pattern) suffixOverlap(i int, suffix string) int {
+	for k := len(suffix); k > 0; k-- {
+		switch m := p.matcher(i - 1).(type) {
+		case *constMatcher:
+			if k <= len(m.val) && strings.HasSuffix(m.val, suffix[:k]) ||
+				k > len(m.val) && strings.HasSuffix(suffix[:k], m.val) {
+				return k
+			}
+		case *varMatcher:
+			return k
+		}
+	}
+	return 0
+}
+
+func (p *Pattern) matcher(i int) Matcher {
+	if i < 0 || i >= len(p.matchers) {
+		return nil
+	}
+	return p.matchers[i]
+}
+
+// parseGlob splits the glob expression into the literal and special parts
+func parseGlob(glob string) []globToken {
+	var (
+		tokens []globToken
+		buf    strings.Builder
+	)
+	flush := func() {
+		if buf.Len() > 0 {
+			tokens = append(tokens, globToken{literal: buf.String()})
+			buf.Reset()
+		}
+	}
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+			}
+			_ = buf.WriteByte(glob[i])
+		case '*', '?':
+			flush()
+			tokens = append(tokens, globToken{meta: true})
+		case '[':
+			flush()
+			if end := strings.IndexByte(glob[i+1:], ']'); end >= 0 {
+				i += end + 1
+			} else {
+				i = len(glob)
+			}
+			tokens = append(tokens, globToken{meta: true})
+		default:
+			_ = buf.WriteByte(c)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// GlobMatch checks if the string matches the redis glob-style pattern
+//
+// Supported special symbols:
+//
+//   - - any sequence of characters
+//     ?     - any single character
+//     [abc] - any character from the set, [^abc] negation, [a-z] ranges
+//     \x    - escaped character
+func GlobMatch(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if GlobMatch(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+			s = s[1:]
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			end := strings.IndexByte(pattern[1:], ']')
+			if end < 0 {
+				return false
+			}
+			if !matchGlobSet(pattern[1:end+1], s[0]) {
+				return false
+			}
+			pattern = pattern[end+1:]
+			s = s[1:]
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(s) == 0 || s[0] != pattern[0] {
+				return false
+			}
+			s = s[1:]
+		}
+		pattern = pattern[1:]
+	}
+	return len(s) == 0
+}
+
+func matchGlobSet(set string, c byte) bool {
+	negate := len(set) > 0 && set[0] == '^'
+	if negate {
+		set = set[1:]
+	}
+	match := false
+	for i := 0; i < len(set) && !match; i++ {
+		switch {
+		case set[i] == '\\' && i+1 < len(set):
+			i++
+			match = set[i] == c
+		case i+2 < len(set) && set[i+1] == '-':
+			lo, hi := set[i], set[i+2]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			match = c >= lo && c <= hi
+			i += 2
+		default:
+			match = set[i] == c
+		}
+	}
+	return match != negate
+}
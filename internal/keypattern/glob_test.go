@@ -0,0 +1,76 @@
+package keypattern
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatternMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		glob    string
+		match   bool
+		vars    []GlobVar
+	}{
+		{pattern: "post_{{slug}}", glob: "*", match: true, vars: []GlobVar{}},
+		{pattern: "post_{{slug}}", glob: "post_*", match: true, vars: []GlobVar{}},
+		{pattern: "post_{{slug}}", glob: "po*", match: true, vars: []GlobVar{}},
+		{pattern: "post_{{slug}}", glob: "user_*", match: false},
+		{pattern: "post_{{slug}}", glob: "post_2024*", match: true,
+			vars: []GlobVar{{Name: "slug", Prefix: "2024"}}},
+		{pattern: "post_{{slug}}", glob: "*_end", match: true,
+			vars: []GlobVar{{Name: "slug", Suffix: "end"}}},
+		{pattern: "{{slug}}_post", glob: "start_*", match: true,
+			vars: []GlobVar{{Name: "slug", Prefix: "start"}}},
+		{pattern: "a{{x}}b{{y}}c", glob: "a*bc", match: true, vars: []GlobVar{}},
+		{pattern: "{{x}}_{{y}}", glob: "_a*", match: true, vars: []GlobVar{}},
+		{pattern: "post_{{slug}}", glob: "post_a*z", match: true,
+			vars: []GlobVar{{Name: "slug", Prefix: "a", Suffix: "z"}}},
+		{pattern: "post_{{slug}}", glob: "post_hello", match: true,
+			vars: []GlobVar{{Name: "slug", Value: "hello", Exact: true}}},
+		{pattern: "post_{{slug}}.json", glob: "*.txt", match: false},
+		{pattern: "post_{{slug}}.json", glob: "*a.json", match: true,
+			vars: []GlobVar{{Name: "slug", Suffix: "a"}}},
+		{pattern: "doc_{{type}}_{{slug}}", glob: "doc_pdf_h?lp*", match: true,
+			vars: []GlobVar{{Name: "type", Value: "pdf", Exact: true}, {Name: "slug", Prefix: "h"}}},
+		{pattern: "doc_{{type}}_{{slug}}", glob: "doc_pd*", match: true,
+			vars: []GlobVar{{Name: "type", Prefix: "pd"}}},
+		{pattern: "doc_{{type}}_{{slug}}", glob: "doc_pdf_\\*x", match: true,
+			vars: []GlobVar{{Name: "type", Value: "pdf", Exact: true}, {Name: "slug", Value: "*x", Exact: true}}},
+	}
+	for _, test := range tests {
+		t.Run(test.pattern+"@"+test.glob, func(t *testing.T) {
+			vars, ok := NewPatternFromExpression(test.pattern).MatchGlob(test.glob)
+			assert.Equal(t, test.match, ok)
+			if test.match {
+				assert.Equal(t, test.vars, vars)
+			}
+		})
+	}
+}
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		str     string
+		match   bool
+	}{
+		{pattern: "*", str: "", match: true},
+		{pattern: "*", str: "a/b/c", match: true},
+		{pattern: "post_*", str: "post_hello", match: true},
+		{pattern: "post_*", str: "user_hello", match: false},
+		{pattern: "*o*", str: "document_main", match: true},
+		{pattern: "h?llo", str: "hello", match: true},
+		{pattern: "h?llo", str: "heello", match: false},
+		{pattern: "h[ae]llo", str: "hallo", match: true},
+		{pattern: "h[^e]llo", str: "hello", match: false},
+		{pattern: "h[a-c]llo", str: "hbllo", match: true},
+		{pattern: "h\\*llo", str: "h*llo", match: true},
+		{pattern: "h\\*llo", str: "hello", match: false},
+		{pattern: "a**b", str: "axxb", match: true},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.match, GlobMatch(test.pattern, test.str), "%s ~ %s", test.pattern, test.str)
+	}
+}
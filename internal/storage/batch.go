@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// BatchItem is the result of the single key of the batch request
+type BatchItem struct {
+	Value []byte
+	Err   error
+	TTL   time.Duration // Remaining time to live if the key expires
+}
+
+// BatchGetter extension of the driver which can load many keys by one request
+type BatchGetter interface {
+	// GetMany values of the keys, the result is aligned with the keys.
+	// Item error ErrNoKey means that the key is not served by the driver.
+	GetMany(ctx context.Context, dbnum int, keys []string) []BatchItem
+}
+
+// GetMany values of the keys using BatchGetter if the driver supports it
+func GetMany(ctx context.Context, drv Driver, dbnum int, keys []string) []BatchItem {
+	if bg, _ := drv.(BatchGetter); bg != nil {
+		return bg.GetMany(ctx, dbnum, keys)
+	}
+	items := make([]BatchItem, len(keys))
+	for i, key := range keys {
+		items[i].Value, items[i].Err = drv.Get(ctx, dbnum, key)
+	}
+	return items
+}
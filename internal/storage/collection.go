@@ -0,0 +1,47 @@
+package storage
+
+import "context"
+
+// CollectionReader extension of the driver for the keys of list, set and zset types
+type CollectionReader interface {
+	// Range returns the members of the list from start to stop inclusive,
+	// negative indexes are counted from the end of the list
+	Range(ctx context.Context, dbnum int, key string, start, stop int64) ([]string, error)
+	// Len returns the number of the members of the list, set or sorted set
+	Len(ctx context.Context, dbnum int, key string) (int64, error)
+	// Members returns the unique members of the set
+	Members(ctx context.Context, dbnum int, key string) ([]string, error)
+	// IsMember returns true if the set contains the member
+	IsMember(ctx context.Context, dbnum int, key, member string) (bool, error)
+}
+
+// CollectionWriter extension of the driver to modify the members of lists and sets
+type CollectionWriter interface {
+	// AddMembers appends the members to the list or set and returns the number of added members
+	AddMembers(ctx context.Context, dbnum int, key string, members []string) (int64, error)
+	// RemMembers removes the members from the set or sorted set and returns the number of removed members
+	RemMembers(ctx context.Context, dbnum int, key string, members []string) (int64, error)
+}
+
+// KeyTyper extension of the driver which reports the type of the keys
+type KeyTyper interface {
+	// KeyType returns the type of the existing key or ErrNotFound
+	KeyType(ctx context.Context, dbnum int, key string) (string, error)
+}
+
+// KeyType returns the type of the key, existing keys of the drivers
+// without types support are strings
+func KeyType(ctx context.Context, drv Driver, dbnum int, key string) (string, error) {
+	if typer, _ := drv.(KeyTyper); typer != nil {
+		return typer.KeyType(ctx, dbnum, key)
+	}
+	if _, err := drv.Get(ctx, dbnum, key); err != nil {
+		return "", err
+	}
+	return KeyTypeString, nil
+}
+
+// IsCollectionType returns true if the keys of the type contain many members
+func IsCollectionType(tp string) bool {
+	return tp == KeyTypeList || tp == KeyTypeSet || tp == KeyTypeZSet
+}
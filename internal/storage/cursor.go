@@ -0,0 +1,44 @@
+package storage
+
+const (
+	cursorOffsetBits = 40
+	cursorBindBits   = 16
+	cursorOffsetMask = 1<<cursorOffsetBits - 1
+	cursorBindMask   = 1<<cursorBindBits - 1
+)
+
+// Cursor of the paging key iteration which is encoded into the single
+// redis SCAN cursor value.
+//
+// Layout: [8 bit store index][16 bit bind index][40 bit offset]
+//
+// The zero cursor means the start of the iteration and
+// returned zero cursor means the end of it.
+type Cursor uint64
+
+// NewCursor from the store, bind and offset positions
+func NewCursor(store, bind int, offset uint64) Cursor {
+	return Cursor(uint64(store)<<(cursorOffsetBits+cursorBindBits) |
+		uint64(bind&cursorBindMask)<<cursorOffsetBits |
+		offset&cursorOffsetMask)
+}
+
+// Store index of the cursor
+func (c Cursor) Store() int {
+	return int(uint64(c) >> (cursorOffsetBits + cursorBindBits))
+}
+
+// Bind index of the cursor
+func (c Cursor) Bind() int {
+	return int(uint64(c) >> cursorOffsetBits & cursorBindMask)
+}
+
+// Offset of the cursor inside of the bind
+func (c Cursor) Offset() uint64 {
+	return uint64(c) & cursorOffsetMask
+}
+
+// WithStore returns cursor with replaced store index
+func (c Cursor) WithStore(store int) Cursor {
+	return NewCursor(store, c.Bind(), c.Offset())
+}
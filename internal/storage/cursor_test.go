@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursor(t *testing.T) {
+	assert.Equal(t, Cursor(0), NewCursor(0, 0, 0), "start cursor must be zero")
+
+	cur := NewCursor(3, 12, 1000)
+	assert.Equal(t, 3, cur.Store())
+	assert.Equal(t, 12, cur.Bind())
+	assert.Equal(t, uint64(1000), cur.Offset())
+
+	cur = cur.WithStore(0)
+	assert.Equal(t, 0, cur.Store())
+	assert.Equal(t, 12, cur.Bind())
+	assert.Equal(t, uint64(1000), cur.Offset())
+
+	cur = cur.WithStore(255)
+	assert.Equal(t, 255, cur.Store())
+	assert.Equal(t, 12, cur.Bind())
+	assert.Equal(t, uint64(1000), cur.Offset())
+}
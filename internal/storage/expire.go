@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// NoExpiration is the TTL of the key which doesn't expire
+const NoExpiration time.Duration = -1
+
+// Expirer extension of the driver which supports expiration of the keys
+type Expirer interface {
+	// Expire sets the expiration time of the key, zero time removes the expiration.
+	// Returns false if the key doesn't exist or has no expiration to remove.
+	Expire(ctx context.Context, dbnum int, key string, at time.Time) (bool, error)
+	// TTL returns the remaining time to live of the key or NoExpiration.
+	// Returns ErrNotFound if the key doesn't exist.
+	TTL(ctx context.Context, dbnum int, key string) (time.Duration, error)
+}
+
+// TTLGetter extension of the driver which returns the value with the remaining time to live
+type TTLGetter interface {
+	// GetWithTTL returns the value and the remaining time to live of the key or NoExpiration
+	GetWithTTL(ctx context.Context, dbnum int, key string) ([]byte, time.Duration, error)
+}
+
+// Expire sets the expiration time of the key if the driver supports it
+func Expire(ctx context.Context, drv Driver, dbnum int, key string, at time.Time) (bool, error) {
+	if exp, _ := drv.(Expirer); exp != nil {
+		return exp.Expire(ctx, dbnum, key, at)
+	}
+	return false, ErrMethodIsNotSupported
+}
+
+// TTL returns the remaining time to live of the key,
+// keys of the drivers without expiration support never expire
+func TTL(ctx context.Context, drv Driver, dbnum int, key string) (time.Duration, error) {
+	if exp, _ := drv.(Expirer); exp != nil {
+		return exp.TTL(ctx, dbnum, key)
+	}
+	if _, err := drv.Get(ctx, dbnum, key); err != nil {
+		return 0, err
+	}
+	return NoExpiration, nil
+}
+
+// GetWithTTL returns the value and the remaining time to live of the key
+func GetWithTTL(ctx context.Context, drv Driver, dbnum int, key string) ([]byte, time.Duration, error) {
+	if getter, _ := drv.(TTLGetter); getter != nil {
+		return getter.GetWithTTL(ctx, dbnum, key)
+	}
+	val, err := drv.Get(ctx, dbnum, key)
+	return val, NoExpiration, err
+}
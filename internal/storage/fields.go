@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+)
+
+// GetFields of the record using FieldReader if the driver supports it,
+// otherwise the whole record is loaded and filtered
+func GetFields(ctx context.Context, drv Driver, dbnum int, key string, fields []string) ([]byte, error) {
+	if fr, _ := drv.(FieldReader); fr != nil {
+		return fr.GetFields(ctx, dbnum, key, fields)
+	}
+	value, err := drv.Get(ctx, dbnum, key)
+	if err != nil {
+		return nil, err
+	}
+	return FilterFields(value, fields)
+}
+
+// FilterFields of the JSON object, values which are not objects are returned as is
+func FilterFields(value []byte, fields []string) ([]byte, error) {
+	if !bytes.HasPrefix(value, []byte("{")) {
+		return value, nil
+	}
+	var record map[string]json.RawMessage
+	if err := json.Unmarshal(value, &record); err != nil {
+		return nil, err
+	}
+	result := make(map[string]json.RawMessage, len(fields))
+	for _, name := range fields {
+		if val, ok := record[name]; ok {
+			result[name] = val
+		}
+	}
+	return json.Marshal(result)
+}
+
+// ReplaceField of the JSON object with the new value
+func ReplaceField(value []byte, field string, fieldValue any) ([]byte, error) {
+	var record map[string]json.RawMessage
+	if err := json.Unmarshal(value, &record); err != nil {
+		return nil, err
+	}
+	// Numeric values of some SQL drivers are returned as text
+	if data, ok := fieldValue.([]byte); ok {
+		fieldValue = string(data)
+	}
+	if str, ok := fieldValue.(string); ok {
+		if _, err := strconv.ParseFloat(str, 64); err == nil {
+			fieldValue = json.Number(str)
+		}
+	}
+	data, err := json.Marshal(fieldValue)
+	if err != nil {
+		return nil, err
+	}
+	record[field] = data
+	return json.Marshal(record)
+}
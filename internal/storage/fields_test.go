@@ -0,0 +1,27 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplaceField(t *testing.T) {
+	tests := []struct {
+		value  any
+		target string
+	}{
+		{value: int64(15), target: `{"name":"test","views":15}`},
+		{value: 1.5, target: `{"name":"test","views":1.5}`},
+		{value: []byte("16"), target: `{"name":"test","views":16}`},
+		{value: "text", target: `{"name":"test","views":"text"}`},
+	}
+	for _, test := range tests {
+		res, err := ReplaceField([]byte(`{"name":"test","views":10}`), "views", test.value)
+		if assert.NoError(t, err) {
+			assert.JSONEq(t, test.target, string(res))
+		}
+	}
+	_, err := ReplaceField([]byte(`"text"`), "views", 1)
+	assert.Error(t, err)
+}
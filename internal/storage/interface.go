@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"io"
+	"strings"
+	"time"
 
 	"github.com/demdxx/redify/internal/cache"
+	"github.com/demdxx/redify/internal/keypattern"
 )
 
 var (
@@ -14,20 +17,82 @@ var (
 	ErrReadOnly             = errors.New("readonly access")
 	ErrInvalidBindConfig    = errors.New("invalid bind config")
 	ErrMethodIsNotSupported = errors.New("method is not supported")
+	ErrWrongType            = errors.New("operation against a key holding the wrong kind of value")
+	ErrExpireIsNotSupported = errors.New("expiration is not supported by the key storage")
+)
+
+// Key types reported by the binds
+const (
+	KeyTypeString = "string"
+	KeyTypeHash   = "hash"
+	KeyTypeList   = "list"
+	KeyTypeSet    = "set"
+	KeyTypeZSet   = "zset"
 )
 
 type BindConfig struct {
-	Pattern          string           `json:"pattern" xml:"pattern" yaml:"pattern" toml:"pattern"`
-	DBNum            int              `json:"dbnum" xml:"dbnum" yaml:"dbnum" toml:"dbnum"`
-	TableName        string           `json:"table_name" xml:"table_name" yaml:"table_name" toml:"table_name"`
-	Readonly         bool             `json:"readonly" xml:"readonly" yaml:"readonly" toml:"readonly"`
-	WhereExt         string           `json:"where_ext" xml:"where_ext" yaml:"where_ext" toml:"where_ext"`
-	GetQuery         string           `json:"get_query" xml:"get_query" yaml:"get_query" toml:"get_query"`
-	ListQuery        string           `json:"list_query" xml:"list_query" yaml:"list_query" toml:"list_query"`
-	UpsertQuery      string           `json:"upsert_query" xml:"upsert_query" yaml:"upsert_query" toml:"upsert_query"`
-	DelQuery         string           `json:"del_query" xml:"del_query" yaml:"del_query" toml:"del_query"`
-	ReorganizeNested bool             `json:"reorganize_nested" xml:"reorganize_nested" yaml:"reorganize_nested" toml:"reorganize_nested"`
-	DatatypeMapping  []DatatypeMapper `json:"datatype_mapping" xml:"datatype_mapping" yaml:"datatype_mapping" toml:"datatype_mapping"`
+	Pattern           string           `json:"pattern" xml:"pattern" yaml:"pattern" toml:"pattern"`
+	DBNum             int              `json:"dbnum" xml:"dbnum" yaml:"dbnum" toml:"dbnum"`
+	Type              string           `json:"type" xml:"type" yaml:"type" toml:"type"`
+	TableName         string           `json:"table_name" xml:"table_name" yaml:"table_name" toml:"table_name"`
+	Readonly          bool             `json:"readonly" xml:"readonly" yaml:"readonly" toml:"readonly"`
+	WhereExt          string           `json:"where_ext" xml:"where_ext" yaml:"where_ext" toml:"where_ext"`
+	GetQuery          string           `json:"get_query" xml:"get_query" yaml:"get_query" toml:"get_query"`
+	ListQuery         string           `json:"list_query" xml:"list_query" yaml:"list_query" toml:"list_query"`
+	UpsertQuery       string           `json:"upsert_query" xml:"upsert_query" yaml:"upsert_query" toml:"upsert_query"`
+	InsertQuery       string           `json:"insert_query" xml:"insert_query" yaml:"insert_query" toml:"insert_query"`
+	UpdateQuery       string           `json:"update_query" xml:"update_query" yaml:"update_query" toml:"update_query"`
+	UpdateRecordQuery string           `json:"update_record_query" xml:"update_record_query" yaml:"update_record_query" toml:"update_record_query"`
+	DelQuery          string           `json:"del_query" xml:"del_query" yaml:"del_query" toml:"del_query"`
+	MemberColumn      string           `json:"member_column" xml:"member_column" yaml:"member_column" toml:"member_column"`
+	ScoreColumn       string           `json:"score_column" xml:"score_column" yaml:"score_column" toml:"score_column"`
+	OrderBy           string           `json:"order_by" xml:"order_by" yaml:"order_by" toml:"order_by"`
+	AddQuery          string           `json:"add_query" xml:"add_query" yaml:"add_query" toml:"add_query"`
+	RemQuery          string           `json:"rem_query" xml:"rem_query" yaml:"rem_query" toml:"rem_query"`
+	CounterColumn     string           `json:"counter_column" xml:"counter_column" yaml:"counter_column" toml:"counter_column"`
+	IncrQuery         string           `json:"incr_query" xml:"incr_query" yaml:"incr_query" toml:"incr_query"`
+	TTLColumn         string           `json:"ttl_column" xml:"ttl_column" yaml:"ttl_column" toml:"ttl_column"`
+	TTLSweepInterval  time.Duration    `json:"ttl_sweep_interval" xml:"ttl_sweep_interval" yaml:"ttl_sweep_interval" toml:"ttl_sweep_interval"`
+	TTLSweepQuery     string           `json:"ttl_sweep_query" xml:"ttl_sweep_query" yaml:"ttl_sweep_query" toml:"ttl_sweep_query"`
+	ReorganizeNested  bool             `json:"reorganize_nested" xml:"reorganize_nested" yaml:"reorganize_nested" toml:"reorganize_nested"`
+	DatatypeMapping   []DatatypeMapper `json:"datatype_mapping" xml:"datatype_mapping" yaml:"datatype_mapping" toml:"datatype_mapping"`
+
+	// Cache of the bind keys instead of the cache of the source, NoCache bypasses any cache
+	Cache   Cacher `json:"-" xml:"-" yaml:"-" toml:"-"`
+	NoCache bool   `json:"no_cache" xml:"no_cache" yaml:"no_cache" toml:"no_cache"`
+	// NegativeTTL caches the missing keys of the bind, zero disables it
+	NegativeTTL time.Duration `json:"negative_ttl" xml:"negative_ttl" yaml:"negative_ttl" toml:"negative_ttl"`
+}
+
+// DefaultScanCount is the number of keys per SCAN page if not specified
+const DefaultScanCount = 10
+
+// ScanOptions of the cursor based key iteration
+type ScanOptions struct {
+	Pattern string // Glob pattern of the keys, empty or `*` means any key
+	Count   int    // Hint of the number of keys returned per page
+	Type    string // Type of the keys, empty means any type
+}
+
+// PageSize returns the count of keys per page or default value
+func (opts *ScanOptions) PageSize() int {
+	if opts.Count <= 0 {
+		return DefaultScanCount
+	}
+	return opts.Count
+}
+
+// MatchType of the key
+func (opts *ScanOptions) MatchType(tp string) bool {
+	return opts.Type == "" || strings.EqualFold(opts.Type, tp)
+}
+
+// MatchKey by the glob pattern
+func (opts *ScanOptions) MatchKey(key string) bool {
+	if opts.Pattern == "" || opts.Pattern == "*" {
+		return true
+	}
+	return keypattern.GlobMatch(opts.Pattern, key)
 }
 
 // Driver storage description
@@ -38,9 +103,43 @@ type Driver interface {
 	Del(ctx context.Context, dbnum int, key string) error
 	Keys(ctx context.Context, dbnum int, pattern string) ([]string, error)
 	List(ctx context.Context, dbnum int, pattern string) ([]Record, error)
+	// Scan keys page by page starting from the cursor position.
+	// Returns the list of keys and the next cursor, zero cursor means the end of the iteration.
+	Scan(ctx context.Context, dbnum int, cursor uint64, opts ScanOptions) ([]string, uint64, error)
 	Bind(ctx context.Context, conf *BindConfig) error
 }
 
+// FieldReader extension of the driver which can load only requested fields of the record
+type FieldReader interface {
+	// GetFields returns JSON object of the record which contains only the requested fields
+	GetFields(ctx context.Context, dbnum int, key string, fields []string) ([]byte, error)
+}
+
+// FieldWriter extension of the driver to modify separate fields of the record
+type FieldWriter interface {
+	// SetFields updates fields of the record and returns the number of affected records
+	SetFields(ctx context.Context, dbnum int, key string, fields map[string]string) (int64, error)
+	// DelFields resets fields of the record to NULL and returns the number of affected records
+	DelFields(ctx context.Context, dbnum int, key string, fields []string) (int64, error)
+	// IncrField increments the numeric field atomically and returns the new value
+	IncrField(ctx context.Context, dbnum int, key, field string, delta any) (any, error)
+}
+
+// Incrementer extension of the driver which supports atomic counters of the keys
+type Incrementer interface {
+	// IncrBy increments the counter of the key atomically and returns
+	// the field of the record which contains the counter and the new value
+	IncrBy(ctx context.Context, dbnum int, key string, delta any) (string, any, error)
+}
+
+// IncrBy increments the counter of the key if the driver supports it
+func IncrBy(ctx context.Context, drv Driver, dbnum int, key string, delta any) (string, any, error) {
+	if incr, _ := drv.(Incrementer); incr != nil {
+		return incr.IncrBy(ctx, dbnum, key, delta)
+	}
+	return "", nil, ErrMethodIsNotSupported
+}
+
 // Cacher manage interface
 type Cacher = cache.Cacher
 
@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+// ErrJSONRootRequired is returned if the new record is set by the path other than root
+var ErrJSONRootRequired = errors.New("new objects must be created at the root")
+
+// JSONReader extension of the driver which evaluates the JSON path in the database
+type JSONReader interface {
+	// GetJSONPath returns the values of the record matched by the path
+	GetJSONPath(ctx context.Context, dbnum int, key string, path *JSONPath) ([]json.RawMessage, error)
+}
+
+// GetJSONPath values of the record using JSONReader if the driver supports it,
+// otherwise the whole record is loaded and evaluated
+func GetJSONPath(ctx context.Context, drv Driver, dbnum int, key string, path *JSONPath) ([]json.RawMessage, error) {
+	if jr, _ := drv.(JSONReader); jr != nil {
+		return jr.GetJSONPath(ctx, dbnum, key, path)
+	}
+	value, err := drv.Get(ctx, dbnum, key)
+	if err != nil {
+		return nil, err
+	}
+	return EvalJSONPath(value, path)
+}
+
+// EvalJSONPath returns the values of the JSON document matched by the path
+func EvalJSONPath(value []byte, path *JSONPath) ([]json.RawMessage, error) {
+	doc, err := DecodeJSON(value)
+	if err != nil {
+		return nil, err
+	}
+	matches := path.Get(doc)
+	res := make([]json.RawMessage, 0, len(matches))
+	for _, match := range matches {
+		data, err := json.Marshal(match)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, data)
+	}
+	return res, nil
+}
+
+// SetJSONPath updates the values of the record matched by the path.
+// The root path replaces the whole record keeping its TTL, other paths
+// change only the modified top-level fields by FieldWriter if the driver supports it.
+// Returns false if the NX/XX condition was not met or the path doesn't match.
+func SetJSONPath(ctx context.Context, drv Driver, dbnum int, key string, path *JSONPath, value []byte, mode SetMode) (bool, error) {
+	newValue, err := DecodeJSON(value)
+	if err != nil {
+		return false, err
+	}
+	if path.IsRoot() {
+		data, err := json.Marshal(newValue)
+		if err != nil {
+			return false, err
+		}
+		res, err := SetWithOptions(ctx, drv, dbnum, key, data, SetOptions{Mode: mode, KeepTTL: true})
+		return res.Applied, err
+	}
+	count, err := updateJSONRecord(ctx, drv, dbnum, key, func(doc any) (any, int) {
+		exists := len(path.Get(doc)) > 0
+		if (mode == SetIfNotExists && exists) || (mode == SetIfExists && !exists) {
+			return doc, 0
+		}
+		return path.Set(doc, newValue)
+	})
+	if errors.Is(err, ErrNotFound) {
+		return false, ErrJSONRootRequired
+	}
+	return count > 0, err
+}
+
+// DelJSONPath deletes the values of the record matched by the path
+// and returns the number of the deleted values
+func DelJSONPath(ctx context.Context, drv Driver, dbnum int, key string, path *JSONPath) (int, error) {
+	if path.IsRoot() {
+		if _, err := drv.Get(ctx, dbnum, key); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return 0, nil
+			}
+			return 0, err
+		}
+		if err := drv.Del(ctx, dbnum, key); err != nil {
+			return 0, err
+		}
+		return 1, nil
+	}
+	count, err := updateJSONRecord(ctx, drv, dbnum, key, path.Del)
+	if errors.Is(err, ErrNotFound) {
+		return 0, nil
+	}
+	return count, err
+}
+
+// updateJSONRecord modifies the decoded record inside the transaction if the driver supports it
+func updateJSONRecord(ctx context.Context, drv Driver, dbnum int, key string, update func(doc any) (any, int)) (int, error) {
+	if !InTx(ctx) {
+		txCtx, tx, err := BeginTx(ctx, drv, dbnum, []string{key})
+		switch {
+		case err == nil:
+			count, err := updateJSONRecord(txCtx, drv, dbnum, key, update)
+			if err != nil {
+				_ = tx.Rollback()
+				return 0, err
+			}
+			return count, tx.Commit()
+		case !errors.Is(err, ErrMethodIsNotSupported):
+			return 0, err
+		}
+	}
+	value, err := drv.Get(ctx, dbnum, key)
+	if err != nil {
+		return 0, err
+	}
+	prev, err := DecodeJSON(value)
+	if err != nil {
+		return 0, err
+	}
+	// The update modifies the document in place, so the previous one is decoded separately
+	doc, _ := DecodeJSON(value)
+	doc, count := update(doc)
+	if count == 0 {
+		return 0, nil
+	}
+	return count, writeJSONRecord(ctx, drv, dbnum, key, prev, doc)
+}
+
+// writeJSONRecord stores the changed top-level fields of the object by FieldWriter
+// or the whole document if the driver or the value doesn't support it
+func writeJSONRecord(ctx context.Context, drv Driver, dbnum int, key string, prev, doc any) error {
+	prevObj, _ := prev.(map[string]any)
+	obj, isObj := doc.(map[string]any)
+	fw, _ := drv.(FieldWriter)
+	if fw == nil || prevObj == nil || !isObj {
+		data, err := json.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		return drv.Set(ctx, dbnum, key, data)
+	}
+	var (
+		set = map[string]string{}
+		del []string
+	)
+	for name, val := range obj {
+		prevVal, ok := prevObj[name]
+		if ok && equalJSON(prevVal, val) {
+			continue
+		}
+		if val == nil {
+			del = append(del, name)
+		} else {
+			set[name] = jsonFieldValue(val)
+		}
+	}
+	for name := range prevObj {
+		if _, ok := obj[name]; !ok {
+			del = append(del, name)
+		}
+	}
+	if len(set) > 0 {
+		if _, err := fw.SetFields(ctx, dbnum, key, set); err != nil {
+			return err
+		}
+	}
+	if len(del) > 0 {
+		if _, err := fw.DelFields(ctx, dbnum, key, del); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func equalJSON(a, b any) bool {
+	adata, aerr := json.Marshal(a)
+	bdata, berr := json.Marshal(b)
+	return aerr == nil && berr == nil && bytes.Equal(adata, bdata)
+}
+
+// jsonFieldValue returns the field value as SetFields expects it,
+// nested objects and arrays are stored as JSON text
+func jsonFieldValue(val any) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	data, _ := json.Marshal(val)
+	return string(data)
+}
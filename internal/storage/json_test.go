@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fieldsDriver keeps the records in memory and updates them by the fields
+type fieldsDriver struct {
+	Driver
+	records map[string]map[string]any
+	set     map[string]string
+	del     []string
+}
+
+func (d *fieldsDriver) Get(_ context.Context, _ int, key string) ([]byte, error) {
+	rec, ok := d.records[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return json.Marshal(rec)
+}
+
+func (d *fieldsDriver) Set(_ context.Context, _ int, key string, value []byte) error {
+	var rec map[string]any
+	if err := json.Unmarshal(value, &rec); err != nil {
+		return err
+	}
+	d.records[key] = rec
+	return nil
+}
+
+func (d *fieldsDriver) Del(_ context.Context, _ int, key string) error {
+	delete(d.records, key)
+	return nil
+}
+
+func (d *fieldsDriver) SetFields(_ context.Context, _ int, key string, fields map[string]string) (int64, error) {
+	d.set = fields
+	for name, value := range fields {
+		d.records[key][name] = value
+	}
+	return 1, nil
+}
+
+func (d *fieldsDriver) DelFields(_ context.Context, _ int, key string, fields []string) (int64, error) {
+	d.del = fields
+	for _, name := range fields {
+		d.records[key][name] = nil
+	}
+	return 1, nil
+}
+
+func (d *fieldsDriver) IncrField(context.Context, int, string, string, any) (any, error) {
+	return nil, ErrMethodIsNotSupported
+}
+
+func TestSetJSONPath(t *testing.T) {
+	ctx := context.Background()
+	drv := &fieldsDriver{records: map[string]map[string]any{
+		"user_1": {"name": "test", "age": 30, "profile": map[string]any{"city": "Berlin"}},
+	}}
+	path, _ := ParseJSONPath("$.profile.city")
+
+	ok, err := SetJSONPath(ctx, drv, 0, "user_1", path, []byte(`"Paris"`), SetAlways)
+	if assert.NoError(t, err) && assert.True(t, ok) {
+		// Only the changed top-level field is written as JSON text
+		assert.Equal(t, map[string]string{"profile": `{"city":"Paris"}`}, drv.set)
+	}
+
+	ok, err = SetJSONPath(ctx, drv, 0, "user_1", path, []byte(`"Rome"`), SetIfNotExists)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	path, _ = ParseJSONPath("$.age")
+	ok, err = SetJSONPath(ctx, drv, 0, "user_1", path, []byte(`31`), SetIfExists)
+	if assert.NoError(t, err) && assert.True(t, ok) {
+		assert.Equal(t, map[string]string{"age": "31"}, drv.set)
+	}
+
+	_, err = SetJSONPath(ctx, drv, 0, "user_2", path, []byte(`1`), SetAlways)
+	assert.ErrorIs(t, err, ErrJSONRootRequired)
+
+	count, err := DelJSONPath(ctx, drv, 0, "user_1", path)
+	if assert.NoError(t, err) && assert.Equal(t, 1, count) {
+		assert.Equal(t, []string{"age"}, drv.del)
+	}
+
+	root, _ := ParseJSONPath("$")
+	count, err = DelJSONPath(ctx, drv, 0, "user_1", root)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	count, err = DelJSONPath(ctx, drv, 0, "user_1", root)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
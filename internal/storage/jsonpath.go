@@ -0,0 +1,332 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidJSONPath in case of the path can't be parsed or uses unsupported syntax
+var ErrInvalidJSONPath = errors.New("invalid JSON path")
+
+// JSONPath of the RedisJSON commands.
+// Supported syntax: `$` root, `.name` and `['name']` members, `[index]` with negative
+// indexes counted from the end, `.*` and `[*]` wildcards.
+// Legacy paths without `$` (`.`, `.name`, `name[0]`) select the single value.
+type JSONPath struct {
+	Legacy bool
+	steps  []jsonPathStep
+}
+
+type jsonPathStep struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// ParseJSONPath of the RedisJSON command
+func ParseJSONPath(path string) (*JSONPath, error) {
+	res := &JSONPath{}
+	switch {
+	case path == "$":
+		return res, nil
+	case strings.HasPrefix(path, "$"):
+		path = path[1:]
+	default:
+		res.Legacy = true
+		if path == "." {
+			return res, nil
+		}
+		if !strings.HasPrefix(path, ".") && !strings.HasPrefix(path, "[") {
+			path = "." + path
+		}
+	}
+	for len(path) > 0 {
+		var (
+			step jsonPathStep
+			err  error
+		)
+		switch path[0] {
+		case '.':
+			step, path, err = parseJSONPathMember(path[1:])
+		case '[':
+			step, path, err = parseJSONPathBracket(path[1:])
+		default:
+			err = ErrInvalidJSONPath
+		}
+		if err != nil {
+			return nil, err
+		}
+		res.steps = append(res.steps, step)
+	}
+	return res, nil
+}
+
+func parseJSONPathMember(path string) (jsonPathStep, string, error) {
+	end := strings.IndexAny(path, ".[")
+	if end < 0 {
+		end = len(path)
+	}
+	name := path[:end]
+	switch name {
+	case "":
+		// Recursive descent `..` is not supported
+		return jsonPathStep{}, "", ErrInvalidJSONPath
+	case "*":
+		return jsonPathStep{wildcard: true}, path[end:], nil
+	}
+	return jsonPathStep{key: name}, path[end:], nil
+}
+
+func parseJSONPathBracket(path string) (jsonPathStep, string, error) {
+	if len(path) > 0 && (path[0] == '\'' || path[0] == '"') {
+		end := strings.IndexByte(path[1:], path[0])
+		if end < 0 || !strings.HasPrefix(path[end+2:], "]") {
+			return jsonPathStep{}, "", ErrInvalidJSONPath
+		}
+		return jsonPathStep{key: path[1 : end+1]}, path[end+3:], nil
+	}
+	end := strings.IndexByte(path, ']')
+	if end < 0 {
+		return jsonPathStep{}, "", ErrInvalidJSONPath
+	}
+	if path[:end] == "*" {
+		return jsonPathStep{wildcard: true}, path[end+1:], nil
+	}
+	index, err := strconv.Atoi(strings.TrimSpace(path[:end]))
+	if err != nil {
+		return jsonPathStep{}, "", ErrInvalidJSONPath
+	}
+	return jsonPathStep{index: index, isIndex: true}, path[end+1:], nil
+}
+
+// IsRoot returns true if the path selects the whole document
+func (p *JSONPath) IsRoot() bool {
+	return len(p.steps) == 0
+}
+
+// Simple returns the members and non-negative indexes of the path
+// which starts from the member and has no wildcards
+func (p *JSONPath) Simple() ([]string, bool) {
+	if p.IsRoot() || p.steps[0].isIndex || p.steps[0].wildcard {
+		return nil, false
+	}
+	keys := make([]string, 0, len(p.steps))
+	for _, step := range p.steps {
+		switch {
+		case step.wildcard, step.isIndex && step.index < 0:
+			return nil, false
+		case step.isIndex:
+			keys = append(keys, strconv.Itoa(step.index))
+		default:
+			keys = append(keys, step.key)
+		}
+	}
+	return keys, true
+}
+
+// Get values of the document matched by the path
+func (p *JSONPath) Get(doc any) []any {
+	nodes := []any{doc}
+	for _, step := range p.steps {
+		next := make([]any, 0, len(nodes))
+		for _, node := range nodes {
+			next = step.children(node, next)
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// Set the value at every match of the path and returns the new document
+// and the number of the updated values. The last member of the path
+// is created if the object doesn't contain it.
+func (p *JSONPath) Set(doc, value any) (any, int) {
+	return setJSONPath(doc, p.steps, value)
+}
+
+// Del values matched by the path and returns the new document
+// and the number of the deleted values
+func (p *JSONPath) Del(doc any) (any, int) {
+	if p.IsRoot() {
+		return nil, 1
+	}
+	return delJSONPath(doc, p.steps)
+}
+
+func (step *jsonPathStep) children(node any, res []any) []any {
+	switch v := node.(type) {
+	case map[string]any:
+		if step.wildcard {
+			for _, key := range sortedJSONKeys(v) {
+				res = append(res, v[key])
+			}
+		} else if val, ok := v[step.key]; ok && !step.isIndex {
+			res = append(res, val)
+		}
+	case []any:
+		if step.wildcard {
+			return append(res, v...)
+		}
+		if i, ok := step.arrayIndex(v); ok {
+			res = append(res, v[i])
+		}
+	}
+	return res
+}
+
+func (step *jsonPathStep) arrayIndex(arr []any) (int, bool) {
+	if !step.isIndex {
+		return 0, false
+	}
+	i := step.index
+	if i < 0 {
+		i += len(arr)
+	}
+	return i, i >= 0 && i < len(arr)
+}
+
+func setJSONPath(node any, steps []jsonPathStep, value any) (any, int) {
+	if len(steps) == 0 {
+		return value, 1
+	}
+	var (
+		step  = steps[0]
+		count int
+	)
+	switch v := node.(type) {
+	case map[string]any:
+		keys := []string{step.key}
+		if step.wildcard {
+			keys = sortedJSONKeys(v)
+		} else if step.isIndex {
+			return node, 0
+		}
+		for _, key := range keys {
+			child, ok := v[key]
+			if !ok && len(steps) > 1 {
+				continue
+			}
+			newChild, n := setJSONPath(child, steps[1:], value)
+			if n > 0 {
+				v[key] = newChild
+				count += n
+			}
+		}
+	case []any:
+		idx, ok := step.arrayIndex(v)
+		for i := range v {
+			if !step.wildcard && (!ok || idx != i) {
+				continue
+			}
+			newChild, n := setJSONPath(v[i], steps[1:], value)
+			v[i] = newChild
+			count += n
+		}
+	}
+	return node, count
+}
+
+func delJSONPath(node any, steps []jsonPathStep) (any, int) {
+	var (
+		step  = steps[0]
+		last  = len(steps) == 1
+		count int
+	)
+	switch v := node.(type) {
+	case map[string]any:
+		keys := []string{step.key}
+		if step.wildcard {
+			keys = sortedJSONKeys(v)
+		} else if step.isIndex {
+			return node, 0
+		}
+		for _, key := range keys {
+			child, ok := v[key]
+			switch {
+			case !ok:
+			case last:
+				delete(v, key)
+				count++
+			default:
+				newChild, n := delJSONPath(child, steps[1:])
+				v[key] = newChild
+				count += n
+			}
+		}
+	case []any:
+		if last && step.wildcard {
+			return v[:0], len(v)
+		}
+		idx, ok := step.arrayIndex(v)
+		if last {
+			if !ok {
+				return node, 0
+			}
+			return slices.Delete(v, idx, idx+1), 1
+		}
+		for i := range v {
+			if !step.wildcard && (!ok || idx != i) {
+				continue
+			}
+			newChild, n := delJSONPath(v[i], steps[1:])
+			v[i] = newChild
+			count += n
+		}
+	}
+	return node, count
+}
+
+func sortedJSONKeys(obj map[string]any) []string {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// DecodeJSON value keeping the numbers as json.Number
+func DecodeJSON(data []byte) (any, error) {
+	var (
+		value any
+		dec   = json.NewDecoder(bytes.NewReader(data))
+	)
+	dec.UseNumber()
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("invalid JSON value")
+	}
+	return value, nil
+}
+
+// JSONType of the decoded value as JSON.TYPE reports it
+func JSONType(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case float32, float64:
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return "integer"
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseJSONPath(t *testing.T) {
+	tests := []struct {
+		path   string
+		legacy bool
+		simple []string
+		err    error
+	}{
+		{path: "$", simple: nil},
+		{path: ".", legacy: true, simple: nil},
+		{path: "$.profile.tags[0]", simple: []string{"profile", "tags", "0"}},
+		{path: "profile.name", legacy: true, simple: []string{"profile", "name"}},
+		{path: `$['first name']`, simple: []string{"first name"}},
+		{path: "$.tags[-1]"},
+		{path: "$.tags[*]"},
+		{path: "$[0]"},
+		{path: "$..name", err: ErrInvalidJSONPath},
+		{path: "$.tags[x]", err: ErrInvalidJSONPath},
+		{path: "$['name", err: ErrInvalidJSONPath},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			path, err := ParseJSONPath(test.path)
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, test.legacy, path.Legacy)
+			simple, _ := path.Simple()
+			assert.Equal(t, test.simple, simple)
+		})
+	}
+}
+
+func TestJSONPathGet(t *testing.T) {
+	value := []byte(`{"name":"test","tags":["a","b"],"profile":{"age":30,"score":1.5}}`)
+	tests := []struct {
+		path   string
+		target string
+	}{
+		{path: "$", target: `[{"name":"test","profile":{"age":30,"score":1.5},"tags":["a","b"]}]`},
+		{path: "$.name", target: `["test"]`},
+		{path: "$.tags[-1]", target: `["b"]`},
+		{path: "$.tags[*]", target: `["a","b"]`},
+		{path: "$.profile.*", target: `[30,1.5]`},
+		{path: "$.missing", target: `[]`},
+		{path: ".profile.age", target: `[30]`},
+	}
+	for _, test := range tests {
+		path, err := ParseJSONPath(test.path)
+		if !assert.NoError(t, err, test.path) {
+			continue
+		}
+		values, err := EvalJSONPath(value, path)
+		if assert.NoError(t, err, test.path) {
+			data, _ := json.Marshal(values)
+			assert.JSONEq(t, test.target, string(data), test.path)
+		}
+	}
+}
+
+func TestJSONPathModify(t *testing.T) {
+	tests := []struct {
+		path   string
+		del    bool
+		count  int
+		target string
+	}{
+		{path: "$.name", count: 1, target: `{"name":1,"tags":["a","b"],"profile":{"age":30}}`},
+		{path: "$.profile.city", count: 1, target: `{"name":"test","tags":["a","b"],"profile":{"age":30,"city":1}}`},
+		{path: "$.profile.address.city", count: 0, target: `{"name":"test","tags":["a","b"],"profile":{"age":30}}`},
+		{path: "$.tags[*]", count: 2, target: `{"name":"test","tags":[1,1],"profile":{"age":30}}`},
+		{path: "$.tags[0]", del: true, count: 1, target: `{"name":"test","tags":["b"],"profile":{"age":30}}`},
+		{path: "$.profile", del: true, count: 1, target: `{"name":"test","tags":["a","b"]}`},
+		{path: "$.tags[5]", del: true, count: 0, target: `{"name":"test","tags":["a","b"],"profile":{"age":30}}`},
+	}
+	for _, test := range tests {
+		path, err := ParseJSONPath(test.path)
+		if !assert.NoError(t, err, test.path) {
+			continue
+		}
+		doc, _ := DecodeJSON([]byte(`{"name":"test","tags":["a","b"],"profile":{"age":30}}`))
+		var count int
+		if test.del {
+			doc, count = path.Del(doc)
+		} else {
+			doc, count = path.Set(doc, json.Number("1"))
+		}
+		assert.Equal(t, test.count, count, test.path)
+		data, _ := json.Marshal(doc)
+		assert.JSONEq(t, test.target, string(data), test.path)
+	}
+}
+
+func TestJSONType(t *testing.T) {
+	doc, _ := DecodeJSON([]byte(`[null,true,"s",1,1.5,[],{}]`))
+	types := []string{}
+	for _, value := range doc.([]any) {
+		types = append(types, JSONType(value))
+	}
+	assert.Equal(t, []string{"null", "boolean", "string", "integer", "number", "array", "object"}, types)
+}
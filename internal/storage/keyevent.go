@@ -0,0 +1,68 @@
+package storage
+
+import "context"
+
+// Events of the keyspace notifications
+const (
+	KeyEventSet          = "set"
+	KeyEventDel          = "del"
+	KeyEventExpired      = "expired"
+	KeyEventExpire       = "expire"
+	KeyEventPersist      = "persist"
+	KeyEventIncrBy       = "incrby"
+	KeyEventIncrByFloat  = "incrbyfloat"
+	KeyEventHSet         = "hset"
+	KeyEventHDel         = "hdel"
+	KeyEventHIncrBy      = "hincrby"
+	KeyEventHIncrByFloat = "hincrbyfloat"
+	KeyEventRPush        = "rpush"
+	KeyEventSAdd         = "sadd"
+	KeyEventSRem         = "srem"
+	KeyEventZAdd         = "zadd"
+	KeyEventZIncr        = "zincr"
+	KeyEventZRem         = "zrem"
+	KeyEventXAdd         = "xadd"
+	KeyEventJSONSet      = "json.set"
+	KeyEventJSONDel      = "json.del"
+)
+
+// KeyEvent describes the change of the key
+type KeyEvent struct {
+	DBNum int
+	Key   string
+	Event string
+}
+
+// KeyEventNotifier extension of the driver which reports the changes of the keys
+// made in the database directly
+type KeyEventNotifier interface {
+	// OnKeyEvent registers the callback which is called for every changed key
+	OnKeyEvent(fn func(ctx context.Context, event KeyEvent))
+}
+
+// KeyEventReporter extension of the driver which reports the changes made
+// by redify too, like the notification triggers of the database do
+type KeyEventReporter interface {
+	// ReportsKeyEvents returns true if every change of the key is reported by OnKeyEvent
+	ReportsKeyEvents(dbnum int, key string) bool
+}
+
+// ReportsKeyEvents returns true if the driver reports every change of the key itself
+func ReportsKeyEvents(driver Driver, dbnum int, key string) bool {
+	r, _ := driver.(KeyEventReporter)
+	return r != nil && r.ReportsKeyEvents(dbnum, key)
+}
+
+// ExpiredEventsSwitch extension of the driver which reports the expired keys
+// only on demand because it slows down the deletion of the expired records
+type ExpiredEventsSwitch interface {
+	// EnableExpiredEvents turns the expired events of the driver on or off
+	EnableExpiredEvents(enabled bool)
+}
+
+// EnableExpiredEvents of the driver if it supports the switch
+func EnableExpiredEvents(driver Driver, enabled bool) {
+	if sw, _ := driver.(ExpiredEventsSwitch); sw != nil {
+		sw.EnableExpiredEvents(enabled)
+	}
+}
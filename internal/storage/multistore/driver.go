@@ -2,6 +2,8 @@ package multistore
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 
 	"go.uber.org/multierr"
 
@@ -30,6 +32,68 @@ func (d *Driver) Get(ctx context.Context, dbnum int, key string) (value []byte,
 	return nil, storage.ErrNoKey
 }
 
+// GetFields of the record from the first store which has the bind
+func (d *Driver) GetFields(ctx context.Context, dbnum int, key string, fields []string) ([]byte, error) {
+	for _, st := range d.stores {
+		value, err := storage.GetFields(ctx, st, dbnum, key, fields)
+		if err == storage.ErrNoKey {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+	return nil, storage.ErrNoKey
+}
+
+// GetJSONPath values of the record from the first store which has the bind
+func (d *Driver) GetJSONPath(ctx context.Context, dbnum int, key string, path *storage.JSONPath) ([]json.RawMessage, error) {
+	for _, st := range d.stores {
+		values, err := storage.GetJSONPath(ctx, st, dbnum, key, path)
+		if err == storage.ErrNoKey {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return values, nil
+	}
+	return nil, storage.ErrNoKey
+}
+
+// GetMany values from the stores, every key is served by the first store which has the bind
+func (d *Driver) GetMany(ctx context.Context, dbnum int, keys []string) []storage.BatchItem {
+	var (
+		items   = make([]storage.BatchItem, len(keys))
+		pending = make([]int, len(keys))
+	)
+	for i := range keys {
+		pending[i] = i
+		items[i].Err = storage.ErrNoKey
+	}
+	for _, st := range d.stores {
+		if len(pending) == 0 {
+			break
+		}
+		stKeys := make([]string, len(pending))
+		for j, i := range pending {
+			stKeys[j] = keys[i]
+		}
+		stItems := storage.GetMany(ctx, st, dbnum, stKeys)
+		nextPending := pending[:0]
+		for j, i := range pending {
+			if stItems[j].Err == storage.ErrNoKey {
+				nextPending = append(nextPending, i)
+				continue
+			}
+			items[i] = stItems[j]
+		}
+		pending = nextPending
+	}
+	return items
+}
+
 func (d *Driver) Set(ctx context.Context, dbnum int, key string, value []byte) (err error) {
 	for _, st := range d.stores {
 		serr := st.Set(ctx, dbnum, key, value)
@@ -43,6 +107,292 @@ func (d *Driver) Set(ctx context.Context, dbnum int, key string, value []byte) (
 	return err
 }
 
+// SetWithOptions writes the value into the first store which serves the key
+func (d *Driver) SetWithOptions(ctx context.Context, dbnum int, key string, value []byte, opts storage.SetOptions) (storage.SetResult, error) {
+	for _, st := range d.stores {
+		res, err := storage.SetWithOptions(ctx, st, dbnum, key, value, opts)
+		if err == storage.ErrNoKey {
+			continue
+		}
+		return res, err
+	}
+	return storage.SetResult{}, storage.ErrNoKey
+}
+
+// Expire sets the expiration time of the key in the first store which serves the key
+func (d *Driver) Expire(ctx context.Context, dbnum int, key string, at time.Time) (bool, error) {
+	for _, st := range d.stores {
+		ok, err := storage.Expire(ctx, st, dbnum, key, at)
+		if err == storage.ErrNoKey {
+			continue
+		}
+		return ok, err
+	}
+	return false, storage.ErrNoKey
+}
+
+// TTL returns the remaining time to live of the key from the first store which serves the key
+func (d *Driver) TTL(ctx context.Context, dbnum int, key string) (time.Duration, error) {
+	for _, st := range d.stores {
+		ttl, err := storage.TTL(ctx, st, dbnum, key)
+		if err == storage.ErrNoKey {
+			continue
+		}
+		return ttl, err
+	}
+	return 0, storage.ErrNoKey
+}
+
+// IncrBy increments the counter of the key in the first store which serves the key
+func (d *Driver) IncrBy(ctx context.Context, dbnum int, key string, delta any) (string, any, error) {
+	for _, st := range d.stores {
+		field, value, err := storage.IncrBy(ctx, st, dbnum, key, delta)
+		if err == storage.ErrNoKey {
+			continue
+		}
+		return field, value, err
+	}
+	return "", nil, storage.ErrNoKey
+}
+
+func (d *Driver) SetFields(ctx context.Context, dbnum int, key string, fields map[string]string) (int64, error) {
+	for _, st := range d.fieldWriters() {
+		count, err := st.SetFields(ctx, dbnum, key, fields)
+		if err == storage.ErrNoKey {
+			continue
+		}
+		return count, err
+	}
+	return 0, storage.ErrNoKey
+}
+
+func (d *Driver) DelFields(ctx context.Context, dbnum int, key string, fields []string) (int64, error) {
+	for _, st := range d.fieldWriters() {
+		count, err := st.DelFields(ctx, dbnum, key, fields)
+		if err == storage.ErrNoKey {
+			continue
+		}
+		return count, err
+	}
+	return 0, storage.ErrNoKey
+}
+
+func (d *Driver) IncrField(ctx context.Context, dbnum int, key, field string, delta any) (any, error) {
+	for _, st := range d.fieldWriters() {
+		value, err := st.IncrField(ctx, dbnum, key, field, delta)
+		if err == storage.ErrNoKey {
+			continue
+		}
+		return value, err
+	}
+	return nil, storage.ErrNoKey
+}
+
+// Range of the list members from the first store which serves the key
+func (d *Driver) Range(ctx context.Context, dbnum int, key string, start, stop int64) ([]string, error) {
+	for _, st := range d.collectionReaders() {
+		members, err := st.Range(ctx, dbnum, key, start, stop)
+		if err == storage.ErrNoKey {
+			continue
+		}
+		return members, err
+	}
+	return nil, storage.ErrNoKey
+}
+
+// Len returns the number of the collection members from the first store which serves the key
+func (d *Driver) Len(ctx context.Context, dbnum int, key string) (int64, error) {
+	for _, st := range d.collectionReaders() {
+		count, err := st.Len(ctx, dbnum, key)
+		if err == storage.ErrNoKey {
+			continue
+		}
+		return count, err
+	}
+	return 0, storage.ErrNoKey
+}
+
+// Members of the set from the first store which serves the key
+func (d *Driver) Members(ctx context.Context, dbnum int, key string) ([]string, error) {
+	for _, st := range d.collectionReaders() {
+		members, err := st.Members(ctx, dbnum, key)
+		if err == storage.ErrNoKey {
+			continue
+		}
+		return members, err
+	}
+	return nil, storage.ErrNoKey
+}
+
+// IsMember checks the member of the set in the first store which serves the key
+func (d *Driver) IsMember(ctx context.Context, dbnum int, key, member string) (bool, error) {
+	for _, st := range d.collectionReaders() {
+		ok, err := st.IsMember(ctx, dbnum, key, member)
+		if err == storage.ErrNoKey {
+			continue
+		}
+		return ok, err
+	}
+	return false, storage.ErrNoKey
+}
+
+// AddMembers to the collection of the first store which serves the key
+func (d *Driver) AddMembers(ctx context.Context, dbnum int, key string, members []string) (int64, error) {
+	for _, st := range d.collectionWriters() {
+		count, err := st.AddMembers(ctx, dbnum, key, members)
+		if err == storage.ErrNoKey {
+			continue
+		}
+		return count, err
+	}
+	return 0, storage.ErrNoKey
+}
+
+// RemMembers of the collection of the first store which serves the key
+func (d *Driver) RemMembers(ctx context.Context, dbnum int, key string, members []string) (int64, error) {
+	for _, st := range d.collectionWriters() {
+		count, err := st.RemMembers(ctx, dbnum, key, members)
+		if err == storage.ErrNoKey {
+			continue
+		}
+		return count, err
+	}
+	return 0, storage.ErrNoKey
+}
+
+// ZRange of the sorted set from the first store which serves the key
+func (d *Driver) ZRange(ctx context.Context, dbnum int, key string, rng storage.ZRange) ([]storage.ScoredMember, error) {
+	for _, st := range d.sortedSetReaders() {
+		members, err := st.ZRange(ctx, dbnum, key, rng)
+		if err == storage.ErrNoKey {
+			continue
+		}
+		return members, err
+	}
+	return nil, storage.ErrNoKey
+}
+
+// ZScore of the member from the first store which serves the key
+func (d *Driver) ZScore(ctx context.Context, dbnum int, key, member string) (float64, error) {
+	for _, st := range d.sortedSetReaders() {
+		score, err := st.ZScore(ctx, dbnum, key, member)
+		if err == storage.ErrNoKey {
+			continue
+		}
+		return score, err
+	}
+	return 0, storage.ErrNoKey
+}
+
+// ZRank of the member from the first store which serves the key
+func (d *Driver) ZRank(ctx context.Context, dbnum int, key, member string, rev bool) (int64, error) {
+	for _, st := range d.sortedSetReaders() {
+		rank, err := st.ZRank(ctx, dbnum, key, member, rev)
+		if err == storage.ErrNoKey {
+			continue
+		}
+		return rank, err
+	}
+	return 0, storage.ErrNoKey
+}
+
+// ZAdd to the sorted set of the first store which serves the key
+func (d *Driver) ZAdd(ctx context.Context, dbnum int, key string, members []storage.ScoredMember, mode storage.SetMode) (int64, int64, error) {
+	for _, st := range d.sortedSetWriters() {
+		added, changed, err := st.ZAdd(ctx, dbnum, key, members, mode)
+		if err == storage.ErrNoKey {
+			continue
+		}
+		return added, changed, err
+	}
+	return 0, 0, storage.ErrNoKey
+}
+
+// ZIncrBy the score of the member in the first store which serves the key
+func (d *Driver) ZIncrBy(ctx context.Context, dbnum int, key, member string, delta float64) (float64, error) {
+	for _, st := range d.sortedSetWriters() {
+		score, err := st.ZIncrBy(ctx, dbnum, key, member, delta)
+		if err == storage.ErrNoKey {
+			continue
+		}
+		return score, err
+	}
+	return 0, storage.ErrNoKey
+}
+
+// XRead entries from the first store which serves the streams,
+// all keys of the request must belong to the same store
+func (d *Driver) XRead(ctx context.Context, dbnum int, req storage.StreamRead) ([]storage.StreamEntries, error) {
+	for _, st := range d.streamReaders() {
+		res, err := st.XRead(ctx, dbnum, req)
+		if err == storage.ErrNoKey {
+			continue
+		}
+		return res, err
+	}
+	return nil, storage.ErrNoKey
+}
+
+// XAdd to the stream of the first store which serves the key
+func (d *Driver) XAdd(ctx context.Context, dbnum int, key, id string, fields []string) (string, error) {
+	for _, st := range d.streamWriters() {
+		newID, err := st.XAdd(ctx, dbnum, key, id, fields)
+		if err == storage.ErrNoKey {
+			continue
+		}
+		return newID, err
+	}
+	return "", storage.ErrNoKey
+}
+
+// XGroupCreate in the first store which serves the key
+func (d *Driver) XGroupCreate(ctx context.Context, dbnum int, key, group, id string) error {
+	for _, st := range d.streamWriters() {
+		err := st.XGroupCreate(ctx, dbnum, key, group, id)
+		if err == storage.ErrNoKey {
+			continue
+		}
+		return err
+	}
+	return storage.ErrNoKey
+}
+
+// XGroupDestroy in the first store which serves the key
+func (d *Driver) XGroupDestroy(ctx context.Context, dbnum int, key, group string) (bool, error) {
+	for _, st := range d.streamWriters() {
+		ok, err := st.XGroupDestroy(ctx, dbnum, key, group)
+		if err == storage.ErrNoKey {
+			continue
+		}
+		return ok, err
+	}
+	return false, storage.ErrNoKey
+}
+
+// XAck entries of the group in the first store which serves the key
+func (d *Driver) XAck(ctx context.Context, dbnum int, key, group string, ids []string) (int64, error) {
+	for _, st := range d.streamWriters() {
+		count, err := st.XAck(ctx, dbnum, key, group, ids)
+		if err == storage.ErrNoKey {
+			continue
+		}
+		return count, err
+	}
+	return 0, storage.ErrNoKey
+}
+
+// KeyType returns the type of the key from the first store which serves the key
+func (d *Driver) KeyType(ctx context.Context, dbnum int, key string) (string, error) {
+	for _, st := range d.stores {
+		tp, err := storage.KeyType(ctx, st, dbnum, key)
+		if err == storage.ErrNoKey {
+			continue
+		}
+		return tp, err
+	}
+	return "", storage.ErrNoKey
+}
+
 func (d *Driver) Del(ctx context.Context, dbnum int, key string) (err error) {
 	for _, st := range d.stores {
 		serr := st.Del(ctx, dbnum, key)
@@ -85,10 +435,191 @@ func (d *Driver) List(ctx context.Context, dbnum int, pattern string) ([]storage
 	return response, nil
 }
 
+func (d *Driver) Scan(ctx context.Context, dbnum int, cursor uint64, opts storage.ScanOptions) ([]string, uint64, error) {
+	var (
+		cur   = storage.Cursor(cursor)
+		count = opts.PageSize()
+		keys  = make([]string, 0, count)
+	)
+	for i := cur.Store(); i < len(d.stores); i++ {
+		if len(keys) >= count {
+			return keys, uint64(storage.NewCursor(i, 0, 0)), nil
+		}
+		var storeCursor uint64
+		if i == cur.Store() {
+			storeCursor = uint64(cur.WithStore(0))
+		}
+		opts.Count = count - len(keys)
+		skeys, next, err := d.stores[i].Scan(ctx, dbnum, storeCursor, opts)
+		if err == storage.ErrNoKey {
+			continue
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		keys = append(keys, skeys...)
+		if next != 0 {
+			return keys, uint64(storage.Cursor(next).WithStore(i)), nil
+		}
+	}
+	return keys, 0, nil
+}
+
 func (d *Driver) Bind(ctx context.Context, conf *storage.BindConfig) error {
 	return storage.ErrMethodIsNotSupported
 }
 
+// DBSize returns the total number of the keys of all stores
+func (d *Driver) DBSize(ctx context.Context, dbnum int) (int64, error) {
+	var size int64
+	for _, st := range d.stores {
+		count, err := storage.DBSize(ctx, st, dbnum)
+		if err != nil {
+			return 0, err
+		}
+		size += count
+	}
+	return size, nil
+}
+
+// Stats of all stores which report them
+func (d *Driver) Stats() []storage.SourceStats {
+	var stats []storage.SourceStats
+	for _, st := range d.stores {
+		stats = append(stats, storage.Stats(st)...)
+	}
+	return stats
+}
+
+// OnKeyEvent registers the callback in every store which reports changed keys
+func (d *Driver) OnKeyEvent(fn func(ctx context.Context, event storage.KeyEvent)) {
+	for _, st := range d.stores {
+		if n, _ := st.(storage.KeyEventNotifier); n != nil {
+			n.OnKeyEvent(fn)
+		}
+	}
+}
+
+// ReportsKeyEvents returns true if any store reports every change of the key
+func (d *Driver) ReportsKeyEvents(dbnum int, key string) bool {
+	for _, st := range d.stores {
+		if storage.ReportsKeyEvents(st, dbnum, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// EnableExpiredEvents of every store which supports the switch
+func (d *Driver) EnableExpiredEvents(enabled bool) {
+	for _, st := range d.stores {
+		storage.EnableExpiredEvents(st, enabled)
+	}
+}
+
+// HasKey returns true if any transactional store serves the key
+func (d *Driver) HasKey(dbnum int, key string) bool {
+	return d.keyOwner(dbnum, key) != nil
+}
+
+// BeginTx in the store which serves all the keys
+func (d *Driver) BeginTx(ctx context.Context, dbnum int, keys []string) (context.Context, storage.Tx, error) {
+	var owner storage.Transactioner
+	for _, key := range keys {
+		switch st := d.keyOwner(dbnum, key); {
+		case st == nil:
+			return ctx, nil, storage.ErrNoKey
+		case owner == nil:
+			owner = st
+		case owner != st:
+			return ctx, nil, storage.ErrCrossSourceTx
+		}
+	}
+	if owner == nil {
+		return ctx, nil, storage.ErrNoKey
+	}
+	return owner.BeginTx(ctx, dbnum, keys)
+}
+
+func (d *Driver) keyOwner(dbnum int, key string) storage.Transactioner {
+	for _, st := range d.stores {
+		if tr, _ := st.(storage.Transactioner); tr != nil && tr.HasKey(dbnum, key) {
+			return tr
+		}
+	}
+	return nil
+}
+
+func (d *Driver) fieldWriters() []storage.FieldWriter {
+	writers := make([]storage.FieldWriter, 0, len(d.stores))
+	for _, st := range d.stores {
+		if fw, _ := st.(storage.FieldWriter); fw != nil {
+			writers = append(writers, fw)
+		}
+	}
+	return writers
+}
+
+func (d *Driver) collectionReaders() []storage.CollectionReader {
+	readers := make([]storage.CollectionReader, 0, len(d.stores))
+	for _, st := range d.stores {
+		if cr, _ := st.(storage.CollectionReader); cr != nil {
+			readers = append(readers, cr)
+		}
+	}
+	return readers
+}
+
+func (d *Driver) collectionWriters() []storage.CollectionWriter {
+	writers := make([]storage.CollectionWriter, 0, len(d.stores))
+	for _, st := range d.stores {
+		if cw, _ := st.(storage.CollectionWriter); cw != nil {
+			writers = append(writers, cw)
+		}
+	}
+	return writers
+}
+
+func (d *Driver) sortedSetReaders() []storage.SortedSetReader {
+	readers := make([]storage.SortedSetReader, 0, len(d.stores))
+	for _, st := range d.stores {
+		if sr, _ := st.(storage.SortedSetReader); sr != nil {
+			readers = append(readers, sr)
+		}
+	}
+	return readers
+}
+
+func (d *Driver) sortedSetWriters() []storage.SortedSetWriter {
+	writers := make([]storage.SortedSetWriter, 0, len(d.stores))
+	for _, st := range d.stores {
+		if sw, _ := st.(storage.SortedSetWriter); sw != nil {
+			writers = append(writers, sw)
+		}
+	}
+	return writers
+}
+
+func (d *Driver) streamReaders() []storage.StreamReader {
+	readers := make([]storage.StreamReader, 0, len(d.stores))
+	for _, st := range d.stores {
+		if sr, _ := st.(storage.StreamReader); sr != nil {
+			readers = append(readers, sr)
+		}
+	}
+	return readers
+}
+
+func (d *Driver) streamWriters() []storage.StreamWriter {
+	writers := make([]storage.StreamWriter, 0, len(d.stores))
+	for _, st := range d.stores {
+		if sw, _ := st.(storage.StreamWriter); sw != nil {
+			writers = append(writers, sw)
+		}
+	}
+	return writers
+}
+
 func (d *Driver) Close() (err error) {
 	for _, st := range d.stores {
 		err = multierr.Append(err, st.Close())
@@ -3,11 +3,19 @@ package pgx
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"maps"
+	"sync"
+	"time"
 
+	"github.com/demdxx/gocast/v2"
 	"github.com/georgysavva/scany/pgxscan"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/zap"
 
+	"github.com/demdxx/redify/internal/context/ctxlogger"
 	"github.com/demdxx/redify/internal/keypattern"
 	"github.com/demdxx/redify/internal/storage"
 	"github.com/demdxx/redify/internal/storage/sql"
@@ -23,12 +31,16 @@ type (
 type pgpoolIface interface {
 	pgxscan.Querier
 	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
 }
 
 type Bind struct {
 	conn pgpoolIface
 	sql.BindAbstract
 	minSizeOfRecord int
+
+	columnsMx sync.Mutex
+	columns   []string
 }
 
 // NewBind create new sql bind instance for the specified database
@@ -63,12 +75,15 @@ func NewBindFromTableName(
 }
 
 func (b *Bind) Get(ctx context.Context, ectx keypattern.ExecContext) (Record, error) {
-	rows, err := b.conn.Query(ctx, b.GetQuery.String(), b.GetQuery.Args(ectx)...)
+	rows, err := b.querier(ctx).Query(ctx, b.GetQuery.String(), b.GetQuery.Args(ectx)...)
 	if err != nil {
 		return nil, err
 	}
 	record := make(Record, b.minSizeOfRecord)
 	err = pgxscan.ScanOne(&record, rows)
+	if pgxscan.NotFound(err) {
+		return nil, storage.ErrNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -76,6 +91,9 @@ func (b *Bind) Get(ctx context.Context, ectx keypattern.ExecContext) (Record, er
 	if err != nil {
 		return nil, err
 	}
+	if b.IsExpired(record) {
+		return nil, storage.ErrNotFound
+	}
 	if len(b.DatatypesMapping) > 0 {
 		record, err = record.DatatypeCasting(b.DatatypesMapping...)
 		if err != nil {
@@ -88,9 +106,164 @@ func (b *Bind) Get(ctx context.Context, ectx keypattern.ExecContext) (Record, er
 	return record, err
 }
 
+// GetFields of the record, table binds select only the requested columns
+func (b *Bind) GetFields(ctx context.Context, ectx keypattern.ExecContext, fields []string) (Record, error) {
+	if !b.SupportProjection() {
+		record, err := b.Get(ctx, ectx)
+		if err != nil {
+			return nil, err
+		}
+		return record.Filter(fields), nil
+	}
+	columns, err := b.tableColumns(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if fields = sql.ProjectColumns(fields, columns); len(fields) == 0 {
+		return Record{}, nil
+	}
+	query := b.FieldsQuery(fields)
+	rows, err := b.querier(ctx).Query(ctx, query.String(), query.Args(ectx)...)
+	if err != nil {
+		return nil, err
+	}
+	record := make(Record, len(fields))
+	if err = pgxscan.ScanOne(&record, rows); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, err
+	}
+	if record, err = prepareRecordValues(record); err != nil {
+		return nil, err
+	}
+	if len(b.DatatypesMapping) > 0 {
+		return record.DatatypeCasting(b.DatatypesMapping...)
+	}
+	return record, nil
+}
+
+// tableColumns returns the list of the source table columns loaded once
+func (b *Bind) tableColumns(ctx context.Context) ([]string, error) {
+	b.columnsMx.Lock()
+	defer b.columnsMx.Unlock()
+	if b.columns != nil {
+		return b.columns, nil
+	}
+	rows, err := b.conn.Query(ctx, b.Syntax.ColumnsQuery(b.SourceTable))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns := make([]string, 0, len(rows.FieldDescriptions()))
+	for _, field := range rows.FieldDescriptions() {
+		columns = append(columns, string(field.Name))
+	}
+	b.columns = columns
+	return columns, rows.Err()
+}
+
+// GetMany records by the list of execution contexts, the result is aligned with contexts
+// and contains nil for the records which are not found
+func (b *Bind) GetMany(ctx context.Context, ectxs []keypattern.ExecContext) ([]Record, error) {
+	if !b.SupportBatch() {
+		result := make([]Record, len(ectxs))
+		for i, ectx := range ectxs {
+			rec, err := b.Get(ctx, ectx)
+			if err != nil && !errors.Is(err, storage.ErrNotFound) {
+				return nil, err
+			}
+			result[i] = rec
+		}
+		return result, nil
+	}
+	result := make([]Record, 0, len(ectxs))
+	for _, chunk := range b.BatchChunks(ectxs) {
+		records, err := b.getBatch(ctx, chunk)
+		if err != nil {
+			return nil, err
+		}
+		chunkResult, retry := b.BatchResult(chunk, records)
+		for _, i := range retry {
+			if chunkResult[i], err = b.Get(ctx, chunk[i]); err != nil && !errors.Is(err, storage.ErrNotFound) {
+				return nil, err
+			}
+		}
+		result = append(result, chunkResult...)
+	}
+	return result, nil
+}
+
+// getBatch selects the records of the keys by one query
+func (b *Bind) getBatch(ctx context.Context, ectxs []keypattern.ExecContext) ([]Record, error) {
+	var (
+		query string
+		args  []any
+	)
+	if len(b.KeyFields) == 1 {
+		values := make([]string, 0, len(ectxs))
+		for _, ectx := range ectxs {
+			values = append(values, ectx[b.KeyFields[0]])
+		}
+		query = b.Syntax.SelectQuery(b.SourceTable, WhereStmt{b.KeyFields[0]: "ANY($1)"}, b.WhereExt)
+		args = []any{values}
+	} else {
+		query = b.Syntax.BatchGetQuery(b.SourceTable, b.KeyFields, len(ectxs), b.WhereExt)
+		args = b.BatchArgs(ectxs)
+	}
+	records := make([]Record, 0, len(ectxs))
+	if err := pgxscan.Select(ctx, b.querier(ctx), &records, query, args...); err != nil {
+		return nil, err
+	}
+	for i, record := range records {
+		record, err := prepareRecordValues(record)
+		if err != nil {
+			return nil, err
+		}
+		if len(b.DatatypesMapping) > 0 {
+			if record, err = record.DatatypeCasting(b.DatatypesMapping...); err != nil {
+				return nil, err
+			}
+		}
+		records[i] = record
+	}
+	return records, nil
+}
+
 func (b *Bind) List(ctx context.Context, ectx keypattern.ExecContext) ([]Record, error) {
+	query := b.PatternListQuery(ectx)
+	if query == nil {
+		return nil, nil
+	}
+	return b.selectRecords(ctx, query.String(), query.Args(ectx))
+}
+
+// Page of the list query records from the offset
+func (b *Bind) Page(ctx context.Context, ectx keypattern.ExecContext, offset, limit uint64) ([]Record, error) {
+	query := b.PatternListQuery(ectx)
+	if query == nil {
+		return nil, nil
+	}
+	return b.selectRecords(ctx,
+		b.Syntax.PageQuery(query.String(), b.PageOrder(), limit, offset), query.Args(ectx))
+}
+
+// CountKeys of the list query
+func (b *Bind) CountKeys(ctx context.Context, ectx keypattern.ExecContext) (int64, error) {
+	query := b.KeysCountQuery(ectx)
+	if query == nil {
+		return 0, nil
+	}
+	_, value, err := b.queryValue(ctx, query.String(), query.Args(ectx))
+	if err != nil {
+		return 0, err
+	}
+	return gocast.Number[int64](value), nil
+}
+
+func (b *Bind) selectRecords(ctx context.Context, query string, args []any) ([]Record, error) {
 	res := make([]Record, 0, 10)
-	err := pgxscan.Select(ctx, b.conn, &res, b.ListQuery.String(), b.ListQuery.Args(ectx)...)
+	err := pgxscan.Select(ctx, b.querier(ctx), &res, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -107,28 +280,203 @@ func (b *Bind) List(ctx context.Context, ectx keypattern.ExecContext) ([]Record,
 }
 
 func (b *Bind) Upsert(ctx context.Context, ectx keypattern.ExecContext, value []byte) error {
-	if b.UpsertQuery == nil {
-		return storage.ErrReadOnly
-	}
+	_, _, err := b.SetRecord(ctx, ectx, value, storage.SetOptions{})
+	return err
+}
+
+// SetRecord writes the record by the SET options and returns the previous record
+// if it's requested and the flag if the record was written
+func (b *Bind) SetRecord(ctx context.Context, ectx keypattern.ExecContext, value []byte, opts storage.SetOptions) (Record, bool, error) {
 	if ectx == nil {
 		ectx = make(keypattern.ExecContext, 10)
 	}
-	var values keypattern.ExecContext
-	if err := json.Unmarshal(value, &values); err != nil {
-		return err
+	keyCtx := maps.Clone(ectx)
+	query, err := b.SetRecordQuery(ectx, value, opts)
+	if err != nil {
+		return nil, false, err
 	}
-	for k, v := range values {
-		ectx[k] = v
+	var prev Record
+	if opts.Get {
+		// Read the previous value in the same transaction
+		if ctx.Value(txKey{conn: b.conn}) == nil {
+			tx, err := b.conn.Begin(ctx)
+			if err != nil {
+				return nil, false, err
+			}
+			defer func() { _ = tx.Rollback(ctx) }()
+			prev, applied, err := b.SetRecord(withTx(ctx, b.conn, tx), keyCtx, value, opts)
+			if err == nil {
+				err = tx.Commit(ctx)
+			}
+			return prev, applied, err
+		}
+		if prev, err = b.Get(ctx, keyCtx); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return nil, false, err
+		}
 	}
-	_, err := b.conn.Exec(ctx, b.UpsertQuery.String(), b.UpsertQuery.Args(ectx)...)
-	return err
+	if opts.Mode == storage.SetIfNotExists && b.DelExpiredQuery != nil {
+		// The expired record doesn't exist for NX
+		if _, err = b.querier(ctx).Exec(ctx, b.DelExpiredQuery.String(), b.DelExpiredQuery.Args(keyCtx)...); err != nil {
+			return nil, false, err
+		}
+	}
+	tag, err := b.querier(ctx).Exec(ctx, query.String(), query.Args(ectx)...)
+	if err != nil {
+		return nil, false, err
+	}
+	return prev, opts.Mode == storage.SetAlways || tag.RowsAffected() > 0, nil
+}
+
+// Expire sets the expiration time of the record, zero time removes the expiration
+func (b *Bind) Expire(ctx context.Context, ectx keypattern.ExecContext, at time.Time) (bool, error) {
+	query, err := b.ExpireQuery(ectx, at)
+	if err != nil {
+		return false, err
+	}
+	tag, err := b.querier(ctx).Exec(ctx, query.String(), query.Args(ectx)...)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// Sweep deletes expired records periodically until the context is done.
+// The keys are deleted one by one and reported as expired if the expired events are enabled.
+func (b *Bind) Sweep(ctx context.Context, interval time.Duration, events *sql.KeyEvents) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		var (
+			count int64
+			err   error
+		)
+		if b.ExpiredKeysQuery != "" && events.ExpiredEvents() {
+			count, err = b.sweepKeys(ctx, events)
+		} else {
+			var tag pgconn.CommandTag
+			tag, err = b.conn.Exec(ctx, b.SweepQuery.String(), b.SweepQuery.Args(keypattern.ExecContext{})...)
+			count = tag.RowsAffected()
+		}
+		if err != nil && ctx.Err() == nil {
+			ctxlogger.Get(ctx).Error("sweep expired records",
+				zap.Int("dbnum", b.DBNum), zap.Error(err))
+		} else if err == nil && count > 0 {
+			ctxlogger.Get(ctx).Debug("sweep expired records",
+				zap.Int("dbnum", b.DBNum), zap.Int64("count", count))
+		}
+	}
+}
+
+// sweepKeys deletes the expired records by keys and reports the expired events
+func (b *Bind) sweepKeys(ctx context.Context, events *sql.KeyEvents) (int64, error) {
+	var count int64
+	for {
+		var records []Record
+		if err := pgxscan.Select(ctx, b.conn, &records, b.ExpiredKeysQuery); err != nil {
+			return count, err
+		}
+		var deleted int64
+		for _, rec := range records {
+			key, ectx := b.RecordKey(rec)
+			tag, err := b.conn.Exec(ctx, b.DelExpiredQuery.String(), b.DelExpiredQuery.Args(ectx)...)
+			if err != nil {
+				return count + deleted, err
+			}
+			if tag.RowsAffected() > 0 {
+				deleted += tag.RowsAffected()
+				events.Notify(ctx, storage.KeyEvent{DBNum: b.DBNum, Key: key, Event: storage.KeyEventExpired})
+			}
+		}
+		// The rest of the keys is deleted by the next sweep if the batch can't be deleted
+		if count += deleted; len(records) < sql.SweepBatch || deleted == 0 {
+			return count, nil
+		}
+	}
+}
+
+// SetFields of the record and returns the number of affected records
+func (b *Bind) SetFields(ctx context.Context, ectx keypattern.ExecContext, fields map[string]string) (int64, error) {
+	query, err := b.UpdateFieldsQuery(ectx, fields)
+	if err != nil {
+		return 0, err
+	}
+	tag, err := b.querier(ctx).Exec(ctx, query.String(), query.Args(ectx)...)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// DelFields of the record and returns the number of affected records
+func (b *Bind) DelFields(ctx context.Context, ectx keypattern.ExecContext, fields []string) (int64, error) {
+	query, err := b.DelFieldsQuery(ectx, fields)
+	if err != nil {
+		return 0, err
+	}
+	tag, err := b.querier(ctx).Exec(ctx, query.String(), query.Args(ectx)...)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// IncrField of the record and returns the new value
+func (b *Bind) IncrField(ctx context.Context, ectx keypattern.ExecContext, field string, delta any) (any, error) {
+	query, err := b.IncrFieldQuery(ectx, field, delta)
+	if err != nil {
+		return nil, err
+	}
+	_, value, err := b.queryValue(ctx, query.String(), query.Args(ectx))
+	return value, err
+}
+
+// IncrBy increments the counter of the record by incr_query or counter_column
+// and returns the column of the counter and the new value
+func (b *Bind) IncrBy(ctx context.Context, ectx keypattern.ExecContext, delta any) (string, any, error) {
+	if b.IncrQuery == nil {
+		if b.CounterColumn == "" {
+			return "", nil, storage.ErrMethodIsNotSupported
+		}
+		value, err := b.IncrField(ctx, ectx, b.CounterColumn, delta)
+		return b.CounterColumn, value, err
+	}
+	query, err := b.CustomIncrQuery(ectx, delta)
+	if err != nil {
+		return "", nil, err
+	}
+	return b.queryValue(ctx, query.String(), query.Args(ectx))
+}
+
+// queryValue returns the name and the value of the first column of the first row of the query result
+func (b *Bind) queryValue(ctx context.Context, query string, args []any) (string, any, error) {
+	rows, err := b.querier(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return "", nil, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return "", nil, err
+		}
+		return "", nil, storage.ErrNotFound
+	}
+	var value any
+	if err = rows.Scan(&value); err != nil {
+		return "", nil, err
+	}
+	return string(rows.FieldDescriptions()[0].Name), value, nil
 }
 
 func (b *Bind) Del(ctx context.Context, ectx keypattern.ExecContext) error {
 	if b.DelQuery == nil {
 		return storage.ErrReadOnly
 	}
-	_, err := b.conn.Exec(ctx, b.DelQuery.String(), b.DelQuery.Args(ectx)...)
+	_, err := b.querier(ctx).Exec(ctx, b.DelQuery.String(), b.DelQuery.Args(ectx)...)
 	return err
 }
 
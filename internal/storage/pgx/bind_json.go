@@ -0,0 +1,34 @@
+package pgx
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/demdxx/redify/internal/keypattern"
+	"github.com/demdxx/redify/internal/storage"
+)
+
+// GetJSONPath selects the value of the record by the jsonb path operator.
+// Returns nil if the record exists but the path doesn't.
+func (b *Bind) GetJSONPath(ctx context.Context, ectx keypattern.ExecContext, path []string) (json.RawMessage, error) {
+	if !b.SupportJSONPath() {
+		return nil, storage.ErrMethodIsNotSupported
+	}
+	query := b.JSONPathQuery(ectx, path)
+	rows, err := b.querier(ctx).Query(ctx, query.String(), query.Args(ectx)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, storage.ErrNotFound
+	}
+	var value []byte
+	if err = rows.Scan(&value); err != nil {
+		return nil, err
+	}
+	return value, rows.Err()
+}
@@ -86,6 +86,34 @@ func testBindCommon(ctx context.Context, t *testing.T, mockPool *pgxpoolmock.Moc
 			assert.Equal(t, "testuser", rec["username"])
 		}
 	})
+	t.Run("select missing record", func(t *testing.T) {
+		mockPool.EXPECT().
+			Query(gomock.Any(), gomock.Any(), gomock.AssignableToTypeOf("")).
+			Return(pgxpoolmock.NewRows([]string{"id", "username"}).ToPgxRows(), nil)
+		_, err := bind.Get(ctx, keypattern.ExecContext{"username": "missing"})
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	})
+	t.Run("select many records", func(t *testing.T) {
+		columns := []string{"id", "username"}
+		ectxs := []keypattern.ExecContext{{"username": "testuser1"}, {"username": "testuser2"}}
+		if bind.SupportBatch() {
+			mockPool.EXPECT().
+				Query(gomock.Any(), `SELECT * FROM users WHERE "username"=ANY($1)`, []string{"testuser1", "testuser2"}).
+				Return(pgxpoolmock.NewRows(columns).AddRow(2, "testuser2").ToPgxRows(), nil)
+		} else {
+			mockPool.EXPECT().
+				Query(gomock.Any(), gomock.Any(), "testuser1").
+				Return(pgxpoolmock.NewRows(columns).ToPgxRows(), nil)
+			mockPool.EXPECT().
+				Query(gomock.Any(), gomock.Any(), "testuser2").
+				Return(pgxpoolmock.NewRows(columns).AddRow(2, "testuser2").ToPgxRows(), nil)
+		}
+		recs, err := bind.GetMany(ctx, ectxs)
+		if assert.NoError(t, err) && assert.Equal(t, 2, len(recs)) {
+			assert.Nil(t, recs[0], "record must be not found")
+			assert.Equal(t, 2, recs[1]["id"])
+		}
+	})
 	t.Run("select list", func(t *testing.T) {
 		columns := []string{"id", "username"}
 		pgxRows := pgxpoolmock.NewRows(columns).
@@ -104,14 +132,86 @@ func testBindCommon(ctx context.Context, t *testing.T, mockPool *pgxpoolmock.Moc
 			assert.Equal(t, 2, res[1]["id"])
 		}
 	})
-	t.Run("insert record", func(t *testing.T) {
+	t.Run("select list by pattern", func(t *testing.T) {
+		columns := []string{"id", "username"}
+		pgxRows := pgxpoolmock.NewRows(columns).
+			AddRow(1, "test_user1").ToPgxRows()
 		mockPool.EXPECT().
-			Exec(gomock.Any(), gomock.Any(), gomock.AssignableToTypeOf("")).
-			Return(pgconn.CommandTag("INSERT"), nil)
+			Query(gomock.Any(), gomock.Any(), `test\_%`).
+			Return(pgxRows, nil)
+		pectx := keypattern.ExecContext{}
+		if !bind.MatchPattern("users_test_*", pectx) {
+			t.Error("invalid pattern matching")
+		}
+		res, err := bind.List(ctx, pectx)
+		if assert.NoError(t, err) {
+			assert.Equal(t, 1, len(res))
+			assert.Equal(t, "test_user1", res[0]["username"])
+		}
+	})
+	t.Run("select page", func(t *testing.T) {
+		columns := []string{"id", "username"}
+		pgxRows := pgxpoolmock.NewRows(columns).
+			AddRow(11, "testuser11").
+			AddRow(12, "testuser12").ToPgxRows()
+		mockPool.EXPECT().
+			Query(gomock.Any(), gomock.Any()).
+			Return(pgxRows, nil)
+		res, err := bind.Page(ctx, ectx, 10, 2)
+		if assert.NoError(t, err) {
+			assert.Equal(t, 2, len(res))
+			assert.Equal(t, 11, res[0]["id"])
+			assert.Equal(t, "testuser12", res[1]["username"])
+		}
+	})
+	t.Run("select fields", func(t *testing.T) {
+		if bind.SupportProjection() {
+			mockPool.EXPECT().
+				Query(gomock.Any(), `SELECT * FROM users WHERE 1=0`).
+				Return(pgxpoolmock.NewRows([]string{"id", "username", "bio"}).ToPgxRows(), nil)
+			mockPool.EXPECT().
+				Query(gomock.Any(), `SELECT "username" FROM users WHERE "username"=$1 LIMIT 1`, "testuser").
+				Return(pgxpoolmock.NewRows([]string{"username"}).AddRow("testuser").ToPgxRows(), nil)
+		} else {
+			mockPool.EXPECT().
+				Query(gomock.Any(), gomock.Any(), gomock.AssignableToTypeOf("")).
+				Return(pgxpoolmock.NewRows([]string{"id", "username"}).AddRow(1, "testuser").ToPgxRows(), nil)
+		}
+		rec, err := bind.GetFields(ctx, keypattern.ExecContext{"username": "testuser"}, []string{"username", "unknown"})
+		if assert.NoError(t, err) {
+			assert.Equal(t, Record{"username": "testuser"}, rec)
+		}
+	})
+	t.Run("insert record", func(t *testing.T) {
+		if bind.IsTableBind() {
+			mockPool.EXPECT().
+				Exec(gomock.Any(), `INSERT INTO users ("newVar", "username") VALUES ($1, $2)`+
+					` ON CONFLICT ("username") DO UPDATE SET "newVar"=$1, "username"=$2`, "val", "testuser").
+				Return(pgconn.CommandTag("INSERT 0 1"), nil)
+		} else {
+			mockPool.EXPECT().
+				Exec(gomock.Any(), gomock.Any(), gomock.AssignableToTypeOf("")).
+				Return(pgconn.CommandTag("INSERT"), nil)
+		}
 		ectx["username"] = "testuser"
 		err := bind.Upsert(ctx, ectx, []byte(`{"newVar":"val"}`))
 		assert.NoError(t, err)
 	})
+	t.Run("set record with options", func(t *testing.T) {
+		if !bind.IsTableBind() {
+			_, _, err := bind.SetRecord(ctx, ectx, []byte(`{}`), storage.SetOptions{Mode: storage.SetIfExists})
+			assert.ErrorIs(t, err, storage.ErrMethodIsNotSupported)
+			return
+		}
+		mockPool.EXPECT().
+			Exec(gomock.Any(), `INSERT INTO users ("newVar", "username") VALUES ($1, $2)`+
+				` ON CONFLICT ("username") DO NOTHING`, "val", "testuser").
+			Return(pgconn.CommandTag("INSERT 0 0"), nil)
+		_, applied, err := bind.SetRecord(ctx, ectx, []byte(`{"newVar":"val"}`), storage.SetOptions{Mode: storage.SetIfNotExists})
+		if assert.NoError(t, err) {
+			assert.False(t, applied, "record already exists")
+		}
+	})
 	t.Run("delete record", func(t *testing.T) {
 		mockPool.EXPECT().
 			Exec(gomock.Any(), gomock.Any(), gomock.AssignableToTypeOf("")).
@@ -120,6 +220,34 @@ func testBindCommon(ctx context.Context, t *testing.T, mockPool *pgxpoolmock.Moc
 		err := bind.Del(ctx, ectx)
 		assert.NoError(t, err)
 	})
+	t.Run("update fields", func(t *testing.T) {
+		if !bind.IsTableBind() {
+			_, err := bind.SetFields(ctx, ectx, map[string]string{"email": "test@mail"})
+			assert.ErrorIs(t, err, storage.ErrReadOnly)
+			_, err = bind.IncrField(ctx, ectx, "visits", int64(1))
+			assert.ErrorIs(t, err, storage.ErrMethodIsNotSupported)
+			return
+		}
+		mockPool.EXPECT().
+			Exec(gomock.Any(), `UPDATE users SET "email"=$1 WHERE "username"=$2`, "test@mail", "testuser").
+			Return(pgconn.CommandTag("UPDATE 1"), nil)
+		count, err := bind.SetFields(ctx, ectx, map[string]string{"email": "test@mail"})
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(1), count)
+		}
+
+		pgxRows := pgxpoolmock.NewRows([]string{"visits"}).AddRow(int64(15)).ToPgxRows()
+		mockPool.EXPECT().
+			Query(gomock.Any(), `UPDATE users SET "visits"=COALESCE("visits", 0) + $1 WHERE "username"=$2 RETURNING "visits"`, "5", "testuser").
+			Return(pgxRows, nil)
+		value, err := bind.IncrField(ctx, ectx, "visits", int64(5))
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(15), value)
+		}
+
+		_, err = bind.DelFields(ctx, ectx, []string{"email; DROP"})
+		assert.ErrorIs(t, err, sql.ErrInvalidFieldName)
+	})
 	t.Run("readnly", func(t *testing.T) {
 		bind.DelQuery = nil
 		bind.UpsertQuery = nil
@@ -129,3 +257,39 @@ func testBindCommon(ctx context.Context, t *testing.T, mockPool *pgxpoolmock.Moc
 		assert.ErrorIs(t, err, storage.ErrReadOnly)
 	})
 }
+
+func TestBindJSONPath(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	var (
+		mockPool = pgxpoolmock.NewMockPgxPool(ctrl)
+		bind     = NewBindFromTableName(
+			mockPool, 0,
+			sql.NewAbstractSyntax(`"`),
+			"users_{{username}}",
+			"users", "", nil, false,
+		)
+		ectx = keypattern.ExecContext{"username": "testuser"}
+	)
+	if !bind.MatchKey("users_testuser", ectx) || !bind.SupportJSONPath() {
+		t.Fatal("invalid bind")
+	}
+	mockPool.EXPECT().
+		Query(gomock.Any(), `SELECT to_jsonb(json_query) #> CAST($2 AS TEXT[]) AS value`+
+			` FROM (SELECT * FROM users WHERE "username"=$1 LIMIT 1) AS json_query LIMIT 1`,
+			"testuser", `{"profile","tags","0"}`).
+		Return(pgxpoolmock.NewRows([]string{"value"}).AddRow([]byte(`"admin"`)).ToPgxRows(), nil)
+	value, err := bind.GetJSONPath(ctx, ectx, []string{"profile", "tags", "0"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, `"admin"`, string(value))
+	}
+
+	mockPool.EXPECT().
+		Query(gomock.Any(), gomock.Any(), "testuser", `{"na\"me"}`).
+		Return(pgxpoolmock.NewRows([]string{"value"}).ToPgxRows(), nil)
+	_, err = bind.GetJSONPath(ctx, ectx, []string{`na"me`})
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
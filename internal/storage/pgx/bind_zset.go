@@ -0,0 +1,163 @@
+package pgx
+
+import (
+	"context"
+	"errors"
+
+	"github.com/demdxx/gocast/v2"
+
+	"github.com/demdxx/redify/internal/keypattern"
+	"github.com/demdxx/redify/internal/storage"
+	"github.com/demdxx/redify/internal/storage/sql"
+)
+
+// ZRange returns the members of the sorted set range with their scores
+func (b *Bind) ZRange(ctx context.Context, ectx keypattern.ExecContext, rng storage.ZRange) ([]storage.ScoredMember, error) {
+	if err := b.CheckType(storage.KeyTypeZSet); err != nil {
+		return nil, err
+	}
+	query, ok, err := b.ZRangeQuery(ectx, rng, func() (int64, error) { return b.count(ctx, ectx) })
+	if err != nil || !ok {
+		return nil, err
+	}
+	return b.queryScoredMembers(ctx, query, ectx)
+}
+
+// ZScore returns the score of the member or ErrNotFound
+func (b *Bind) ZScore(ctx context.Context, ectx keypattern.ExecContext, member string) (float64, error) {
+	if err := b.CheckType(storage.KeyTypeZSet); err != nil {
+		return 0, err
+	}
+	members, err := b.queryScoredMembers(ctx, b.ZScoreQuery(ectx, member), ectx)
+	if err != nil {
+		return 0, err
+	}
+	if len(members) == 0 {
+		return 0, storage.ErrNotFound
+	}
+	return members[0].Score, nil
+}
+
+// ZRank returns the index of the member ordered by the score or ErrNotFound
+func (b *Bind) ZRank(ctx context.Context, ectx keypattern.ExecContext, member string, rev bool) (int64, error) {
+	score, err := b.ZScore(ctx, ectx, member)
+	if err != nil {
+		return 0, err
+	}
+	query := b.ZRankQuery(ectx, member, score, rev)
+	_, value, err := b.queryValue(ctx, query.String(), query.Args(ectx))
+	if err != nil {
+		return 0, err
+	}
+	return gocast.Number[int64](value), nil
+}
+
+// ZAdd adds new members or updates the scores of the existing members by the mode
+// and returns the number of added members and the number of members with changed scores
+func (b *Bind) ZAdd(ctx context.Context, ectx keypattern.ExecContext, members []storage.ScoredMember, mode storage.SetMode) (added, changed int64, err error) {
+	if err := b.CheckType(storage.KeyTypeZSet); err != nil {
+		return 0, 0, err
+	}
+	if ctx.Value(txKey{conn: b.conn}) == nil {
+		tx, err := b.conn.Begin(ctx)
+		if err != nil {
+			return 0, 0, err
+		}
+		defer func() { _ = tx.Rollback(ctx) }()
+		added, changed, err = b.ZAdd(withTx(ctx, b.conn, tx), ectx, members, mode)
+		if err == nil {
+			err = tx.Commit(ctx)
+		}
+		return added, changed, err
+	}
+	for _, member := range members {
+		// The current score is checked to apply the mode and to count the changes
+		score, err := b.ZScore(ctx, ectx, member.Member)
+		exists := err == nil
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return 0, 0, err
+		}
+		if (exists && mode == storage.SetIfNotExists) || (!exists && mode == storage.SetIfExists) ||
+			(exists && score == member.Score) {
+			continue
+		}
+		query, err := b.ZAddQuery(ectx, member)
+		if err != nil {
+			return 0, 0, err
+		}
+		if _, err = b.querier(ctx).Exec(ctx, query.String(), query.Args(ectx)...); err != nil {
+			return 0, 0, err
+		}
+		if exists {
+			changed++
+		} else {
+			added++
+		}
+	}
+	return added, changed, nil
+}
+
+// ZIncrBy increments the score of the member and returns the new score,
+// the member is added if it doesn't exist
+func (b *Bind) ZIncrBy(ctx context.Context, ectx keypattern.ExecContext, member string, delta float64) (float64, error) {
+	if err := b.CheckType(storage.KeyTypeZSet); err != nil {
+		return 0, err
+	}
+	if ctx.Value(txKey{conn: b.conn}) == nil {
+		tx, err := b.conn.Begin(ctx)
+		if err != nil {
+			return 0, err
+		}
+		defer func() { _ = tx.Rollback(ctx) }()
+		score, err := b.ZIncrBy(withTx(ctx, b.conn, tx), ectx, member, delta)
+		if err == nil {
+			err = tx.Commit(ctx)
+		}
+		return score, err
+	}
+	query, err := b.ZIncrQuery(ectx, member, delta)
+	switch {
+	case err == nil:
+		tag, err := b.querier(ctx).Exec(ctx, query.String(), query.Args(ectx)...)
+		if err != nil {
+			return 0, err
+		}
+		if tag.RowsAffected() > 0 {
+			return b.ZScore(ctx, ectx, member)
+		}
+	case !errors.Is(err, storage.ErrMethodIsNotSupported):
+		return 0, err
+	}
+	// The member doesn't exist or the bind has no increment query
+	score, err := b.ZScore(ctx, ectx, member)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return 0, err
+	}
+	score += delta
+	_, _, err = b.ZAdd(ctx, ectx, []storage.ScoredMember{{Member: member, Score: score}}, storage.SetAlways)
+	return score, err
+}
+
+func (b *Bind) queryScoredMembers(ctx context.Context, query *sql.Query, ectx keypattern.ExecContext) ([]storage.ScoredMember, error) {
+	records, err := b.selectRecords(ctx, query.String(), query.Args(ectx))
+	if err != nil {
+		return nil, err
+	}
+	members := make([]storage.ScoredMember, 0, len(records))
+	for _, rec := range records {
+		if score, ok := rec[sql.SortedScoreColumn].(assigner); ok {
+			// Numeric scores are converted by the pgtype value
+			var value float64
+			if err = score.AssignTo(&value); err != nil {
+				return nil, err
+			}
+			rec[sql.SortedScoreColumn] = value
+		}
+		member, err := sql.ScoredMember(rec)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	return members, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/log/zapadapter"
@@ -17,9 +18,11 @@ import (
 )
 
 type Driver struct {
-	pool   *pgxpool.Pool
-	binds  []*Bind
-	syntax sql.Syntax
+	pool     *pgxpool.Pool
+	binds    []*Bind
+	syntax   sql.Syntax
+	sweepers []context.CancelFunc
+	sql.KeyEvents
 }
 
 func Open(ctx context.Context, connURL string) (storage.Driver, error) {
@@ -43,18 +46,104 @@ func Open(ctx context.Context, connURL string) (storage.Driver, error) {
 }
 
 func (pg *Driver) Get(ctx context.Context, dbnum int, key string) ([]byte, error) {
+	val, _, err := pg.GetWithTTL(ctx, dbnum, key)
+	return val, err
+}
+
+// GetWithTTL returns the record and the remaining time to live of the key
+func (pg *Driver) GetWithTTL(ctx context.Context, dbnum int, key string) ([]byte, time.Duration, error) {
 	ectx := keypattern.ExecContext{}
 	bind, err := pg.bindByKey(key, dbnum, ectx)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+	if bind.IsCollection() {
+		val, err := collectionValue(ctx, bind, ectx)
+		return val, storage.NoExpiration, err
 	}
 	rec, err := bind.Get(ctx, ectx)
+	if err != nil {
+		return nil, 0, err
+	}
+	val, err := json.Marshal(rec)
+	return val, bind.ExpiresIn(rec), err
+}
+
+func (pg *Driver) GetFields(ctx context.Context, dbnum int, key string, fields []string) ([]byte, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := pg.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return nil, err
+	}
+	rec, err := bind.GetFields(ctx, ectx, fields)
 	if err != nil {
 		return nil, err
 	}
 	return json.Marshal(rec)
 }
 
+// GetJSONPath of the record, simple paths of table binds are evaluated by the jsonb operator
+func (pg *Driver) GetJSONPath(ctx context.Context, dbnum int, key string, path *storage.JSONPath) ([]json.RawMessage, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := pg.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return nil, err
+	}
+	if keys, ok := path.Simple(); ok && bind.SupportJSONPath() {
+		value, err := bind.GetJSONPath(ctx, ectx, keys)
+		if err != nil || value == nil {
+			return nil, err
+		}
+		return []json.RawMessage{value}, nil
+	}
+	value, err := pg.Get(ctx, dbnum, key)
+	if err != nil {
+		return nil, err
+	}
+	return storage.EvalJSONPath(value, path)
+}
+
+func (pg *Driver) GetMany(ctx context.Context, dbnum int, keys []string) []storage.BatchItem {
+	var (
+		items  = make([]storage.BatchItem, len(keys))
+		groups = map[*Bind][]int{}
+		ectxs  = make([]keypattern.ExecContext, len(keys))
+	)
+	for i, key := range keys {
+		ectxs[i] = keypattern.ExecContext{}
+		bind, err := pg.bindByKey(key, dbnum, ectxs[i])
+		if err != nil {
+			items[i].Err = err
+			continue
+		}
+		if bind.IsCollection() {
+			items[i].Value, items[i].Err = collectionValue(ctx, bind, ectxs[i])
+			items[i].TTL = storage.NoExpiration
+			continue
+		}
+		groups[bind] = append(groups[bind], i)
+	}
+	for bind, idxs := range groups {
+		bindCtxs := make([]keypattern.ExecContext, 0, len(idxs))
+		for _, i := range idxs {
+			bindCtxs = append(bindCtxs, ectxs[i])
+		}
+		records, err := bind.GetMany(ctx, bindCtxs)
+		for j, i := range idxs {
+			switch {
+			case err != nil:
+				items[i].Err = err
+			case records[j] == nil:
+				items[i].Err = storage.ErrNotFound
+			default:
+				items[i].Value, items[i].Err = json.Marshal(records[j])
+				items[i].TTL = bind.ExpiresIn(records[j])
+			}
+		}
+	}
+	return items
+}
+
 func (pg *Driver) Set(ctx context.Context, dbnum int, key string, value []byte) error {
 	ectx := keypattern.ExecContext{}
 	bind, err := pg.bindByKey(key, dbnum, ectx)
@@ -64,6 +153,210 @@ func (pg *Driver) Set(ctx context.Context, dbnum int, key string, value []byte)
 	return bind.Upsert(ctx, ectx, value)
 }
 
+// SetWithOptions writes the record by the SET options
+func (pg *Driver) SetWithOptions(ctx context.Context, dbnum int, key string, value []byte, opts storage.SetOptions) (storage.SetResult, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := pg.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return storage.SetResult{}, err
+	}
+	prev, applied, err := bind.SetRecord(ctx, ectx, value, opts)
+	if err != nil {
+		return storage.SetResult{}, err
+	}
+	res := storage.SetResult{Applied: applied}
+	if prev != nil {
+		res.Prev, err = json.Marshal(prev)
+	}
+	return res, err
+}
+
+// IncrBy increments the counter of the key and returns the counter column and the new value
+func (pg *Driver) IncrBy(ctx context.Context, dbnum int, key string, delta any) (string, any, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := pg.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return "", nil, err
+	}
+	return bind.IncrBy(ctx, ectx, delta)
+}
+
+// Expire sets the expiration time of the key, zero time removes the expiration
+func (pg *Driver) Expire(ctx context.Context, dbnum int, key string, at time.Time) (bool, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := pg.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return false, err
+	}
+	return bind.Expire(ctx, ectx, at)
+}
+
+// TTL returns the remaining time to live of the key
+func (pg *Driver) TTL(ctx context.Context, dbnum int, key string) (time.Duration, error) {
+	_, ttl, err := pg.GetWithTTL(ctx, dbnum, key)
+	return ttl, err
+}
+
+func (pg *Driver) SetFields(ctx context.Context, dbnum int, key string, fields map[string]string) (int64, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := pg.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return 0, err
+	}
+	return bind.SetFields(ctx, ectx, fields)
+}
+
+func (pg *Driver) DelFields(ctx context.Context, dbnum int, key string, fields []string) (int64, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := pg.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return 0, err
+	}
+	return bind.DelFields(ctx, ectx, fields)
+}
+
+func (pg *Driver) IncrField(ctx context.Context, dbnum int, key, field string, delta any) (any, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := pg.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return nil, err
+	}
+	return bind.IncrField(ctx, ectx, field, delta)
+}
+
+// Range of the list members from start to stop inclusive
+func (pg *Driver) Range(ctx context.Context, dbnum int, key string, start, stop int64) ([]string, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := pg.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return nil, err
+	}
+	return bind.Range(ctx, ectx, start, stop)
+}
+
+// Len returns the number of the members of the list, set or sorted set
+func (pg *Driver) Len(ctx context.Context, dbnum int, key string) (int64, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := pg.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return 0, err
+	}
+	return bind.Len(ctx, ectx)
+}
+
+// Members returns the unique members of the set
+func (pg *Driver) Members(ctx context.Context, dbnum int, key string) ([]string, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := pg.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return nil, err
+	}
+	return bind.Members(ctx, ectx)
+}
+
+// IsMember returns true if the set contains the member
+func (pg *Driver) IsMember(ctx context.Context, dbnum int, key, member string) (bool, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := pg.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return false, err
+	}
+	return bind.IsMember(ctx, ectx, member)
+}
+
+// AddMembers to the list or set and returns the number of added members
+func (pg *Driver) AddMembers(ctx context.Context, dbnum int, key string, members []string) (int64, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := pg.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return 0, err
+	}
+	return bind.AddMembers(ctx, ectx, members)
+}
+
+// RemMembers of the set or sorted set and returns the number of removed members
+func (pg *Driver) RemMembers(ctx context.Context, dbnum int, key string, members []string) (int64, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := pg.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return 0, err
+	}
+	return bind.RemMembers(ctx, ectx, members)
+}
+
+// ZRange returns the members of the sorted set range with their scores
+func (pg *Driver) ZRange(ctx context.Context, dbnum int, key string, rng storage.ZRange) ([]storage.ScoredMember, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := pg.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return nil, err
+	}
+	return bind.ZRange(ctx, ectx, rng)
+}
+
+// ZScore returns the score of the member or ErrNotFound
+func (pg *Driver) ZScore(ctx context.Context, dbnum int, key, member string) (float64, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := pg.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return 0, err
+	}
+	return bind.ZScore(ctx, ectx, member)
+}
+
+// ZRank returns the index of the member ordered by the score or ErrNotFound
+func (pg *Driver) ZRank(ctx context.Context, dbnum int, key, member string, rev bool) (int64, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := pg.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return 0, err
+	}
+	return bind.ZRank(ctx, ectx, member, rev)
+}
+
+// ZAdd adds or updates the members of the sorted set by the mode
+func (pg *Driver) ZAdd(ctx context.Context, dbnum int, key string, members []storage.ScoredMember, mode storage.SetMode) (int64, int64, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := pg.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return 0, 0, err
+	}
+	return bind.ZAdd(ctx, ectx, members, mode)
+}
+
+// ZIncrBy increments the score of the member and returns the new score
+func (pg *Driver) ZIncrBy(ctx context.Context, dbnum int, key, member string, delta float64) (float64, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := pg.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return 0, err
+	}
+	return bind.ZIncrBy(ctx, ectx, member, delta)
+}
+
+// KeyType returns the type of the bind if the key exists
+func (pg *Driver) KeyType(ctx context.Context, dbnum int, key string) (string, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := pg.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return "", err
+	}
+	if bind.IsCollection() {
+		count, err := bind.Len(ctx, ectx)
+		if err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return "", storage.ErrNotFound
+		}
+		return bind.Type(), nil
+	}
+	if _, err = bind.Get(ctx, ectx); err != nil {
+		return "", err
+	}
+	return bind.Type(), nil
+}
+
 func (pg *Driver) Del(ctx context.Context, dbnum int, key string) error {
 	ectx := keypattern.ExecContext{}
 	bind, err := pg.bindByKey(key, dbnum, ectx)
@@ -92,7 +385,9 @@ func (pg *Driver) Keys(ctx context.Context, dbnum int, pattern string) ([]string
 			keys = make([]string, 0, len(res))
 		}
 		for _, r := range res {
-			keys = append(keys, bind.Pattern.Format(r))
+			if key := bind.Pattern.Format(r); keypattern.GlobMatch(pattern, key) {
+				keys = append(keys, key)
+			}
 		}
 	}
 	if !hasKey {
@@ -117,6 +412,41 @@ func (pg *Driver) List(ctx context.Context, dbnum int, pattern string) ([]storag
 	return response, nil
 }
 
+func (pg *Driver) Scan(ctx context.Context, dbnum int, cursor uint64, opts storage.ScanOptions) ([]string, uint64, error) {
+	var (
+		cur   = storage.Cursor(cursor)
+		count = opts.PageSize()
+		keys  = make([]string, 0, count)
+	)
+	for i := cur.Bind(); i < len(pg.binds) && len(keys) < count; i++ {
+		bind := pg.binds[i]
+		ectx := keypattern.ExecContext{}
+		if bind.DBNum != dbnum || !opts.MatchType(bind.Type()) || !bind.MatchPattern(opts.Pattern, ectx) {
+			continue
+		}
+		var (
+			offset uint64
+			limit  = uint64(count - len(keys))
+		)
+		if i == cur.Bind() {
+			offset = cur.Offset()
+		}
+		res, err := bind.Page(ctx, ectx, offset, limit)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, r := range res {
+			if key := bind.Pattern.Format(r); opts.MatchKey(key) {
+				keys = append(keys, key)
+			}
+		}
+		if uint64(len(res)) >= limit {
+			return keys, uint64(storage.NewCursor(0, i, offset+limit)), nil
+		}
+	}
+	return keys, 0, nil
+}
+
 func (pg *Driver) Bind(ctx context.Context, conf *storage.BindConfig) error {
 	var bind *Bind
 	if conf.GetQuery != "" {
@@ -128,15 +458,97 @@ func (pg *Driver) Bind(ctx context.Context, conf *storage.BindConfig) error {
 	} else {
 		return storage.ErrInvalidBindConfig
 	}
+	if err := bind.Configure(conf); err != nil {
+		return err
+	}
+	if conf.TTLSweepInterval > 0 && bind.SweepQuery != nil {
+		sweepCtx, cancel := context.WithCancel(ctx)
+		pg.sweepers = append(pg.sweepers, cancel)
+		go bind.Sweep(sweepCtx, conf.TTLSweepInterval, &pg.KeyEvents)
+	}
 	pg.binds = append(pg.binds, bind)
 	return nil
 }
 
+// HasKey returns true if any bind of the database matches the key
+func (pg *Driver) HasKey(dbnum int, key string) bool {
+	_, err := pg.bindByKey(key, dbnum, keypattern.ExecContext{})
+	return err == nil
+}
+
+// BeginTx of the database, all the keys must be served by the driver
+func (pg *Driver) BeginTx(ctx context.Context, dbnum int, keys []string) (context.Context, storage.Tx, error) {
+	for _, key := range keys {
+		if !pg.HasKey(dbnum, key) {
+			return ctx, nil, storage.ErrCrossSourceTx
+		}
+	}
+	tx, err := pg.pool.Begin(ctx)
+	if err != nil {
+		return ctx, nil, err
+	}
+	return withTx(ctx, pg.pool, tx), &pgTx{ctx: ctx, tx: tx}, nil
+}
+
+// DBSize returns the number of the keys of all binds of the dbnum
+func (pg *Driver) DBSize(ctx context.Context, dbnum int) (int64, error) {
+	var size int64
+	for _, bind := range pg.binds {
+		if bind.DBNum != dbnum {
+			continue
+		}
+		count, err := bind.CountKeys(ctx, keypattern.ExecContext{})
+		if err != nil {
+			return 0, err
+		}
+		size += count
+	}
+	return size, nil
+}
+
+// Stats of the binds and the connection pool
+func (pg *Driver) Stats() []storage.SourceStats {
+	var (
+		stats = pg.pool.Stat()
+		binds = map[int]int{}
+	)
+	for _, bind := range pg.binds {
+		binds[bind.DBNum]++
+	}
+	return []storage.SourceStats{{
+		Driver: "pgx",
+		Binds:  binds,
+		Pool: &storage.PoolStats{
+			MaxConns:     int(stats.MaxConns()),
+			OpenConns:    int(stats.TotalConns()),
+			InUse:        int(stats.AcquiredConns()),
+			Idle:         int(stats.IdleConns()),
+			WaitCount:    stats.EmptyAcquireCount(),
+			WaitDuration: stats.AcquireDuration(),
+		},
+	}}
+}
+
 func (pg *Driver) Close() error {
+	for _, cancel := range pg.sweepers {
+		cancel()
+	}
 	pg.pool.Close()
 	return nil
 }
 
+// collectionValue returns JSON array of the collection members, empty collections don't exist
+func collectionValue(ctx context.Context, bind *Bind, ectx keypattern.ExecContext) ([]byte, error) {
+	members, err := bind.AllMembers(ctx, ectx)
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return nil, storage.ErrNotFound
+	}
+	return json.Marshal(members)
+}
+
 func (pg *Driver) bindByKey(key string, dbnum int, ectx keypattern.ExecContext) (*Bind, error) {
 	for _, b := range pg.binds {
 		if b.DBNum == dbnum && b.MatchKey(key, ectx) {
@@ -183,7 +595,34 @@ func (pg *Driver) bindByKey(key string, dbnum int, ectx keypattern.ExecContext)
 // AFTER INSERT OR UPDATE OR DELETE ON products
 //
 //	FOR EACH ROW EXECUTE PROCEDURE notify_event();
-func (pg *Driver) ListenUpdateNotifies(ctx context.Context, chanelName string, notifyFnk func(ctx context.Context, key string)) error {
+func (pg *Driver) ListenUpdateNotifies(ctx context.Context, chanelName string, notifyFnk func(ctx context.Context, event storage.KeyEvent)) error {
+	return pg.ListenNotifies(ctx, chanelName, func(ctx context.Context, payload string) {
+		var notifyData Notification
+		if err := notifyData.unmarshal([]byte(payload)); err != nil {
+			ctxlogger.Get(ctx).Error("unmarshal notification message", zap.Error(err))
+			return
+		}
+
+		if ectx, err := notifyData.ectx(); err != nil {
+			ctxlogger.Get(ctx).Error("unmarshal notification payload", zap.Error(err))
+		} else {
+			bind := pg.bindByTable(notifyData.Table)
+			if bind == nil {
+				ctxlogger.Get(ctx).Error("detect key from notification", zap.Error(storage.ErrNoKey))
+			} else {
+				notifyFnk(ctx, storage.KeyEvent{
+					DBNum: bind.DBNum,
+					Key:   bind.Pattern.Format(ectx),
+					Event: notifyData.event(ectx, bind.TTLColumn),
+				})
+			}
+		}
+	})
+}
+
+// ListenNotifies calls the function with the payload of every notification
+// of the channel until the context is done
+func (pg *Driver) ListenNotifies(ctx context.Context, chanelName string, notifyFnk func(ctx context.Context, payload string)) error {
 	conn, err := pg.pool.Acquire(ctx)
 	if err != nil {
 		return err
@@ -196,42 +635,28 @@ func (pg *Driver) ListenUpdateNotifies(ctx context.Context, chanelName string, n
 		return err
 	}
 
-	for !conn.Conn().IsClosed() {
-		notification, err := conn.Conn().WaitForNotification(context.Background())
+	for !conn.Conn().IsClosed() && ctx.Err() == nil {
+		notification, err := conn.Conn().WaitForNotification(ctx)
 		if err != nil {
-			ctxlogger.Get(ctx).Error("pgx notification listen", zap.Error(err))
-			continue
-		}
-
-		var notifyData Notification
-		if err := notifyData.unmarshal([]byte(notification.Payload)); err != nil {
-			ctxlogger.Get(ctx).Error("unmarshal notification message", zap.Error(err))
-			continue
-		}
-
-		if ectx, err := notifyData.ectx(); err != nil {
-			ctxlogger.Get(ctx).Error("unmarshal notification payload", zap.Error(err))
-		} else {
-			key, err := pg.keyFromTableAndContext(notifyData.Table, ectx)
-			if err != nil {
-				ctxlogger.Get(ctx).Error("detect key from notification", zap.Error(err))
-			} else {
-				notifyFnk(ctx, key)
+			if ctx.Err() == nil {
+				ctxlogger.Get(ctx).Error("pgx notification listen", zap.Error(err))
 			}
+			continue
 		}
+		notifyFnk(ctx, notification.Payload)
 	}
 
-	ctxlogger.Get(ctx).Info(`stop listen notifications "redify_update"`)
+	ctxlogger.Get(ctx).Info(`stop listen notifications "` + chanelName + `"`)
 	return nil
 }
 
-func (pg *Driver) keyFromTableAndContext(tableName string, ectx keypattern.ExecContext) (string, error) {
+func (pg *Driver) bindByTable(tableName string) *Bind {
 	for _, b := range pg.binds {
 		if b.TableName() == tableName {
-			return b.Pattern.Format(ectx), nil
+			return b
 		}
 	}
-	return "", storage.ErrNoKey
+	return nil
 }
 
 var _ storage.Driver = (*Driver)(nil)
@@ -1,15 +1,20 @@
 package pgx
 
 import (
+	"context"
 	"encoding/json"
+	"strings"
+	"time"
 
 	"github.com/demdxx/gocast/v2"
 	"github.com/demdxx/redify/internal/keypattern"
+	"github.com/demdxx/redify/internal/storage"
 )
 
 type Notification struct {
-	Table string          `json:"table"`
-	Data  json.RawMessage `json:"data"`
+	Table  string          `json:"table"`
+	Action string          `json:"action"`
+	Data   json.RawMessage `json:"data"`
 }
 
 func (n *Notification) unmarshal(data []byte) error {
@@ -27,3 +32,51 @@ func (n *Notification) ectx() (e keypattern.ExecContext, err error) {
 	}
 	return e, err
 }
+
+// event of the key, deleted records with the expiration time in the past are expired
+func (n *Notification) event(ectx keypattern.ExecContext, ttlColumn string) string {
+	if !strings.EqualFold(n.Action, "DELETE") {
+		return storage.KeyEventSet
+	}
+	if expiresAt, ok := parseNotifyTime(ectx[ttlColumn]); ttlColumn != "" && ok && !expiresAt.After(time.Now()) {
+		return storage.KeyEventExpired
+	}
+	return storage.KeyEventDel
+}
+
+// parseNotifyTime of the timestamp encoded by row_to_json, timestamps without time zone are UTC
+func parseNotifyTime(value string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// notifyChannel bridges the notification channel of the database to the Redis pub/sub channel of the same name
+type notifyChannel struct {
+	pg   *Driver
+	name string
+}
+
+// NotifyChannel returns the bridge of the notification channel
+func (pg *Driver) NotifyChannel(name string) storage.ChannelBridge {
+	return &notifyChannel{pg: pg, name: name}
+}
+
+func (nc *notifyChannel) HasChannel(channel string) bool {
+	return channel == nc.name
+}
+
+// PublishChannel is refused because the messages of the channel are the row changes
+// which clear the cache of the keys
+func (nc *notifyChannel) PublishChannel(ctx context.Context, channel string, message []byte) error {
+	return storage.ErrReadOnly
+}
+
+func (nc *notifyChannel) ListenChannels(ctx context.Context, fn func(channel string, message []byte)) error {
+	return nc.pg.ListenNotifies(ctx, nc.name, func(_ context.Context, payload string) {
+		fn(nc.name, []byte(payload))
+	})
+}
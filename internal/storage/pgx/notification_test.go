@@ -0,0 +1,55 @@
+package pgx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demdxx/redify/internal/storage"
+)
+
+func TestNotificationEvent(t *testing.T) {
+	var (
+		past   = time.Now().Add(-time.Hour).UTC()
+		future = time.Now().Add(time.Hour)
+	)
+	tests := []struct {
+		name    string
+		payload string
+		event   string
+	}{
+		{
+			name:    "insert",
+			payload: `{"table":"users","action":"INSERT","data":{"id":1}}`,
+			event:   storage.KeyEventSet,
+		},
+		{
+			name:    "delete",
+			payload: `{"table":"users","action":"DELETE","data":{"id":1,"expires_at":null}}`,
+			event:   storage.KeyEventDel,
+		},
+		{
+			name:    "delete_alive",
+			payload: `{"table":"users","action":"DELETE","data":{"id":1,"expires_at":"` + future.Format(time.RFC3339Nano) + `"}}`,
+			event:   storage.KeyEventDel,
+		},
+		{
+			name:    "delete_expired",
+			payload: `{"table":"users","action":"DELETE","data":{"id":1,"expires_at":"` + past.Format("2006-01-02T15:04:05.999999") + `"}}`,
+			event:   storage.KeyEventExpired,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var n Notification
+			if !assert.NoError(t, n.unmarshal([]byte(test.payload))) {
+				return
+			}
+			ectx, err := n.ectx()
+			if assert.NoError(t, err) {
+				assert.Equal(t, test.event, n.event(ectx, "expires_at"))
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package pgx
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+)
+
+type txKey struct {
+	conn pgpoolIface
+}
+
+// pgTx adapts the pgx transaction to the storage transaction interface
+type pgTx struct {
+	ctx context.Context
+	tx  pgx.Tx
+}
+
+func (tx *pgTx) Commit() error {
+	return tx.tx.Commit(tx.ctx)
+}
+
+func (tx *pgTx) Rollback() error {
+	return tx.tx.Rollback(tx.ctx)
+}
+
+// withTx returns the context which executes queries of the connection inside the transaction
+func withTx(ctx context.Context, conn pgpoolIface, tx pgx.Tx) context.Context {
+	return context.WithValue(ctx, txKey{conn: conn}, tx)
+}
+
+// querier returns the transaction of the context or the connection pool
+func (b *Bind) querier(ctx context.Context) pgpoolIface {
+	if tx, _ := ctx.Value(txKey{conn: b.conn}).(pgx.Tx); tx != nil {
+		return tx
+	}
+	return b.conn
+}
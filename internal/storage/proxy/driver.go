@@ -1,81 +1,728 @@
 package proxy
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"go.uber.org/multierr"
 	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
 
+	"github.com/demdxx/redify/internal/cache"
 	"github.com/demdxx/redify/internal/context/ctxlogger"
+	"github.com/demdxx/redify/internal/keypattern"
 	"github.com/demdxx/redify/internal/storage"
 )
 
 type notifyListener interface {
-	ListenUpdateNotifies(ctx context.Context, chanelName string, notifyFnk func(ctx context.Context, key string)) error
+	ListenUpdateNotifies(ctx context.Context, chanelName string, notifyFnk func(ctx context.Context, event storage.KeyEvent)) error
+}
+
+// loadTimeout limits the store requests shared by the concurrent reads,
+// they are not canceled by the reader which has started them
+const loadTimeout = 30 * time.Second
+
+// notFoundValue marks the cached missing keys
+var notFoundValue = []byte("\x00redify:not-found")
+
+// bindCache of the keys matched by the bind pattern, nil cache bypasses the cache
+type bindCache struct {
+	dbnum       int
+	pattern     *keypattern.Pattern
+	cache       storage.Cacher
+	negativeTTL time.Duration
+}
+
+// Options of the proxy store
+type Options struct {
+	// NotifyChannel of the database update notifications which clear the cache
+	NotifyChannel string
+	// MaxInFlight limits the number of the distinct missed keys loaded from the store at once,
+	// zero means unlimited
+	MaxInFlight int
+	// StaleTTL keeps the expired values which are served while the refresh is running
+	// and when the store fails
+	StaleTTL time.Duration
+	// RefreshAhead refreshes the values in the background before the expiration
+	RefreshAhead time.Duration
+}
+
+// keyChanges counts the changes of the keys of the stripe
+type keyChanges struct {
+	mx  sync.Mutex
+	gen uint64
 }
 
 type proxyStore struct {
 	cache storage.Cacher
 	store storage.Driver
+
+	// Caches of the binds which override the source cache
+	binds []bindCache
+
+	// Concurrent misses of the same key share one store request
+	loads    singleflight.Group
+	inflight chan struct{}
+	// Loads don't cache the values of the keys changed while they were loading
+	changes [256]keyChanges
+
+	staleTTL     time.Duration
+	refreshAhead time.Duration
+
+	// Reads served by the cache and by the store
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	coalesced atomic.Uint64
+	throttled atomic.Uint64
+	loading   atomic.Int64
+	stale     atomic.Uint64
+	refreshed atomic.Uint64
+	negative  atomic.Uint64
+
+	listenersMx    sync.RWMutex
+	eventListeners []func(ctx context.Context, event storage.KeyEvent)
+	// The changes of the keys are reported by the notify channel
+	notifies bool
 }
 
-// New proxy driver cache implementation
-func New(ctx context.Context, cache storage.Cacher, store storage.Driver, notifyChannelName string) storage.Driver {
-	if cache == nil {
-		return store
-	}
+// New proxy driver cache implementation.
+// The source cache can be nil if only the binds define their own caches.
+// Caches are expected to be prefixed by the namespace of the source,
+// the proxy stores the keys prefixed by the dbnum.
+func New(ctx context.Context, cache storage.Cacher, store storage.Driver, opts Options) storage.Driver {
 	prx := &proxyStore{
-		cache: cache,
-		store: store,
+		cache:        cache,
+		store:        store,
+		staleTTL:     opts.StaleTTL,
+		refreshAhead: opts.RefreshAhead,
+	}
+	if opts.MaxInFlight > 0 {
+		prx.inflight = make(chan struct{}, opts.MaxInFlight)
 	}
-	if notifier, _ := store.(notifyListener); notifier != nil && notifyChannelName != "" {
+	if notifier, _ := store.(notifyListener); notifier != nil && opts.NotifyChannel != "" {
+		prx.notifies = true
 		go func() {
 			ctxlogger.Get(ctx).Info("run notify listener")
-			if err := notifier.ListenUpdateNotifies(ctx, notifyChannelName, prx.notifier); err != nil {
+			if err := notifier.ListenUpdateNotifies(ctx, opts.NotifyChannel, prx.updateNotifier); err != nil {
 				ctxlogger.Get(ctx).Error("notification updates listener", zap.Error(err))
 			}
 		}()
+	} else if notifier, _ := store.(storage.KeyEventNotifier); notifier != nil {
+		// Changes of the notify channel include the events of the store itself
+		notifier.OnKeyEvent(prx.updateNotifier)
 	}
 	return prx
 }
 
 func (d *proxyStore) Get(ctx context.Context, dbnum int, key string) ([]byte, error) {
-	val, err := d.cache.Get(ctx, key)
+	c := d.cacheFor(dbnum, key)
+	if c == nil || storage.InTx(ctx) {
+		// Not committed values must not be cached
+		return d.store.Get(ctx, dbnum, key)
+	}
+	ent, err := cache.GetEntry(ctx, c, cacheKey(dbnum, key))
 	if err != nil && !errors.Is(err, storage.ErrNotFound) {
 		return nil, err
 	}
 	if err == nil {
+		if val, hit, err := d.cachedValue(ctx, c, dbnum, key, ent); hit {
+			return val, err
+		}
+	}
+	d.misses.Add(1)
+	return d.load(ctx, c, dbnum, key)
+}
+
+// cachedValue returns the value of the cache entry or false if the entry is expired.
+// The stale value is served while it's refreshed in the background.
+func (d *proxyStore) cachedValue(ctx context.Context, c storage.Cacher, dbnum int, key string, ent cache.Entry) ([]byte, bool, error) {
+	now := time.Now()
+	switch {
+	case ent.Stale(now):
+		if d.staleTTL <= 0 || isNotFound(ent.Value) {
+			return nil, false, nil
+		}
+		// The expired value is served until the refresh succeeds or the stale TTL is over
+		ctxlogger.Get(ctx).Debug("get stale value from cache",
+			zap.String("key", key), zap.Int("dbnum", dbnum))
+		d.hits.Add(1)
+		d.stale.Add(1)
+		d.refresh(ctx, c, dbnum, key)
+		return ent.Value, true, nil
+	case isNotFound(ent.Value):
+		ctxlogger.Get(ctx).Debug("get missing key from cache",
+			zap.String("key", key), zap.Int("dbnum", dbnum))
+		d.hits.Add(1)
+		d.negative.Add(1)
+		return nil, true, storage.ErrNotFound
+	case ent.RefreshDue(now, d.refreshAhead):
+		d.hits.Add(1)
+		d.refresh(ctx, c, dbnum, key)
+		return ent.Value, true, nil
+	default:
 		ctxlogger.Get(ctx).Debug("get value from cache",
 			zap.String("key", key), zap.Int("dbnum", dbnum))
-		return val, nil
+		d.hits.Add(1)
+		return ent.Value, true, nil
+	}
+}
+
+// load the missed value from the store and cache it.
+// Concurrent misses of the key wait for the same store request and share its result.
+func (d *proxyStore) load(ctx context.Context, c storage.Cacher, dbnum int, key string) ([]byte, error) {
+	var loaded bool
+	res := d.loads.DoChan(cacheKey(dbnum, key), func() (any, error) {
+		loaded = true
+		// The waiters of the load must not fail if the first reader goes away
+		lctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), loadTimeout)
+		defer cancel()
+		return d.fetch(lctx, c, dbnum, key)
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-res:
+		if !loaded {
+			d.coalesced.Add(1)
+		}
+		if r.Err != nil {
+			return nil, r.Err
+		}
+		return r.Val.([]byte), nil
+	}
+}
+
+// refresh the cached value in the background, the stale value is kept if the store fails.
+// The refresh joins the load of the key if it's running already.
+func (d *proxyStore) refresh(ctx context.Context, c storage.Cacher, dbnum int, key string) {
+	var loaded bool
+	ctx = context.WithoutCancel(ctx)
+	res := d.loads.DoChan(cacheKey(dbnum, key), func() (any, error) {
+		loaded = true
+		lctx, cancel := context.WithTimeout(ctx, loadTimeout)
+		defer cancel()
+		val, err := d.fetch(lctx, c, dbnum, key)
+		switch {
+		case errors.Is(err, storage.ErrNotFound) && d.negativeTTL(dbnum, key) <= 0:
+			// Removed records must not be served from the cache
+			if err := c.Del(ctx, cacheKey(dbnum, key)); err != nil && !errors.Is(err, storage.ErrNotFound) {
+				ctxlogger.Get(ctx).Error("clear key cache", zap.String("key", key), zap.Error(err))
+			}
+		case err != nil:
+			ctxlogger.Get(ctx).Warn("refresh key cache", zap.String("key", key), zap.Error(err))
+		}
+		return val, err
+	})
+	go func() {
+		// The key is released by the loads before the result is sent
+		if <-res; loaded {
+			d.refreshed.Add(1)
+		}
+	}()
+}
+
+// fetch the value from the store and cache it
+func (d *proxyStore) fetch(ctx context.Context, c storage.Cacher, dbnum int, key string) ([]byte, error) {
+	if err := d.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer d.release()
+	gen := d.generation(dbnum, key)
+	val, ttl, err := storage.GetWithTTL(ctx, d.store, dbnum, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		d.cacheLoaded(dbnum, key, gen, func() { d.setNotFound(ctx, c, dbnum, key) })
 	}
-	if val, err = d.store.Get(ctx, dbnum, key); err != nil {
+	if err != nil {
 		return nil, err
 	}
 	ctxlogger.Get(ctx).Debug("get value from store",
 		zap.String("key", key), zap.Int("dbnum", dbnum))
-	if err = d.cache.Set(ctx, key, val); err != nil {
+	d.cacheLoaded(dbnum, key, gen, func() {
+		err = d.setCache(ctx, c, cacheKey(dbnum, key), val, ttl)
+	})
+	if err != nil {
 		return nil, err
 	}
 	return val, nil
 }
 
+// keyChanges of the stripe of the key
+func (d *proxyStore) keyChanges(dbnum int, key string) *keyChanges {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(cacheKey(dbnum, key)))
+	return &d.changes[h.Sum32()%uint32(len(d.changes))]
+}
+
+// generation of the key changes before the load
+func (d *proxyStore) generation(dbnum int, key string) uint64 {
+	ch := d.keyChanges(dbnum, key)
+	ch.mx.Lock()
+	defer ch.mx.Unlock()
+	return ch.gen
+}
+
+// changed marks the key as changed for the loads started before,
+// the cache of the key must be updated after the mark
+func (d *proxyStore) changed(dbnum int, key string) {
+	ch := d.keyChanges(dbnum, key)
+	ch.mx.Lock()
+	ch.gen++
+	ch.mx.Unlock()
+}
+
+// cacheLoaded calls the cache write of the loaded value if the key wasn't changed
+// since the generation, otherwise the load could overwrite the newer cached value.
+// Keys of the same stripe skip the write of the load by the change of each other.
+func (d *proxyStore) cacheLoaded(dbnum int, key string, gen uint64, write func()) {
+	ch := d.keyChanges(dbnum, key)
+	ch.mx.Lock()
+	defer ch.mx.Unlock()
+	if ch.gen == gen {
+		write()
+	}
+}
+
+// setCache stores the value which can be served stale after the expiration.
+// Expiring records are cached not longer than they live and are never served stale.
+func (d *proxyStore) setCache(ctx context.Context, c storage.Cacher, ckey string, value []byte, ttl time.Duration) error {
+	if ttl > 0 {
+		return cache.SetWithTTL(ctx, c, ckey, value, ttl)
+	}
+	return cache.SetStale(ctx, c, ckey, value, d.staleTTL)
+}
+
+// setNotFound caches the missing key if the bind of the key has the negative TTL
+func (d *proxyStore) setNotFound(ctx context.Context, c storage.Cacher, dbnum int, key string) {
+	ttl := d.negativeTTL(dbnum, key)
+	if ttl <= 0 {
+		return
+	}
+	if err := cache.SetWithTTL(ctx, c, cacheKey(dbnum, key), notFoundValue, ttl); err != nil {
+		ctxlogger.Get(ctx).Error("cache missing key", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// acquire the slot of the store loads, waits if the limit of the loads is reached
+func (d *proxyStore) acquire(ctx context.Context) error {
+	if d.inflight != nil {
+		select {
+		case d.inflight <- struct{}{}:
+		default:
+			d.throttled.Add(1)
+			select {
+			case d.inflight <- struct{}{}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+	d.loading.Add(1)
+	return nil
+}
+
+func (d *proxyStore) release() {
+	d.loading.Add(-1)
+	if d.inflight != nil {
+		<-d.inflight
+	}
+}
+
+// GetFields of the record from the cached value or load only the fields from the store.
+// Partial records are not cached.
+func (d *proxyStore) GetFields(ctx context.Context, dbnum int, key string, fields []string) ([]byte, error) {
+	c := d.cacheFor(dbnum, key)
+	if c == nil || storage.InTx(ctx) {
+		return storage.GetFields(ctx, d.store, dbnum, key, fields)
+	}
+	val, err := c.Get(ctx, cacheKey(dbnum, key))
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return nil, err
+	}
+	if err == nil {
+		ctxlogger.Get(ctx).Debug("get fields from cache",
+			zap.String("key", key), zap.Int("dbnum", dbnum))
+		d.hits.Add(1)
+		if isNotFound(val) {
+			d.negative.Add(1)
+			return nil, storage.ErrNotFound
+		}
+		return storage.FilterFields(val, fields)
+	}
+	d.misses.Add(1)
+	return storage.GetFields(ctx, d.store, dbnum, key, fields)
+}
+
+// GetJSONPath values of the record from the cached value or evaluate the path by the store
+func (d *proxyStore) GetJSONPath(ctx context.Context, dbnum int, key string, path *storage.JSONPath) ([]json.RawMessage, error) {
+	c := d.cacheFor(dbnum, key)
+	if c == nil || storage.InTx(ctx) {
+		return storage.GetJSONPath(ctx, d.store, dbnum, key, path)
+	}
+	val, err := c.Get(ctx, cacheKey(dbnum, key))
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return nil, err
+	}
+	if err == nil {
+		ctxlogger.Get(ctx).Debug("get JSON path from cache",
+			zap.String("key", key), zap.Int("dbnum", dbnum))
+		d.hits.Add(1)
+		if isNotFound(val) {
+			d.negative.Add(1)
+			return nil, storage.ErrNotFound
+		}
+		return storage.EvalJSONPath(val, path)
+	}
+	d.misses.Add(1)
+	return storage.GetJSONPath(ctx, d.store, dbnum, key, path)
+}
+
+// GetMany values from the cache and load only missed keys from the store
+func (d *proxyStore) GetMany(ctx context.Context, dbnum int, keys []string) []storage.BatchItem {
+	if storage.InTx(ctx) {
+		return storage.GetMany(ctx, d.store, dbnum, keys)
+	}
+	var (
+		items  = make([]storage.BatchItem, len(keys))
+		caches = make([]storage.Cacher, len(keys))
+		misses = make([]int, 0, len(keys))
+	)
+	for i, key := range keys {
+		if caches[i] = d.cacheFor(dbnum, key); caches[i] == nil {
+			misses = append(misses, i)
+			continue
+		}
+		ent, err := cache.GetEntry(ctx, caches[i], cacheKey(dbnum, key))
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			items[i].Err = err
+			continue
+		}
+		if err == nil {
+			if val, hit, err := d.cachedValue(ctx, caches[i], dbnum, key, ent); hit {
+				items[i] = storage.BatchItem{Value: val, Err: err}
+				continue
+			}
+		}
+		d.misses.Add(1)
+		misses = append(misses, i)
+	}
+	ctxlogger.Get(ctx).Debug("get values from cache",
+		zap.Int("keys", len(keys)), zap.Int("misses", len(misses)), zap.Int("dbnum", dbnum))
+	switch {
+	case len(misses) == 0:
+	case len(misses) == 1 && caches[misses[0]] != nil:
+		i := misses[0]
+		items[i].Value, items[i].Err = d.load(ctx, caches[i], dbnum, keys[i])
+	default:
+		missKeys := make([]string, len(misses))
+		missCaches := make([]storage.Cacher, len(misses))
+		for j, i := range misses {
+			missKeys[j], missCaches[j] = keys[i], caches[i]
+		}
+		for j, item := range d.loadMany(ctx, missCaches, dbnum, missKeys) {
+			items[misses[j]] = item
+		}
+	}
+	return items
+}
+
+// loadMany the missed values from the store by one batch request and cache them.
+// Concurrent batches of the same keys wait for the same store request.
+func (d *proxyStore) loadMany(ctx context.Context, caches []storage.Cacher, dbnum int, keys []string) []storage.BatchItem {
+	var loaded bool
+	res := d.loads.DoChan(batchKey(dbnum, keys), func() (any, error) {
+		loaded = true
+		lctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), loadTimeout)
+		defer cancel()
+		return d.fetchMany(lctx, caches, dbnum, keys)
+	})
+	var err error
+	select {
+	case <-ctx.Done():
+		err = ctx.Err()
+	case r := <-res:
+		if !loaded {
+			d.coalesced.Add(1)
+		}
+		if r.Err == nil {
+			return r.Val.([]storage.BatchItem)
+		}
+		err = r.Err
+	}
+	items := make([]storage.BatchItem, len(keys))
+	for i := range items {
+		items[i].Err = err
+	}
+	return items
+}
+
+// fetchMany values from the store and cache them, keys without the cache are only fetched
+func (d *proxyStore) fetchMany(ctx context.Context, caches []storage.Cacher, dbnum int, keys []string) ([]storage.BatchItem, error) {
+	if err := d.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer d.release()
+	gens := make([]uint64, len(keys))
+	for i, key := range keys {
+		gens[i] = d.generation(dbnum, key)
+	}
+	items := storage.GetMany(ctx, d.store, dbnum, keys)
+	for i, item := range items {
+		if caches[i] == nil {
+			continue
+		}
+		d.cacheLoaded(dbnum, keys[i], gens[i], func() {
+			switch {
+			case errors.Is(item.Err, storage.ErrNotFound):
+				d.setNotFound(ctx, caches[i], dbnum, keys[i])
+			case item.Err != nil:
+			default:
+				if err := d.setCache(ctx, caches[i], cacheKey(dbnum, keys[i]), item.Value, item.TTL); err != nil {
+					ctxlogger.Get(ctx).Error("cache set", zap.String("key", keys[i]), zap.Error(err))
+				}
+			}
+		})
+	}
+	return items, nil
+}
+
 func (d *proxyStore) Set(ctx context.Context, dbnum int, key string, value []byte) error {
 	err := d.store.Set(ctx, dbnum, key, value)
 	if err == nil {
-		cerr := d.cache.Set(ctx, key, value)
-		if cerr != nil {
-			ctxlogger.Get(ctx).Error("cache set", zap.Error(err))
-		}
+		storage.AfterCommit(ctx, func() {
+			if c := d.cacheFor(dbnum, key); c != nil {
+				d.changed(dbnum, key)
+				if cerr := d.setCache(ctx, c, cacheKey(dbnum, key), value, 0); cerr != nil {
+					ctxlogger.Get(ctx).Error("cache set", zap.Error(cerr))
+				}
+			}
+		})
 	}
 	return err
 }
 
+// SetWithOptions writes the value by the SET options of the store,
+// conditional and expiring values are removed from the cache
+func (d *proxyStore) SetWithOptions(ctx context.Context, dbnum int, key string, value []byte, opts storage.SetOptions) (storage.SetResult, error) {
+	if opts.IsDefault() {
+		return storage.SetResult{Applied: true}, d.Set(ctx, dbnum, key, value)
+	}
+	res, err := storage.SetWithOptions(ctx, d.store, dbnum, key, value, opts)
+	if err == nil && res.Applied {
+		storage.AfterCommit(ctx, func() { d.notifier(ctx, dbnum, key) })
+	}
+	return res, err
+}
+
+// Expire sets the expiration time of the key and removes it from the cache
+func (d *proxyStore) Expire(ctx context.Context, dbnum int, key string, at time.Time) (bool, error) {
+	ok, err := storage.Expire(ctx, d.store, dbnum, key, at)
+	if err == nil && ok {
+		storage.AfterCommit(ctx, func() { d.notifier(ctx, dbnum, key) })
+	}
+	return ok, err
+}
+
+// TTL returns the remaining time to live of the key from the store
+func (d *proxyStore) TTL(ctx context.Context, dbnum int, key string) (time.Duration, error) {
+	return storage.TTL(ctx, d.store, dbnum, key)
+}
+
+func (d *proxyStore) SetFields(ctx context.Context, dbnum int, key string, fields map[string]string) (int64, error) {
+	fw, _ := d.store.(storage.FieldWriter)
+	if fw == nil {
+		return 0, storage.ErrMethodIsNotSupported
+	}
+	count, err := fw.SetFields(ctx, dbnum, key, fields)
+	if err == nil {
+		storage.AfterCommit(ctx, func() { d.notifier(ctx, dbnum, key) })
+	}
+	return count, err
+}
+
+func (d *proxyStore) DelFields(ctx context.Context, dbnum int, key string, fields []string) (int64, error) {
+	fw, _ := d.store.(storage.FieldWriter)
+	if fw == nil {
+		return 0, storage.ErrMethodIsNotSupported
+	}
+	count, err := fw.DelFields(ctx, dbnum, key, fields)
+	if err == nil {
+		storage.AfterCommit(ctx, func() { d.notifier(ctx, dbnum, key) })
+	}
+	return count, err
+}
+
+func (d *proxyStore) IncrField(ctx context.Context, dbnum int, key, field string, delta any) (any, error) {
+	fw, _ := d.store.(storage.FieldWriter)
+	if fw == nil {
+		return nil, storage.ErrMethodIsNotSupported
+	}
+	value, err := fw.IncrField(ctx, dbnum, key, field, delta)
+	if err == nil {
+		storage.AfterCommit(ctx, func() { d.notifier(ctx, dbnum, key) })
+	}
+	return value, err
+}
+
+// IncrBy increments the counter in the store and patches the cached record
+// with the new value keeping its expiration
+func (d *proxyStore) IncrBy(ctx context.Context, dbnum int, key string, delta any) (string, any, error) {
+	field, value, err := storage.IncrBy(ctx, d.store, dbnum, key, delta)
+	if err == nil {
+		storage.AfterCommit(ctx, func() {
+			d.changed(dbnum, key)
+			if !d.replaceField(ctx, dbnum, key, field, value) {
+				d.notifier(ctx, dbnum, key)
+			}
+		})
+	}
+	return field, value, err
+}
+
+// Range of the list members from the store
+func (d *proxyStore) Range(ctx context.Context, dbnum int, key string, start, stop int64) ([]string, error) {
+	cr, _ := d.store.(storage.CollectionReader)
+	if cr == nil {
+		return nil, storage.ErrMethodIsNotSupported
+	}
+	return cr.Range(ctx, dbnum, key, start, stop)
+}
+
+// Len returns the number of the collection members from the store
+func (d *proxyStore) Len(ctx context.Context, dbnum int, key string) (int64, error) {
+	cr, _ := d.store.(storage.CollectionReader)
+	if cr == nil {
+		return 0, storage.ErrMethodIsNotSupported
+	}
+	return cr.Len(ctx, dbnum, key)
+}
+
+// Members of the set from the store
+func (d *proxyStore) Members(ctx context.Context, dbnum int, key string) ([]string, error) {
+	cr, _ := d.store.(storage.CollectionReader)
+	if cr == nil {
+		return nil, storage.ErrMethodIsNotSupported
+	}
+	return cr.Members(ctx, dbnum, key)
+}
+
+// IsMember checks the member of the set in the store
+func (d *proxyStore) IsMember(ctx context.Context, dbnum int, key, member string) (bool, error) {
+	cr, _ := d.store.(storage.CollectionReader)
+	if cr == nil {
+		return false, storage.ErrMethodIsNotSupported
+	}
+	return cr.IsMember(ctx, dbnum, key, member)
+}
+
+// AddMembers to the collection and removes the cached members
+func (d *proxyStore) AddMembers(ctx context.Context, dbnum int, key string, members []string) (int64, error) {
+	cw, _ := d.store.(storage.CollectionWriter)
+	if cw == nil {
+		return 0, storage.ErrMethodIsNotSupported
+	}
+	count, err := cw.AddMembers(ctx, dbnum, key, members)
+	if err == nil {
+		storage.AfterCommit(ctx, func() { d.notifier(ctx, dbnum, key) })
+	}
+	return count, err
+}
+
+// RemMembers of the collection and removes the cached members
+func (d *proxyStore) RemMembers(ctx context.Context, dbnum int, key string, members []string) (int64, error) {
+	cw, _ := d.store.(storage.CollectionWriter)
+	if cw == nil {
+		return 0, storage.ErrMethodIsNotSupported
+	}
+	count, err := cw.RemMembers(ctx, dbnum, key, members)
+	if err == nil {
+		storage.AfterCommit(ctx, func() { d.notifier(ctx, dbnum, key) })
+	}
+	return count, err
+}
+
+// ZRange of the sorted set from the store
+func (d *proxyStore) ZRange(ctx context.Context, dbnum int, key string, rng storage.ZRange) ([]storage.ScoredMember, error) {
+	sr, _ := d.store.(storage.SortedSetReader)
+	if sr == nil {
+		return nil, storage.ErrMethodIsNotSupported
+	}
+	return sr.ZRange(ctx, dbnum, key, rng)
+}
+
+// ZScore of the member from the store
+func (d *proxyStore) ZScore(ctx context.Context, dbnum int, key, member string) (float64, error) {
+	sr, _ := d.store.(storage.SortedSetReader)
+	if sr == nil {
+		return 0, storage.ErrMethodIsNotSupported
+	}
+	return sr.ZScore(ctx, dbnum, key, member)
+}
+
+// ZRank of the member from the store
+func (d *proxyStore) ZRank(ctx context.Context, dbnum int, key, member string, rev bool) (int64, error) {
+	sr, _ := d.store.(storage.SortedSetReader)
+	if sr == nil {
+		return 0, storage.ErrMethodIsNotSupported
+	}
+	return sr.ZRank(ctx, dbnum, key, member, rev)
+}
+
+// ZAdd to the sorted set and removes the cached members
+func (d *proxyStore) ZAdd(ctx context.Context, dbnum int, key string, members []storage.ScoredMember, mode storage.SetMode) (int64, int64, error) {
+	sw, _ := d.store.(storage.SortedSetWriter)
+	if sw == nil {
+		return 0, 0, storage.ErrMethodIsNotSupported
+	}
+	added, changed, err := sw.ZAdd(ctx, dbnum, key, members, mode)
+	if err == nil && added+changed > 0 {
+		storage.AfterCommit(ctx, func() { d.notifier(ctx, dbnum, key) })
+	}
+	return added, changed, err
+}
+
+// ZIncrBy the score of the member and removes the cached members
+func (d *proxyStore) ZIncrBy(ctx context.Context, dbnum int, key, member string, delta float64) (float64, error) {
+	sw, _ := d.store.(storage.SortedSetWriter)
+	if sw == nil {
+		return 0, storage.ErrMethodIsNotSupported
+	}
+	score, err := sw.ZIncrBy(ctx, dbnum, key, member, delta)
+	if err == nil {
+		storage.AfterCommit(ctx, func() { d.notifier(ctx, dbnum, key) })
+	}
+	return score, err
+}
+
+// KeyType returns the type of the key from the store
+func (d *proxyStore) KeyType(ctx context.Context, dbnum int, key string) (string, error) {
+	return storage.KeyType(ctx, d.store, dbnum, key)
+}
+
 func (d *proxyStore) Del(ctx context.Context, dbnum int, key string) error {
-	err := d.cache.Del(ctx, key)
-	err = multierr.Append(err, d.store.Del(ctx, dbnum, key))
-	return err
+	if storage.InTx(ctx) {
+		err := d.store.Del(ctx, dbnum, key)
+		if err == nil {
+			storage.AfterCommit(ctx, func() { d.notifier(ctx, dbnum, key) })
+		}
+		return err
+	}
+	var (
+		err  error
+		serr = d.store.Del(ctx, dbnum, key)
+	)
+	if c := d.cacheFor(dbnum, key); c != nil {
+		d.changed(dbnum, key)
+		err = c.Del(ctx, cacheKey(dbnum, key))
+	}
+	return multierr.Append(err, serr)
 }
 
 func (d *proxyStore) Keys(ctx context.Context, dbnum int, pattern string) ([]string, error) {
@@ -86,12 +733,138 @@ func (d *proxyStore) List(ctx context.Context, dbnum int, pattern string) ([]sto
 	return d.store.List(ctx, dbnum, pattern)
 }
 
+func (d *proxyStore) Scan(ctx context.Context, dbnum int, cursor uint64, opts storage.ScanOptions) ([]string, uint64, error) {
+	return d.store.Scan(ctx, dbnum, cursor, opts)
+}
+
+// Bind the keys in the store and register the cache of the bind if it's defined
 func (d *proxyStore) Bind(ctx context.Context, conf *storage.BindConfig) error {
-	return d.store.Bind(ctx, conf)
+	if err := d.store.Bind(ctx, conf); err != nil {
+		return err
+	}
+	if conf.Cache != nil || conf.NoCache || conf.NegativeTTL > 0 {
+		bind := bindCache{
+			dbnum:       conf.DBNum,
+			pattern:     keypattern.NewPatternFromExpression(conf.Pattern),
+			cache:       conf.Cache,
+			negativeTTL: conf.NegativeTTL,
+		}
+		switch {
+		case conf.NoCache:
+			bind.cache = nil
+		case conf.Cache == nil:
+			bind.cache = d.cache
+		}
+		d.binds = append(d.binds, bind)
+	}
+	return nil
+}
+
+// HasKey returns true if the transactional store serves the key
+func (d *proxyStore) HasKey(dbnum int, key string) bool {
+	tr, _ := d.store.(storage.Transactioner)
+	return tr != nil && tr.HasKey(dbnum, key)
+}
+
+// BeginTx of the store, cache updates are applied after the commit
+func (d *proxyStore) BeginTx(ctx context.Context, dbnum int, keys []string) (context.Context, storage.Tx, error) {
+	tr, _ := d.store.(storage.Transactioner)
+	if tr == nil {
+		return ctx, nil, storage.ErrMethodIsNotSupported
+	}
+	return tr.BeginTx(ctx, dbnum, keys)
+}
+
+// DBSize returns the number of the keys from the store
+func (d *proxyStore) DBSize(ctx context.Context, dbnum int) (int64, error) {
+	return storage.DBSize(ctx, d.store, dbnum)
 }
 
-func (d *proxyStore) notifier(ctx context.Context, key string) {
-	if err := d.cache.Del(ctx, key); err != nil {
+// Stats of the store sources with the cache reads
+func (d *proxyStore) Stats() []storage.SourceStats {
+	stats := storage.Stats(d.store)
+	if len(stats) == 0 {
+		stats = []storage.SourceStats{{}}
+	}
+	for i := range stats {
+		stats[i].Cache = &storage.CacheStats{
+			Hits:      d.hits.Load(),
+			Misses:    d.misses.Load(),
+			Coalesced: d.coalesced.Load(),
+			Throttled: d.throttled.Load(),
+			InFlight:  d.loading.Load(),
+			Stale:     d.stale.Load(),
+			Refreshed: d.refreshed.Load(),
+			Negative:  d.negative.Load(),
+		}
+	}
+	return stats
+}
+
+// cacheKey of the database key, the source namespace is the prefix of the cache
+func cacheKey(dbnum int, key string) string {
+	return strconv.Itoa(dbnum) + ":" + key
+}
+
+// batchKey of the loads which can't be the cache key of a single key
+func batchKey(dbnum int, keys []string) string {
+	return "\x00" + strconv.Itoa(dbnum) + ":" + strings.Join(keys, "\x00")
+}
+
+// bindFor returns the cache bind which matches the key or nil
+func (d *proxyStore) bindFor(dbnum int, key string) *bindCache {
+	for i, bind := range d.binds {
+		if bind.dbnum == dbnum && bind.pattern.Match(key, keypattern.ExecContext{}) {
+			return &d.binds[i]
+		}
+	}
+	return nil
+}
+
+// cacheFor returns the cache of the bind which matches the key or the source cache
+func (d *proxyStore) cacheFor(dbnum int, key string) storage.Cacher {
+	if bind := d.bindFor(dbnum, key); bind != nil {
+		return bind.cache
+	}
+	return d.cache
+}
+
+// negativeTTL of the missing key, zero if the missing key is not cached
+func (d *proxyStore) negativeTTL(dbnum int, key string) time.Duration {
+	if bind := d.bindFor(dbnum, key); bind != nil && bind.cache != nil {
+		return bind.negativeTTL
+	}
+	return 0
+}
+
+// isNotFound returns true if the cached value marks the missing key
+func isNotFound(value []byte) bool {
+	return bytes.Equal(value, notFoundValue)
+}
+
+// replaceField of the cached record, returns false if the cached value is not updated
+func (d *proxyStore) replaceField(ctx context.Context, dbnum int, key, field string, value any) bool {
+	c := d.cacheFor(dbnum, key)
+	if c == nil {
+		return false
+	}
+	cached, err := c.Get(ctx, cacheKey(dbnum, key))
+	if err != nil || isNotFound(cached) {
+		return false
+	}
+	if cached, err = storage.ReplaceField(cached, field, value); err != nil {
+		return false
+	}
+	return cache.Replace(ctx, c, cacheKey(dbnum, key), cached) == nil
+}
+
+func (d *proxyStore) notifier(ctx context.Context, dbnum int, key string) {
+	c := d.cacheFor(dbnum, key)
+	if c == nil {
+		return
+	}
+	d.changed(dbnum, key)
+	if err := c.Del(ctx, cacheKey(dbnum, key)); err != nil {
 		if err != storage.ErrNotFound && err != storage.ErrNoKey {
 			ctxlogger.Get(ctx).Error("clear key cache", zap.String("key", key), zap.Error(err))
 		}
@@ -100,8 +873,46 @@ func (d *proxyStore) notifier(ctx context.Context, key string) {
 	}
 }
 
+// updateNotifier clears the cache of the key changed in the database and reports the key event
+func (d *proxyStore) updateNotifier(ctx context.Context, event storage.KeyEvent) {
+	d.notifier(ctx, event.DBNum, event.Key)
+	d.listenersMx.RLock()
+	defer d.listenersMx.RUnlock()
+	for _, fn := range d.eventListeners {
+		fn(ctx, event)
+	}
+}
+
+// ReportsKeyEvents returns true if the key of the store is reported by the notify channel
+func (d *proxyStore) ReportsKeyEvents(dbnum int, key string) bool {
+	return d.notifies && d.HasKey(dbnum, key)
+}
+
+// EnableExpiredEvents of the store, the notify channel reports the expired keys itself
+func (d *proxyStore) EnableExpiredEvents(enabled bool) {
+	if !d.notifies {
+		storage.EnableExpiredEvents(d.store, enabled)
+	}
+}
+
+// OnKeyEvent registers the callback which is called for every key changed in the database
+func (d *proxyStore) OnKeyEvent(fn func(ctx context.Context, event storage.KeyEvent)) {
+	d.listenersMx.Lock()
+	defer d.listenersMx.Unlock()
+	d.eventListeners = append(d.eventListeners, fn)
+}
+
 func (d *proxyStore) Close() error {
-	err := d.cache.Close()
+	var err error
+	if d.cache != nil {
+		err = d.cache.Close()
+	}
+	for _, bind := range d.binds {
+		// Binds without their own cache share the source cache
+		if bind.cache != nil && bind.cache != d.cache {
+			err = multierr.Append(err, bind.cache.Close())
+		}
+	}
 	err = multierr.Append(err, d.store.Close())
 	return err
 }
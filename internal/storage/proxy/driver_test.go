@@ -0,0 +1,563 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demdxx/redify/internal/cache"
+	"github.com/demdxx/redify/internal/cache/lrucache"
+	"github.com/demdxx/redify/internal/cache/simplecache"
+	"github.com/demdxx/redify/internal/storage"
+)
+
+// memStore counts the reads of the values, the keys of db0 are stored as is
+type memStore struct {
+	values map[string][]byte
+	reads  map[string]int
+}
+
+func newMemStore() *memStore {
+	return &memStore{values: map[string][]byte{}, reads: map[string]int{}}
+}
+
+func (s *memStore) key(dbnum int, key string) string {
+	if dbnum == 0 {
+		return key
+	}
+	return strconv.Itoa(dbnum) + "/" + key
+}
+
+func (s *memStore) Get(ctx context.Context, dbnum int, key string) ([]byte, error) {
+	key = s.key(dbnum, key)
+	s.reads[key]++
+	if val, ok := s.values[key]; ok {
+		return val, nil
+	}
+	return nil, storage.ErrNotFound
+}
+
+func (s *memStore) Set(ctx context.Context, dbnum int, key string, value []byte) error {
+	s.values[s.key(dbnum, key)] = value
+	return nil
+}
+
+func (s *memStore) Del(ctx context.Context, dbnum int, key string) error {
+	delete(s.values, s.key(dbnum, key))
+	return nil
+}
+
+func (s *memStore) Keys(ctx context.Context, dbnum int, pattern string) ([]string, error) {
+	return nil, nil
+}
+
+func (s *memStore) List(ctx context.Context, dbnum int, pattern string) ([]storage.Record, error) {
+	return nil, nil
+}
+
+func (s *memStore) Scan(ctx context.Context, dbnum int, cursor uint64, opts storage.ScanOptions) ([]string, uint64, error) {
+	return nil, 0, nil
+}
+
+func (s *memStore) Bind(ctx context.Context, conf *storage.BindConfig) error { return nil }
+func (s *memStore) Close() error                                             { return nil }
+
+func TestProxyBindCache(t *testing.T) {
+	ctx := context.Background()
+	globalCache, err := simplecache.New(10, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settingsCache, err := simplecache.New(10, 3600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		store = newMemStore()
+		prx   = New(ctx, globalCache, store, Options{})
+	)
+	defer prx.Close()
+
+	assert.NoError(t, prx.Bind(ctx, &storage.BindConfig{Pattern: "settings_{{name}}", Cache: settingsCache}))
+	assert.NoError(t, prx.Bind(ctx, &storage.BindConfig{Pattern: "stock_{{id}}", NoCache: true}))
+	assert.NoError(t, prx.Bind(ctx, &storage.BindConfig{Pattern: "user_{{id}}"}))
+	for _, key := range []string{"settings_theme", "stock_1", "user_1"} {
+		assert.NoError(t, store.Set(ctx, 0, key, []byte(`{"value":1}`)))
+		for i := 0; i < 2; i++ {
+			val, err := prx.Get(ctx, 0, key)
+			assert.NoError(t, err, key)
+			assert.Equal(t, []byte(`{"value":1}`), val, key)
+		}
+	}
+	assert.Equal(t, map[string]int{"settings_theme": 1, "stock_1": 2, "user_1": 1}, store.reads)
+
+	_, err = settingsCache.Get(ctx, "0:settings_theme")
+	assert.NoError(t, err, "the bind cache must contain the key")
+	_, err = globalCache.Get(ctx, "0:settings_theme")
+	assert.ErrorIs(t, err, storage.ErrNotFound, "the global cache must not contain the key of the bind cache")
+	_, err = globalCache.Get(ctx, "0:user_1")
+	assert.NoError(t, err)
+
+	items := prx.(storage.BatchGetter).GetMany(ctx, 0, []string{"settings_theme", "stock_1", "user_1"})
+	for _, item := range items {
+		assert.NoError(t, item.Err)
+	}
+	assert.Equal(t, 3, store.reads["stock_1"])
+
+	// Writes are stored in the bind cache, the deletion clears it
+	assert.NoError(t, prx.Set(ctx, 0, "settings_theme", []byte(`{"value":2}`)))
+	val, err := settingsCache.Get(ctx, "0:settings_theme")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(`{"value":2}`), val)
+	assert.NoError(t, prx.Del(ctx, 0, "settings_theme"))
+	_, err = settingsCache.Get(ctx, "0:settings_theme")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	stats := storage.Stats(prx)
+	if assert.Len(t, stats, 1) && assert.NotNil(t, stats[0].Cache) {
+		assert.Equal(t, uint64(4), stats[0].Cache.Hits)
+		assert.Equal(t, uint64(2), stats[0].Cache.Misses)
+	}
+}
+
+func TestProxyCacheKeyspace(t *testing.T) {
+	ctx := context.Background()
+	// LRU cache shares the storage of the prefixed caches
+	globalCache, err := lrucache.New(10, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer globalCache.Close()
+	var (
+		store1 = newMemStore()
+		store2 = newMemStore()
+		prx1   = New(ctx, globalCache.WithPrefix(cache.Namespace("1", "0")), store1, Options{})
+		prx2   = New(ctx, globalCache.WithPrefix(cache.Namespace("1", "1")), store2, Options{})
+	)
+	defer prx1.Close()
+	defer prx2.Close()
+
+	assert.NoError(t, store1.Set(ctx, 0, "user_1", []byte("db0")))
+	assert.NoError(t, store1.Set(ctx, 1, "user_1", []byte("db1")))
+	assert.NoError(t, store2.Set(ctx, 0, "user_1", []byte("source2")))
+	for i := 0; i < 2; i++ {
+		val, err := prx1.Get(ctx, 0, "user_1")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("db0"), val)
+		val, err = prx1.Get(ctx, 1, "user_1")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("db1"), val)
+		val, err = prx2.Get(ctx, 0, "user_1")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("source2"), val)
+	}
+	assert.Equal(t, map[string]int{"user_1": 1, "1/user_1": 1}, store1.reads)
+	assert.Equal(t, map[string]int{"user_1": 1}, store2.reads)
+}
+
+// blockingStore holds the reads until the release or the cancellation
+type blockingStore struct {
+	*memStore
+	mx      sync.Mutex
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (s *blockingStore) Get(ctx context.Context, dbnum int, key string) ([]byte, error) {
+	s.calls.Add(1)
+	select {
+	case <-s.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.memStore.Get(ctx, dbnum, key)
+}
+
+func TestProxyCoalescing(t *testing.T) {
+	ctx := context.Background()
+	globalCache, err := simplecache.New(10, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		store = &blockingStore{memStore: newMemStore(), release: make(chan struct{})}
+		prx   = New(ctx, globalCache, store, Options{MaxInFlight: 1})
+		wg    sync.WaitGroup
+	)
+	defer prx.Close()
+	assert.NoError(t, store.Set(ctx, 0, "user_1", []byte("user1")))
+	assert.NoError(t, store.Set(ctx, 0, "user_2", []byte("user2")))
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			val, err := prx.Get(ctx, 0, key)
+			assert.NoError(t, err)
+			assert.Equal(t, []byte(key[:4]+key[5:]), val)
+		}([]string{"user_1", "user_2"}[i%2])
+	}
+	assert.Eventually(t, func() bool {
+		cache := storage.Stats(prx)[0].Cache
+		return store.calls.Load() == 1 && cache.Misses == 10 && cache.Throttled == 1 && cache.InFlight == 1
+	}, time.Second, time.Millisecond, "the second key must wait for the first one")
+	// Let the missed requests join the loads
+	time.Sleep(10 * time.Millisecond)
+	close(store.release)
+	wg.Wait()
+
+	cache := storage.Stats(prx)[0].Cache
+	assert.Equal(t, int32(2), store.calls.Load())
+	assert.Equal(t, uint64(10), cache.Misses)
+	assert.Equal(t, uint64(8), cache.Coalesced)
+	assert.Equal(t, int64(0), cache.InFlight)
+}
+
+// agingCache keeps the stale entries which can be aged by the test
+type agingCache struct {
+	mx      sync.Mutex
+	entries map[string]cache.Entry
+}
+
+func (c *agingCache) WithPrefix(prefix string) cache.Cacher { return c }
+func (c *agingCache) Close() error                          { return nil }
+
+func (c *agingCache) Get(ctx context.Context, key string) ([]byte, error) {
+	ent, err := c.GetEntry(ctx, key)
+	if err != nil || ent.Stale(time.Now()) {
+		return nil, cache.ErrNotFound
+	}
+	return ent.Value, nil
+}
+
+func (c *agingCache) GetEntry(ctx context.Context, key string) (cache.Entry, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if ent, ok := c.entries[key]; ok {
+		return ent, nil
+	}
+	return cache.Entry{}, cache.ErrNotFound
+}
+
+func (c *agingCache) Set(ctx context.Context, key string, value []byte) error {
+	return c.SetStale(ctx, key, value, 0)
+}
+
+func (c *agingCache) SetStale(ctx context.Context, key string, value []byte, staleTTL time.Duration) error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.entries[key] = cache.Entry{Value: value, CreatedAt: time.Now(), TTL: time.Minute}
+	return nil
+}
+
+func (c *agingCache) Del(ctx context.Context, key string) error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	delete(c.entries, key)
+	return nil
+}
+
+// age the entry of the key by the duration
+func (c *agingCache) age(key string, d time.Duration) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	ent := c.entries[key]
+	ent.CreatedAt = ent.CreatedAt.Add(-d)
+	c.entries[key] = ent
+}
+
+func (c *agingCache) value(key string) []byte {
+	ent, _ := c.GetEntry(context.Background(), key)
+	return ent.Value
+}
+
+// flakyStore fails the reads with the error if it's defined
+type flakyStore struct {
+	*memStore
+	mx  sync.Mutex
+	err error
+}
+
+func (s *flakyStore) Get(ctx context.Context, dbnum int, key string) ([]byte, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.memStore.Get(ctx, dbnum, key)
+}
+
+func (s *flakyStore) update(fn func(s *flakyStore)) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	fn(s)
+}
+
+func TestProxyStaleWhileRevalidate(t *testing.T) {
+	var (
+		ctx       = context.Background()
+		cacheObj  = &agingCache{entries: map[string]cache.Entry{}}
+		store     = &flakyStore{memStore: newMemStore()}
+		prx       = New(ctx, cacheObj, store, Options{StaleTTL: time.Hour, RefreshAhead: 10 * time.Second})
+		refreshed = func(count uint64) func() bool {
+			return func() bool { return storage.Stats(prx)[0].Cache.Refreshed == count }
+		}
+	)
+	defer prx.Close()
+	assert.NoError(t, store.Set(ctx, 0, "user_1", []byte("v1")))
+	val, err := prx.Get(ctx, 0, "user_1")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("v1"), val)
+
+	// The expired value is served while the refresh is running
+	store.update(func(s *flakyStore) { s.values["user_1"] = []byte("v2") })
+	cacheObj.age("0:user_1", 2*time.Minute)
+	val, err = prx.Get(ctx, 0, "user_1")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("v1"), val)
+	assert.Eventually(t, refreshed(1), time.Second, time.Millisecond)
+	assert.Eventually(t, func() bool { return string(cacheObj.value("0:user_1")) == "v2" }, time.Second, time.Millisecond)
+
+	// The expired value is kept if the store fails
+	store.update(func(s *flakyStore) { s.err = errors.New("connection refused") })
+	cacheObj.age("0:user_1", 2*time.Minute)
+	val, err = prx.Get(ctx, 0, "user_1")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("v2"), val)
+	assert.Eventually(t, refreshed(2), time.Second, time.Millisecond)
+	assert.Equal(t, []byte("v2"), cacheObj.value("0:user_1"))
+
+	// The value is refreshed before the expiration
+	store.update(func(s *flakyStore) { s.err, s.values["user_1"] = nil, []byte("v3") })
+	assert.NoError(t, cacheObj.Set(ctx, "0:user_1", []byte("v2")))
+	cacheObj.age("0:user_1", 55*time.Second)
+	val, err = prx.Get(ctx, 0, "user_1")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("v2"), val)
+	assert.Eventually(t, refreshed(3), time.Second, time.Millisecond)
+	assert.Equal(t, []byte("v3"), cacheObj.value("0:user_1"))
+
+	// The removed record is cleared from the cache by the refresh
+	store.update(func(s *flakyStore) { delete(s.values, "user_1") })
+	cacheObj.age("0:user_1", 2*time.Minute)
+	_, err = prx.Get(ctx, 0, "user_1")
+	assert.NoError(t, err)
+	assert.Eventually(t, refreshed(4), time.Second, time.Millisecond)
+	assert.Nil(t, cacheObj.value("0:user_1"))
+	_, err = prx.Get(ctx, 0, "user_1")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	stats := storage.Stats(prx)[0].Cache
+	assert.Equal(t, uint64(3), stats.Stale)
+	assert.Equal(t, uint64(4), stats.Refreshed)
+}
+
+func TestProxyGetManyStale(t *testing.T) {
+	var (
+		ctx       = context.Background()
+		cacheObj  = &agingCache{entries: map[string]cache.Entry{}}
+		store     = &flakyStore{memStore: newMemStore()}
+		prx       = New(ctx, cacheObj, store, Options{StaleTTL: time.Hour, MaxInFlight: 1})
+		getMany   = prx.(storage.BatchGetter).GetMany
+		refreshed = func() bool { return storage.Stats(prx)[0].Cache.Refreshed == 1 }
+	)
+	defer prx.Close()
+	assert.NoError(t, store.Set(ctx, 0, "user_1", []byte("v1")))
+	assert.NoError(t, store.Set(ctx, 0, "user_2", []byte("v1")))
+	items := getMany(ctx, 0, []string{"user_1", "user_2"})
+	assert.Equal(t, []byte("v1"), items[0].Value)
+	assert.Equal(t, []byte("v1"), items[1].Value)
+
+	// The expired value is served and kept if the store fails
+	store.update(func(s *flakyStore) { s.err = errors.New("connection refused") })
+	cacheObj.age("0:user_1", 2*time.Minute)
+	items = getMany(ctx, 0, []string{"user_1", "user_2"})
+	assert.NoError(t, items[0].Err)
+	assert.Equal(t, []byte("v1"), items[0].Value)
+	assert.Equal(t, []byte("v1"), items[1].Value)
+	assert.Eventually(t, refreshed, time.Second, time.Millisecond)
+	assert.Equal(t, []byte("v1"), cacheObj.value("0:user_1"))
+
+	stats := storage.Stats(prx)[0].Cache
+	assert.Equal(t, uint64(1), stats.Stale)
+	assert.Equal(t, int64(0), stats.InFlight, "the batch load must release the slot")
+}
+
+func TestProxyNegativeCache(t *testing.T) {
+	ctx := context.Background()
+	globalCache, err := simplecache.New(10, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		store = newMemStore()
+		prx   = New(ctx, globalCache, store, Options{})
+	)
+	defer prx.Close()
+	assert.NoError(t, prx.Bind(ctx, &storage.BindConfig{Pattern: "post_{{slug}}", NegativeTTL: time.Minute}))
+
+	for i := 0; i < 2; i++ {
+		_, err = prx.Get(ctx, 0, "post_wp-admin")
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+		_, err = prx.Get(ctx, 0, "user_1")
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	}
+	items := prx.(storage.BatchGetter).GetMany(ctx, 0, []string{"post_wp-admin", "post_login"})
+	assert.ErrorIs(t, items[0].Err, storage.ErrNotFound)
+	assert.ErrorIs(t, items[1].Err, storage.ErrNotFound)
+	_, err = prx.Get(ctx, 0, "post_login")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	assert.Equal(t, map[string]int{"post_wp-admin": 1, "post_login": 1, "user_1": 2}, store.reads,
+		"missing keys of the bind must be read once")
+
+	// The write replaces the missing key in the cache
+	assert.NoError(t, prx.Set(ctx, 0, "post_wp-admin", []byte("post1")))
+	val, err := prx.Get(ctx, 0, "post_wp-admin")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("post1"), val)
+
+	// The notification of the inserted record clears the missing key
+	assert.NoError(t, store.Set(ctx, 0, "post_login", []byte("post2")))
+	prx.(*proxyStore).updateNotifier(ctx, storage.KeyEvent{Key: "post_login", Event: "set"})
+	val, err = prx.Get(ctx, 0, "post_login")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("post2"), val)
+	assert.Equal(t, 2, store.reads["post_login"])
+
+	stats := storage.Stats(prx)[0].Cache
+	assert.Equal(t, uint64(3), stats.Negative)
+}
+
+// lateStore returns the value read before the release
+type lateStore struct {
+	*memStore
+	mx      sync.Mutex
+	read    chan struct{}
+	release chan struct{}
+}
+
+func (s *lateStore) Get(ctx context.Context, dbnum int, key string) ([]byte, error) {
+	s.mx.Lock()
+	val, err := s.memStore.Get(ctx, dbnum, key)
+	s.mx.Unlock()
+	close(s.read)
+	<-s.release
+	return val, err
+}
+
+func (s *lateStore) Set(ctx context.Context, dbnum int, key string, value []byte) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.memStore.Set(ctx, dbnum, key, value)
+}
+
+func TestProxyNegativeCacheRace(t *testing.T) {
+	ctx := context.Background()
+	globalCache, err := simplecache.New(10, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		store = &lateStore{memStore: newMemStore(), read: make(chan struct{}), release: make(chan struct{})}
+		prx   = New(ctx, globalCache, store, Options{})
+		done  = make(chan struct{})
+	)
+	defer prx.Close()
+	assert.NoError(t, prx.Bind(ctx, &storage.BindConfig{Pattern: "post_{{slug}}", NegativeTTL: time.Minute}))
+
+	go func() {
+		defer close(done)
+		_, err := prx.Get(ctx, 0, "post_new")
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	}()
+	<-store.read
+	assert.NoError(t, prx.Set(ctx, 0, "post_new", []byte("post")))
+	close(store.release)
+	<-done
+
+	val, err := prx.Get(ctx, 0, "post_new")
+	assert.NoError(t, err, "the load started before the insert must not cache the missing key")
+	assert.Equal(t, []byte("post"), val)
+}
+
+func TestProxyCoalescingLeaderCanceled(t *testing.T) {
+	ctx := context.Background()
+	globalCache, err := simplecache.New(10, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		store               = &blockingStore{memStore: newMemStore(), release: make(chan struct{})}
+		prx                 = New(ctx, globalCache, store, Options{})
+		leaderCtx, cancel   = context.WithCancel(ctx)
+		leaderErr, follower = make(chan error, 1), make(chan []byte, 1)
+	)
+	defer prx.Close()
+	assert.NoError(t, store.Set(ctx, 0, "user_1", []byte("user1")))
+
+	go func() {
+		_, err := prx.Get(leaderCtx, 0, "user_1")
+		leaderErr <- err
+	}()
+	assert.Eventually(t, func() bool { return store.calls.Load() == 1 }, time.Second, time.Millisecond)
+	go func() {
+		val, err := prx.Get(ctx, 0, "user_1")
+		assert.NoError(t, err)
+		follower <- val
+	}()
+	// Let the follower join the load
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-leaderErr, context.Canceled)
+	close(store.release)
+
+	assert.Equal(t, []byte("user1"), <-follower)
+	assert.Equal(t, int32(1), store.calls.Load())
+	val, err := globalCache.Get(ctx, "0:user_1")
+	assert.NoError(t, err, "the value loaded for the canceled reader must be cached")
+	assert.Equal(t, []byte("user1"), val)
+}
+
+// notifyStore serves the post keys and reports their changes by the notify channel
+type notifyStore struct {
+	*memStore
+}
+
+func (s notifyStore) HasKey(dbnum int, key string) bool {
+	return strings.HasPrefix(key, "post_")
+}
+
+func (s notifyStore) BeginTx(ctx context.Context, dbnum int, keys []string) (context.Context, storage.Tx, error) {
+	return ctx, nil, storage.ErrMethodIsNotSupported
+}
+
+func (s notifyStore) ListenUpdateNotifies(ctx context.Context, channel string, fn func(ctx context.Context, event storage.KeyEvent)) error {
+	<-ctx.Done()
+	return nil
+}
+
+func TestProxyReportsKeyEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	globalCache, err := simplecache.New(10, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := notifyStore{memStore: newMemStore()}
+	prx := New(ctx, globalCache, store, Options{NotifyChannel: "updates"})
+	defer prx.Close()
+	assert.True(t, storage.ReportsKeyEvents(prx, 0, "post_1"))
+	assert.False(t, storage.ReportsKeyEvents(prx, 0, "user_1"), "keys of other sources are not reported")
+
+	prx = New(ctx, globalCache, store, Options{})
+	assert.False(t, storage.ReportsKeyEvents(prx, 0, "post_1"), "changes are reported only by the notify channel")
+}
@@ -11,9 +11,10 @@ type bind struct {
 	counter uint64
 	dbnum   int
 	key     string
+	stream  stream
 }
 
-func (b *bind) Publish(ctx context.Context, pub nc.Publisher, value []byte) error {
+func (b *bind) Publish(ctx context.Context, pub nc.Publisher, value any) error {
 	_ = atomic.AddUint64(&b.counter, 1)
 	return pub.Publish(ctx, value)
 }
@@ -0,0 +1,59 @@
+package pubstream
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/demdxx/redify/internal/storage"
+)
+
+// HasChannel returns true if the channel is the key of the bind in any database
+func (dr *driver) HasChannel(channel string) bool {
+	return dr.bindByChannel(channel) != nil
+}
+
+// PublishChannel publishes the message to the stream of the bind,
+// JSON messages are published as is and other messages as JSON strings
+func (dr *driver) PublishChannel(ctx context.Context, channel string, message []byte) error {
+	bind := dr.bindByChannel(channel)
+	if bind == nil {
+		return storage.ErrNoKey
+	}
+	var value any = string(message)
+	if json.Valid(message) {
+		value = json.RawMessage(message)
+	}
+	return bind.Publish(ctx, dr.publisher, value)
+}
+
+// ListenChannels starts the subscriber of the stream and delivers
+// the received messages by the keys of the binds
+func (dr *driver) ListenChannels(ctx context.Context, fn func(channel string, message []byte)) error {
+	dr.streamMx.Lock()
+	dr.channelListeners = append(dr.channelListeners, fn)
+	dr.streamMx.Unlock()
+	if err := dr.listen(); err != nil {
+		return err
+	}
+	<-ctx.Done()
+	return nil
+}
+
+// channelMessage returns the payload of the received message for the channel subscribers,
+// JSON strings published by PUBLISH are unwrapped
+func channelMessage(body []byte) []byte {
+	var str string
+	if len(body) > 0 && body[0] == '"' && json.Unmarshal(body, &str) == nil {
+		return []byte(str)
+	}
+	return body
+}
+
+func (dr *driver) bindByChannel(channel string) *bind {
+	for _, b := range dr.binds {
+		if b.key == channel {
+			return b
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package pubstream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	nc "github.com/geniusrabbit/notificationcenter/v2"
+	"github.com/geniusrabbit/notificationcenter/v2/gochan"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demdxx/redify/internal/storage"
+)
+
+func TestDriverChannels(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	type message struct{ channel, body string }
+	var (
+		proxy    = gochan.New(10)
+		received = make(chan message, 10)
+		dr       = &driver{
+			publisher: proxy.Publisher(),
+			ctx:       ctx,
+			newSubscriber: func(context.Context) (nc.Subscriber, error) {
+				return proxy, nil
+			},
+		}
+	)
+	assert.NoError(t, dr.Bind(ctx, &storage.BindConfig{Pattern: "news_notify"}))
+	assert.True(t, dr.HasChannel("news_notify"))
+	assert.False(t, dr.HasChannel("undefined"))
+	assert.ErrorIs(t, dr.PublishChannel(ctx, "undefined", []byte("hello")), storage.ErrNoKey)
+
+	go func() {
+		_ = dr.ListenChannels(ctx, func(channel string, body []byte) {
+			received <- message{channel: channel, body: string(body)}
+		})
+	}()
+
+	assert.NoError(t, dr.PublishChannel(ctx, "news_notify", []byte("hello")))
+	assert.NoError(t, dr.PublishChannel(ctx, "news_notify", []byte(`{"title": "news"}`)))
+	for _, expected := range []message{
+		{channel: "news_notify", body: "hello"},
+		{channel: "news_notify", body: `{"title":"news"}`},
+	} {
+		select {
+		case msg := <-received:
+			assert.Equal(t, expected, msg)
+		case <-ctx.Done():
+			t.Fatal("message is not received")
+		}
+	}
+}
@@ -3,17 +3,32 @@ package pubstream
 import (
 	"context"
 	"io"
-	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/demdxx/gocast/v2"
+	"github.com/demdxx/redify/internal/keypattern"
 	"github.com/demdxx/redify/internal/storage"
 	nc "github.com/geniusrabbit/notificationcenter/v2"
+	"go.uber.org/multierr"
 )
 
 // Driver of the abstract stream publisher
 type driver struct {
+	scheme    string
 	publisher nc.Publisher
 	binds     []*bind
+
+	// Subscriber to the same stream is started by the first read of the stream entries
+	ctx           context.Context
+	newSubscriber func(ctx context.Context) (nc.Subscriber, error)
+	subscriber    nc.Subscriber
+	subscribeOnce sync.Once
+	subscribeErr  error
+
+	streamMx         sync.Mutex
+	streamNotify     chan struct{}
+	channelListeners []func(channel string, message []byte)
 }
 
 func Open(ctx context.Context, connURL string) (storage.Driver, error) {
@@ -21,7 +36,15 @@ func Open(ctx context.Context, connURL string) (storage.Driver, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &driver{publisher: pub}, nil
+	scheme, _, _ := strings.Cut(connURL, "://")
+	return &driver{
+		scheme:    scheme,
+		publisher: pub,
+		ctx:       ctx,
+		newSubscriber: func(ctx context.Context) (nc.Subscriber, error) {
+			return subscribe(ctx, connURL)
+		},
+	}, nil
 }
 
 func (dr *driver) Get(ctx context.Context, dbnum int, key string) ([]byte, error) {
@@ -54,7 +77,7 @@ func (dr *driver) Keys(ctx context.Context, dbnum int, pattern string) ([]string
 		if bind.dbnum != dbnum {
 			continue
 		}
-		if matched, _ := filepath.Match(pattern, bind.key); !matched {
+		if !keypattern.GlobMatch(pattern, bind.key) {
 			continue
 		}
 		keys = append(keys, bind.key)
@@ -66,6 +89,25 @@ func (dr *driver) List(ctx context.Context, dbnum int, pattern string) ([]storag
 	return nil, nil // storage.ErrMethodIsNotSupported
 }
 
+func (dr *driver) Scan(ctx context.Context, dbnum int, cursor uint64, opts storage.ScanOptions) ([]string, uint64, error) {
+	var (
+		cur   = storage.Cursor(cursor)
+		count = opts.PageSize()
+		keys  = make([]string, 0, count)
+	)
+	for i := cur.Bind(); i < len(dr.binds); i++ {
+		bind := dr.binds[i]
+		if bind.dbnum != dbnum || !opts.MatchType(storage.KeyTypeString) || !opts.MatchKey(bind.key) {
+			continue
+		}
+		if len(keys) >= count {
+			return keys, uint64(storage.NewCursor(0, i, 0)), nil
+		}
+		keys = append(keys, bind.key)
+	}
+	return keys, 0, nil
+}
+
 func (dr *driver) Bind(ctx context.Context, conf *storage.BindConfig) error {
 	dr.binds = append(dr.binds, &bind{
 		dbnum: conf.DBNum,
@@ -75,10 +117,23 @@ func (dr *driver) Bind(ctx context.Context, conf *storage.BindConfig) error {
 }
 
 func (dr *driver) Close() error {
+	var err error
+	if dr.subscriber != nil {
+		err = dr.subscriber.Close()
+	}
 	if cl, _ := dr.publisher.(io.Closer); cl != nil {
-		return cl.Close()
+		err = multierr.Append(err, cl.Close())
 	}
-	return nil
+	return err
+}
+
+// Stats of the stream binds
+func (dr *driver) Stats() []storage.SourceStats {
+	binds := map[int]int{}
+	for _, bind := range dr.binds {
+		binds[bind.dbnum]++
+	}
+	return []storage.SourceStats{{Driver: dr.scheme, Binds: binds}}
 }
 
 func (dr *driver) SupportCache() bool {
@@ -46,6 +46,15 @@ func TestDriver(t *testing.T) {
 	assert.NoError(t, err)
 	assert.ElementsMatch(t, []string{"key1", "key2"}, keys)
 
+	keys, next, err := dr.Scan(ctx, 1, 0, storage.ScanOptions{Pattern: "*", Count: 2})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"some_key", "key1"}, keys)
+	assert.NotEqual(t, uint64(0), next, "cursor must point to the next page")
+	keys, next, err = dr.Scan(ctx, 1, next, storage.ScanOptions{Pattern: "*", Count: 2})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"key2"}, keys)
+	assert.Equal(t, uint64(0), next, "iteration must be finished")
+
 	assert.ErrorIs(t, dr.Del(ctx, 1, "key1"), storage.ErrMethodIsNotSupported)
 	assert.NoError(t, dr.Close())
 }
@@ -13,7 +13,12 @@ var ErrUnsupportedScheme = errors.New(`unsupported scheme`)
 
 type publisherConnector func(ctx context.Context, url string) (nc.Publisher, error)
 
-var publisherConnectors = map[string]publisherConnector{}
+type subscriberConnector func(ctx context.Context, url string) (nc.Subscriber, error)
+
+var (
+	publisherConnectors  = map[string]publisherConnector{}
+	subscriberConnectors = map[string]subscriberConnector{}
+)
 
 // connect stream publisher from URL
 func connect(ctx context.Context, urlStr string) (nc.Publisher, error) {
@@ -28,3 +33,16 @@ func connect(ctx context.Context, urlStr string) (nc.Publisher, error) {
 	pub, err := conn(ctx, urlStr)
 	return pub, err
 }
+
+// subscribe to the stream from URL
+func subscribe(ctx context.Context, urlStr string) (nc.Subscriber, error) {
+	parsedURL, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, err
+	}
+	conn := subscriberConnectors[parsedURL.Scheme]
+	if conn == nil {
+		return nil, errors.Wrap(ErrUnsupportedScheme, parsedURL.Scheme)
+	}
+	return conn(ctx, urlStr)
+}
@@ -14,4 +14,7 @@ func init() {
 	publisherConnectors["kafka"] = func(ctx context.Context, url string) (nc.Publisher, error) {
 		return kafka.NewPublisher(ctx, kafka.WithKafkaURL(url))
 	}
+	subscriberConnectors["kafka"] = func(ctx context.Context, url string) (nc.Subscriber, error) {
+		return kafka.NewSubscriber(kafka.WithKafkaURL(url))
+	}
 }
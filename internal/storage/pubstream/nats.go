@@ -14,4 +14,7 @@ func init() {
 	publisherConnectors["nats"] = func(ctx context.Context, url string) (nc.Publisher, error) {
 		return nats.NewPublisher(nats.WithNatsURL(url))
 	}
+	subscriberConnectors["nats"] = func(ctx context.Context, url string) (nc.Subscriber, error) {
+		return nats.NewSubscriber(nats.WithNatsURL(url))
+	}
 }
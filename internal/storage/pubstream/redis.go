@@ -14,4 +14,7 @@ func init() {
 	publisherConnectors["redispub"] = func(ctx context.Context, url string) (nc.Publisher, error) {
 		return redis.NewPublisher(redis.WithRedisURL(url))
 	}
+	subscriberConnectors["redispub"] = func(ctx context.Context, url string) (nc.Subscriber, error) {
+		return redis.NewSubscriber(redis.WithRedisURL(url))
+	}
 }
@@ -0,0 +1,462 @@
+package pubstream
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"slices"
+	"sort"
+	"time"
+
+	nc "github.com/geniusrabbit/notificationcenter/v2"
+	"go.uber.org/zap"
+
+	"github.com/demdxx/redify/internal/context/ctxlogger"
+	"github.com/demdxx/redify/internal/storage"
+)
+
+// streamLength is the number of the last received entries kept by every stream
+const streamLength = 10000
+
+// messageField contains the body of the received message which is not a stream message
+const messageField = "message"
+
+// brokerMessage is shared by the entries of all streams which received the message
+type brokerMessage struct {
+	msg nc.Message
+	// Number of the streams which have groups waiting for the message
+	streams int
+}
+
+// release the message by the stream and acknowledge it after the last one
+func (m *brokerMessage) release() {
+	if m.streams--; m.streams == 0 {
+		_ = m.msg.Ack()
+	}
+}
+
+type streamEntry struct {
+	id     storage.StreamID
+	fields []string
+	msg    *brokerMessage
+	// Groups which didn't acknowledge the entry yet
+	waiting map[*streamGroup]struct{}
+}
+
+// done marks the entry as processed by the group and releases
+// the broker message if no group of the stream waits for it anymore
+func (e *streamEntry) done(g *streamGroup) {
+	if _, ok := e.waiting[g]; !ok {
+		return
+	}
+	delete(e.waiting, g)
+	if len(e.waiting) == 0 {
+		e.msg.release()
+	}
+}
+
+type pendingEntry struct {
+	entry    *streamEntry
+	consumer string
+}
+
+type streamGroup struct {
+	lastID  storage.StreamID
+	pending map[storage.StreamID]*pendingEntry
+}
+
+// stream keeps the entries received from the broker and the consumer groups.
+// All streams of the driver are protected by the driver mutex.
+type stream struct {
+	entries []*streamEntry
+	lastID  storage.StreamID // ID of the last received entry
+	addedID storage.StreamID // ID of the last entry published by XADD
+	groups  map[string]*streamGroup
+}
+
+// append the entry of the message, every group of the stream has to acknowledge it
+// before the message is released by the stream
+func (s *stream) append(id storage.StreamID, fields []string, msg *brokerMessage) {
+	if !s.lastID.Less(id) {
+		id = s.lastID.Next(time.Now())
+	}
+	s.lastID = id
+	entry := &streamEntry{id: id, fields: fields, msg: msg}
+	if len(s.groups) > 0 {
+		entry.waiting = make(map[*streamGroup]struct{}, len(s.groups))
+		for _, g := range s.groups {
+			entry.waiting[g] = struct{}{}
+		}
+		msg.streams++
+	}
+	s.entries = append(s.entries, entry)
+	if len(s.entries) > streamLength {
+		// Entries dropped from the buffer can't be delivered to the groups anymore
+		for _, old := range s.entries[:len(s.entries)-streamLength] {
+			for g := range old.waiting {
+				old.done(g)
+			}
+		}
+		s.entries = s.entries[len(s.entries)-streamLength:]
+	}
+}
+
+// after returns the entries with ID greater than the given one
+func (s *stream) after(id storage.StreamID, count int) []*streamEntry {
+	i := sort.Search(len(s.entries), func(i int) bool { return id.Less(s.entries[i].id) })
+	entries := s.entries[i:]
+	if count > 0 && len(entries) > count {
+		entries = entries[:count]
+	}
+	return entries
+}
+
+// nextID of the entry published by XADD, `*` generates the new one
+func (s *stream) nextID(id string) (storage.StreamID, error) {
+	last := s.lastID
+	if last.Less(s.addedID) {
+		last = s.addedID
+	}
+	if id == "*" {
+		return last.Next(time.Now()), nil
+	}
+	newID, err := storage.ParseStreamID(id)
+	if err != nil || !last.Less(newID) {
+		return newID, storage.ErrInvalidStreamID
+	}
+	return newID, nil
+}
+
+// XAdd publishes the stream message with the headers of the key and the entry ID
+func (dr *driver) XAdd(ctx context.Context, dbnum int, key, id string, fields []string) (string, error) {
+	bind, err := dr.bindByKey(key, dbnum)
+	if err != nil {
+		return "", err
+	}
+	dr.streamMx.Lock()
+	newID, err := bind.stream.nextID(id)
+	if err == nil {
+		bind.stream.addedID = newID
+	}
+	dr.streamMx.Unlock()
+	if err != nil {
+		return "", err
+	}
+	msg := storage.StreamMessage{
+		Headers: map[string]string{
+			storage.StreamHeaderID:     newID.String(),
+			storage.StreamHeaderStream: key,
+			storage.StreamHeaderTime:   time.Now().UTC().Format(time.RFC3339Nano),
+		},
+		Fields: fields,
+	}
+	return newID.String(), bind.Publish(ctx, dr.publisher, msg)
+}
+
+// XRead entries of the streams received by the subscriber
+func (dr *driver) XRead(ctx context.Context, dbnum int, req storage.StreamRead) ([]storage.StreamEntries, error) {
+	binds := make([]*bind, 0, len(req.Keys))
+	for _, key := range req.Keys {
+		bind, err := dr.bindByKey(key, dbnum)
+		if err != nil {
+			return nil, err
+		}
+		binds = append(binds, bind)
+	}
+	if err := dr.listen(); err != nil {
+		return nil, err
+	}
+	var timer <-chan time.Time
+	if req.Block && req.Timeout > 0 {
+		t := time.NewTimer(req.Timeout)
+		defer t.Stop()
+		timer = t.C
+	}
+	dr.streamMx.Lock()
+	ids, history, err := resolveStreamIDs(binds, req)
+	if err != nil {
+		dr.streamMx.Unlock()
+		return nil, err
+	}
+	for {
+		res, err := readStreams(binds, ids, req)
+		if err != nil || len(res) > 0 || !req.Block || history {
+			dr.streamMx.Unlock()
+			return res, err
+		}
+		notify := dr.notifyChan()
+		dr.streamMx.Unlock()
+		select {
+		case <-notify:
+		case <-timer:
+			return nil, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		dr.streamMx.Lock()
+	}
+}
+
+// resolveStreamIDs of the request, `$` is replaced by the last ID of the stream.
+// Returns true if the group reads the history of the pending entries.
+func resolveStreamIDs(binds []*bind, req storage.StreamRead) ([]storage.StreamID, bool, error) {
+	var (
+		ids     = make([]storage.StreamID, len(binds))
+		history bool
+	)
+	for i, bind := range binds {
+		switch id := req.IDs[i]; {
+		case req.Group != "" && id == ">":
+		case req.Group == "" && id == "$":
+			ids[i] = bind.stream.lastID
+		default:
+			var err error
+			if ids[i], err = storage.ParseStreamID(id); err != nil {
+				return nil, false, err
+			}
+			history = req.Group != ""
+		}
+		if req.Group != "" && bind.stream.groups[req.Group] == nil {
+			return nil, false, storage.ErrNoGroup
+		}
+	}
+	return ids, history, nil
+}
+
+func readStreams(binds []*bind, ids []storage.StreamID, req storage.StreamRead) ([]storage.StreamEntries, error) {
+	var res []storage.StreamEntries
+	for i, bind := range binds {
+		var entries []*streamEntry
+		if req.Group == "" {
+			entries = bind.stream.after(ids[i], req.Count)
+		} else {
+			group := bind.stream.groups[req.Group]
+			if group == nil {
+				return nil, storage.ErrNoGroup
+			}
+			if req.IDs[i] == ">" {
+				entries = group.deliver(bind.stream.after(group.lastID, req.Count), req.Consumer, req.NoAck)
+			} else {
+				entries = group.consumerPending(req.Consumer, ids[i], req.Count)
+			}
+		}
+		if len(entries) == 0 && req.IDs[i] != ">" && req.Group != "" {
+			// History of the consumer is returned for every requested stream
+			res = append(res, storage.StreamEntries{Key: bind.key})
+			continue
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		item := storage.StreamEntries{Key: bind.key, Entries: make([]storage.StreamEntry, 0, len(entries))}
+		for _, entry := range entries {
+			item.Entries = append(item.Entries, storage.StreamEntry{ID: entry.id.String(), Fields: entry.fields})
+		}
+		res = append(res, item)
+	}
+	return res, nil
+}
+
+// deliver new entries to the consumer and add them to the pending list
+func (g *streamGroup) deliver(entries []*streamEntry, consumer string, noAck bool) []*streamEntry {
+	if len(entries) == 0 {
+		return nil
+	}
+	g.lastID = entries[len(entries)-1].id
+	for _, entry := range entries {
+		if noAck {
+			entry.done(g)
+		} else {
+			g.pending[entry.id] = &pendingEntry{entry: entry, consumer: consumer}
+		}
+	}
+	return entries
+}
+
+// consumerPending returns the pending entries of the consumer after the ID
+func (g *streamGroup) consumerPending(consumer string, after storage.StreamID, count int) []*streamEntry {
+	var entries []*streamEntry
+	for id, pending := range g.pending {
+		if pending.consumer == consumer && after.Less(id) {
+			entries = append(entries, pending.entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].id.Less(entries[j].id) })
+	if count > 0 && len(entries) > count {
+		entries = entries[:count]
+	}
+	return entries
+}
+
+// ack removes the entry from the pending list and acknowledges the broker message
+// if no group of any stream waits for it anymore
+func (g *streamGroup) ack(id storage.StreamID) bool {
+	pending := g.pending[id]
+	if pending == nil {
+		return false
+	}
+	delete(g.pending, id)
+	pending.entry.done(g)
+	return true
+}
+
+// XGroupCreate of the stream, `$` starts the group from the last received entry
+func (dr *driver) XGroupCreate(ctx context.Context, dbnum int, key, group, id string) error {
+	bind, err := dr.bindByKey(key, dbnum)
+	if err != nil {
+		return err
+	}
+	if err = dr.listen(); err != nil {
+		return err
+	}
+	dr.streamMx.Lock()
+	defer dr.streamMx.Unlock()
+	if bind.stream.groups[group] != nil {
+		return storage.ErrGroupExists
+	}
+	lastID := bind.stream.lastID
+	if id != "$" {
+		if lastID, err = storage.ParseStreamID(id); err != nil {
+			return err
+		}
+	}
+	if bind.stream.groups == nil {
+		bind.stream.groups = map[string]*streamGroup{}
+	}
+	bind.stream.groups[group] = &streamGroup{lastID: lastID, pending: map[storage.StreamID]*pendingEntry{}}
+	return nil
+}
+
+// XGroupDestroy of the stream acknowledges all pending and undelivered entries of the group
+func (dr *driver) XGroupDestroy(ctx context.Context, dbnum int, key, group string) (bool, error) {
+	bind, err := dr.bindByKey(key, dbnum)
+	if err != nil {
+		return false, err
+	}
+	dr.streamMx.Lock()
+	defer dr.streamMx.Unlock()
+	g := bind.stream.groups[group]
+	if g == nil {
+		return false, nil
+	}
+	for id := range g.pending {
+		g.ack(id)
+	}
+	for _, entry := range bind.stream.after(g.lastID, 0) {
+		entry.done(g)
+	}
+	delete(bind.stream.groups, group)
+	return true, nil
+}
+
+// XAck pending entries of the group
+func (dr *driver) XAck(ctx context.Context, dbnum int, key, group string, ids []string) (int64, error) {
+	bind, err := dr.bindByKey(key, dbnum)
+	if err != nil {
+		return 0, err
+	}
+	parsed := make([]storage.StreamID, 0, len(ids))
+	for _, id := range ids {
+		streamID, err := storage.ParseStreamID(id)
+		if err != nil {
+			return 0, err
+		}
+		parsed = append(parsed, streamID)
+	}
+	dr.streamMx.Lock()
+	defer dr.streamMx.Unlock()
+	g := bind.stream.groups[group]
+	if g == nil {
+		return 0, nil
+	}
+	var count int64
+	for _, id := range parsed {
+		if g.ack(id) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// listen starts the subscriber of the stream once
+func (dr *driver) listen() error {
+	dr.subscribeOnce.Do(func() {
+		if dr.newSubscriber == nil {
+			dr.subscribeErr = storage.ErrMethodIsNotSupported
+			return
+		}
+		sub, err := dr.newSubscriber(dr.ctx)
+		if err == nil {
+			err = sub.Subscribe(dr.ctx, nc.FuncReceiver(dr.receive))
+		}
+		if err != nil {
+			dr.subscribeErr = err
+			return
+		}
+		dr.subscriber = sub
+		go func() {
+			if err := sub.Listen(dr.ctx); err != nil {
+				ctxlogger.Get(dr.ctx).Error("listen stream", zap.Error(err))
+			}
+		}()
+	})
+	return dr.subscribeErr
+}
+
+// receive the message of the broker. Stream messages are added to the stream of the key,
+// other messages are added to every stream of the driver as the single field.
+// The message is delivered to the channel listeners of the same keys.
+func (dr *driver) receive(msg nc.Message) error {
+	var (
+		sm   storage.StreamMessage
+		body = bytes.TrimSpace(msg.Body())
+	)
+	if err := json.Unmarshal(body, &sm); err != nil || sm.Fields == nil {
+		sm = storage.StreamMessage{Fields: storage.StreamFields{messageField, string(body)}}
+	}
+	var (
+		fields = []string(sm.Fields)
+		id, _  = storage.ParseStreamID(sm.Headers[storage.StreamHeaderID])
+		key    = sm.Headers[storage.StreamHeaderStream]
+	)
+	dr.streamMx.Lock()
+	var (
+		channels []string
+		bmsg     = &brokerMessage{msg: msg}
+	)
+	for _, bind := range dr.binds {
+		if key == "" || bind.key == key {
+			bind.stream.append(id, fields, bmsg)
+			if !slices.Contains(channels, bind.key) {
+				channels = append(channels, bind.key)
+			}
+		}
+	}
+	if bmsg.streams == 0 {
+		// Nobody waits for the acknowledgment of the message
+		_ = msg.Ack()
+	}
+	if dr.streamNotify != nil {
+		close(dr.streamNotify)
+		dr.streamNotify = nil
+	}
+	listeners := dr.channelListeners
+	dr.streamMx.Unlock()
+
+	// Channel subscribers are called without the lock
+	payload := channelMessage(body)
+	for _, channel := range channels {
+		for _, fn := range listeners {
+			fn(channel, payload)
+		}
+	}
+	return nil
+}
+
+// notifyChan is closed on the next received message, the driver mutex must be locked
+func (dr *driver) notifyChan() chan struct{} {
+	if dr.streamNotify == nil {
+		dr.streamNotify = make(chan struct{})
+	}
+	return dr.streamNotify
+}
@@ -0,0 +1,195 @@
+package pubstream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	nc "github.com/geniusrabbit/notificationcenter/v2"
+	"github.com/geniusrabbit/notificationcenter/v2/gochan"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demdxx/redify/internal/storage"
+)
+
+func TestDriverStream(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	var (
+		proxy = gochan.New(10)
+		dr    = &driver{
+			publisher: proxy.Publisher(),
+			ctx:       ctx,
+			newSubscriber: func(context.Context) (nc.Subscriber, error) {
+				return proxy, nil
+			},
+		}
+	)
+	assert.NoError(t, dr.Bind(ctx, &storage.BindConfig{Pattern: "events"}))
+	assert.NoError(t, dr.XGroupCreate(ctx, 0, "events", "workers", "$"))
+	assert.ErrorIs(t, dr.XGroupCreate(ctx, 0, "events", "workers", "$"), storage.ErrGroupExists)
+
+	id, err := dr.XAdd(ctx, 0, "events", "*", []string{"name", "signup", "user", "bob"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = dr.XAdd(ctx, 0, "events", "1-1", []string{"name", "old"})
+	assert.ErrorIs(t, err, storage.ErrInvalidStreamID)
+	_, err = dr.XAdd(ctx, 0, "undefined", "*", []string{"name", "old"})
+	assert.ErrorIs(t, err, storage.ErrNoKey)
+
+	res, err := dr.XRead(ctx, 0, storage.StreamRead{Keys: []string{"events"}, IDs: []string{"0"}, Block: true})
+	if assert.NoError(t, err) && assert.Len(t, res, 1) {
+		assert.Equal(t, []storage.StreamEntry{{ID: id, Fields: []string{"name", "signup", "user", "bob"}}}, res[0].Entries)
+	}
+
+	groupRead := storage.StreamRead{Keys: []string{"events"}, IDs: []string{">"}, Group: "workers", Consumer: "c1"}
+	res, err = dr.XRead(ctx, 0, groupRead)
+	if assert.NoError(t, err) && assert.Len(t, res, 1) {
+		assert.Equal(t, id, res[0].Entries[0].ID)
+	}
+	res, err = dr.XRead(ctx, 0, groupRead)
+	assert.NoError(t, err)
+	assert.Empty(t, res, "entry must be delivered to the group once")
+
+	history := groupRead
+	history.IDs = []string{"0"}
+	res, err = dr.XRead(ctx, 0, history)
+	if assert.NoError(t, err) && assert.Len(t, res, 1) {
+		assert.Len(t, res[0].Entries, 1, "entry must be pending")
+	}
+	count, err := dr.XAck(ctx, 0, "events", "workers", []string{id, id})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	res, err = dr.XRead(ctx, 0, history)
+	if assert.NoError(t, err) && assert.Len(t, res, 1) {
+		assert.Empty(t, res[0].Entries)
+	}
+
+	// Messages of other publishers are added to every stream as the single field
+	assert.NoError(t, proxy.Publisher().Publish(ctx, "raw"))
+	groupRead.Block = true
+	res, err = dr.XRead(ctx, 0, groupRead)
+	if assert.NoError(t, err) && assert.Len(t, res, 1) {
+		assert.Equal(t, []string{"message", `"raw"`}, res[0].Entries[0].Fields)
+	}
+
+	groupRead.Timeout = time.Millisecond * 10
+	res, err = dr.XRead(ctx, 0, groupRead)
+	assert.NoError(t, err)
+	assert.Empty(t, res, "blocking read must be finished by the timeout")
+
+	_, err = dr.XRead(ctx, 0, storage.StreamRead{Keys: []string{"events"}, IDs: []string{">"}, Group: "undefined"})
+	assert.ErrorIs(t, err, storage.ErrNoGroup)
+
+	ok, err := dr.XGroupDestroy(ctx, 0, "events", "workers")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	data, err := dr.Get(ctx, 0, "events")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("1"), data)
+	assert.NoError(t, dr.Close())
+}
+
+type ackMessage struct {
+	body []byte
+	acks int
+}
+
+func (m *ackMessage) Context() context.Context { return context.Background() }
+func (m *ackMessage) ID() string               { return "" }
+func (m *ackMessage) Body() []byte             { return m.body }
+func (m *ackMessage) Ack() error               { m.acks++; return nil }
+
+func TestDriverStreamAckSharedMessage(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	var (
+		proxy = gochan.New(10)
+		dr    = &driver{
+			publisher: proxy.Publisher(),
+			ctx:       ctx,
+			newSubscriber: func(context.Context) (nc.Subscriber, error) {
+				return proxy, nil
+			},
+		}
+	)
+	defer dr.Close()
+	assert.NoError(t, dr.Bind(ctx, &storage.BindConfig{Pattern: "events"}))
+	assert.NoError(t, dr.Bind(ctx, &storage.BindConfig{Pattern: "audit"}))
+	assert.NoError(t, dr.XGroupCreate(ctx, 0, "events", "workers", "$"))
+	assert.NoError(t, dr.XGroupCreate(ctx, 0, "audit", "auditors", "$"))
+	assert.NoError(t, dr.XGroupCreate(ctx, 0, "audit", "archive", "$"))
+
+	// The message without the stream header is added to both streams
+	msg := &ackMessage{body: []byte("raw")}
+	assert.NoError(t, dr.receive(msg))
+
+	ackAll := func(key, group string) {
+		res, err := dr.XRead(ctx, 0, storage.StreamRead{Keys: []string{key}, IDs: []string{">"}, Group: group, Consumer: "c1"})
+		if assert.NoError(t, err) && assert.Len(t, res, 1) && assert.Len(t, res[0].Entries, 1) {
+			count, err := dr.XAck(ctx, 0, key, group, []string{res[0].Entries[0].ID})
+			assert.NoError(t, err)
+			assert.Equal(t, int64(1), count)
+		}
+	}
+	ackAll("events", "workers")
+	assert.Equal(t, 0, msg.acks, "message is still pending in the audit groups")
+	ackAll("audit", "auditors")
+	assert.Equal(t, 0, msg.acks, "message is still pending in the archive group")
+
+	ok, err := dr.XGroupDestroy(ctx, 0, "audit", "archive")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 1, msg.acks, "message must be acknowledged once by the last group")
+
+	// Nobody waits for the message of the stream without groups
+	msg = &ackMessage{body: []byte(`{"headers":{"stream":"events"},"fields":{"a":"b"}}`)}
+	_, err = dr.XGroupDestroy(ctx, 0, "events", "workers")
+	assert.NoError(t, err)
+	assert.NoError(t, dr.receive(msg))
+	assert.Equal(t, 1, msg.acks)
+}
+
+func TestDriverStreamFieldsOrder(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	var (
+		proxy = gochan.New(10)
+		dr    = &driver{
+			publisher: proxy.Publisher(),
+			ctx:       ctx,
+			newSubscriber: func(context.Context) (nc.Subscriber, error) {
+				return proxy, nil
+			},
+		}
+		read = storage.StreamRead{Keys: []string{"events"}, IDs: []string{"0"}, Block: true}
+	)
+	assert.NoError(t, dr.Bind(ctx, &storage.BindConfig{Pattern: "events"}))
+	res, err := dr.XRead(ctx, 0, storage.StreamRead{Keys: []string{"events"}, IDs: []string{"$"}})
+	assert.NoError(t, err)
+	assert.Empty(t, res)
+
+	fields := []string{"user", "bob", "name", "signup", "name", "login"}
+	id, err := dr.XAdd(ctx, 0, "events", "*", fields)
+	if !assert.NoError(t, err) {
+		return
+	}
+	res, err = dr.XRead(ctx, 0, read)
+	if assert.NoError(t, err) && assert.Len(t, res, 1) && assert.Len(t, res[0].Entries, 1) {
+		assert.Equal(t, storage.StreamEntry{ID: id, Fields: fields}, res[0].Entries[0],
+			"fields must keep the order and the repeated names")
+	}
+
+	// Fields of other publishers may be the JSON object
+	assert.NoError(t, dr.receive(&ackMessage{body: []byte(`{"headers":{"stream":"events"},"fields":{"z":"1","a":"2"}}`)}))
+	res, err = dr.XRead(ctx, 0, read)
+	if assert.NoError(t, err) && assert.Len(t, res, 1) && assert.Len(t, res[0].Entries, 2) {
+		assert.Equal(t, []string{"z", "1", "a", "2"}, res[0].Entries[1].Fields)
+	}
+	assert.NoError(t, dr.Close())
+}
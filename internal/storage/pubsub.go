@@ -0,0 +1,20 @@
+package storage
+
+import "context"
+
+// ChannelBridge extension of the driver which exposes the external channels as Redis pub/sub channels
+type ChannelBridge interface {
+	// HasChannel returns true if the channel is served by the bridge
+	HasChannel(channel string) bool
+	// PublishChannel forwards the message to the external channel
+	PublishChannel(ctx context.Context, channel string, message []byte) error
+	// ListenChannels delivers the messages of all channels of the bridge
+	// to the function until the context is done
+	ListenChannels(ctx context.Context, fn func(channel string, message []byte)) error
+}
+
+// NotifyChanneler extension of the driver which can bridge the notification channel of the database
+type NotifyChanneler interface {
+	// NotifyChannel returns the bridge of the database notification channel
+	NotifyChannel(name string) ChannelBridge
+}
@@ -35,6 +35,17 @@ func (r Record) GetString(key string) string {
 	return gocast.Str(r.Get(key))
 }
 
+// Filter returns the record which contains only the fields from the list
+func (r Record) Filter(fields []string) Record {
+	res := make(Record, len(fields))
+	for _, name := range fields {
+		if val, ok := r[name]; ok {
+			res[name] = val
+		}
+	}
+	return res
+}
+
 // ReorganizeNested record according to the key pattern
 // If some fields represented as a key with separator `.` in the key name
 // it need to combine with the other fields with the same prefix
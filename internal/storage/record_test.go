@@ -121,3 +121,17 @@ func TestDatetypeCasting(t *testing.T) {
 		t.Errorf("Expected %v, got %v", expected, res)
 	}
 }
+
+func TestRecordFilter(t *testing.T) {
+	rec := Record{"id": 1, "title": "Hello", "content": "Hello everyone"}
+	assert.Equal(t, Record{"title": "Hello"}, rec.Filter([]string{"title", "unknown"}))
+
+	value, err := FilterFields([]byte(`{"id":1,"title":"Hello","content":"Hello everyone"}`), []string{"id", "title"})
+	if assert.NoError(t, err) {
+		assert.JSONEq(t, `{"id":1,"title":"Hello"}`, string(value))
+	}
+	value, err = FilterFields([]byte(`"plain"`), []string{"id"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, `"plain"`, string(value))
+	}
+}
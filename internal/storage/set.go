@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// SetMode is the condition of the value writing
+type SetMode int
+
+// Conditions of the SET command
+const (
+	SetAlways      SetMode = iota
+	SetIfNotExists         // NX, insert only
+	SetIfExists            // XX, update only
+)
+
+// SetOptions of the SET command
+type SetOptions struct {
+	Mode    SetMode
+	TTL     time.Duration // Expiration of the key, zero means no expiration
+	KeepTTL bool          // Keep the current expiration of the key
+	Get     bool          // Return the previous value of the key
+}
+
+// IsDefault returns true if the options don't change the plain SET behavior
+func (opts *SetOptions) IsDefault() bool {
+	return opts.Mode == SetAlways && opts.TTL <= 0 && !opts.KeepTTL && !opts.Get
+}
+
+// SetResult of the SET command with options
+type SetResult struct {
+	Applied bool   // False if the NX/XX condition was not met
+	Prev    []byte // Previous value if requested, nil if the key didn't exist
+}
+
+// OptionsSetter extension of the driver which supports the SET options
+type OptionsSetter interface {
+	SetWithOptions(ctx context.Context, dbnum int, key string, value []byte, opts SetOptions) (SetResult, error)
+}
+
+// SetWithOptions writes the value by the driver if it supports options,
+// otherwise only GET option is emulated and the expiration is ignored
+func SetWithOptions(ctx context.Context, drv Driver, dbnum int, key string, value []byte, opts SetOptions) (SetResult, error) {
+	if setter, _ := drv.(OptionsSetter); setter != nil {
+		return setter.SetWithOptions(ctx, dbnum, key, value, opts)
+	}
+	if opts.Mode != SetAlways {
+		return SetResult{}, ErrMethodIsNotSupported
+	}
+	var res SetResult
+	if opts.Get {
+		prev, err := drv.Get(ctx, dbnum, key)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return res, err
+		}
+		res.Prev = prev
+	}
+	if err := drv.Set(ctx, dbnum, key, value); err != nil {
+		return res, err
+	}
+	res.Applied = true
+	return res, nil
+}
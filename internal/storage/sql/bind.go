@@ -2,7 +2,11 @@ package sql
 
 import (
 	"context"
-	"encoding/json"
+	"database/sql"
+	"errors"
+	"maps"
+	"sync"
+	"time"
 
 	"github.com/demdxx/redify/internal/context/ctxlogger"
 	"github.com/demdxx/redify/internal/keypattern"
@@ -17,6 +21,9 @@ type Bind struct {
 	db               *sqlx.DB
 	reorganizeNested bool
 	minSizeOfRecord  int
+
+	columnsMx sync.Mutex
+	columns   []string
 }
 
 // NewBind create new sql bind instance for the specified database
@@ -56,7 +63,7 @@ func NewBindFromTableName(
 
 func (b *Bind) Get(ctx context.Context, ectx keypattern.ExecContext) (Record, error) {
 	record := make(Record, b.minSizeOfRecord)
-	rows, err := b.db.QueryxContext(ctx, b.GetQuery.String(), b.GetQuery.Args(ectx)...)
+	rows, err := b.conn(ctx).QueryxContext(ctx, b.GetQuery.String(), b.GetQuery.Args(ectx)...)
 	ctxlogger.Get(ctx).Debug("Get",
 		zap.String("driver", b.driverName),
 		zap.Int("dbnum", b.DBNum),
@@ -67,8 +74,18 @@ func (b *Bind) Get(ctx context.Context, ectx keypattern.ExecContext) (Record, er
 	if err != nil {
 		return nil, err
 	}
-	if rows.Next() {
-		err = rows.MapScan(record)
+	defer rows.Close()
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, storage.ErrNotFound
+	}
+	if err = rows.MapScan(record); err != nil {
+		return nil, err
+	}
+	if b.IsExpired(record) {
+		return nil, storage.ErrNotFound
 	}
 	if len(record) != b.minSizeOfRecord {
 		b.minSizeOfRecord = len(record)
@@ -90,22 +107,124 @@ func (b *Bind) Get(ctx context.Context, ectx keypattern.ExecContext) (Record, er
 	return record, err
 }
 
+// GetFields of the record, table binds select only the requested columns
+func (b *Bind) GetFields(ctx context.Context, ectx keypattern.ExecContext, fields []string) (Record, error) {
+	if !b.SupportProjection() || b.reorganizeNested {
+		record, err := b.Get(ctx, ectx)
+		if err != nil {
+			return nil, err
+		}
+		return record.Filter(fields), nil
+	}
+	columns, err := b.tableColumns(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if fields = ProjectColumns(fields, columns); len(fields) == 0 {
+		return Record{}, nil
+	}
+	query := b.FieldsQuery(fields)
+	records, err := b.queryRecords(ctx, "GetFields", query.String(), query.Args(ectx))
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, storage.ErrNotFound
+	}
+	return records[0], nil
+}
+
+// tableColumns returns the list of the source table columns loaded once
+func (b *Bind) tableColumns(ctx context.Context) ([]string, error) {
+	b.columnsMx.Lock()
+	defer b.columnsMx.Unlock()
+	if b.columns != nil {
+		return b.columns, nil
+	}
+	rows, err := b.db.QueryxContext(ctx, b.Syntax.ColumnsQuery(b.SourceTable))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	if b.columns, err = rows.Columns(); err != nil {
+		return nil, err
+	}
+	return b.columns, nil
+}
+
+// GetMany records by the list of execution contexts, the result is aligned with contexts
+// and contains nil for the records which are not found
+func (b *Bind) GetMany(ctx context.Context, ectxs []keypattern.ExecContext) ([]Record, error) {
+	if !b.SupportBatch() {
+		result := make([]Record, len(ectxs))
+		for i, ectx := range ectxs {
+			rec, err := b.Get(ctx, ectx)
+			if err != nil && !errors.Is(err, storage.ErrNotFound) {
+				return nil, err
+			}
+			result[i] = rec
+		}
+		return result, nil
+	}
+	result := make([]Record, 0, len(ectxs))
+	for _, chunk := range b.BatchChunks(ectxs) {
+		records, err := b.queryRecords(ctx, "GetMany",
+			b.Syntax.BatchGetQuery(b.SourceTable, b.KeyFields, len(chunk), b.WhereExt), b.BatchArgs(chunk))
+		if err != nil {
+			return nil, err
+		}
+		chunkResult, retry := b.BatchResult(chunk, records)
+		for _, i := range retry {
+			if chunkResult[i], err = b.Get(ctx, chunk[i]); err != nil && !errors.Is(err, storage.ErrNotFound) {
+				return nil, err
+			}
+		}
+		result = append(result, chunkResult...)
+	}
+	return result, nil
+}
+
 func (b *Bind) List(ctx context.Context, ectx keypattern.ExecContext) ([]Record, error) {
-	if b.ListQuery == nil {
+	query := b.PatternListQuery(ectx)
+	if query == nil {
 		return nil, nil
 	}
+	return b.queryRecords(ctx, "List", query.String(), query.Args(ectx))
+}
+
+// Page of the list query records from the offset
+func (b *Bind) Page(ctx context.Context, ectx keypattern.ExecContext, offset, limit uint64) ([]Record, error) {
+	query := b.PatternListQuery(ectx)
+	if query == nil {
+		return nil, nil
+	}
+	return b.queryRecords(ctx, "Page",
+		b.Syntax.PageQuery(query.String(), b.PageOrder(), limit, offset), query.Args(ectx))
+}
+
+// CountKeys of the list query
+func (b *Bind) CountKeys(ctx context.Context, ectx keypattern.ExecContext) (int64, error) {
+	query := b.KeysCountQuery(ectx)
+	if query == nil {
+		return 0, nil
+	}
+	return b.queryCount(ctx, "CountKeys", query, ectx)
+}
+
+func (b *Bind) queryRecords(ctx context.Context, name, query string, args []any) ([]Record, error) {
 	res := make([]Record, 0, 10)
-	rows, err := b.db.QueryxContext(ctx, b.ListQuery.String(), b.ListQuery.Args(ectx)...)
-	ctxlogger.Get(ctx).Debug("List",
+	rows, err := b.conn(ctx).QueryxContext(ctx, query, args...)
+	ctxlogger.Get(ctx).Debug(name,
 		zap.String("driver", b.driverName),
 		zap.Int("dbnum", b.DBNum),
-		zap.String("query", b.GetQuery.String()),
-		zap.Any("args", b.GetQuery.Args(ectx)),
+		zap.String("query", query),
+		zap.Any("args", args),
 		zap.Error(err),
 	)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 	for rows.Next() {
 		record := make(Record, b.minSizeOfRecord)
 		if err = rows.MapScan(record); err != nil {
@@ -129,39 +248,257 @@ func (b *Bind) List(ctx context.Context, ectx keypattern.ExecContext) ([]Record,
 		}
 		res = append(res, record)
 	}
-	return res, err
+	return res, rows.Err()
 }
 
 func (b *Bind) Upsert(ctx context.Context, ectx keypattern.ExecContext, value []byte) error {
-	if b.UpsertQuery == nil {
-		return storage.ErrReadOnly
-	}
+	_, _, err := b.SetRecord(ctx, ectx, value, storage.SetOptions{})
+	return err
+}
+
+// SetRecord writes the record by the SET options and returns the previous record
+// if it's requested and the flag if the record was written
+func (b *Bind) SetRecord(ctx context.Context, ectx keypattern.ExecContext, value []byte, opts storage.SetOptions) (Record, bool, error) {
 	if ectx == nil {
 		ectx = make(keypattern.ExecContext, 10)
 	}
-	var values keypattern.ExecContext
-	if err := json.Unmarshal(value, &values); err != nil {
-		return err
+	keyCtx := maps.Clone(ectx)
+	query, err := b.SetRecordQuery(ectx, value, opts)
+	if err != nil {
+		return nil, false, err
+	}
+	var prev Record
+	if opts.Get {
+		// Read the previous value in the same transaction
+		if txFromContext(ctx, b.db) == nil {
+			tx, err := b.db.BeginTxx(ctx, nil)
+			if err != nil {
+				return nil, false, err
+			}
+			defer func() { _ = tx.Rollback() }()
+			prev, applied, err := b.SetRecord(withTx(ctx, b.db, tx), keyCtx, value, opts)
+			if err == nil {
+				err = tx.Commit()
+			}
+			return prev, applied, err
+		}
+		if prev, err = b.Get(ctx, keyCtx); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return nil, false, err
+		}
+	}
+	if opts.Mode == storage.SetIfNotExists && b.DelExpiredQuery != nil {
+		// The expired record doesn't exist for NX
+		if _, err = b.execAffected(ctx, "DelExpired", b.DelExpiredQuery, keyCtx); err != nil {
+			return nil, false, err
+		}
+	}
+	affected, err := b.execAffected(ctx, "SetRecord", query, ectx)
+	if err != nil {
+		return nil, false, err
+	}
+	return prev, opts.Mode == storage.SetAlways || affected > 0, nil
+}
+
+// Expire sets the expiration time of the record, zero time removes the expiration
+func (b *Bind) Expire(ctx context.Context, ectx keypattern.ExecContext, at time.Time) (bool, error) {
+	query, err := b.ExpireQuery(ectx, at)
+	if err != nil {
+		return false, err
+	}
+	affected, err := b.execAffected(ctx, "Expire", query, ectx)
+	return affected > 0, err
+}
+
+// Sweep deletes expired records periodically until the context is done.
+// The keys are deleted one by one and reported as expired if the expired events are enabled.
+func (b *Bind) Sweep(ctx context.Context, interval time.Duration, events *KeyEvents) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		var (
+			count int64
+			err   error
+		)
+		if b.ExpiredKeysQuery != "" && events.ExpiredEvents() {
+			count, err = b.sweepKeys(ctx, events)
+		} else {
+			count, err = b.execAffected(ctx, "Sweep", b.SweepQuery, keypattern.ExecContext{})
+		}
+		if err != nil && ctx.Err() == nil {
+			ctxlogger.Get(ctx).Error("sweep expired records",
+				zap.String("driver", b.driverName),
+				zap.Int("dbnum", b.DBNum),
+				zap.Error(err))
+		} else if count > 0 {
+			ctxlogger.Get(ctx).Debug("sweep expired records",
+				zap.Int("dbnum", b.DBNum), zap.Int64("count", count))
+		}
 	}
-	for k, v := range values {
-		ectx[k] = v
+}
+
+// sweepKeys deletes the expired records by keys and reports the expired events
+func (b *Bind) sweepKeys(ctx context.Context, events *KeyEvents) (int64, error) {
+	var count int64
+	for {
+		records, err := b.queryRecords(ctx, "SweepKeys", b.ExpiredKeysQuery, nil)
+		if err != nil {
+			return count, err
+		}
+		var deleted int64
+		for _, rec := range records {
+			key, ectx := b.RecordKey(rec)
+			affected, err := b.execAffected(ctx, "Sweep", b.DelExpiredQuery, ectx)
+			if err != nil {
+				return count + deleted, err
+			}
+			if affected > 0 {
+				deleted += affected
+				events.Notify(ctx, storage.KeyEvent{DBNum: b.DBNum, Key: key, Event: storage.KeyEventExpired})
+			}
+		}
+		// The rest of the keys is deleted by the next sweep if the batch can't be deleted
+		if count += deleted; len(records) < SweepBatch || deleted == 0 {
+			return count, nil
+		}
 	}
-	_, err := b.db.ExecContext(ctx, b.UpsertQuery.String(), b.UpsertQuery.Args(ectx)...)
-	ctxlogger.Get(ctx).Debug("Upsert",
+}
+
+// SetFields of the record and returns the number of affected records
+func (b *Bind) SetFields(ctx context.Context, ectx keypattern.ExecContext, fields map[string]string) (int64, error) {
+	query, err := b.UpdateFieldsQuery(ectx, fields)
+	if err != nil {
+		return 0, err
+	}
+	return b.execAffected(ctx, "SetFields", query, ectx)
+}
+
+// DelFields of the record and returns the number of affected records
+func (b *Bind) DelFields(ctx context.Context, ectx keypattern.ExecContext, fields []string) (int64, error) {
+	query, err := b.DelFieldsQuery(ectx, fields)
+	if err != nil {
+		return 0, err
+	}
+	return b.execAffected(ctx, "DelFields", query, ectx)
+}
+
+// IncrField of the record and returns the new value
+func (b *Bind) IncrField(ctx context.Context, ectx keypattern.ExecContext, field string, delta any) (any, error) {
+	query, err := b.IncrFieldQuery(ectx, field, delta)
+	if err != nil {
+		return nil, err
+	}
+	if b.Syntax.SupportReturning() {
+		var value any
+		err = b.conn(ctx).QueryRowxContext(ctx, query.String(), query.Args(ectx)...).Scan(&value)
+		ctxlogger.Get(ctx).Debug("IncrField",
+			zap.String("driver", b.driverName),
+			zap.Int("dbnum", b.DBNum),
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args(ectx)),
+			zap.Error(err),
+		)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return value, err
+	}
+
+	// Select the new value in the same transaction if RETURNING is not supported
+	tx, ownTx := txFromContext(ctx, b.db), false
+	if tx == nil {
+		if tx, err = b.db.BeginTxx(ctx, nil); err != nil {
+			return nil, err
+		}
+		defer func() { _ = tx.Rollback() }()
+		ownTx = true
+	}
+	res, err := tx.ExecContext(ctx, query.String(), query.Args(ectx)...)
+	if err != nil {
+		return nil, err
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if affected == 0 {
+		return nil, storage.ErrNotFound
+	}
+	record := Record{}
+	if err = tx.QueryRowxContext(ctx, b.GetQuery.String(), b.GetQuery.Args(ectx)...).MapScan(record); err != nil {
+		return nil, err
+	}
+	if ownTx {
+		err = tx.Commit()
+	}
+	return record[field], err
+}
+
+// IncrBy increments the counter of the record by incr_query or counter_column
+// and returns the column of the counter and the new value
+func (b *Bind) IncrBy(ctx context.Context, ectx keypattern.ExecContext, delta any) (string, any, error) {
+	if b.IncrQuery == nil {
+		if b.CounterColumn == "" {
+			return "", nil, storage.ErrMethodIsNotSupported
+		}
+		value, err := b.IncrField(ctx, ectx, b.CounterColumn, delta)
+		return b.CounterColumn, value, err
+	}
+	query, err := b.CustomIncrQuery(ectx, delta)
+	if err != nil {
+		return "", nil, err
+	}
+	rows, err := b.conn(ctx).QueryxContext(ctx, query.String(), query.Args(ectx)...)
+	ctxlogger.Get(ctx).Debug("IncrBy",
 		zap.String("driver", b.driverName),
 		zap.Int("dbnum", b.DBNum),
-		zap.String("query", b.GetQuery.String()),
-		zap.Any("args", b.GetQuery.Args(ectx)),
+		zap.String("query", query.String()),
+		zap.Any("args", query.Args(ectx)),
 		zap.Error(err),
 	)
-	return err
+	if err != nil {
+		return "", nil, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return "", nil, err
+		}
+		return "", nil, storage.ErrNotFound
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", nil, err
+	}
+	var value any
+	if err = rows.Scan(&value); err != nil {
+		return "", nil, err
+	}
+	return columns[0], value, nil
+}
+
+func (b *Bind) execAffected(ctx context.Context, name string, query *Query, ectx keypattern.ExecContext) (int64, error) {
+	res, err := b.conn(ctx).ExecContext(ctx, query.String(), query.Args(ectx)...)
+	ctxlogger.Get(ctx).Debug(name,
+		zap.String("driver", b.driverName),
+		zap.Int("dbnum", b.DBNum),
+		zap.String("query", query.String()),
+		zap.Any("args", query.Args(ectx)),
+		zap.Error(err),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
 }
 
 func (b *Bind) Del(ctx context.Context, ectx keypattern.ExecContext) error {
 	if b.DelQuery == nil {
 		return storage.ErrReadOnly
 	}
-	_, err := b.db.ExecContext(ctx, b.DelQuery.String(), b.DelQuery.Args(ectx)...)
+	_, err := b.conn(ctx).ExecContext(ctx, b.DelQuery.String(), b.DelQuery.Args(ectx)...)
 	ctxlogger.Get(ctx).Debug("Del",
 		zap.String("driver", b.driverName),
 		zap.Int("dbnum", b.DBNum),
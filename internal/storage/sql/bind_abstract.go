@@ -1,8 +1,17 @@
 package sql
 
 import (
+	"bytes"
 	"context"
-	"path/filepath"
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/demdxx/gocast/v2"
+	"github.com/pkg/errors"
 
 	"github.com/demdxx/redify/internal/keypattern"
 	"github.com/demdxx/redify/internal/storage"
@@ -15,20 +24,112 @@ type (
 
 type Syntax interface {
 	UpsertQuery(tableName string, insertFields DataFields, keyFields []string) string
+	InsertQuery(tableName string, insertFields DataFields, keyFields []string) string
+	FormatTime(tm time.Time) string
+	CurrentTime() string
+	EscapeColumn(name string) string
 	GetQuery(tableName string, where WhereStmt, whereExt string) string
 	SelectQuery(tableName string, where WhereStmt, whereExt string) string
+	ProjectionQuery(tableName string, columns []string, where WhereStmt, whereExt string) string
+	DistinctQuery(tableName string, columns []string, where WhereStmt, whereExt string) string
+	ColumnsQuery(tableName string) string
 	DeleteQuery(tableName string, where WhereStmt, whereExt string) string
+	UpdateQuery(tableName string, set DataFields, where WhereStmt, whereExt string) string
+	IncrementQuery(tableName, field, delta string, where WhereStmt, whereExt string) string
+	SupportReturning() bool
+	PageQuery(query string, orderBy []string, limit, offset uint64) string
+	CountQuery(query string) string
+	SortedQuery(query, member, score, where string, desc bool, limit, offset uint64) string
+	FilterQuery(query string, equals, likes WhereStmt) string
+	BatchGetQuery(tableName string, keyFields []string, count int, whereExt string) string
 }
 
+// ErrInvalidFieldName in case of the field name can't be used as a column name
+var ErrInvalidFieldName = errors.New("invalid field name")
+
+var reFieldName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Prefixes of the execution context arguments extracted from the glob pattern
+const (
+	globEqualArg = "glob_eq:"
+	globLikeArg  = "glob_like:"
+)
+
+// Names of the execution context arguments of the field modification and JSON queries
+const (
+	fieldArg     = "field:"
+	deltaArg     = "incr:delta"
+	expiresAtArg = "ttl:expires_at"
+	jsonPathArg  = "json:path"
+)
+
+// CounterDeltaVar is the variable of the incr_query with the increment value
+const CounterDeltaVar = "delta"
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// maxBatchArgs limits the arguments of the batch queries below the parameter limits
+// of the drivers (2100 of MSSQL, 65535 of PostgreSQL)
+const maxBatchArgs = 2000
+
+// SweepBatch is the number of the expired keys selected by the sweeper at once
+const SweepBatch = 1000
+
 type BindAbstract struct {
-	DBNum            int
-	Pattern          *keypattern.Pattern
-	Syntax           Syntax
-	GetQuery         *query
-	ListQuery        *query
-	UpsertQuery      *query
-	DelQuery         *query
-	DatatypesMapping []storage.DatatypeMapper
+	DBNum             int
+	Pattern           *keypattern.Pattern
+	Syntax            Syntax
+	GetQuery          *query
+	ListQuery         *query
+	UpsertQuery       *query
+	InsertQuery       *query
+	UpdateQuery       *query
+	UpdateRecordQuery *query
+	DelQuery          *query
+	DatatypesMapping  []storage.DatatypeMapper
+	Readonly          bool
+
+	// KeyType of the bind keys, hash by default
+	KeyType string
+	// MemberColumn contains the members of the list and set keys,
+	// zset binds accept any SQL expression over the columns of the get query
+	MemberColumn string
+	// ScoreColumn contains the scores of the zset members, may be an SQL expression
+	ScoreColumn string
+	// OrderBy expression of the members of the list table binds
+	OrderBy  string
+	AddQuery *query
+	RemQuery *query
+
+	// CounterColumn is incremented by INCR commands if IncrQuery is not defined
+	CounterColumn string
+	IncrQuery     *query
+
+	// TTLColumn contains the expiration time of the record
+	TTLColumn string
+	// SweepQuery deletes expired records
+	SweepQuery *query
+	// ExpiredKeysQuery selects the keys of the expired records
+	// which are deleted one by one if the expired events are listened
+	ExpiredKeysQuery string
+	// DelExpiredQuery deletes the record of the key if it's expired
+	DelExpiredQuery *query
+
+	// Source table of the bind defined by table name
+	SourceTable string
+	KeyFields   []string
+	WhereExt    string
+}
+
+// PageOrder returns the key columns which keep the order of the pages between the requests
+func (b *BindAbstract) PageOrder() []string {
+	columns := make([]string, 0, len(b.KeyFields))
+	for _, name := range b.Pattern.Keys() {
+		if reFieldName.MatchString(name) {
+			columns = append(columns, name)
+		}
+	}
+	return columns
 }
 
 func NewBindAbstract(dbnum int, syntax Syntax, pattern, getQuery, listQuery, upsertQuery, delQuery string, datatypesMapping []storage.DatatypeMapper) *BindAbstract {
@@ -72,30 +173,550 @@ func NewBindAbstractFromTableName(dbnum int, syntax Syntax, pattern, tableName,
 		DelQuery:         delQyeryObj,
 		UpsertQuery:      upinsertQyeryObj,
 		DatatypesMapping: datatypesMapping,
+		Readonly:         readonly,
+		SourceTable:      tableName,
+		KeyFields:        keyFields,
+		WhereExt:         whereExt,
 	}
 }
 
+// Configure optional parameters of the bind
+func (b *BindAbstract) Configure(conf *storage.BindConfig) error {
+	b.Readonly = b.Readonly || conf.Readonly
+	if conf.InsertQuery != "" {
+		b.InsertQuery = ParseQuery(conf.InsertQuery)
+	}
+	if conf.UpdateQuery != "" {
+		b.UpdateQuery = ParseQuery(conf.UpdateQuery)
+	}
+	if conf.UpdateRecordQuery != "" {
+		b.UpdateRecordQuery = ParseQuery(conf.UpdateRecordQuery)
+	}
+	if conf.IncrQuery != "" {
+		b.IncrQuery = ParseQuery(conf.IncrQuery)
+	}
+	if conf.CounterColumn != "" {
+		if !reFieldName.MatchString(conf.CounterColumn) {
+			return errors.Wrap(ErrInvalidFieldName, conf.CounterColumn)
+		}
+		b.CounterColumn = conf.CounterColumn
+	}
+	if err := b.configureType(conf); err != nil {
+		return err
+	}
+	if conf.TTLColumn == "" {
+		return nil
+	}
+	if !reFieldName.MatchString(conf.TTLColumn) {
+		return errors.Wrap(ErrInvalidFieldName, conf.TTLColumn)
+	}
+	b.TTLColumn = conf.TTLColumn
+	if conf.TTLSweepQuery != "" {
+		b.SweepQuery = ParseQuery(conf.TTLSweepQuery)
+	}
+	if !b.IsTableBind() {
+		return nil
+	}
+	var (
+		column  = b.Syntax.EscapeColumn(b.TTLColumn)
+		expired = column + ` IS NOT NULL AND ` + column + ` <= ` + b.Syntax.CurrentTime()
+	)
+	if b.SweepQuery == nil && !b.Readonly {
+		b.SweepQuery = ParseQuery(b.Syntax.DeleteQuery(b.SourceTable, WhereStmt{}, andCond(b.WhereExt, expired)))
+		b.ExpiredKeysQuery = b.Syntax.PageQuery(
+			b.Syntax.DistinctQuery(b.SourceTable, b.KeyFields, WhereStmt{}, andCond(b.WhereExt, expired)), nil, SweepBatch, 0)
+	}
+	b.DelExpiredQuery = ParseQuery(b.Syntax.DeleteQuery(b.SourceTable, b.keyWhere(), andCond(b.WhereExt, expired)))
+	// Expired records are invisible for all queries except the deletion
+	b.WhereExt = andCond(b.WhereExt, `(`+column+` IS NULL OR `+column+` > `+b.Syntax.CurrentTime()+`)`)
+	b.buildTableQueries()
+	return nil
+}
+
+// andCond joins the SQL conditions by AND
+func andCond(cond, ext string) string {
+	if cond == "" {
+		return ext
+	}
+	return `(` + cond + `) AND ` + ext
+}
+
 func (b *BindAbstract) TableName() string {
 	return b.GetQuery.TableName
 }
 
+// Type of the keys of the bind
+func (b *BindAbstract) Type() string {
+	if b.KeyType == "" {
+		return storage.KeyTypeHash
+	}
+	return b.KeyType
+}
+
+// IsTableBind returns true if the bind is defined by the table name and queries are generated
+func (b *BindAbstract) IsTableBind() bool {
+	return b.SourceTable != "" && len(b.KeyFields) > 0
+}
+
+// SupportBatch returns true if the bind can load many records by one query
+func (b *BindAbstract) SupportBatch() bool {
+	return b.IsTableBind() && !b.IsCollection()
+}
+
+// SupportProjection returns true if the bind can select separate columns of the record
+func (b *BindAbstract) SupportProjection() bool {
+	return b.IsTableBind() && !b.IsCollection()
+}
+
+// FieldsQuery returns the query to select only the columns of the record.
+// Columns must be validated against the table columns by ProjectColumns.
+func (b *BindAbstract) FieldsQuery(columns []string) *Query {
+	return ParseQuery(b.Syntax.ProjectionQuery(b.SourceTable, columns, b.keyWhere(), b.WhereExt))
+}
+
+// SupportJSONPath returns true if the simple JSON path can be evaluated
+// by the jsonb operator over the record selected from the table
+func (b *BindAbstract) SupportJSONPath() bool {
+	return b.SupportProjection() && len(b.DatatypesMapping) == 0
+}
+
+// JSONPathQuery returns the PostgreSQL query to select the value of the record
+// by the `#>` jsonb operator and puts the path into the execution context.
+// The selected value is NULL if the path doesn't exist.
+func (b *BindAbstract) JSONPathQuery(ectx keypattern.ExecContext, path []string) *Query {
+	elems := make([]string, 0, len(path))
+	for _, key := range path {
+		elems = append(elems, `"`+strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(key)+`"`)
+	}
+	ectx[jsonPathArg] = "{" + strings.Join(elems, ",") + "}"
+	args := append(slices.Clone(b.GetQuery.arguments), jsonPathArg)
+	return &Query{
+		queryStr: `SELECT to_jsonb(json_query) #> CAST($` + strconv.Itoa(len(args)) +
+			` AS TEXT[]) AS value FROM (` + b.GetQuery.String() + `) AS json_query LIMIT 1`,
+		TableName: b.GetQuery.TableName,
+		arguments: args,
+	}
+}
+
+// ProjectColumns returns unique fields which are present in the table columns
+func ProjectColumns(fields, columns []string) []string {
+	res := make([]string, 0, len(fields))
+	for _, name := range fields {
+		if slices.Contains(columns, name) && !slices.Contains(res, name) {
+			res = append(res, name)
+		}
+	}
+	return res
+}
+
+// UpdateFieldsQuery returns the query to update fields of the record
+// and puts the field values into the execution context
+func (b *BindAbstract) UpdateFieldsQuery(ectx keypattern.ExecContext, fields map[string]string) (*Query, error) {
+	if b.IsCollection() {
+		return nil, storage.ErrWrongType
+	}
+	if b.Readonly {
+		return nil, storage.ErrReadOnly
+	}
+	if b.UpdateQuery != nil {
+		for name, value := range fields {
+			ectx[name] = value
+		}
+		return b.UpdateQuery, nil
+	}
+	if !b.IsTableBind() {
+		return nil, storage.ErrReadOnly
+	}
+	set := make(DataFields, len(fields))
+	for name, value := range fields {
+		if !reFieldName.MatchString(name) {
+			return nil, errors.Wrap(ErrInvalidFieldName, name)
+		}
+		set[name] = "{{" + fieldArg + name + "}}"
+		ectx[fieldArg+name] = value
+	}
+	return ParseQuery(b.Syntax.UpdateQuery(b.SourceTable, set, b.keyWhere(), b.WhereExt)), nil
+}
+
+// DelFieldsQuery returns the query to reset fields of the record to NULL
+func (b *BindAbstract) DelFieldsQuery(ectx keypattern.ExecContext, fields []string) (*Query, error) {
+	if b.IsCollection() {
+		return nil, storage.ErrWrongType
+	}
+	if b.Readonly {
+		return nil, storage.ErrReadOnly
+	}
+	if !b.IsTableBind() {
+		return nil, storage.ErrMethodIsNotSupported
+	}
+	set := make(DataFields, len(fields))
+	for _, name := range fields {
+		if !reFieldName.MatchString(name) {
+			return nil, errors.Wrap(ErrInvalidFieldName, name)
+		}
+		set[name] = "NULL"
+	}
+	return ParseQuery(b.Syntax.UpdateQuery(b.SourceTable, set, b.keyWhere(), b.WhereExt)), nil
+}
+
+// IncrFieldQuery returns the query to increment the field of the record
+// and puts the delta value into the execution context
+func (b *BindAbstract) IncrFieldQuery(ectx keypattern.ExecContext, field string, delta any) (*Query, error) {
+	if b.IsCollection() {
+		return nil, storage.ErrWrongType
+	}
+	if b.Readonly {
+		return nil, storage.ErrReadOnly
+	}
+	if !b.IsTableBind() {
+		return nil, storage.ErrMethodIsNotSupported
+	}
+	if !reFieldName.MatchString(field) {
+		return nil, errors.Wrap(ErrInvalidFieldName, field)
+	}
+	ectx[deltaArg] = gocast.Str(delta)
+	return ParseQuery(b.Syntax.IncrementQuery(b.SourceTable, field, "{{"+deltaArg+"}}", b.keyWhere(), b.WhereExt)), nil
+}
+
+// CustomIncrQuery returns the incr_query and puts the delta value into the execution context
+func (b *BindAbstract) CustomIncrQuery(ectx keypattern.ExecContext, delta any) (*Query, error) {
+	if b.IsCollection() {
+		return nil, storage.ErrWrongType
+	}
+	if b.Readonly {
+		return nil, storage.ErrReadOnly
+	}
+	if b.IncrQuery == nil {
+		return nil, storage.ErrMethodIsNotSupported
+	}
+	ectx[CounterDeltaVar] = gocast.Str(delta)
+	return b.IncrQuery, nil
+}
+
+// SetRecordQuery returns the query to write the record by the SET mode
+// and puts the record values into the execution context.
+// Custom binds use upsert_query, insert_query (NX) and update_record_query (XX),
+// table binds generate the query by the fields of the record.
+// The expiration requires the ttl_column of the table bind.
+func (b *BindAbstract) SetRecordQuery(ectx keypattern.ExecContext, value []byte, opts storage.SetOptions) (*Query, error) {
+	if b.IsCollection() {
+		return nil, storage.ErrWrongType
+	}
+	if b.Readonly || b.UpsertQuery == nil {
+		return nil, storage.ErrReadOnly
+	}
+	if opts.TTL > 0 && (b.TTLColumn == "" || !b.IsTableBind()) {
+		return nil, storage.ErrExpireIsNotSupported
+	}
+	values, err := decodeRecord(value)
+	if err != nil {
+		return nil, err
+	}
+	if !b.IsTableBind() {
+		var query *Query
+		switch opts.Mode {
+		case storage.SetIfNotExists:
+			query = b.InsertQuery
+		case storage.SetIfExists:
+			query = b.UpdateRecordQuery
+		default:
+			query = b.UpsertQuery
+		}
+		if query == nil {
+			return nil, storage.ErrMethodIsNotSupported
+		}
+		for name, val := range values {
+			ectx[name] = gocast.Str(val)
+		}
+		return query, nil
+	}
+
+	fields := make(DataFields, len(values)+1)
+	for name, val := range values {
+		if slices.Contains(b.KeyFields, name) {
+			continue
+		}
+		if !reFieldName.MatchString(name) {
+			return nil, errors.Wrap(ErrInvalidFieldName, name)
+		}
+		if val == nil {
+			fields[name] = "NULL"
+			continue
+		}
+		fields[name] = "{{" + fieldArg + name + "}}"
+		ectx[fieldArg+name] = gocast.Str(val)
+	}
+	if b.TTLColumn != "" {
+		switch {
+		case opts.TTL > 0:
+			fields[b.TTLColumn] = "{{" + expiresAtArg + "}}"
+			ectx[expiresAtArg] = b.Syntax.FormatTime(time.Now().Add(opts.TTL))
+		case !opts.KeepTTL:
+			fields[b.TTLColumn] = "NULL"
+		}
+	}
+	if opts.Mode == storage.SetIfExists {
+		if len(fields) == 0 {
+			// Touch the record to check the existence
+			fields[b.KeyFields[0]] = "{{" + b.KeyFields[0] + "}}"
+		}
+		return ParseQuery(b.Syntax.UpdateQuery(b.SourceTable, fields, b.keyWhere(), b.WhereExt)), nil
+	}
+	for _, key := range b.KeyFields {
+		fields[key] = "{{" + key + "}}"
+	}
+	if opts.Mode == storage.SetIfNotExists {
+		return ParseQuery(b.Syntax.InsertQuery(b.SourceTable, fields, b.KeyFields)), nil
+	}
+	return ParseQuery(b.Syntax.UpsertQuery(b.SourceTable, fields, b.KeyFields)), nil
+}
+
+// ExpireQuery returns the query to set the expiration time of the record.
+// Zero time removes the expiration of the record which has it.
+func (b *BindAbstract) ExpireQuery(ectx keypattern.ExecContext, at time.Time) (*Query, error) {
+	if b.Readonly {
+		return nil, storage.ErrReadOnly
+	}
+	if b.TTLColumn == "" || !b.IsTableBind() {
+		return nil, storage.ErrMethodIsNotSupported
+	}
+	var (
+		set      = DataFields{}
+		whereExt = b.WhereExt
+	)
+	if at.IsZero() {
+		set[b.TTLColumn] = "NULL"
+		whereExt = andCond(whereExt, b.Syntax.EscapeColumn(b.TTLColumn)+` IS NOT NULL`)
+	} else {
+		set[b.TTLColumn] = "{{" + expiresAtArg + "}}"
+		ectx[expiresAtArg] = b.Syntax.FormatTime(at)
+	}
+	return ParseQuery(b.Syntax.UpdateQuery(b.SourceTable, set, b.keyWhere(), whereExt)), nil
+}
+
+// ExpiresIn returns the remaining time to live of the record or NoExpiration
+func (b *BindAbstract) ExpiresIn(rec Record) time.Duration {
+	if b.TTLColumn == "" {
+		return storage.NoExpiration
+	}
+	at, ok := parseTime(rec[b.TTLColumn])
+	if !ok {
+		return storage.NoExpiration
+	}
+	return time.Until(at)
+}
+
+// IsExpired returns true if the expiration time of the record is passed
+func (b *BindAbstract) IsExpired(rec Record) bool {
+	ttl := b.ExpiresIn(rec)
+	return ttl != storage.NoExpiration && ttl <= 0
+}
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+}
+
+// parseTime value of the timestamp column, values without time zone are in UTC
+func parseTime(v any) (time.Time, bool) {
+	switch tv := v.(type) {
+	case time.Time:
+		return tv, true
+	case *time.Time:
+		if tv != nil {
+			return *tv, true
+		}
+	case []byte:
+		return parseTime(string(tv))
+	case string:
+		for _, layout := range timeLayouts {
+			if tm, err := time.Parse(layout, tv); err == nil {
+				return tm, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// decodeRecord of the JSON object, nested values are kept as JSON strings
+func decodeRecord(value []byte) (map[string]any, error) {
+	var (
+		values map[string]any
+		dec    = json.NewDecoder(bytes.NewReader(value))
+	)
+	dec.UseNumber()
+	if err := dec.Decode(&values); err != nil {
+		return nil, err
+	}
+	for name, val := range values {
+		switch val.(type) {
+		case map[string]any, []any:
+			data, err := json.Marshal(val)
+			if err != nil {
+				return nil, err
+			}
+			values[name] = string(data)
+		}
+	}
+	return values, nil
+}
+
+// RecordKey returns the key of the record and the execution context of the key fields
+func (b *BindAbstract) RecordKey(rec Record) (string, keypattern.ExecContext) {
+	ectx := make(keypattern.ExecContext, len(b.KeyFields))
+	for _, name := range b.KeyFields {
+		ectx[name] = gocast.Str(rec[name])
+	}
+	return b.Pattern.Format(ectx), ectx
+}
+
+func (b *BindAbstract) keyWhere() WhereStmt {
+	where := make(WhereStmt, len(b.KeyFields))
+	for _, key := range b.KeyFields {
+		where[key] = "{{" + key + "}}"
+	}
+	return where
+}
+
+// BatchArgs returns the list of key values of every execution context
+// in order of the key fields
+func (b *BindAbstract) BatchArgs(ectxs []keypattern.ExecContext) []any {
+	args := make([]any, 0, len(ectxs)*len(b.KeyFields))
+	for _, ectx := range ectxs {
+		for _, field := range b.KeyFields {
+			args = append(args, ectx[field])
+		}
+	}
+	return args
+}
+
+// BatchChunks splits the execution contexts into the batches
+// which don't exceed the parameter limits of the drivers
+func (b *BindAbstract) BatchChunks(ectxs []keypattern.ExecContext) [][]keypattern.ExecContext {
+	size := max(maxBatchArgs/max(len(b.KeyFields), 1), 1)
+	chunks := make([][]keypattern.ExecContext, 0, (len(ectxs)+size-1)/size)
+	for len(ectxs) > size {
+		chunks = append(chunks, ectxs[:size])
+		ectxs = ectxs[size:]
+	}
+	return append(chunks, ectxs)
+}
+
+// BatchResult aligns the loaded records with the execution contexts by the key.
+// The database can return the key columns in another form than the requested key
+// (leading zeros of numbers, case insensitive collation), so if any record doesn't match
+// the requested keys the indexes of the unmatched contexts are returned to be read one by one.
+func (b *BindAbstract) BatchResult(ectxs []keypattern.ExecContext, records []Record) ([]Record, []int) {
+	var (
+		byKey   = make(map[string]Record, len(records))
+		result  = make([]Record, len(ectxs))
+		matched int
+		retry   []int
+	)
+	for _, rec := range records {
+		byKey[b.Pattern.Format(rec)] = rec
+	}
+	for i, ectx := range ectxs {
+		key := b.Pattern.Format(ectx)
+		if rec, ok := byKey[key]; ok {
+			result[i] = rec
+			delete(byKey, key)
+			matched++
+		}
+	}
+	if matched < len(records) {
+		for i, rec := range result {
+			if rec == nil {
+				retry = append(retry, i)
+			}
+		}
+	}
+	return result, retry
+}
+
 func (b *BindAbstract) MatchKey(key string, ectx keypattern.ExecContext) bool {
 	return b.Pattern.Match(key, ectx)
 }
 
+// MatchPattern checks if the glob pattern can match keys of the bind
+// and puts the restrictions of the key variables into the execution context
+// to filter the list query on the database side.
 func (b *BindAbstract) MatchPattern(pt string, ectx keypattern.ExecContext) bool {
-	ok, _ := filepath.Match(pt, b.Pattern.String())
-	return ok
+	vars, ok := b.Pattern.MatchGlob(pt)
+	if !ok {
+		return false
+	}
+	for _, v := range vars {
+		if v.Exact {
+			ectx[globEqualArg+v.Name] = v.Value
+		} else {
+			ectx[globLikeArg+v.Name] = likeEscaper.Replace(v.Prefix) + "%" + likeEscaper.Replace(v.Suffix)
+		}
+	}
+	return true
+}
+
+// PatternListQuery returns the list query extended with the key variable
+// restrictions from the execution context prepared by MatchPattern
+func (b *BindAbstract) PatternListQuery(ectx keypattern.ExecContext) *Query {
+	if b.ListQuery == nil {
+		return nil
+	}
+	var (
+		equals = WhereStmt{}
+		likes  = WhereStmt{}
+		args   = append([]string{}, b.ListQuery.arguments...)
+	)
+	for _, name := range b.Pattern.Keys() {
+		if _, ok := ectx[globEqualArg+name]; ok {
+			args = append(args, globEqualArg+name)
+			equals[name] = "$" + strconv.Itoa(len(args))
+		} else if _, ok := ectx[globLikeArg+name]; ok {
+			args = append(args, globLikeArg+name)
+			likes[name] = "$" + strconv.Itoa(len(args))
+		}
+	}
+	if len(args) == len(b.ListQuery.arguments) {
+		return b.ListQuery
+	}
+	return &Query{
+		queryStr:  b.Syntax.FilterQuery(b.ListQuery.String(), equals, likes),
+		TableName: b.ListQuery.TableName,
+		arguments: args,
+	}
+}
+
+// KeysCountQuery returns the query to count the keys of the list query
+func (b *BindAbstract) KeysCountQuery(ectx keypattern.ExecContext) *Query {
+	query := b.PatternListQuery(ectx)
+	if query == nil {
+		return nil
+	}
+	return &Query{
+		queryStr:  b.Syntax.CountQuery(query.String()),
+		TableName: query.TableName,
+		arguments: query.arguments,
+	}
 }
 
 func (b *BindAbstract) Get(ctx context.Context, ectx keypattern.ExecContext) (Record, error) {
 	return nil, nil
 }
 
+func (b *BindAbstract) GetMany(ctx context.Context, ectxs []keypattern.ExecContext) ([]Record, error) {
+	return nil, nil
+}
+
 func (b *BindAbstract) List(ctx context.Context, ectx keypattern.ExecContext) ([]Record, error) {
 	return nil, nil
 }
 
+func (b *BindAbstract) Page(ctx context.Context, ectx keypattern.ExecContext, offset, limit uint64) ([]Record, error) {
+	return nil, nil
+}
+
 func (b *BindAbstract) Upsert(ctx context.Context, ectx keypattern.ExecContext, value []byte) error {
 	return nil
 }
@@ -0,0 +1,242 @@
+package sql
+
+import (
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/demdxx/gocast/v2"
+	"github.com/pkg/errors"
+
+	"github.com/demdxx/redify/internal/keypattern"
+	"github.com/demdxx/redify/internal/storage"
+)
+
+// MemberVar is the variable of the add_query and rem_query with the member value
+const MemberVar = "member"
+
+// Name of the execution context argument of the generated member queries
+const memberArg = "collection:member"
+
+// configureType of the bind keys, table binds of collections select all rows of the key
+func (b *BindAbstract) configureType(conf *storage.BindConfig) error {
+	switch tp := strings.ToLower(conf.Type); tp {
+	case "", storage.KeyTypeHash:
+		return nil
+	case storage.KeyTypeList, storage.KeyTypeSet, storage.KeyTypeZSet:
+		b.KeyType = tp
+	default:
+		return errors.Wrap(storage.ErrInvalidBindConfig, "unsupported bind type "+conf.Type)
+	}
+	if conf.TTLColumn != "" {
+		return errors.Wrap(storage.ErrInvalidBindConfig, "ttl_column is not supported by "+b.KeyType+" binds")
+	}
+	if b.KeyType == storage.KeyTypeZSet {
+		return b.configureSortedSet(conf)
+	}
+	if conf.MemberColumn != "" && !reFieldName.MatchString(conf.MemberColumn) {
+		return errors.Wrap(ErrInvalidFieldName, conf.MemberColumn)
+	}
+	b.MemberColumn = conf.MemberColumn
+	b.OrderBy = conf.OrderBy
+	if conf.AddQuery != "" {
+		b.AddQuery = ParseQuery(conf.AddQuery)
+	}
+	if conf.RemQuery != "" {
+		b.RemQuery = ParseQuery(conf.RemQuery)
+	}
+	if !b.IsTableBind() {
+		return nil
+	}
+	if b.MemberColumn == "" {
+		return errors.Wrap(storage.ErrInvalidBindConfig, "member_column is required for "+b.KeyType+" table binds")
+	}
+	b.buildTableQueries()
+	if b.Readonly {
+		return nil
+	}
+	var (
+		fields   = DataFields{b.MemberColumn: "{{" + memberArg + "}}"}
+		where    = b.keyWhere()
+		conflict []string
+	)
+	for _, key := range b.KeyFields {
+		fields[key] = "{{" + key + "}}"
+	}
+	if b.KeyType == storage.KeyTypeSet {
+		// Members of the set are unique by the key fields and the member column
+		conflict = append(slices.Clone(b.KeyFields), b.MemberColumn)
+	}
+	where[b.MemberColumn] = "{{" + memberArg + "}}"
+	if b.AddQuery == nil {
+		b.AddQuery = ParseQuery(b.Syntax.InsertQuery(b.SourceTable, fields, conflict))
+	}
+	if b.RemQuery == nil {
+		b.RemQuery = ParseQuery(b.Syntax.DeleteQuery(b.SourceTable, where, b.WhereExt))
+	}
+	return nil
+}
+
+// buildTableQueries generates the read queries of the table bind
+func (b *BindAbstract) buildTableQueries() {
+	if b.IsCollection() {
+		query := b.Syntax.SelectQuery(b.SourceTable, b.keyWhere(), b.WhereExt)
+		if b.OrderBy != "" {
+			query += ` ORDER BY ` + b.OrderBy
+		}
+		b.GetQuery = ParseQuery(query)
+		b.ListQuery = ParseQuery(b.Syntax.DistinctQuery(b.SourceTable, b.KeyFields, WhereStmt{}, b.WhereExt))
+		return
+	}
+	b.GetQuery = ParseQuery(b.Syntax.GetQuery(b.SourceTable, b.keyWhere(), b.WhereExt))
+	b.ListQuery = ParseQuery(b.Syntax.SelectQuery(b.SourceTable, WhereStmt{}, b.WhereExt))
+}
+
+// IsCollection returns true if the get query of the bind returns many members of the key
+func (b *BindAbstract) IsCollection() bool {
+	return storage.IsCollectionType(b.KeyType)
+}
+
+// CheckType returns ErrWrongType if the bind keys are not of the one of the types
+func (b *BindAbstract) CheckType(types ...string) error {
+	if !slices.Contains(types, b.Type()) {
+		return storage.ErrWrongType
+	}
+	return nil
+}
+
+// Member of the collection from the record: the value of the member_column,
+// the value of the single column or JSON of the whole record
+func (b *BindAbstract) Member(rec Record) (string, error) {
+	if b.MemberColumn != "" {
+		return memberValue(rec[b.MemberColumn]), nil
+	}
+	if len(rec) == 1 {
+		for _, val := range rec {
+			return memberValue(val), nil
+		}
+	}
+	data, err := json.Marshal(rec)
+	return string(data), err
+}
+
+func memberValue(val any) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case json.RawMessage:
+		return string(v)
+	}
+	return gocast.Str(val)
+}
+
+// RecordMembers returns the members of the collection from the records, members of sets are unique
+func (b *BindAbstract) RecordMembers(records []Record) ([]string, error) {
+	members := make([]string, 0, len(records))
+	for _, rec := range records {
+		member, err := b.Member(rec)
+		if err != nil {
+			return nil, err
+		}
+		if b.KeyType == storage.KeyTypeSet && slices.Contains(members, member) {
+			continue
+		}
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+// CountQuery returns the query to count the members of the key
+func (b *BindAbstract) CountQuery() *Query {
+	return &Query{
+		queryStr:  b.Syntax.CountQuery(b.GetQuery.String()),
+		TableName: b.GetQuery.TableName,
+		arguments: b.GetQuery.arguments,
+	}
+}
+
+// RangeQuery returns the query to select the members of the list from start to stop inclusive.
+// The length of the list is requested only for negative indexes.
+// Returns false if the range is empty.
+func (b *BindAbstract) RangeQuery(start, stop int64, length func() (int64, error)) (*Query, bool, error) {
+	limit, offset, ok, err := rangeLimits(start, stop, length)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	if limit == 0 {
+		return b.GetQuery, true, nil
+	}
+	return &Query{
+		queryStr:  b.Syntax.PageQuery(b.GetQuery.String(), nil, limit, offset),
+		TableName: b.GetQuery.TableName,
+		arguments: b.GetQuery.arguments,
+	}, true, nil
+}
+
+// rangeLimits converts the inclusive range of indexes to LIMIT and OFFSET,
+// zero limit means all members
+func rangeLimits(start, stop int64, length func() (int64, error)) (limit, offset uint64, ok bool, err error) {
+	if start == 0 && stop == -1 {
+		return 0, 0, true, nil
+	}
+	if start < 0 || stop < 0 {
+		count, err := length()
+		if err != nil {
+			return 0, 0, false, err
+		}
+		if start < 0 {
+			start = max(start+count, 0)
+		}
+		if stop < 0 {
+			stop += count
+		}
+	}
+	if start > stop {
+		return 0, 0, false, nil
+	}
+	return uint64(stop - start + 1), uint64(start), true, nil
+}
+
+// IsMemberQuery returns the query which selects the member of the key
+// and puts the member value into the execution context.
+// The query requires member_column to filter the members.
+func (b *BindAbstract) IsMemberQuery(ectx keypattern.ExecContext, member string) (*Query, error) {
+	if b.MemberColumn == "" {
+		return nil, storage.ErrMethodIsNotSupported
+	}
+	args := append(slices.Clone(b.GetQuery.arguments), memberArg)
+	ectx[memberArg] = member
+	return &Query{
+		queryStr: b.Syntax.FilterQuery(b.GetQuery.String(),
+			WhereStmt{b.MemberColumn: "$" + strconv.Itoa(len(args))}, nil),
+		TableName: b.GetQuery.TableName,
+		arguments: args,
+	}, nil
+}
+
+// AddMemberQuery returns the query to add the member to the key
+// and puts the member value into the execution context
+func (b *BindAbstract) AddMemberQuery(ectx keypattern.ExecContext, member string) (*Query, error) {
+	return b.memberQuery(ectx, b.AddQuery, member)
+}
+
+// RemMemberQuery returns the query to remove the member of the key
+// and puts the member value into the execution context
+func (b *BindAbstract) RemMemberQuery(ectx keypattern.ExecContext, member string) (*Query, error) {
+	return b.memberQuery(ectx, b.RemQuery, member)
+}
+
+func (b *BindAbstract) memberQuery(ectx keypattern.ExecContext, query *Query, member string) (*Query, error) {
+	if b.Readonly {
+		return nil, storage.ErrReadOnly
+	}
+	if query == nil {
+		return nil, storage.ErrMethodIsNotSupported
+	}
+	ectx[MemberVar] = member
+	ectx[memberArg] = member
+	return query, nil
+}
@@ -0,0 +1,225 @@
+package sql
+
+import (
+	"slices"
+	"strconv"
+
+	"github.com/demdxx/gocast/v2"
+	"github.com/pkg/errors"
+
+	"github.com/demdxx/redify/internal/keypattern"
+	"github.com/demdxx/redify/internal/storage"
+)
+
+// ScoreVar is the variable of the add_query of zset binds with the member score
+const ScoreVar = "score"
+
+// Names of the execution context arguments of the generated zset queries
+const (
+	scoreArg = "zset:score"
+	minArg   = "zset:min"
+	maxArg   = "zset:max"
+)
+
+// configureSortedSet bind which selects the members and the scores by the expressions.
+// Writes are generated only if the member and the score are columns of the table.
+func (b *BindAbstract) configureSortedSet(conf *storage.BindConfig) error {
+	if conf.MemberColumn == "" || conf.ScoreColumn == "" {
+		return errors.Wrap(storage.ErrInvalidBindConfig, "member_column and score_column are required for zset binds")
+	}
+	b.MemberColumn = conf.MemberColumn
+	b.ScoreColumn = conf.ScoreColumn
+	if conf.AddQuery != "" {
+		b.AddQuery = ParseQuery(conf.AddQuery)
+	}
+	if conf.RemQuery != "" {
+		b.RemQuery = ParseQuery(conf.RemQuery)
+	}
+	if !b.IsTableBind() {
+		return nil
+	}
+	b.buildTableQueries()
+	if b.Readonly || !reFieldName.MatchString(b.MemberColumn) || !reFieldName.MatchString(b.ScoreColumn) {
+		return nil
+	}
+	var (
+		fields = DataFields{
+			b.MemberColumn: "{{" + memberArg + "}}",
+			b.ScoreColumn:  "{{" + scoreArg + "}}",
+		}
+		where = b.keyWhere()
+	)
+	for _, key := range b.KeyFields {
+		fields[key] = "{{" + key + "}}"
+	}
+	where[b.MemberColumn] = "{{" + memberArg + "}}"
+	if b.AddQuery == nil {
+		// Members of the sorted set are unique by the key fields and the member column
+		conflict := append(slices.Clone(b.KeyFields), b.MemberColumn)
+		b.AddQuery = ParseQuery(b.Syntax.UpsertQuery(b.SourceTable, fields, conflict))
+	}
+	if b.RemQuery == nil {
+		b.RemQuery = ParseQuery(b.Syntax.DeleteQuery(b.SourceTable, where, b.WhereExt))
+	}
+	if b.IncrQuery == nil {
+		// The new score is selected after the update, so RETURNING is not required
+		score := b.Syntax.EscapeColumn(b.ScoreColumn)
+		b.IncrQuery = ParseQuery(b.Syntax.UpdateQuery(b.SourceTable,
+			DataFields{b.ScoreColumn: `COALESCE(` + score + `, 0) + {{` + deltaArg + `}}`}, where, b.WhereExt))
+	}
+	return nil
+}
+
+// sortedExpr returns the escaped column or the SQL expression in parentheses
+func (b *BindAbstract) sortedExpr(expr string) string {
+	if reFieldName.MatchString(expr) {
+		return b.Syntax.EscapeColumn(expr)
+	}
+	return `(` + expr + `)`
+}
+
+// sortedQuery builds the query over the get query of the zset bind
+// with the extra arguments of the conditions
+type sortedQuery struct {
+	b     *BindAbstract
+	ectx  keypattern.ExecContext
+	args  []string
+	conds []string
+}
+
+func (b *BindAbstract) newSortedQuery(ectx keypattern.ExecContext) *sortedQuery {
+	return &sortedQuery{b: b, ectx: ectx, args: slices.Clone(b.GetQuery.arguments)}
+}
+
+// arg puts the value into the execution context and returns its placeholder
+func (q *sortedQuery) arg(name, value string) string {
+	q.args = append(q.args, name)
+	q.ectx[name] = value
+	return "$" + strconv.Itoa(len(q.args))
+}
+
+// bound adds the condition of the range bound of the expression
+func (q *sortedQuery) bound(expr string, bound storage.ZBound, name, op string) {
+	if bound.Unbounded {
+		return
+	}
+	if !bound.Exclusive {
+		op += "="
+	}
+	q.conds = append(q.conds, expr+` `+op+` `+q.arg(name, bound.Value))
+}
+
+func (q *sortedQuery) query(desc bool, limit, offset uint64) *Query {
+	where := ""
+	for _, cond := range q.conds {
+		if where != "" {
+			where += ` AND `
+		}
+		where += cond
+	}
+	return &Query{
+		queryStr: q.b.Syntax.SortedQuery(q.b.GetQuery.String(),
+			q.b.sortedExpr(q.b.MemberColumn), q.b.sortedExpr(q.b.ScoreColumn), where, desc, limit, offset),
+		TableName: q.b.GetQuery.TableName,
+		arguments: q.args,
+	}
+}
+
+// ZRangeQuery returns the query to select the members and the scores of the sorted set range
+// and puts the bound values into the execution context.
+// The length of the set is requested only for negative indexes.
+// Returns false if the range is empty.
+func (b *BindAbstract) ZRangeQuery(ectx keypattern.ExecContext, rng storage.ZRange, length func() (int64, error)) (*Query, bool, error) {
+	q := b.newSortedQuery(ectx)
+	if rng.By == storage.ZRangeByIndex {
+		limit, offset, ok, err := rangeLimits(rng.Start, rng.Stop, length)
+		if err != nil || !ok {
+			return nil, false, err
+		}
+		return q.query(rng.Rev, limit, offset), true, nil
+	}
+	if rng.Count == 0 || rng.Offset < 0 {
+		return nil, false, nil
+	}
+	expr := b.sortedExpr(b.ScoreColumn)
+	if rng.By == storage.ZRangeByLex {
+		expr = b.sortedExpr(b.MemberColumn)
+	}
+	q.bound(expr, rng.Min, minArg, ">")
+	q.bound(expr, rng.Max, maxArg, "<")
+	return q.query(rng.Rev, uint64(max(rng.Count, 0)), uint64(rng.Offset)), true, nil
+}
+
+// ZScoreQuery returns the query to select the member with its score
+// and puts the member value into the execution context
+func (b *BindAbstract) ZScoreQuery(ectx keypattern.ExecContext, member string) *Query {
+	q := b.newSortedQuery(ectx)
+	q.conds = append(q.conds, b.sortedExpr(b.MemberColumn)+` = `+q.arg(memberArg, member))
+	return q.query(false, 1, 0)
+}
+
+// ZRankQuery returns the query to count the members before the member with the score
+func (b *BindAbstract) ZRankQuery(ectx keypattern.ExecContext, member string, score float64, rev bool) *Query {
+	var (
+		q       = b.newSortedQuery(ectx)
+		op      = "<"
+		value   = storage.FormatScore(score)
+		memExpr = b.sortedExpr(b.MemberColumn)
+		scExpr  = b.sortedExpr(b.ScoreColumn)
+	)
+	if rev {
+		op = ">"
+	}
+	q.conds = append(q.conds, `(`+scExpr+` `+op+` `+q.arg(scoreArg, value)+` OR (`+
+		scExpr+` = `+q.arg(scoreArg, value)+` AND `+memExpr+` `+op+` `+q.arg(memberArg, member)+`))`)
+	query := q.query(false, 0, 0)
+	query.queryStr = b.Syntax.CountQuery(query.queryStr)
+	return query
+}
+
+// ZAddQuery returns the query to add the member or update its score
+// and puts the member and the score values into the execution context
+func (b *BindAbstract) ZAddQuery(ectx keypattern.ExecContext, member storage.ScoredMember) (*Query, error) {
+	query, err := b.memberQuery(ectx, b.AddQuery, member.Member)
+	if err != nil {
+		return nil, err
+	}
+	score := storage.FormatScore(member.Score)
+	ectx[ScoreVar] = score
+	ectx[scoreArg] = score
+	return query, nil
+}
+
+// ZIncrQuery returns the query to increment the score of the existing member
+// and puts the member and the delta values into the execution context
+func (b *BindAbstract) ZIncrQuery(ectx keypattern.ExecContext, member string, delta float64) (*Query, error) {
+	query, err := b.memberQuery(ectx, b.IncrQuery, member)
+	if err != nil {
+		return nil, err
+	}
+	value := storage.FormatScore(delta)
+	ectx[CounterDeltaVar] = value
+	ectx[deltaArg] = value
+	return query, nil
+}
+
+// ScoredMember of the sorted set from the record of the sorted query
+func ScoredMember(rec Record) (storage.ScoredMember, error) {
+	score, err := scoreValue(rec[SortedScoreColumn])
+	return storage.ScoredMember{Member: memberValue(rec[SortedMemberColumn]), Score: score}, err
+}
+
+func scoreValue(val any) (float64, error) {
+	switch v := val.(type) {
+	case []byte:
+		return strconv.ParseFloat(string(v), 64)
+	case string:
+		return strconv.ParseFloat(v, 64)
+	}
+	score, err := gocast.TryNumber[float64](val)
+	if err != nil {
+		// Decimal types of the drivers are converted by the string representation
+		return strconv.ParseFloat(gocast.Str(val), 64)
+	}
+	return score, nil
+}
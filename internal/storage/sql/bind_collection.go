@@ -0,0 +1,150 @@
+package sql
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/demdxx/redify/internal/context/ctxlogger"
+	"github.com/demdxx/redify/internal/keypattern"
+	"github.com/demdxx/redify/internal/storage"
+	"go.uber.org/zap"
+)
+
+// AllMembers of the list in order, the unique members of the set
+// or the members of the sorted set ordered by the score
+func (b *Bind) AllMembers(ctx context.Context, ectx keypattern.ExecContext) ([]string, error) {
+	if b.Type() == storage.KeyTypeZSet {
+		scored, err := b.ZRange(ctx, ectx, storage.ZRange{Start: 0, Stop: -1})
+		if err != nil {
+			return nil, err
+		}
+		members := make([]string, 0, len(scored))
+		for _, member := range scored {
+			members = append(members, member.Member)
+		}
+		return members, nil
+	}
+	if err := b.CheckType(storage.KeyTypeList, storage.KeyTypeSet); err != nil {
+		return nil, err
+	}
+	return b.queryMembers(ctx, "AllMembers", b.GetQuery, ectx)
+}
+
+// Range of the list members from start to stop inclusive
+func (b *Bind) Range(ctx context.Context, ectx keypattern.ExecContext, start, stop int64) ([]string, error) {
+	if err := b.CheckType(storage.KeyTypeList); err != nil {
+		return nil, err
+	}
+	query, ok, err := b.RangeQuery(start, stop, func() (int64, error) { return b.count(ctx, ectx) })
+	if err != nil || !ok {
+		return nil, err
+	}
+	return b.queryMembers(ctx, "Range", query, ectx)
+}
+
+// Len returns the number of the members of the list, set or sorted set
+func (b *Bind) Len(ctx context.Context, ectx keypattern.ExecContext) (int64, error) {
+	if err := b.CheckType(storage.KeyTypeList, storage.KeyTypeSet, storage.KeyTypeZSet); err != nil {
+		return 0, err
+	}
+	return b.count(ctx, ectx)
+}
+
+// Members returns the unique members of the set
+func (b *Bind) Members(ctx context.Context, ectx keypattern.ExecContext) ([]string, error) {
+	if err := b.CheckType(storage.KeyTypeSet); err != nil {
+		return nil, err
+	}
+	return b.queryMembers(ctx, "Members", b.GetQuery, ectx)
+}
+
+// IsMember returns true if the set contains the member
+func (b *Bind) IsMember(ctx context.Context, ectx keypattern.ExecContext, member string) (bool, error) {
+	if err := b.CheckType(storage.KeyTypeSet); err != nil {
+		return false, err
+	}
+	query, err := b.IsMemberQuery(ectx, member)
+	if errors.Is(err, storage.ErrMethodIsNotSupported) {
+		// Members without the member column can be checked only on the application side
+		members, err := b.queryMembers(ctx, "IsMember", b.GetQuery, ectx)
+		return slices.Contains(members, member), err
+	}
+	if err != nil {
+		return false, err
+	}
+	records, err := b.queryRecords(ctx, "IsMember", query.String(), query.Args(ectx))
+	return len(records) > 0, err
+}
+
+// AddMembers to the list or set and returns the number of added members
+func (b *Bind) AddMembers(ctx context.Context, ectx keypattern.ExecContext, members []string) (int64, error) {
+	if err := b.CheckType(storage.KeyTypeList, storage.KeyTypeSet); err != nil {
+		return 0, err
+	}
+	return b.execMembers(ctx, "AddMembers", b.AddMemberQuery, ectx, members)
+}
+
+// RemMembers of the set or sorted set and returns the number of removed members
+func (b *Bind) RemMembers(ctx context.Context, ectx keypattern.ExecContext, members []string) (int64, error) {
+	if err := b.CheckType(storage.KeyTypeSet, storage.KeyTypeZSet); err != nil {
+		return 0, err
+	}
+	return b.execMembers(ctx, "RemMembers", b.RemMemberQuery, ectx, members)
+}
+
+// execMembers runs the member query for every member in one transaction
+func (b *Bind) execMembers(ctx context.Context, name string, queryFnk func(keypattern.ExecContext, string) (*Query, error), ectx keypattern.ExecContext, members []string) (int64, error) {
+	if len(members) > 1 && txFromContext(ctx, b.db) == nil {
+		tx, err := b.db.BeginTxx(ctx, nil)
+		if err != nil {
+			return 0, err
+		}
+		defer func() { _ = tx.Rollback() }()
+		count, err := b.execMembers(withTx(ctx, b.db, tx), name, queryFnk, ectx, members)
+		if err == nil {
+			err = tx.Commit()
+		}
+		return count, err
+	}
+	var count int64
+	for _, member := range members {
+		query, err := queryFnk(ectx, member)
+		if err != nil {
+			return 0, err
+		}
+		affected, err := b.execAffected(ctx, name, query, ectx)
+		if err != nil {
+			return 0, err
+		}
+		count += affected
+	}
+	return count, nil
+}
+
+func (b *Bind) queryMembers(ctx context.Context, name string, query *Query, ectx keypattern.ExecContext) ([]string, error) {
+	records, err := b.queryRecords(ctx, name, query.String(), query.Args(ectx))
+	if err != nil {
+		return nil, err
+	}
+	return b.RecordMembers(records)
+}
+
+func (b *Bind) count(ctx context.Context, ectx keypattern.ExecContext) (int64, error) {
+	return b.queryCount(ctx, "Count", b.CountQuery(), ectx)
+}
+
+func (b *Bind) queryCount(ctx context.Context, name string, query *Query, ectx keypattern.ExecContext) (int64, error) {
+	var (
+		count int64
+		err   = b.conn(ctx).QueryRowxContext(ctx, query.String(), query.Args(ectx)...).Scan(&count)
+	)
+	ctxlogger.Get(ctx).Debug(name,
+		zap.String("driver", b.driverName),
+		zap.Int("dbnum", b.DBNum),
+		zap.String("query", query.String()),
+		zap.Any("args", query.Args(ectx)),
+		zap.Error(err),
+	)
+	return count, err
+}
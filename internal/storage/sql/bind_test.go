@@ -99,6 +99,75 @@ func testBindCommon(ctx context.Context, t *testing.T, mock sqlmock.Sqlmock, bin
 			assert.Equal(t, "testuser", rec["username"])
 		}
 	})
+	t.Run("select missing record", func(t *testing.T) {
+		mock.ExpectQuery("SELECT \\*").
+			WithArgs("missing").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username"}))
+		_, err := bind.Get(ctx, keypattern.ExecContext{"username": "missing"})
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	})
+	t.Run("select many records", func(t *testing.T) {
+		columns := []string{"id", "username"}
+		ectxs := []keypattern.ExecContext{{"username": "testuser1"}, {"username": "testuser2"}}
+		if bind.SupportBatch() {
+			mock.ExpectQuery(`SELECT \* FROM users WHERE "username" IN \(\$1, \$2\)`).
+				WithArgs("testuser1", "testuser2").
+				WillReturnRows(sqlmock.NewRows(columns).AddRow(2, "testuser2"))
+		} else {
+			mock.ExpectQuery("SELECT \\*").
+				WithArgs("testuser1").
+				WillReturnRows(sqlmock.NewRows(columns))
+			mock.ExpectQuery("SELECT \\*").
+				WithArgs("testuser2").
+				WillReturnRows(sqlmock.NewRows(columns).AddRow(2, "testuser2"))
+		}
+		recs, err := bind.GetMany(ctx, ectxs)
+		if assert.NoError(t, err) && assert.Equal(t, 2, len(recs)) {
+			assert.Equal(t, 2, gocast.Int(recs[1]["id"]))
+			if bind.SupportBatch() {
+				assert.Nil(t, recs[0], "record must be not found")
+			}
+		}
+	})
+	t.Run("select many records with other key form", func(t *testing.T) {
+		if !bind.SupportBatch() {
+			t.Skip("batch is not supported")
+		}
+		columns := []string{"id", "username"}
+		ectxs := []keypattern.ExecContext{{"username": "testuser3"}, {"username": "testuser4"}}
+		mock.ExpectQuery(`SELECT \* FROM users WHERE "username" IN \(\$1, \$2\)`).
+			WithArgs("testuser3", "testuser4").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(3, "TestUser3"))
+		mock.ExpectQuery("SELECT \\*").
+			WithArgs("testuser3").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(3, "TestUser3"))
+		mock.ExpectQuery("SELECT \\*").
+			WithArgs("testuser4").
+			WillReturnRows(sqlmock.NewRows(columns))
+		recs, err := bind.GetMany(ctx, ectxs)
+		if assert.NoError(t, err) && assert.Equal(t, 2, len(recs)) {
+			assert.Equal(t, 3, gocast.Int(recs[0]["id"]))
+			assert.Nil(t, recs[1], "record must be not found")
+		}
+	})
+	t.Run("select fields", func(t *testing.T) {
+		columns := []string{"id", "username"}
+		if bind.SupportProjection() {
+			mock.ExpectQuery(`SELECT \* FROM users WHERE 1=0`).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "username", "bio"}))
+			mock.ExpectQuery(`SELECT "username" FROM users WHERE "username"=\$1 LIMIT 1`).
+				WithArgs("testuser").
+				WillReturnRows(sqlmock.NewRows(columns[1:]).AddRow("testuser"))
+		} else {
+			mock.ExpectQuery("SELECT \\*").
+				WithArgs("testuser").
+				WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "testuser"))
+		}
+		rec, err := bind.GetFields(ctx, keypattern.ExecContext{"username": "testuser"}, []string{"username", "unknown"})
+		if assert.NoError(t, err) {
+			assert.Equal(t, Record{"username": "testuser"}, rec)
+		}
+	})
 	t.Run("select list", func(t *testing.T) {
 		columns := []string{"id", "username"}
 		mock.ExpectQuery("SELECT").
@@ -117,14 +186,85 @@ func testBindCommon(ctx context.Context, t *testing.T, mock sqlmock.Sqlmock, bin
 			assert.Equal(t, 2, gocast.Int(res[1]["id"]))
 		}
 	})
+	t.Run("select list by pattern", func(t *testing.T) {
+		columns := []string{"id", "username"}
+		mock.ExpectQuery(`SELECT \* FROM \(.+\) AS filter_query WHERE CAST\("username" AS TEXT\) LIKE \$1 ESCAPE '\\'`).
+			WithArgs(`test\_%`).
+			WillReturnRows(
+				sqlmock.NewRows(columns).
+					AddRow(1, "test_user1"),
+			)
+		pectx := keypattern.ExecContext{}
+		if !bind.MatchPattern("users_test_*", pectx) {
+			t.Error("invalid pattern matching")
+		}
+		if bind.MatchPattern("posts_*", keypattern.ExecContext{}) {
+			t.Error("invalid negative pattern matching")
+		}
+		res, err := bind.List(ctx, pectx)
+		if assert.NoError(t, err) {
+			assert.Equal(t, 1, len(res))
+			assert.Equal(t, "test_user1", gocast.Str(res[0]["username"]))
+		}
+	})
+	t.Run("select page", func(t *testing.T) {
+		columns := []string{"id", "username"}
+		mock.ExpectQuery(`SELECT \* FROM \(.+\) AS page_query ORDER BY "username" LIMIT 2 OFFSET 10`).
+			WillReturnRows(
+				sqlmock.NewRows(columns).
+					AddRow(11, "testuser11").
+					AddRow(12, "testuser12"),
+			)
+		res, err := bind.Page(ctx, ectx, 10, 2)
+		if assert.NoError(t, err) {
+			assert.Equal(t, 2, len(res))
+			assert.Equal(t, 11, gocast.Int(res[0]["id"]))
+			assert.Equal(t, "testuser12", gocast.Str(res[1]["username"]))
+		}
+	})
 	t.Run("insert record", func(t *testing.T) {
-		mock.ExpectExec("INSERT INTO").
-			WithArgs(sqlmock.AnyArg()).
-			WillReturnResult(sqlmock.NewResult(1, 1))
+		if bind.IsTableBind() {
+			mock.ExpectExec(`INSERT INTO users \("newVar", "username"\) VALUES \(\$1, \$2\) ON CONFLICT \("username"\) DO UPDATE SET`).
+				WithArgs("val", "testuser").
+				WillReturnResult(sqlmock.NewResult(1, 1))
+		} else {
+			mock.ExpectExec("INSERT INTO").
+				WithArgs("testuser").
+				WillReturnResult(sqlmock.NewResult(1, 1))
+		}
 		ectx["username"] = "testuser"
 		err := bind.Upsert(ctx, ectx, []byte(`{"newVar":"val"}`))
 		assert.NoError(t, err)
 	})
+	t.Run("set record with options", func(t *testing.T) {
+		if !bind.IsTableBind() {
+			_, _, err := bind.SetRecord(ctx, ectx, []byte(`{}`), storage.SetOptions{Mode: storage.SetIfNotExists})
+			assert.ErrorIs(t, err, storage.ErrMethodIsNotSupported)
+			return
+		}
+		mock.ExpectExec(`INSERT INTO users \("newVar", "username"\) VALUES \(\$1, \$2\) ON CONFLICT \("username"\) DO NOTHING`).
+			WithArgs("val", "testuser").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		_, applied, err := bind.SetRecord(ctx, ectx, []byte(`{"newVar":"val"}`), storage.SetOptions{Mode: storage.SetIfNotExists})
+		if assert.NoError(t, err) {
+			assert.False(t, applied, "record already exists")
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM users WHERE "username"=\$1 LIMIT 1`).
+			WithArgs("testuser").
+			WillReturnRows(sqlmock.NewRows([]string{"username", "newVar"}).AddRow("testuser", "old"))
+		mock.ExpectExec(`UPDATE users SET "newVar"=\$1 WHERE "username"=\$2`).
+			WithArgs("val", "testuser").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		prev, applied, err := bind.SetRecord(ctx, ectx, []byte(`{"newVar":"val"}`),
+			storage.SetOptions{Mode: storage.SetIfExists, Get: true})
+		if assert.NoError(t, err) {
+			assert.True(t, applied)
+			assert.Equal(t, "old", gocast.Str(prev["newVar"]))
+		}
+	})
 	t.Run("delete record", func(t *testing.T) {
 		mock.ExpectExec("DELETE").
 			WithArgs(sqlmock.AnyArg()).
@@ -133,6 +273,41 @@ func testBindCommon(ctx context.Context, t *testing.T, mock sqlmock.Sqlmock, bin
 		err := bind.Del(ctx, ectx)
 		assert.NoError(t, err)
 	})
+	t.Run("update fields", func(t *testing.T) {
+		if !bind.IsTableBind() {
+			_, err := bind.SetFields(ctx, ectx, map[string]string{"email": "test@mail"})
+			assert.ErrorIs(t, err, storage.ErrReadOnly)
+			_, err = bind.IncrField(ctx, ectx, "visits", int64(1))
+			assert.ErrorIs(t, err, storage.ErrMethodIsNotSupported)
+			return
+		}
+		mock.ExpectExec(`UPDATE users SET "email"=\$1 WHERE "username"=\$2`).
+			WithArgs("test@mail", "testuser").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		count, err := bind.SetFields(ctx, ectx, map[string]string{"email": "test@mail"})
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(1), count)
+		}
+
+		mock.ExpectExec(`UPDATE users SET "email"=NULL WHERE "username"=\$1`).
+			WithArgs("testuser").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		count, err = bind.DelFields(ctx, ectx, []string{"email"})
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(0), count)
+		}
+
+		mock.ExpectQuery(`UPDATE users SET "visits"=COALESCE\("visits", 0\) \+ \$1 WHERE "username"=\$2 RETURNING "visits"`).
+			WithArgs("5", "testuser").
+			WillReturnRows(sqlmock.NewRows([]string{"visits"}).AddRow(15))
+		value, err := bind.IncrField(ctx, ectx, "visits", int64(5))
+		if assert.NoError(t, err) {
+			assert.Equal(t, 15, gocast.Int(value))
+		}
+
+		_, err = bind.SetFields(ctx, ectx, map[string]string{"email; DROP": "x"})
+		assert.ErrorIs(t, err, ErrInvalidFieldName)
+	})
 	t.Run("readnly", func(t *testing.T) {
 		bind.DelQuery = nil
 		bind.UpsertQuery = nil
@@ -142,3 +317,437 @@ func testBindCommon(ctx context.Context, t *testing.T, mock sqlmock.Sqlmock, bin
 		assert.ErrorIs(t, err, storage.ErrReadOnly)
 	})
 }
+
+func TestBindSetRecordQuery(t *testing.T) {
+	bind := NewBindAbstractFromTableName(0, NewAbstractSyntax(`"`), "users_{{username}}", "users", "", nil, false)
+	bind.Configure(&storage.BindConfig{TTLColumn: "expires_at"})
+
+	ectx := keypattern.ExecContext{"username": "testuser"}
+	query, err := bind.SetRecordQuery(ectx, []byte(`{"age":30,"tags":["a"]}`), storage.SetOptions{TTL: time.Minute})
+	if assert.NoError(t, err) {
+		assert.Equal(t, `INSERT INTO users ("age", "expires_at", "tags", "username") VALUES ($1, $2, $3, $4)`+
+			` ON CONFLICT ("username") DO UPDATE SET "age"=$1, "expires_at"=$2, "tags"=$3, "username"=$4`, query.String())
+		args := query.Args(ectx)
+		assert.Equal(t, "30", args[0])
+		assert.NotEmpty(t, args[1])
+		assert.Equal(t, `["a"]`, args[2])
+	}
+
+	query, err = bind.SetRecordQuery(ectx, []byte(`{"age":30}`), storage.SetOptions{Mode: storage.SetIfExists, KeepTTL: true})
+	if assert.NoError(t, err) {
+		assert.Equal(t, `UPDATE users SET "age"=$1 WHERE "username"=$2`+
+			` AND ("expires_at" IS NULL OR "expires_at" > CURRENT_TIMESTAMP)`, query.String())
+	}
+
+	query, err = bind.SetRecordQuery(ectx, []byte(`{}`), storage.SetOptions{Mode: storage.SetIfNotExists})
+	if assert.NoError(t, err) {
+		assert.Equal(t, `INSERT INTO users ("expires_at", "username") VALUES (NULL, $1) ON CONFLICT ("username") DO NOTHING`, query.String())
+	}
+
+	_, err = bind.SetRecordQuery(ectx, []byte(`{"bad name":1}`), storage.SetOptions{})
+	assert.ErrorIs(t, err, ErrInvalidFieldName)
+
+	noTTL := NewBindAbstractFromTableName(0, NewAbstractSyntax(`"`), "users_{{username}}", "users", "", nil, false)
+	_, err = noTTL.SetRecordQuery(ectx, []byte(`{"age":30}`), storage.SetOptions{TTL: time.Minute})
+	assert.ErrorIs(t, err, storage.ErrExpireIsNotSupported, "the expiration must not be dropped")
+
+	custom := NewBindAbstract(0, NewAbstractSyntax(`"`), "users_{{username}}", "SELECT * FROM users WHERE username={{username}}",
+		"", "INSERT INTO users (username, age) VALUES ({{username}}, {{age}})", "", nil)
+	custom.Configure(&storage.BindConfig{UpdateQuery: "UPDATE users SET email={{email}} WHERE username={{username}}"})
+	_, err = custom.SetRecordQuery(ectx, []byte(`{"age":30}`), storage.SetOptions{TTL: time.Minute})
+	assert.ErrorIs(t, err, storage.ErrExpireIsNotSupported)
+	_, err = custom.SetRecordQuery(ectx, []byte(`{"age":30}`), storage.SetOptions{Mode: storage.SetIfExists})
+	assert.ErrorIs(t, err, storage.ErrMethodIsNotSupported, "update_query is the query of HSET")
+	custom.Configure(&storage.BindConfig{UpdateRecordQuery: "UPDATE users SET age={{age}} WHERE username={{username}}"})
+	query, err = custom.SetRecordQuery(ectx, []byte(`{"age":30}`), storage.SetOptions{Mode: storage.SetIfExists})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "UPDATE users SET age=$1 WHERE username=$2", query.String())
+	}
+}
+
+func TestBindBatchChunks(t *testing.T) {
+	bind := NewBindAbstractFromTableName(0, NewAbstractSyntax(`"`), "users_{{username}}", "users", "", nil, false)
+	ectxs := make([]keypattern.ExecContext, maxBatchArgs*2+1)
+	chunks := bind.BatchChunks(ectxs)
+	if assert.Equal(t, 3, len(chunks)) {
+		assert.Equal(t, maxBatchArgs, len(chunks[0]))
+		assert.Equal(t, maxBatchArgs, len(chunks[1]))
+		assert.Equal(t, 1, len(chunks[2]))
+	}
+	assert.Equal(t, 1, len(bind.BatchChunks(ectxs[:1])))
+}
+
+func TestBindExpiration(t *testing.T) {
+	bind := NewBindAbstractFromTableName(0, NewAbstractSyntax(`"`), "session_{{id}}", "sessions", "active", nil, false)
+	if err := bind.Configure(&storage.BindConfig{TTLColumn: "expires_at"}); err != nil {
+		t.Fatal(err)
+	}
+	notExpired := ` AND ("expires_at" IS NULL OR "expires_at" > CURRENT_TIMESTAMP)`
+	assert.Equal(t, `SELECT * FROM sessions WHERE "id"=$1 AND (active)`+notExpired+` LIMIT 1`, bind.GetQuery.String())
+	assert.Equal(t, `SELECT * FROM sessions WHERE (active)`+notExpired, bind.ListQuery.String())
+	assert.Equal(t, `DELETE FROM sessions WHERE (active) AND "expires_at" IS NOT NULL AND "expires_at" <= CURRENT_TIMESTAMP`,
+		bind.SweepQuery.String())
+	assert.Equal(t, `SELECT * FROM (SELECT DISTINCT "id" FROM sessions WHERE (active) AND "expires_at" IS NOT NULL`+
+		` AND "expires_at" <= CURRENT_TIMESTAMP) AS page_query LIMIT 1000 OFFSET 0`, bind.ExpiredKeysQuery)
+
+	ectx := keypattern.ExecContext{"id": "1"}
+	query, err := bind.ExpireQuery(ectx, time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC))
+	if assert.NoError(t, err) {
+		assert.Equal(t, `UPDATE sessions SET "expires_at"=$1 WHERE "id"=$2 AND (active)`+notExpired, query.String())
+		assert.Equal(t, []any{"2030-01-02T03:04:05Z", "1"}, query.Args(ectx))
+	}
+	query, err = bind.ExpireQuery(ectx, time.Time{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, `UPDATE sessions SET "expires_at"=NULL WHERE "id"=$1 AND ((active)`+notExpired+`) AND "expires_at" IS NOT NULL`,
+			query.String())
+	}
+
+	assert.Equal(t, storage.NoExpiration, bind.ExpiresIn(Record{"expires_at": nil}))
+	assert.True(t, bind.IsExpired(Record{"expires_at": "2001-01-01 00:00:00"}))
+	assert.False(t, bind.IsExpired(Record{"expires_at": time.Now().Add(time.Hour)}))
+	ttl := bind.ExpiresIn(Record{"expires_at": []byte(time.Now().Add(time.Minute).UTC().Format(time.RFC3339Nano))})
+	assert.True(t, ttl > 50*time.Second && ttl <= time.Minute, ttl)
+
+	custom := NewBindAbstract(0, NewAbstractSyntax(`"`), "session_{{id}}", "SELECT * FROM sessions WHERE id={{id}}", "", "", "", nil)
+	assert.ErrorIs(t, custom.Configure(&storage.BindConfig{TTLColumn: "bad column"}), ErrInvalidFieldName)
+	if err = custom.Configure(&storage.BindConfig{TTLColumn: "expires_at"}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = custom.ExpireQuery(ectx, time.Now())
+	assert.ErrorIs(t, err, storage.ErrMethodIsNotSupported)
+	assert.Nil(t, custom.SweepQuery)
+}
+
+func TestBindSweepKeys(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	sqlxdb := sqlx.NewDb(db, "test")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	bind := NewBindFromTableName(sqlxdb, 0, NewAbstractSyntax(`"`), "session_{{id}}", "sessions", "", false, nil, false)
+	if err := bind.Configure(&storage.BindConfig{TTLColumn: "expires_at"}); err != nil {
+		t.Fatal(err)
+	}
+	var (
+		events   KeyEvents
+		reported []storage.KeyEvent
+	)
+	events.OnKeyEvent(func(_ context.Context, event storage.KeyEvent) {
+		reported = append(reported, event)
+	})
+	assert.False(t, events.ExpiredEvents(), "listeners must not enable the sweep by keys")
+	events.EnableExpiredEvents(true)
+	assert.True(t, events.ExpiredEvents())
+	mock.ExpectQuery(`SELECT DISTINCT "id" FROM sessions WHERE "expires_at" IS NOT NULL`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	mock.ExpectExec(`DELETE FROM sessions WHERE "id"=\$1 AND "expires_at" IS NOT NULL`).
+		WithArgs("1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM sessions WHERE "id"=\$1 AND "expires_at" IS NOT NULL`).
+		WithArgs("2").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	count, err := bind.sweepKeys(ctx, &events)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(1), count)
+		assert.Equal(t, []storage.KeyEvent{{Key: "session_1", Event: storage.KeyEventExpired}}, reported,
+			"record extended after the selection must not be reported")
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBindCounter(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	sqlxdb := sqlx.NewDb(db, "test")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	ectx := keypattern.ExecContext{"name": "visits"}
+	t.Run("counter column", func(t *testing.T) {
+		bind := NewBindFromTableName(sqlxdb, 0, NewAbstractSyntax(`"`), "counter_{{name}}", "counters", "", false, nil, false)
+		assert.ErrorIs(t, bind.Configure(&storage.BindConfig{CounterColumn: "bad column"}), ErrInvalidFieldName)
+		if err := bind.Configure(&storage.BindConfig{CounterColumn: "value"}); err != nil {
+			t.Fatal(err)
+		}
+		mock.ExpectQuery(`UPDATE counters SET "value"=COALESCE\("value", 0\) \+ \$1 WHERE "name"=\$2 RETURNING "value"`).
+			WithArgs("-2", "visits").
+			WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow(8))
+		field, value, err := bind.IncrBy(ctx, ectx, int64(-2))
+		if assert.NoError(t, err) {
+			assert.Equal(t, "value", field)
+			assert.Equal(t, 8, gocast.Int(value))
+		}
+
+		mock.ExpectQuery(`UPDATE counters SET "value"=COALESCE\("value", 0\) \+ \$1 WHERE "name"=\$2 RETURNING "value"`).
+			WithArgs("1", "visits").
+			WillReturnRows(sqlmock.NewRows([]string{"value"}))
+		_, _, err = bind.IncrBy(ctx, ectx, int64(1))
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	})
+	t.Run("incr query", func(t *testing.T) {
+		bind := NewBind(sqlxdb, 0, NewAbstractSyntax(`"`), "counter_{{name}}",
+			"SELECT * FROM counters WHERE name={{name}}", "", "", "", nil, false)
+		_, _, err := bind.IncrBy(ctx, ectx, int64(1))
+		assert.ErrorIs(t, err, storage.ErrMethodIsNotSupported)
+
+		err = bind.Configure(&storage.BindConfig{
+			IncrQuery: "UPDATE counters SET hits=hits+{{delta}} WHERE name={{name}} RETURNING hits",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		mock.ExpectQuery(`UPDATE counters SET hits=hits\+\$1 WHERE name=\$2 RETURNING hits`).
+			WithArgs("0.5", "visits").
+			WillReturnRows(sqlmock.NewRows([]string{"hits"}).AddRow(3.5))
+		field, value, err := bind.IncrBy(ctx, ectx, 0.5)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "hits", field)
+			assert.Equal(t, 3.5, gocast.Float64(value))
+		}
+	})
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBindCollection(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	sqlxdb := sqlx.NewDb(db, "test")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	ectx := keypattern.ExecContext{"post_id": "1"}
+	t.Run("set table bind", func(t *testing.T) {
+		bind := NewBindFromTableName(sqlxdb, 0, NewAbstractSyntax(`"`), "post_tags_{{post_id}}", "post_tags", "", false, nil, false)
+		assert.ErrorIs(t, bind.Configure(&storage.BindConfig{Type: "set"}), storage.ErrInvalidBindConfig)
+		assert.ErrorIs(t, bind.Configure(&storage.BindConfig{Type: "zlist", MemberColumn: "tag"}), storage.ErrInvalidBindConfig)
+		if err := bind.Configure(&storage.BindConfig{Type: "set", MemberColumn: "tag"}); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, storage.KeyTypeSet, bind.Type())
+		assert.False(t, bind.SupportBatch())
+		assert.Equal(t, `SELECT * FROM post_tags WHERE "post_id"=$1`, bind.GetQuery.String())
+		assert.Equal(t, `SELECT DISTINCT "post_id" FROM post_tags`, bind.ListQuery.String())
+		assert.Equal(t, `INSERT INTO post_tags ("post_id", "tag") VALUES ($1, $2) ON CONFLICT ("post_id", "tag") DO NOTHING`,
+			bind.AddQuery.String())
+		assert.Equal(t, `DELETE FROM post_tags WHERE "post_id"=$1 AND "tag"=$2`, bind.RemQuery.String())
+
+		mock.ExpectQuery(`SELECT \* FROM post_tags WHERE "post_id"=\$1`).
+			WithArgs("1").
+			WillReturnRows(sqlmock.NewRows([]string{"post_id", "tag"}).AddRow(1, "go").AddRow(1, "sql").AddRow(1, "go"))
+		members, err := bind.Members(ctx, ectx)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"go", "sql"}, members)
+		}
+
+		mock.ExpectQuery(`SELECT \* FROM \(SELECT \* FROM post_tags WHERE "post_id"=\$1\) AS filter_query WHERE "tag"=\$2`).
+			WithArgs("1", "go").
+			WillReturnRows(sqlmock.NewRows([]string{"post_id", "tag"}).AddRow(1, "go"))
+		ok, err := bind.IsMember(ctx, ectx, "go")
+		if assert.NoError(t, err) {
+			assert.True(t, ok)
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO post_tags`).WithArgs("1", "go").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO post_tags`).WithArgs("1", "redis").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		count, err := bind.AddMembers(ctx, ectx, []string{"go", "redis"})
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(1), count)
+		}
+
+		mock.ExpectExec(`DELETE FROM post_tags WHERE "post_id"=\$1 AND "tag"=\$2`).
+			WithArgs("1", "go").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		count, err = bind.RemMembers(ctx, ectx, []string{"go"})
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(1), count)
+		}
+
+		_, err = bind.Range(ctx, ectx, 0, -1)
+		assert.ErrorIs(t, err, storage.ErrWrongType)
+		_, err = bind.SetFields(ctx, ectx, map[string]string{"tag": "go"})
+		assert.ErrorIs(t, err, storage.ErrWrongType)
+	})
+	t.Run("list custom bind", func(t *testing.T) {
+		bind := NewBind(sqlxdb, 0, NewAbstractSyntax(`"`), "post_comments_{{post_id}}",
+			"SELECT body FROM comments WHERE post_id={{post_id}} ORDER BY id", "", "", "", nil, false)
+		err := bind.Configure(&storage.BindConfig{
+			Type:     "list",
+			AddQuery: "INSERT INTO comments (post_id, body) VALUES ({{post_id}}, {{member}})",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM \(SELECT body FROM comments WHERE post_id=\$1 ORDER BY id\) AS count_query`).
+			WithArgs("1").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+		mock.ExpectQuery(`SELECT \* FROM \(SELECT body FROM comments WHERE post_id=\$1 ORDER BY id\) AS page_query LIMIT 2 OFFSET 3`).
+			WithArgs("1").
+			WillReturnRows(sqlmock.NewRows([]string{"body"}).AddRow("fourth").AddRow("fifth"))
+		members, err := bind.Range(ctx, ectx, -2, -1)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"fourth", "fifth"}, members)
+		}
+
+		members, err = bind.Range(ctx, ectx, 3, 1)
+		if assert.NoError(t, err) {
+			assert.Empty(t, members)
+		}
+
+		mock.ExpectExec(`INSERT INTO comments \(post_id, body\) VALUES \(\$1, \$2\)`).
+			WithArgs("1", "new").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		count, err := bind.AddMembers(ctx, ectx, []string{"new"})
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(1), count)
+		}
+
+		_, err = bind.RemMembers(ctx, ectx, []string{"new"})
+		assert.ErrorIs(t, err, storage.ErrWrongType)
+	})
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBindSortedSet(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	sqlxdb := sqlx.NewDb(db, "test")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	t.Run("table bind", func(t *testing.T) {
+		ectx := keypattern.ExecContext{"board": "main"}
+		bind := NewBindFromTableName(sqlxdb, 0, NewAbstractSyntax(`"`), "leaderboard_{{board}}", "scores", "", false, nil, false)
+		assert.ErrorIs(t, bind.Configure(&storage.BindConfig{Type: "zset", MemberColumn: "player"}), storage.ErrInvalidBindConfig)
+		if err := bind.Configure(&storage.BindConfig{Type: "zset", MemberColumn: "player", ScoreColumn: "points"}); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, storage.KeyTypeZSet, bind.Type())
+		assert.Equal(t, `INSERT INTO scores ("board", "player", "points") VALUES ($1, $2, $3) ON CONFLICT ("board", "player") DO UPDATE SET "board"=$1, "player"=$2, "points"=$3`,
+			bind.AddQuery.String())
+		assert.Equal(t, `DELETE FROM scores WHERE "board"=$1 AND "player"=$2`, bind.RemQuery.String())
+		assert.Equal(t, `UPDATE scores SET "points"=COALESCE("points", 0) + $1 WHERE "board"=$2 AND "player"=$3`,
+			bind.IncrQuery.String())
+
+		mock.ExpectQuery(`SELECT "player" AS "zset_member", "points" AS "zset_score" FROM \(SELECT \* FROM scores WHERE "board"=\$1\) AS sorted_query `+
+			`WHERE "points" > \$2 AND "points" <= \$3 ORDER BY "zset_score" DESC, "zset_member" DESC LIMIT 2 OFFSET 1`).
+			WithArgs("main", "10", "100.5").
+			WillReturnRows(sqlmock.NewRows([]string{"zset_member", "zset_score"}).AddRow("bob", 50).AddRow("eve", []byte("20.5")))
+		members, err := bind.ZRange(ctx, ectx, storage.ZRange{
+			By:     storage.ZRangeByScore,
+			Min:    storage.ZBound{Value: "10", Exclusive: true},
+			Max:    storage.ZBound{Value: "100.5"},
+			Rev:    true,
+			Offset: 1,
+			Count:  2,
+		})
+		if assert.NoError(t, err) {
+			assert.Equal(t, []storage.ScoredMember{{Member: "bob", Score: 50}, {Member: "eve", Score: 20.5}}, members)
+		}
+
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM \(SELECT \* FROM scores WHERE "board"=\$1\) AS count_query`).
+			WithArgs("main").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(10))
+		mock.ExpectQuery(`SELECT "player" AS "zset_member", "points" AS "zset_score" FROM \(SELECT \* FROM scores WHERE "board"=\$1\) AS sorted_query ` +
+			`ORDER BY "zset_score", "zset_member" LIMIT 3 OFFSET 7`).
+			WithArgs("main").
+			WillReturnRows(sqlmock.NewRows([]string{"zset_member", "zset_score"}).AddRow("ann", 1))
+		members, err = bind.ZRange(ctx, ectx, storage.ZRange{Start: -3, Stop: -1})
+		if assert.NoError(t, err) {
+			assert.Equal(t, []storage.ScoredMember{{Member: "ann", Score: 1}}, members)
+		}
+
+		mock.ExpectQuery(`SELECT "player" AS "zset_member", "points" AS "zset_score" FROM \(SELECT \* FROM scores WHERE "board"=\$1\) AS sorted_query `+
+			`WHERE "player" = \$2 ORDER BY "zset_score", "zset_member" LIMIT 1 OFFSET 0`).
+			WithArgs("main", "bob").
+			WillReturnRows(sqlmock.NewRows([]string{"zset_member", "zset_score"}).AddRow("bob", 50))
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM \(SELECT "player" AS "zset_member", "points" AS "zset_score" FROM \(SELECT \* FROM scores WHERE "board"=\$1\) AS sorted_query `+
+			`WHERE \("points" < \$2 OR \("points" = \$3 AND "player" < \$4\)\) ORDER BY "zset_score", "zset_member"\) AS count_query`).
+			WithArgs("main", "50", "50", "bob").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+		rank, err := bind.ZRank(ctx, ectx, "bob", false)
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(4), rank)
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT .* WHERE "player" = \$2`).
+			WithArgs("main", "bob").
+			WillReturnRows(sqlmock.NewRows([]string{"zset_member", "zset_score"}).AddRow("bob", 50))
+		mock.ExpectQuery(`SELECT .* WHERE "player" = \$2`).
+			WithArgs("main", "eve").
+			WillReturnRows(sqlmock.NewRows([]string{"zset_member", "zset_score"}))
+		mock.ExpectExec(`INSERT INTO scores`).WithArgs("main", "eve", "7").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		added, changed, err := bind.ZAdd(ctx, ectx, []storage.ScoredMember{{Member: "bob", Score: 60}, {Member: "eve", Score: 7}}, storage.SetIfNotExists)
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(1), added)
+			assert.Equal(t, int64(0), changed)
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE scores SET "points"=COALESCE\("points", 0\) \+ \$1`).
+			WithArgs("2.5", "main", "bob").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT .* WHERE "player" = \$2`).
+			WithArgs("main", "bob").
+			WillReturnRows(sqlmock.NewRows([]string{"zset_member", "zset_score"}).AddRow("bob", 52.5))
+		mock.ExpectCommit()
+		score, err := bind.ZIncrBy(ctx, ectx, "bob", 2.5)
+		if assert.NoError(t, err) {
+			assert.Equal(t, 52.5, score)
+		}
+
+		_, _, err = bind.IncrBy(ctx, ectx, 1)
+		assert.ErrorIs(t, err, storage.ErrWrongType)
+		_, err = bind.Members(ctx, ectx)
+		assert.ErrorIs(t, err, storage.ErrWrongType)
+	})
+	t.Run("custom bind with expressions", func(t *testing.T) {
+		ectx := keypattern.ExecContext{"team": "red"}
+		bind := NewBind(sqlxdb, 0, NewAbstractSyntax(`"`), "team_{{team}}",
+			"SELECT * FROM players WHERE team={{team}}", "", "", "", nil, false)
+		err := bind.Configure(&storage.BindConfig{Type: "zset", MemberColumn: "first_name || ' ' || last_name", ScoreColumn: "wins - losses"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mock.ExpectQuery(`SELECT \(first_name \|\| ' ' \|\| last_name\) AS "zset_member", \(wins - losses\) AS "zset_score" `+
+			`FROM \(SELECT \* FROM players WHERE team=\$1\) AS sorted_query WHERE \(first_name \|\| ' ' \|\| last_name\) >= \$2 `+
+			`ORDER BY "zset_score", "zset_member"`).
+			WithArgs("red", "b").
+			WillReturnRows(sqlmock.NewRows([]string{"zset_member", "zset_score"}).AddRow("bob smith", "3"))
+		members, err := bind.ZRange(ctx, ectx, storage.ZRange{
+			By:    storage.ZRangeByLex,
+			Min:   storage.ZBound{Value: "b"},
+			Max:   storage.ZBound{Unbounded: true},
+			Count: -1,
+		})
+		if assert.NoError(t, err) {
+			assert.Equal(t, []storage.ScoredMember{{Member: "bob smith", Score: 3}}, members)
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT .* WHERE \(first_name \|\| ' ' \|\| last_name\) = \$2`).
+			WithArgs("red", "ann").
+			WillReturnRows(sqlmock.NewRows([]string{"zset_member", "zset_score"}))
+		mock.ExpectRollback()
+		_, _, err = bind.ZAdd(ctx, ectx, []storage.ScoredMember{{Member: "ann", Score: 1}}, storage.SetAlways)
+		assert.ErrorIs(t, err, storage.ErrMethodIsNotSupported)
+	})
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
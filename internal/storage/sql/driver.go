@@ -3,6 +3,7 @@ package sql
 import (
 	"context"
 	"encoding/json"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 
@@ -15,6 +16,8 @@ type sqlStore struct {
 	db         *sqlx.DB
 	binds      []*Bind
 	syntax     Syntax
+	sweepers   []context.CancelFunc
+	KeyEvents
 }
 
 // Open sql driver connect
@@ -30,12 +33,12 @@ func Open(ctx context.Context, driver, connURL string) (storage.Driver, error) {
 	case "mysql":
 		syntax = NewMysqlSyntax()
 	case "sqlite", "sqlite3":
-		syntax = NewAbstractSyntax(`"`)
+		syntax = NewSqliteSyntax()
 	case "mssql", "sqlserver":
 		// TODO: Upsert query
 		syntax = NewAbstractSyntax(`"`)
 	case "clickhouse":
-		syntax = NewAbstractSyntax("`")
+		syntax = NewClickhouseSyntax()
 	default:
 		syntax = NewAbstractSyntax(`"`)
 	}
@@ -43,18 +46,83 @@ func Open(ctx context.Context, driver, connURL string) (storage.Driver, error) {
 }
 
 func (dr *sqlStore) Get(ctx context.Context, dbnum int, key string) ([]byte, error) {
+	val, _, err := dr.GetWithTTL(ctx, dbnum, key)
+	return val, err
+}
+
+// GetWithTTL returns the record and the remaining time to live of the key
+func (dr *sqlStore) GetWithTTL(ctx context.Context, dbnum int, key string) ([]byte, time.Duration, error) {
 	ectx := keypattern.ExecContext{}
 	bind, err := dr.bindByKey(key, dbnum, ectx)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+	if bind.IsCollection() {
+		val, err := collectionValue(ctx, bind, ectx)
+		return val, storage.NoExpiration, err
 	}
 	rec, err := bind.Get(ctx, ectx)
+	if err != nil {
+		return nil, 0, err
+	}
+	val, err := json.Marshal(rec)
+	return val, bind.ExpiresIn(rec), err
+}
+
+func (dr *sqlStore) GetFields(ctx context.Context, dbnum int, key string, fields []string) ([]byte, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := dr.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return nil, err
+	}
+	rec, err := bind.GetFields(ctx, ectx, fields)
 	if err != nil {
 		return nil, err
 	}
 	return json.Marshal(rec)
 }
 
+func (dr *sqlStore) GetMany(ctx context.Context, dbnum int, keys []string) []storage.BatchItem {
+	var (
+		items  = make([]storage.BatchItem, len(keys))
+		groups = map[*Bind][]int{}
+		ectxs  = make([]keypattern.ExecContext, len(keys))
+	)
+	for i, key := range keys {
+		ectxs[i] = keypattern.ExecContext{}
+		bind, err := dr.bindByKey(key, dbnum, ectxs[i])
+		if err != nil {
+			items[i].Err = err
+			continue
+		}
+		if bind.IsCollection() {
+			items[i].Value, items[i].Err = collectionValue(ctx, bind, ectxs[i])
+			items[i].TTL = storage.NoExpiration
+			continue
+		}
+		groups[bind] = append(groups[bind], i)
+	}
+	for bind, idxs := range groups {
+		bindCtxs := make([]keypattern.ExecContext, 0, len(idxs))
+		for _, i := range idxs {
+			bindCtxs = append(bindCtxs, ectxs[i])
+		}
+		records, err := bind.GetMany(ctx, bindCtxs)
+		for j, i := range idxs {
+			switch {
+			case err != nil:
+				items[i].Err = err
+			case records[j] == nil:
+				items[i].Err = storage.ErrNotFound
+			default:
+				items[i].Value, items[i].Err = json.Marshal(records[j])
+				items[i].TTL = bind.ExpiresIn(records[j])
+			}
+		}
+	}
+	return items
+}
+
 func (dr *sqlStore) Set(ctx context.Context, dbnum int, key string, value []byte) error {
 	ectx := keypattern.ExecContext{}
 	bind, err := dr.bindByKey(key, dbnum, ectx)
@@ -64,6 +132,210 @@ func (dr *sqlStore) Set(ctx context.Context, dbnum int, key string, value []byte
 	return bind.Upsert(ctx, ectx, value)
 }
 
+// SetWithOptions writes the record by the SET options
+func (dr *sqlStore) SetWithOptions(ctx context.Context, dbnum int, key string, value []byte, opts storage.SetOptions) (storage.SetResult, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := dr.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return storage.SetResult{}, err
+	}
+	prev, applied, err := bind.SetRecord(ctx, ectx, value, opts)
+	if err != nil {
+		return storage.SetResult{}, err
+	}
+	res := storage.SetResult{Applied: applied}
+	if prev != nil {
+		res.Prev, err = json.Marshal(prev)
+	}
+	return res, err
+}
+
+// Expire sets the expiration time of the key, zero time removes the expiration
+func (dr *sqlStore) Expire(ctx context.Context, dbnum int, key string, at time.Time) (bool, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := dr.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return false, err
+	}
+	return bind.Expire(ctx, ectx, at)
+}
+
+// TTL returns the remaining time to live of the key
+func (dr *sqlStore) TTL(ctx context.Context, dbnum int, key string) (time.Duration, error) {
+	_, ttl, err := dr.GetWithTTL(ctx, dbnum, key)
+	return ttl, err
+}
+
+func (dr *sqlStore) SetFields(ctx context.Context, dbnum int, key string, fields map[string]string) (int64, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := dr.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return 0, err
+	}
+	return bind.SetFields(ctx, ectx, fields)
+}
+
+func (dr *sqlStore) DelFields(ctx context.Context, dbnum int, key string, fields []string) (int64, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := dr.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return 0, err
+	}
+	return bind.DelFields(ctx, ectx, fields)
+}
+
+func (dr *sqlStore) IncrField(ctx context.Context, dbnum int, key, field string, delta any) (any, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := dr.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return nil, err
+	}
+	return bind.IncrField(ctx, ectx, field, delta)
+}
+
+// IncrBy increments the counter of the key and returns the counter column and the new value
+func (dr *sqlStore) IncrBy(ctx context.Context, dbnum int, key string, delta any) (string, any, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := dr.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return "", nil, err
+	}
+	return bind.IncrBy(ctx, ectx, delta)
+}
+
+// Range of the list members from start to stop inclusive
+func (dr *sqlStore) Range(ctx context.Context, dbnum int, key string, start, stop int64) ([]string, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := dr.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return nil, err
+	}
+	return bind.Range(ctx, ectx, start, stop)
+}
+
+// Len returns the number of the members of the list, set or sorted set
+func (dr *sqlStore) Len(ctx context.Context, dbnum int, key string) (int64, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := dr.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return 0, err
+	}
+	return bind.Len(ctx, ectx)
+}
+
+// Members returns the unique members of the set
+func (dr *sqlStore) Members(ctx context.Context, dbnum int, key string) ([]string, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := dr.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return nil, err
+	}
+	return bind.Members(ctx, ectx)
+}
+
+// IsMember returns true if the set contains the member
+func (dr *sqlStore) IsMember(ctx context.Context, dbnum int, key, member string) (bool, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := dr.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return false, err
+	}
+	return bind.IsMember(ctx, ectx, member)
+}
+
+// AddMembers to the list or set and returns the number of added members
+func (dr *sqlStore) AddMembers(ctx context.Context, dbnum int, key string, members []string) (int64, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := dr.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return 0, err
+	}
+	return bind.AddMembers(ctx, ectx, members)
+}
+
+// RemMembers of the set or sorted set and returns the number of removed members
+func (dr *sqlStore) RemMembers(ctx context.Context, dbnum int, key string, members []string) (int64, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := dr.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return 0, err
+	}
+	return bind.RemMembers(ctx, ectx, members)
+}
+
+// ZRange returns the members of the sorted set range with their scores
+func (dr *sqlStore) ZRange(ctx context.Context, dbnum int, key string, rng storage.ZRange) ([]storage.ScoredMember, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := dr.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return nil, err
+	}
+	return bind.ZRange(ctx, ectx, rng)
+}
+
+// ZScore returns the score of the member or ErrNotFound
+func (dr *sqlStore) ZScore(ctx context.Context, dbnum int, key, member string) (float64, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := dr.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return 0, err
+	}
+	return bind.ZScore(ctx, ectx, member)
+}
+
+// ZRank returns the index of the member ordered by the score or ErrNotFound
+func (dr *sqlStore) ZRank(ctx context.Context, dbnum int, key, member string, rev bool) (int64, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := dr.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return 0, err
+	}
+	return bind.ZRank(ctx, ectx, member, rev)
+}
+
+// ZAdd adds or updates the members of the sorted set by the mode
+func (dr *sqlStore) ZAdd(ctx context.Context, dbnum int, key string, members []storage.ScoredMember, mode storage.SetMode) (int64, int64, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := dr.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return 0, 0, err
+	}
+	return bind.ZAdd(ctx, ectx, members, mode)
+}
+
+// ZIncrBy increments the score of the member and returns the new score
+func (dr *sqlStore) ZIncrBy(ctx context.Context, dbnum int, key, member string, delta float64) (float64, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := dr.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return 0, err
+	}
+	return bind.ZIncrBy(ctx, ectx, member, delta)
+}
+
+// KeyType returns the type of the bind if the key exists
+func (dr *sqlStore) KeyType(ctx context.Context, dbnum int, key string) (string, error) {
+	ectx := keypattern.ExecContext{}
+	bind, err := dr.bindByKey(key, dbnum, ectx)
+	if err != nil {
+		return "", err
+	}
+	if bind.IsCollection() {
+		count, err := bind.Len(ctx, ectx)
+		if err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return "", storage.ErrNotFound
+		}
+		return bind.Type(), nil
+	}
+	if _, err = bind.Get(ctx, ectx); err != nil {
+		return "", err
+	}
+	return bind.Type(), nil
+}
+
 func (dr *sqlStore) Del(ctx context.Context, dbnum int, key string) error {
 	ectx := keypattern.ExecContext{}
 	bind, err := dr.bindByKey(key, dbnum, ectx)
@@ -92,7 +364,9 @@ func (dr *sqlStore) Keys(ctx context.Context, dbnum int, pattern string) ([]stri
 			keys = make([]string, 0, len(res))
 		}
 		for _, r := range res {
-			keys = append(keys, bind.Pattern.Format(r))
+			if key := bind.Pattern.Format(r); keypattern.GlobMatch(pattern, key) {
+				keys = append(keys, key)
+			}
 		}
 	}
 	if !hasKey {
@@ -117,6 +391,41 @@ func (dr *sqlStore) List(ctx context.Context, dbnum int, pattern string) ([]stor
 	return response, nil
 }
 
+func (dr *sqlStore) Scan(ctx context.Context, dbnum int, cursor uint64, opts storage.ScanOptions) ([]string, uint64, error) {
+	var (
+		cur   = storage.Cursor(cursor)
+		count = opts.PageSize()
+		keys  = make([]string, 0, count)
+	)
+	for i := cur.Bind(); i < len(dr.binds) && len(keys) < count; i++ {
+		bind := dr.binds[i]
+		ectx := keypattern.ExecContext{}
+		if bind.DBNum != dbnum || !opts.MatchType(bind.Type()) || !bind.MatchPattern(opts.Pattern, ectx) {
+			continue
+		}
+		var (
+			offset uint64
+			limit  = uint64(count - len(keys))
+		)
+		if i == cur.Bind() {
+			offset = cur.Offset()
+		}
+		res, err := bind.Page(ctx, ectx, offset, limit)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, r := range res {
+			if key := bind.Pattern.Format(r); opts.MatchKey(key) {
+				keys = append(keys, key)
+			}
+		}
+		if uint64(len(res)) >= limit {
+			return keys, uint64(storage.NewCursor(0, i, offset+limit)), nil
+		}
+	}
+	return keys, 0, nil
+}
+
 func (dr *sqlStore) Bind(ctx context.Context, conf *storage.BindConfig) error {
 	var bind *Bind
 	if conf.GetQuery != "" {
@@ -129,14 +438,96 @@ func (dr *sqlStore) Bind(ctx context.Context, conf *storage.BindConfig) error {
 		return storage.ErrInvalidBindConfig
 	}
 	bind.driverName = dr.driverName
+	if err := bind.Configure(conf); err != nil {
+		return err
+	}
+	if conf.TTLSweepInterval > 0 && bind.SweepQuery != nil {
+		sweepCtx, cancel := context.WithCancel(ctx)
+		dr.sweepers = append(dr.sweepers, cancel)
+		go bind.Sweep(sweepCtx, conf.TTLSweepInterval, &dr.KeyEvents)
+	}
 	dr.binds = append(dr.binds, bind)
 	return nil
 }
 
+// HasKey returns true if any bind of the database matches the key
+func (dr *sqlStore) HasKey(dbnum int, key string) bool {
+	_, err := dr.bindByKey(key, dbnum, keypattern.ExecContext{})
+	return err == nil
+}
+
+// BeginTx of the database, all the keys must be served by the driver
+func (dr *sqlStore) BeginTx(ctx context.Context, dbnum int, keys []string) (context.Context, storage.Tx, error) {
+	for _, key := range keys {
+		if !dr.HasKey(dbnum, key) {
+			return ctx, nil, storage.ErrCrossSourceTx
+		}
+	}
+	tx, err := dr.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return ctx, nil, err
+	}
+	return withTx(ctx, dr.db, tx), tx, nil
+}
+
+// DBSize returns the number of the keys of all binds of the dbnum
+func (dr *sqlStore) DBSize(ctx context.Context, dbnum int) (int64, error) {
+	var size int64
+	for _, bind := range dr.binds {
+		if bind.DBNum != dbnum {
+			continue
+		}
+		count, err := bind.CountKeys(ctx, keypattern.ExecContext{})
+		if err != nil {
+			return 0, err
+		}
+		size += count
+	}
+	return size, nil
+}
+
+// Stats of the binds and the connection pool
+func (dr *sqlStore) Stats() []storage.SourceStats {
+	var (
+		stats = dr.db.Stats()
+		binds = map[int]int{}
+	)
+	for _, bind := range dr.binds {
+		binds[bind.DBNum]++
+	}
+	return []storage.SourceStats{{
+		Driver: dr.driverName,
+		Binds:  binds,
+		Pool: &storage.PoolStats{
+			MaxConns:     stats.MaxOpenConnections,
+			OpenConns:    stats.OpenConnections,
+			InUse:        stats.InUse,
+			Idle:         stats.Idle,
+			WaitCount:    stats.WaitCount,
+			WaitDuration: stats.WaitDuration,
+		},
+	}}
+}
+
 func (dr *sqlStore) Close() error {
+	for _, cancel := range dr.sweepers {
+		cancel()
+	}
 	return dr.db.Close()
 }
 
+// collectionValue returns JSON array of the collection members, empty collections don't exist
+func collectionValue(ctx context.Context, bind *Bind, ectx keypattern.ExecContext) ([]byte, error) {
+	members, err := bind.AllMembers(ctx, ectx)
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return nil, storage.ErrNotFound
+	}
+	return json.Marshal(members)
+}
+
 func (dr *sqlStore) bindByKey(key string, dbnum int, ectx keypattern.ExecContext) (*Bind, error) {
 	for _, b := range dr.binds {
 		if b.DBNum == dbnum && b.MatchKey(key, ectx) {
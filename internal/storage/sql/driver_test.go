@@ -0,0 +1,97 @@
+package sql
+
+import (
+	"context"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demdxx/redify/internal/storage"
+)
+
+func TestStoreTx(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	var (
+		ctx   = context.Background()
+		store = &sqlStore{db: sqlx.NewDb(db, "test"), driverName: "test", syntax: NewAbstractSyntax(`"`)}
+	)
+	assert.NoError(t, store.Bind(ctx, &storage.BindConfig{Pattern: "user_{{username}}", TableName: "users"}))
+	assert.True(t, store.HasKey(0, "user_test"))
+	assert.False(t, store.HasKey(1, "user_test"))
+
+	_, _, err = store.BeginTx(ctx, 0, []string{"user_test", "post_test"})
+	assert.ErrorIs(t, err, storage.ErrCrossSourceTx)
+
+	t.Run("commit", func(t *testing.T) {
+		committed := false
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE users SET`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`DELETE FROM users`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		txCtx, tx, err := storage.BeginTx(ctx, store, 0, []string{"user_test"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = store.SetFields(txCtx, 0, "user_test", map[string]string{"name": "Test"})
+		assert.NoError(t, err)
+		assert.NoError(t, store.Del(txCtx, 0, "user_test"))
+		storage.AfterCommit(txCtx, func() { committed = true })
+		assert.False(t, committed, "hooks must be called after the commit")
+		assert.NoError(t, tx.Commit())
+		assert.True(t, committed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		committed := false
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE users SET`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectRollback()
+
+		txCtx, tx, err := storage.BeginTx(ctx, store, 0, []string{"user_test"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = store.SetFields(txCtx, 0, "user_test", map[string]string{"name": "Test"})
+		assert.NoError(t, err)
+		storage.AfterCommit(txCtx, func() { committed = true })
+		assert.NoError(t, tx.Rollback())
+		assert.False(t, committed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestStoreStats(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	var (
+		ctx   = context.Background()
+		store = &sqlStore{db: sqlx.NewDb(db, "test"), driverName: "test", syntax: NewAbstractSyntax(`"`)}
+	)
+	assert.NoError(t, store.Bind(ctx, &storage.BindConfig{Pattern: "user_{{username}}", TableName: "users"}))
+	assert.NoError(t, store.Bind(ctx, &storage.BindConfig{Pattern: "post_{{id}}", TableName: "posts"}))
+	assert.NoError(t, store.Bind(ctx, &storage.BindConfig{DBNum: 1, Pattern: "tag_{{id}}", TableName: "tags"}))
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM \(SELECT \* FROM users\)`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM \(SELECT \* FROM posts\)`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+
+	size, err := storage.DBSize(ctx, store, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), size)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	stats := storage.Stats(store)
+	if assert.Len(t, stats, 1) {
+		assert.Equal(t, "test", stats[0].Driver)
+		assert.Equal(t, map[int]int{0: 2, 1: 1}, stats[0].Binds)
+		assert.NotNil(t, stats[0].Pool)
+	}
+}
@@ -0,0 +1,48 @@
+package sql
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/demdxx/redify/internal/storage"
+)
+
+// KeyEvents keeps the listeners of the key events reported by the driver itself
+type KeyEvents struct {
+	mx        sync.RWMutex
+	listeners []func(ctx context.Context, event storage.KeyEvent)
+	expired   atomic.Bool
+}
+
+// OnKeyEvent registers the callback which is called for every key event of the driver
+func (e *KeyEvents) OnKeyEvent(fn func(ctx context.Context, event storage.KeyEvent)) {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+	e.listeners = append(e.listeners, fn)
+}
+
+// EnableExpiredEvents turns on the deletion of the expired records one by one
+// to report the expired keys
+func (e *KeyEvents) EnableExpiredEvents(enabled bool) {
+	e.expired.Store(enabled)
+}
+
+// ExpiredEvents returns true if the expired events are enabled and listened
+func (e *KeyEvents) ExpiredEvents() bool {
+	if !e.expired.Load() {
+		return false
+	}
+	e.mx.RLock()
+	defer e.mx.RUnlock()
+	return len(e.listeners) > 0
+}
+
+// Notify the listeners about the event
+func (e *KeyEvents) Notify(ctx context.Context, event storage.KeyEvent) {
+	e.mx.RLock()
+	defer e.mx.RUnlock()
+	for _, fn := range e.listeners {
+		fn(ctx, event)
+	}
+}
@@ -1,6 +1,9 @@
 package sql
 
-import "bytes"
+import (
+	"bytes"
+	"sort"
+)
 
 type (
 	WhereStmt  map[string]string
@@ -11,7 +14,8 @@ func (ws WhereStmt) Where(escape, whereExt string) string {
 	var buf bytes.Buffer
 	if ws != nil && (len(ws) > 0 || whereExt != "") {
 		buf.WriteString(` WHERE `)
-		for k, v := range ws {
+		for _, k := range sortedKeys(ws) {
+			v := ws[k]
 			if buf.Len() > 7 {
 				buf.WriteString(" AND ")
 			}
@@ -33,7 +37,7 @@ func (ws WhereStmt) Where(escape, whereExt string) string {
 
 func (df DataFields) Columns(escape string) string {
 	var buf bytes.Buffer
-	for k := range df {
+	for _, k := range sortedKeys(df) {
 		if buf.Len() > 0 {
 			buf.WriteString(", ")
 		}
@@ -46,7 +50,8 @@ func (df DataFields) Columns(escape string) string {
 
 func (df DataFields) Values() string {
 	var buf bytes.Buffer
-	for _, v := range df {
+	for _, k := range sortedKeys(df) {
+		v := df[k]
 		if buf.Len() > 0 {
 			buf.WriteString(", ")
 		}
@@ -57,7 +62,8 @@ func (df DataFields) Values() string {
 
 func (df DataFields) SetValues(escape string) string {
 	var buf bytes.Buffer
-	for k, v := range df {
+	for _, k := range sortedKeys(df) {
+		v := df[k]
 		if buf.Len() > 0 {
 			buf.WriteString(", ")
 		}
@@ -69,3 +75,13 @@ func (df DataFields) SetValues(escape string) string {
 	}
 	return buf.String()
 }
+
+// sortedKeys of the statement to build the same query every time
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
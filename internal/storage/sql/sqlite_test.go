@@ -0,0 +1,41 @@
+//go:build sqlite || sqlite3
+// +build sqlite sqlite3
+
+package sql
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demdxx/redify/internal/storage"
+)
+
+func TestSqliteKeysPattern(t *testing.T) {
+	ctx := context.Background()
+	store, err := Open(ctx, "sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	db := store.(*sqlStore).db
+	if _, err = db.Exec(`CREATE TABLE users (username TEXT PRIMARY KEY, name TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.Exec(`INSERT INTO users VALUES ('test_1', 'Test1'), ('testx1', 'Test2'), ('100%', 'Test3')`); err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, store.Bind(ctx, &storage.BindConfig{Pattern: "user_{{username}}", TableName: "users"}))
+
+	// Wildcards of LIKE in the key prefix must match only themselves
+	keys, err := store.Keys(ctx, 0, "user_test_*")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user_test_1"}, keys)
+	keys, err = store.Keys(ctx, 0, "user_100%*")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user_100%"}, keys)
+}
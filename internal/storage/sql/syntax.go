@@ -1,18 +1,76 @@
 package sql
 
-import "strings"
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// likeEscapeClause defines the backslash as the escape character of the LIKE patterns
+const likeEscapeClause = ` ESCAPE '\'`
 
 type AbstractSyntax struct {
 	columnEscape string
+	textType     string
+	timeLayout   string
+	returning    bool
+	likeEscape   string // Clause of the LIKE escape character, empty if the backslash is the default one
 }
 
 func NewAbstractSyntax(escape string) *AbstractSyntax {
-	return &AbstractSyntax{columnEscape: escape}
+	return &AbstractSyntax{columnEscape: escape, textType: "TEXT", timeLayout: time.RFC3339Nano, returning: true, likeEscape: likeEscapeClause}
+}
+
+// NewSqliteSyntax uses the time format of the SQLite date functions
+func NewSqliteSyntax() *AbstractSyntax {
+	return &AbstractSyntax{columnEscape: `"`, textType: "TEXT", timeLayout: "2006-01-02 15:04:05", returning: true, likeEscape: likeEscapeClause}
+}
+
+// NewClickhouseSyntax doesn't support the ESCAPE clause, the backslash escapes LIKE patterns by default
+func NewClickhouseSyntax() *AbstractSyntax {
+	return &AbstractSyntax{columnEscape: "`", textType: "TEXT", timeLayout: time.RFC3339Nano, returning: true}
 }
 
 func (sx *AbstractSyntax) UpsertQuery(tableName string, insertFields DataFields, keyFields []string) string {
-	return `INSERT INTO ` + tableName + ` VALUES(` + insertFields.Columns(sx.columnEscape) + `) (` + insertFields.Values() + `)` +
-		` ON CONFLICT (` + strings.Join(keyFields, ", ") + `) DO UPDATE SET ` + insertFields.SetValues(sx.columnEscape)
+	return sx.insert(tableName, insertFields) +
+		` ON CONFLICT (` + sx.columns(keyFields) + `) DO UPDATE SET ` + insertFields.SetValues(sx.columnEscape)
+}
+
+// InsertQuery returns the query which inserts the record only if it doesn't exist,
+// records are always inserted if there are no key fields
+func (sx *AbstractSyntax) InsertQuery(tableName string, insertFields DataFields, keyFields []string) string {
+	if len(keyFields) == 0 {
+		return sx.insert(tableName, insertFields)
+	}
+	return sx.insert(tableName, insertFields) + ` ON CONFLICT (` + sx.columns(keyFields) + `) DO NOTHING`
+}
+
+// FormatTime returns the time value in the format accepted by the timestamp columns
+func (sx *AbstractSyntax) FormatTime(tm time.Time) string {
+	return tm.UTC().Format(sx.timeLayout)
+}
+
+// CurrentTime returns the expression of the current UTC time comparable with FormatTime values
+func (sx *AbstractSyntax) CurrentTime() string {
+	return `CURRENT_TIMESTAMP`
+}
+
+// EscapeColumn name
+func (sx *AbstractSyntax) EscapeColumn(name string) string {
+	return sx.columnEscape + name + sx.columnEscape
+}
+
+func (sx *AbstractSyntax) insert(tableName string, insertFields DataFields) string {
+	return `INSERT INTO ` + tableName + ` (` + insertFields.Columns(sx.columnEscape) + `) VALUES (` + insertFields.Values() + `)`
+}
+
+func (sx *AbstractSyntax) columns(names []string) string {
+	escaped := make([]string, 0, len(names))
+	for _, name := range names {
+		escaped = append(escaped, sx.EscapeColumn(name))
+	}
+	return strings.Join(escaped, ", ")
 }
 
 func (sx *AbstractSyntax) GetQuery(tableName string, where WhereStmt, whereExt string) string {
@@ -23,20 +81,163 @@ func (sx *AbstractSyntax) SelectQuery(tableName string, where WhereStmt, whereEx
 	return `SELECT * FROM ` + tableName + where.Where(sx.columnEscape, whereExt)
 }
 
+func (sx *AbstractSyntax) ProjectionQuery(tableName string, columns []string, where WhereStmt, whereExt string) string {
+	return `SELECT ` + sx.columns(columns) + ` FROM ` + tableName + where.Where(sx.columnEscape, whereExt) + ` LIMIT 1`
+}
+
+// DistinctQuery returns the query which selects unique combinations of the columns
+func (sx *AbstractSyntax) DistinctQuery(tableName string, columns []string, where WhereStmt, whereExt string) string {
+	return `SELECT DISTINCT ` + sx.columns(columns) + ` FROM ` + tableName + where.Where(sx.columnEscape, whereExt)
+}
+
+// ColumnsQuery returns the query which selects no rows but describes all columns of the table
+func (sx *AbstractSyntax) ColumnsQuery(tableName string) string {
+	return `SELECT * FROM ` + tableName + ` WHERE 1=0`
+}
+
+func (sx *AbstractSyntax) UpdateQuery(tableName string, set DataFields, where WhereStmt, whereExt string) string {
+	return `UPDATE ` + tableName + ` SET ` + set.SetValues(sx.columnEscape) + where.Where(sx.columnEscape, whereExt)
+}
+
+func (sx *AbstractSyntax) IncrementQuery(tableName, field, delta string, where WhereStmt, whereExt string) string {
+	column := sx.columnEscape + field + sx.columnEscape
+	query := `UPDATE ` + tableName + ` SET ` + column + `=COALESCE(` + column + `, 0) + ` + delta +
+		where.Where(sx.columnEscape, whereExt)
+	if sx.returning {
+		query += ` RETURNING ` + column
+	}
+	return query
+}
+
+// SupportReturning returns true if the dialect supports RETURNING clause of the modification queries
+func (sx *AbstractSyntax) SupportReturning() bool {
+	return sx.returning
+}
+
 func (sx *AbstractSyntax) DeleteQuery(tableName string, where WhereStmt, whereExt string) string {
 	return `DELETE FROM ` + tableName + where.Where(sx.columnEscape, whereExt)
 }
 
+// PageQuery returns the page of the query rows ordered by the columns,
+// the order of the query is kept if there are no columns
+func (sx *AbstractSyntax) PageQuery(query string, orderBy []string, limit, offset uint64) string {
+	var order string
+	if len(orderBy) > 0 {
+		order = ` ORDER BY ` + sx.columns(orderBy)
+	}
+	return `SELECT * FROM (` + strings.TrimRight(strings.TrimSpace(query), ";") + `) AS page_query` + order +
+		` LIMIT ` + strconv.FormatUint(limit, 10) + ` OFFSET ` + strconv.FormatUint(offset, 10)
+}
+
+// CountQuery returns the query which counts the rows of the query
+func (sx *AbstractSyntax) CountQuery(query string) string {
+	return `SELECT COUNT(*) FROM (` + strings.TrimRight(strings.TrimSpace(query), ";") + `) AS count_query`
+}
+
+// Columns of the SortedQuery rows
+const (
+	SortedMemberColumn = "zset_member"
+	SortedScoreColumn  = "zset_score"
+)
+
+// SortedQuery selects the member and the score expressions of the query rows
+// filtered by the condition and ordered by the score and the member.
+// Zero limit means all rows after the offset.
+func (sx *AbstractSyntax) SortedQuery(query, member, score, where string, desc bool, limit, offset uint64) string {
+	order := ` ORDER BY ` + sx.EscapeColumn(SortedScoreColumn) + `, ` + sx.EscapeColumn(SortedMemberColumn)
+	if desc {
+		order = ` ORDER BY ` + sx.EscapeColumn(SortedScoreColumn) + ` DESC, ` + sx.EscapeColumn(SortedMemberColumn) + ` DESC`
+	}
+	if where != "" {
+		where = ` WHERE ` + where
+	}
+	res := `SELECT ` + member + ` AS ` + sx.EscapeColumn(SortedMemberColumn) + `, ` +
+		score + ` AS ` + sx.EscapeColumn(SortedScoreColumn) +
+		` FROM (` + strings.TrimRight(strings.TrimSpace(query), ";") + `) AS sorted_query` + where + order
+	if limit == 0 && offset > 0 {
+		// OFFSET without LIMIT is not supported by all dialects
+		limit = math.MaxInt64
+	}
+	if limit > 0 {
+		res += ` LIMIT ` + strconv.FormatUint(limit, 10) + ` OFFSET ` + strconv.FormatUint(offset, 10)
+	}
+	return res
+}
+
+func (sx *AbstractSyntax) FilterQuery(query string, equals, likes WhereStmt) string {
+	conds := make([]string, 0, len(likes))
+	for k, v := range likes {
+		conds = append(conds, `CAST(`+sx.columnEscape+k+sx.columnEscape+` AS `+sx.textType+`) LIKE `+v+sx.likeEscape)
+	}
+	return `SELECT * FROM (` + strings.TrimRight(strings.TrimSpace(query), ";") + `) AS filter_query` +
+		equals.Where(sx.columnEscape, strings.Join(conds, " AND "))
+}
+
+func (sx *AbstractSyntax) BatchGetQuery(tableName string, keyFields []string, count int, whereExt string) string {
+	var buf strings.Builder
+	buf.WriteString(`SELECT * FROM ` + tableName + ` WHERE `)
+	if len(keyFields) > 1 {
+		buf.WriteByte('(')
+	}
+	for i, field := range keyFields {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(sx.columnEscape + field + sx.columnEscape)
+	}
+	if len(keyFields) > 1 {
+		buf.WriteByte(')')
+	}
+	buf.WriteString(` IN (`)
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		if len(keyFields) > 1 {
+			buf.WriteByte('(')
+		}
+		for j := range keyFields {
+			if j > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString("$" + strconv.Itoa(i*len(keyFields)+j+1))
+		}
+		if len(keyFields) > 1 {
+			buf.WriteByte(')')
+		}
+	}
+	buf.WriteByte(')')
+	if whereExt != "" {
+		buf.WriteString(` AND ` + whereExt)
+	}
+	return buf.String()
+}
+
 type MysqlSyntax struct {
 	AbstractSyntax
 }
 
 func NewMysqlSyntax() *MysqlSyntax {
 	return &MysqlSyntax{
-		AbstractSyntax: AbstractSyntax{columnEscape: "`"},
+		AbstractSyntax: AbstractSyntax{columnEscape: "`", textType: "CHAR"},
 	}
 }
 
 func (sx *MysqlSyntax) UpsertQuery(tableName string, insertFields DataFields, keyFields []string) string {
-	return `REPLACE INTO ` + tableName + ` VALUES(` + insertFields.Columns(sx.columnEscape) + `) (` + insertFields.Values() + `)`
+	return `REPLACE INTO ` + tableName + ` (` + insertFields.Columns(sx.columnEscape) + `) VALUES (` + insertFields.Values() + `)`
+}
+
+func (sx *MysqlSyntax) InsertQuery(tableName string, insertFields DataFields, keyFields []string) string {
+	if len(keyFields) == 0 {
+		return sx.insert(tableName, insertFields)
+	}
+	return `INSERT IGNORE INTO ` + tableName + ` (` + insertFields.Columns(sx.columnEscape) + `) VALUES (` + insertFields.Values() + `)`
+}
+
+func (sx *MysqlSyntax) FormatTime(tm time.Time) string {
+	return tm.UTC().Format("2006-01-02 15:04:05.999999")
+}
+
+func (sx *MysqlSyntax) CurrentTime() string {
+	return `UTC_TIMESTAMP(6)`
 }
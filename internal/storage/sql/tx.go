@@ -0,0 +1,30 @@
+package sql
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type txKey struct {
+	db *sqlx.DB
+}
+
+// withTx returns the context which executes queries of the database inside the transaction
+func withTx(ctx context.Context, db *sqlx.DB, tx *sqlx.Tx) context.Context {
+	return context.WithValue(ctx, txKey{db: db}, tx)
+}
+
+// txFromContext returns the transaction of the database if the context has one
+func txFromContext(ctx context.Context, db *sqlx.DB) *sqlx.Tx {
+	tx, _ := ctx.Value(txKey{db: db}).(*sqlx.Tx)
+	return tx
+}
+
+// conn returns the transaction of the context or the database connection
+func (b *Bind) conn(ctx context.Context) sqlx.ExtContext {
+	if tx := txFromContext(ctx, b.db); tx != nil {
+		return tx
+	}
+	return b.db
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// KeyCounter extension of the driver which counts the keys in the database
+type KeyCounter interface {
+	// DBSize returns the number of the keys of the dbnum
+	DBSize(ctx context.Context, dbnum int) (int64, error)
+}
+
+// DBSize returns the number of the keys using KeyCounter if the driver supports it,
+// otherwise all keys are listed
+func DBSize(ctx context.Context, drv Driver, dbnum int) (int64, error) {
+	if kc, _ := drv.(KeyCounter); kc != nil {
+		return kc.DBSize(ctx, dbnum)
+	}
+	keys, err := drv.Keys(ctx, dbnum, "*")
+	if errors.Is(err, ErrNoKey) {
+		return 0, nil
+	}
+	return int64(len(keys)), err
+}
+
+// PoolStats of the source connections
+type PoolStats struct {
+	MaxConns     int
+	OpenConns    int
+	InUse        int
+	Idle         int
+	WaitCount    int64
+	WaitDuration time.Duration
+}
+
+// CacheStats of the source reads
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Coalesced uint64 // Misses served by the concurrent load of the same key
+	Throttled uint64 // Misses waited for the limit of the loads in flight
+	InFlight  int64  // Keys loaded from the store at the moment
+	Stale     uint64 // Expired values served while refreshing
+	Refreshed uint64 // Background refreshes of the cached values
+	Negative  uint64 // Missing keys served by the cache
+}
+
+// HitRatio of the cache reads, zero if there were no reads
+func (s CacheStats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// SourceStats of the connected source reported by INFO
+type SourceStats struct {
+	Driver string
+	Binds  map[int]int // Number of the binds by dbnum
+	Pool   *PoolStats
+	Cache  *CacheStats
+}
+
+// StatsReporter extension of the driver which reports the statistics of the sources
+type StatsReporter interface {
+	Stats() []SourceStats
+}
+
+// Stats of the sources if the driver supports it
+func Stats(drv Driver) []SourceStats {
+	if sr, _ := drv.(StatsReporter); sr != nil {
+		return sr.Stats()
+	}
+	return nil
+}
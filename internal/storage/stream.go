@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidStreamID in case of the ID is not `ms-seq` or not greater than the last ID
+	ErrInvalidStreamID = errors.New("invalid stream ID")
+	// ErrNoGroup is returned if the consumer group of the stream doesn't exist
+	ErrNoGroup = errors.New("no such consumer group")
+	// ErrGroupExists is returned by XGROUP CREATE for the existing group
+	ErrGroupExists = errors.New("consumer group name already exists")
+
+	errInvalidStreamFields = errors.New("stream fields must be name and value pairs")
+)
+
+// Headers of the stream message published by XADD
+const (
+	StreamHeaderID     = "id"
+	StreamHeaderStream = "stream"
+	StreamHeaderTime   = "time"
+)
+
+// StreamMessage is published to the stream broker by XADD.
+// Headers identify the stream key and the entry ID, fields are the entry values.
+type StreamMessage struct {
+	Headers map[string]string `json:"headers"`
+	Fields  StreamFields      `json:"fields"`
+}
+
+// StreamFields of the entry as the list of name and value pairs.
+// The order and the repeated names of XADD are kept, so fields are encoded
+// as the JSON array `["name","value",...]`. The JSON object is accepted too,
+// its fields are taken in the order of the document.
+type StreamFields []string
+
+// UnmarshalJSON of the array of pairs or of the object with string values
+func (f *StreamFields) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		*f = nil
+		return nil
+	case len(data) > 0 && data[0] == '[':
+		var list []string
+		if err := json.Unmarshal(data, &list); err != nil {
+			return err
+		}
+		if len(list)%2 != 0 {
+			return errInvalidStreamFields
+		}
+		*f = list
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil {
+		return err
+	} else if tok != json.Delim('{') {
+		return errInvalidStreamFields
+	}
+	list := StreamFields{}
+	for dec.More() {
+		name, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		var value string
+		if err = dec.Decode(&value); err != nil {
+			return err
+		}
+		list = append(list, name.(string), value)
+	}
+	*f = list
+	return nil
+}
+
+// StreamID of the entry is the milliseconds time and the sequence number
+type StreamID struct {
+	Ms  uint64
+	Seq uint64
+}
+
+// ParseStreamID in `ms-seq` or `ms` format
+func ParseStreamID(s string) (StreamID, error) {
+	ms, seq, hasSeq := strings.Cut(s, "-")
+	var (
+		id  StreamID
+		err error
+	)
+	if id.Ms, err = strconv.ParseUint(ms, 10, 64); err != nil {
+		return id, ErrInvalidStreamID
+	}
+	if hasSeq {
+		if id.Seq, err = strconv.ParseUint(seq, 10, 64); err != nil {
+			return id, ErrInvalidStreamID
+		}
+	}
+	return id, nil
+}
+
+// Less returns true if the ID is before the other one
+func (id StreamID) Less(other StreamID) bool {
+	return id.Ms < other.Ms || (id.Ms == other.Ms && id.Seq < other.Seq)
+}
+
+// Next ID after the current one for the time
+func (id StreamID) Next(now time.Time) StreamID {
+	if ms := uint64(now.UnixMilli()); ms > id.Ms {
+		return StreamID{Ms: ms}
+	}
+	return StreamID{Ms: id.Ms, Seq: id.Seq + 1}
+}
+
+func (id StreamID) String() string {
+	return strconv.FormatUint(id.Ms, 10) + "-" + strconv.FormatUint(id.Seq, 10)
+}
+
+// StreamEntry with the field-value pairs
+type StreamEntry struct {
+	ID     string
+	Fields []string
+}
+
+// StreamEntries of the stream key
+type StreamEntries struct {
+	Key     string
+	Entries []StreamEntry
+}
+
+// StreamRead request of XREAD and XREADGROUP.
+// IDs are the last seen entries of the keys: `$` selects only new entries,
+// `>` selects entries never delivered to the group, other IDs of XREADGROUP
+// select the pending entries of the consumer.
+type StreamRead struct {
+	Keys     []string
+	IDs      []string
+	Count    int
+	Block    bool
+	Timeout  time.Duration // Zero timeout blocks until the context is done
+	Group    string
+	Consumer string
+	NoAck    bool
+}
+
+// StreamReader extension of the driver to consume the streams
+type StreamReader interface {
+	// XRead returns the entries of the streams which have them,
+	// empty result means the blocking timeout is reached
+	XRead(ctx context.Context, dbnum int, req StreamRead) ([]StreamEntries, error)
+}
+
+// StreamWriter extension of the driver to publish to the streams and manage consumer groups
+type StreamWriter interface {
+	// XAdd publishes the entry, `*` ID generates the new one. Returns the ID of the entry.
+	XAdd(ctx context.Context, dbnum int, key, id string, fields []string) (string, error)
+	// XGroupCreate creates the consumer group which starts after the ID, `$` means the last entry
+	XGroupCreate(ctx context.Context, dbnum int, key, group, id string) error
+	// XGroupDestroy removes the consumer group and returns false if it doesn't exist
+	XGroupDestroy(ctx context.Context, dbnum int, key, group string) (bool, error)
+	// XAck acknowledges the pending entries of the group and returns their number
+	XAck(ctx context.Context, dbnum int, key, group string, ids []string) (int64, error)
+}
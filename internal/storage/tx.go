@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrCrossSourceTx is returned if the keys of the transaction belong to different sources
+var ErrCrossSourceTx = errors.New("transaction keys belong to different sources")
+
+// Tx of the database which executes the driver commands atomically
+type Tx interface {
+	Commit() error
+	Rollback() error
+}
+
+// Transactioner extension of the driver which can execute write commands in one transaction
+type Transactioner interface {
+	// HasKey returns true if the key of the database is served by the driver
+	HasKey(dbnum int, key string) bool
+	// BeginTx starts the transaction for the keys of the database.
+	// The driver methods called with the returned context are executed inside the transaction.
+	BeginTx(ctx context.Context, dbnum int, keys []string) (context.Context, Tx, error)
+}
+
+type commitHooksKey struct{}
+
+type commitHooks struct {
+	mx    sync.Mutex
+	hooks []func()
+}
+
+// BeginTx starts the transaction if the driver supports it.
+// Functions registered by AfterCommit with the returned context are called after the successful commit.
+func BeginTx(ctx context.Context, drv Driver, dbnum int, keys []string) (context.Context, Tx, error) {
+	tr, _ := drv.(Transactioner)
+	if tr == nil {
+		return ctx, nil, ErrMethodIsNotSupported
+	}
+	hooks := &commitHooks{}
+	ctx, tx, err := tr.BeginTx(context.WithValue(ctx, commitHooksKey{}, hooks), dbnum, keys)
+	if err != nil {
+		return ctx, nil, err
+	}
+	return ctx, &hookedTx{Tx: tx, hooks: hooks}, nil
+}
+
+// InTx returns true if the context belongs to the transaction
+func InTx(ctx context.Context) bool {
+	return ctx.Value(commitHooksKey{}) != nil
+}
+
+// AfterCommit calls the function after the commit of the context transaction
+// or immediately if the context has no transaction
+func AfterCommit(ctx context.Context, fn func()) {
+	hooks, _ := ctx.Value(commitHooksKey{}).(*commitHooks)
+	if hooks == nil {
+		fn()
+		return
+	}
+	hooks.mx.Lock()
+	defer hooks.mx.Unlock()
+	hooks.hooks = append(hooks.hooks, fn)
+}
+
+// hookedTx runs the registered functions after the commit
+type hookedTx struct {
+	Tx
+	hooks *commitHooks
+}
+
+func (tx *hookedTx) Commit() error {
+	if err := tx.Tx.Commit(); err != nil {
+		return err
+	}
+	tx.hooks.mx.Lock()
+	hooks := tx.hooks.hooks
+	tx.hooks.hooks = nil
+	tx.hooks.mx.Unlock()
+	for _, fn := range hooks {
+		fn()
+	}
+	return nil
+}
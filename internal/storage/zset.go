@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"math"
+	"strconv"
+)
+
+// ScoredMember of the sorted set
+type ScoredMember struct {
+	Member string
+	Score  float64
+}
+
+// ZRangeBy is the kind of the sorted set range
+type ZRangeBy int
+
+// Kinds of the ZRANGE command
+const (
+	ZRangeByIndex ZRangeBy = iota
+	ZRangeByScore          // BYSCORE
+	ZRangeByLex            // BYLEX
+)
+
+// ZBound of the sorted set range by score or by member
+type ZBound struct {
+	Value     string // Score or member of the bound
+	Exclusive bool   // The bound value is not included into the range
+	Unbounded bool   // No limit of the range: -inf, +inf, - or +
+}
+
+// ZRange options of the ZRANGE command
+type ZRange struct {
+	By          ZRangeBy
+	Start, Stop int64  // Indexes of the range by index, negative indexes are counted from the end
+	Min, Max    ZBound // Bounds of the range by score or by member
+	Rev         bool   // Members are ordered from the highest to the lowest score
+	Offset      int64  // LIMIT offset of the range by score or by member
+	Count       int64  // LIMIT count of the range by score or by member, negative count means all
+}
+
+// SortedSetReader extension of the driver for the keys of zset type
+type SortedSetReader interface {
+	// ZRange returns the members of the sorted set with their scores
+	ZRange(ctx context.Context, dbnum int, key string, rng ZRange) ([]ScoredMember, error)
+	// ZScore returns the score of the member or ErrNotFound
+	ZScore(ctx context.Context, dbnum int, key, member string) (float64, error)
+	// ZRank returns the index of the member ordered by the score or ErrNotFound
+	ZRank(ctx context.Context, dbnum int, key, member string, rev bool) (int64, error)
+}
+
+// SortedSetWriter extension of the driver to modify the members of sorted sets.
+// Members are removed by CollectionWriter.RemMembers.
+type SortedSetWriter interface {
+	// ZAdd adds new members or updates the scores of the existing members by the mode
+	// and returns the number of added members and the number of members with changed scores
+	ZAdd(ctx context.Context, dbnum int, key string, members []ScoredMember, mode SetMode) (int64, int64, error)
+	// ZIncrBy increments the score of the member and returns the new score,
+	// the member is added if it doesn't exist
+	ZIncrBy(ctx context.Context, dbnum int, key, member string, delta float64) (float64, error)
+}
+
+// FormatScore of the sorted set member as Redis does
+func FormatScore(score float64) string {
+	switch {
+	case math.IsInf(score, 1):
+		return "inf"
+	case math.IsInf(score, -1):
+		return "-inf"
+	}
+	return strconv.FormatFloat(score, 'f', -1, 64)
+}
@@ -0,0 +1,156 @@
+// Package tlsutil prepares TLS configuration of the listeners
+// with reloading of the certificates after the files rotation
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Client certificate verification modes
+const (
+	ClientAuthNone          = "none"
+	ClientAuthVerifyIfGiven = "verify_if_given"
+	ClientAuthRequire       = "require"
+)
+
+// DefaultCheckInterval of the certificate files modifications
+const DefaultCheckInterval = 10 * time.Second
+
+var (
+	ErrInvalidClientAuth = errors.New("invalid client auth mode")
+	ErrInvalidClientCA   = errors.New("no valid certificates in the client CA file")
+)
+
+// Options of the TLS listener
+type Options struct {
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string
+	ClientAuth   string // none, verify_if_given, require
+
+	// CheckInterval of the files modifications, DefaultCheckInterval if not defined
+	CheckInterval time.Duration
+}
+
+// Enabled returns true if the certificate is defined
+func (opt *Options) Enabled() bool {
+	return opt.CertFile != "" && opt.KeyFile != ""
+}
+
+func (opt *Options) clientAuth() (tls.ClientAuthType, error) {
+	switch strings.ToLower(opt.ClientAuth) {
+	case "":
+		if opt.ClientCAFile != "" {
+			return tls.RequireAndVerifyClientCert, nil
+		}
+		return tls.NoClientCert, nil
+	case ClientAuthNone:
+		return tls.NoClientCert, nil
+	case ClientAuthVerifyIfGiven:
+		return tls.VerifyClientCertIfGiven, nil
+	case ClientAuthRequire:
+		return tls.RequireAndVerifyClientCert, nil
+	}
+	return tls.NoClientCert, ErrInvalidClientAuth
+}
+
+// NewConfig returns TLS configuration which reloads the certificate
+// and the client CA when the files are changed
+func NewConfig(opt Options) (*tls.Config, error) {
+	clientAuth, err := opt.clientAuth()
+	if err != nil {
+		return nil, err
+	}
+	if opt.CheckInterval <= 0 {
+		opt.CheckInterval = DefaultCheckInterval
+	}
+	rl := &reloader{opt: opt, clientAuth: clientAuth}
+	if err := rl.load(); err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		GetConfigForClient: rl.configForClient,
+	}, nil
+}
+
+type reloader struct {
+	mx         sync.RWMutex
+	opt        Options
+	clientAuth tls.ClientAuthType
+	config     *tls.Config
+	modTimes   [3]time.Time
+	lastCheck  time.Time
+}
+
+func (rl *reloader) configForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	rl.mx.RLock()
+	config, check := rl.config, time.Since(rl.lastCheck) >= rl.opt.CheckInterval
+	rl.mx.RUnlock()
+	if check {
+		// Keep serving the previous certificate if the new one is broken
+		if err := rl.load(); err == nil {
+			rl.mx.RLock()
+			config = rl.config
+			rl.mx.RUnlock()
+		}
+	}
+	return config, nil
+}
+
+// load certificates if the files were modified since the last loading
+func (rl *reloader) load() error {
+	rl.mx.Lock()
+	defer rl.mx.Unlock()
+	rl.lastCheck = time.Now()
+
+	modTimes := [3]time.Time{
+		modTime(rl.opt.CertFile),
+		modTime(rl.opt.KeyFile),
+		modTime(rl.opt.ClientCAFile),
+	}
+	if rl.config != nil && modTimes == rl.modTimes {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(rl.opt.CertFile, rl.opt.KeyFile)
+	if err != nil {
+		return err
+	}
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   rl.clientAuth,
+	}
+	if rl.opt.ClientCAFile != "" {
+		data, err := os.ReadFile(rl.opt.ClientCAFile)
+		if err != nil {
+			return err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return ErrInvalidClientCA
+		}
+		config.ClientCAs = pool
+	}
+	rl.config = config
+	rl.modTimes = modTimes
+	return nil
+}
+
+func modTime(filename string) time.Time {
+	if filename == "" {
+		return time.Time{}
+	}
+	stat, err := os.Stat(filename)
+	if err != nil {
+		return time.Time{}
+	}
+	return stat.ModTime()
+}
@@ -0,0 +1,130 @@
+package tlsutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewConfig(t *testing.T) {
+	var (
+		dir      = t.TempDir()
+		certFile = filepath.Join(dir, "cert.pem")
+		keyFile  = filepath.Join(dir, "key.pem")
+	)
+	writeCert(t, certFile, keyFile, "first")
+
+	conf, err := NewConfig(Options{
+		CertFile:      certFile,
+		KeyFile:       keyFile,
+		ClientCAFile:  certFile,
+		CheckInterval: time.Nanosecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientConf, err := conf.GetConfigForClient(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, tls.RequireAndVerifyClientCert, clientConf.ClientAuth)
+	assert.NotNil(t, clientConf.ClientCAs)
+	assert.Equal(t, "first", certName(t, clientConf))
+
+	// The same config is used while the files are not changed
+	sameConf, _ := conf.GetConfigForClient(nil)
+	assert.Same(t, clientConf, sameConf)
+
+	writeCert(t, certFile, keyFile, "second")
+	future := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(certFile, future, future))
+	clientConf, err = conf.GetConfigForClient(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "second", certName(t, clientConf))
+
+	// Broken files keep the previous certificate
+	assert.NoError(t, os.WriteFile(keyFile, []byte("broken"), 0o600))
+	clientConf, err = conf.GetConfigForClient(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "second", certName(t, clientConf))
+}
+
+func TestNewConfigErrors(t *testing.T) {
+	var (
+		dir      = t.TempDir()
+		certFile = filepath.Join(dir, "cert.pem")
+		keyFile  = filepath.Join(dir, "key.pem")
+	)
+	writeCert(t, certFile, keyFile, "test")
+
+	_, err := NewConfig(Options{CertFile: certFile, KeyFile: keyFile, ClientAuth: "any"})
+	assert.ErrorIs(t, err, ErrInvalidClientAuth)
+
+	_, err = NewConfig(Options{CertFile: certFile, KeyFile: keyFile, ClientCAFile: keyFile})
+	assert.ErrorIs(t, err, ErrInvalidClientCA)
+
+	_, err = NewConfig(Options{CertFile: certFile, KeyFile: filepath.Join(dir, "none.pem")})
+	assert.Error(t, err)
+
+	conf, err := NewConfig(Options{CertFile: certFile, KeyFile: keyFile, ClientAuth: ClientAuthVerifyIfGiven})
+	if assert.NoError(t, err) {
+		clientConf, _ := conf.GetConfigForClient(nil)
+		assert.Equal(t, tls.VerifyClientCertIfGiven, clientConf.ClientAuth)
+	}
+}
+
+func certName(t *testing.T, conf *tls.Config) string {
+	cert, err := x509.ParseCertificate(conf.Certificates[0].Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert.Subject.CommonName
+}
+
+func writeCert(t *testing.T, certFile, keyFile, name string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyData := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err = os.WriteFile(certFile, certData, 0o600); err == nil {
+		err = os.WriteFile(keyFile, keyData, 0o600)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}